@@ -0,0 +1,214 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package abbrev builds, parses and validates CereProc abbreviation files for
+// UploadAbbreviations.
+//
+// An abbreviation file contains one entry per line: the abbreviation, which
+// may not contain whitespace, followed by whitespace and the expansion which
+// is spoken in its place. Blank lines and lines starting with "#" are ignored.
+//
+//	# abbreviation  expansion
+//	Dr              doctor
+//	approx.         approximately
+//	BBC             b b c
+package abbrev
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Entry is a single abbreviation and its expansion
+type Entry struct {
+	Abbreviation string
+	Expansion    string
+	Line         int // Line number in the parsed file, 0 if not parsed
+}
+
+// String formats the entry as an abbreviation file line
+func (e Entry) String() string {
+	return e.Abbreviation + "\t" + e.Expansion
+}
+
+// Validate checks the abbreviation and expansion
+func (e Entry) Validate() error {
+	if e.Abbreviation == "" || strings.ContainsAny(e.Abbreviation, " \t\r\n") {
+		return fmt.Errorf("invalid abbreviation %q", e.Abbreviation)
+	}
+	if strings.HasPrefix(e.Abbreviation, "#") {
+		return fmt.Errorf("abbreviation %q starts with a comment character", e.Abbreviation)
+	}
+	if strings.TrimSpace(e.Expansion) == "" {
+		return fmt.Errorf("missing expansion for %q", e.Abbreviation)
+	}
+	if strings.ContainsAny(e.Expansion, "\r\n") {
+		return fmt.Errorf("expansion for %q contains a line break", e.Abbreviation)
+	}
+
+	return nil
+}
+
+// List is an ordered list of abbreviations
+type List struct {
+	Entries []Entry
+}
+
+// FromMap returns a List of the abbreviations and expansions in m, sorted by
+// abbreviation
+func FromMap(m map[string]string) *List {
+	l := &List{}
+	for abbr, expansion := range m {
+		l.Entries = append(l.Entries, Entry{Abbreviation: abbr, Expansion: expansion})
+	}
+	sort.Slice(l.Entries, func(i, j int) bool {
+		return l.Entries[i].Abbreviation < l.Entries[j].Abbreviation
+	})
+
+	return l
+}
+
+// Set adds an abbreviation, replacing the expansion of an existing entry
+func (l *List) Set(abbreviation, expansion string) {
+	for i := range l.Entries {
+		if l.Entries[i].Abbreviation == abbreviation {
+			l.Entries[i].Expansion = expansion
+			return
+		}
+	}
+
+	l.Entries = append(l.Entries, Entry{Abbreviation: abbreviation, Expansion: expansion})
+}
+
+// Map returns the abbreviations and their expansions
+func (l *List) Map() map[string]string {
+	m := make(map[string]string, len(l.Entries))
+	for _, e := range l.Entries {
+		m[e.Abbreviation] = e.Expansion
+	}
+
+	return m
+}
+
+// Validate checks every entry, returning Errors listing every problem
+func (l *List) Validate() error {
+	seen := make(map[string]int)
+	var errs Errors
+
+	for i, e := range l.Entries {
+		n := e.Line
+		if n == 0 {
+			n = i + 1
+		}
+
+		if err := e.Validate(); err != nil {
+			errs = append(errs, &LineError{n, err})
+			continue
+		}
+		if prev, ok := seen[e.Abbreviation]; ok {
+			errs = append(errs, &LineError{n, fmt.Errorf("duplicate entry for %q, first defined on line %d", e.Abbreviation, prev)})
+			continue
+		}
+		seen[e.Abbreviation] = n
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// LineError describes a problem with a single line of an abbreviation file
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a list of line errors found while parsing or validating
+type Errors []*LineError
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return "abbrev: " + e[0].Error()
+	}
+
+	return fmt.Sprintf("abbrev: %v (and %d more errors)", e[0], len(e)-1)
+}
+
+// Parse reads an abbreviation file. If any lines are malformed the entries
+// that could be parsed are returned along with an Errors listing every problem.
+func Parse(r io.Reader) (*List, error) {
+	l := &List{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		abbr, expansion := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			abbr, expansion = line[:i], strings.TrimSpace(line[i:])
+		}
+		l.Entries = append(l.Entries, Entry{Abbreviation: abbr, Expansion: expansion, Line: n})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := l.Validate(); err != nil {
+		valid := &List{}
+		bad := make(map[int]bool)
+		for _, e := range err.(Errors) {
+			bad[e.Line] = true
+		}
+		for _, e := range l.Entries {
+			if !bad[e.Line] {
+				valid.Entries = append(valid.Entries, e)
+			}
+		}
+		return valid, err
+	}
+
+	return l, nil
+}
+
+// Validate reads an abbreviation file and reports any malformed lines
+func Validate(r io.Reader) error {
+	_, err := Parse(r)
+	return err
+}
+
+// WriteTo writes the abbreviations in file format
+func (l *List) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, e := range l.Entries {
+		n, err := io.WriteString(w, e.String()+"\n")
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// String returns the abbreviations in file format
+func (l *List) String() string {
+	var b strings.Builder
+	l.WriteTo(&b)
+	return b.String()
+}
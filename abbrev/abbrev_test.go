@@ -0,0 +1,105 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package abbrev
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []Entry
+		errs    []int // Lines reported as malformed
+		message string
+	}{
+		{
+			name: "entries",
+			file: "# abbreviation expansion\n\nDr doctor\n  approx.\t  approximately \nBBC b b c\n",
+			want: []Entry{{"Dr", "doctor", 3}, {"approx.", "approximately", 4}, {"BBC", "b b c", 5}},
+		},
+		{
+			name:    "missing expansion",
+			file:    "Dr doctor\nSt\n",
+			want:    []Entry{{"Dr", "doctor", 1}},
+			errs:    []int{2},
+			message: `abbrev: line 2: missing expansion for "St"`,
+		},
+		{
+			name:    "duplicate",
+			file:    "Dr doctor\nDr drive\nSt\n",
+			want:    []Entry{{"Dr", "doctor", 1}},
+			errs:    []int{2, 3},
+			message: `abbrev: line 2: duplicate entry for "Dr", first defined on line 1 (and 1 more errors)`,
+		},
+		{name: "empty", file: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		l, err := Parse(strings.NewReader(tt.file))
+		if !reflect.DeepEqual(l.Entries, tt.want) {
+			t.Errorf("%s: entries = %v, want %v", tt.name, l.Entries, tt.want)
+		}
+
+		var errs Errors
+		errors.As(err, &errs)
+		var lines []int
+		for _, e := range errs {
+			lines = append(lines, e.Line)
+		}
+		if !reflect.DeepEqual(lines, tt.errs) {
+			t.Errorf("%s: error lines = %v, want %v (%v)", tt.name, lines, tt.errs, err)
+		}
+		if err != nil && err.Error() != tt.message {
+			t.Errorf("%s: error = %q, want %q", tt.name, err, tt.message)
+		}
+	}
+}
+
+func TestEntryValidate(t *testing.T) {
+	tests := []struct {
+		entry Entry
+		ok    bool
+	}{
+		{Entry{Abbreviation: "Dr", Expansion: "doctor"}, true},
+		{Entry{Abbreviation: "e.g.", Expansion: "for example"}, true},
+		{Entry{Abbreviation: "", Expansion: "nothing"}, false},
+		{Entry{Abbreviation: "A B", Expansion: "a b"}, false},
+		{Entry{Abbreviation: "#1", Expansion: "number one"}, false},
+		{Entry{Abbreviation: "Dr", Expansion: "  "}, false},
+		{Entry{Abbreviation: "Dr", Expansion: "doc\ntor"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.entry.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%q) = %v", tt.entry, err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	l := FromMap(map[string]string{"St": "street", "Dr": "doctor"})
+	l.Set("Rd", "road")
+	l.Set("St", "saint")
+
+	if want := "Dr\tdoctor\nSt\tsaint\nRd\troad\n"; l.String() != want {
+		t.Errorf("list = %q, want %q", l.String(), want)
+	}
+	if want := map[string]string{"Dr": "doctor", "St": "saint", "Rd": "road"}; !reflect.DeepEqual(l.Map(), want) {
+		t.Errorf("Map = %v, want %v", l.Map(), want)
+	}
+
+	// The written file parses back to the same list
+	parsed, err := Parse(strings.NewReader(l.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Map(), l.Map()) {
+		t.Errorf("parsed %v, want %v", parsed.Map(), l.Map())
+	}
+}
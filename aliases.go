@@ -0,0 +1,64 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"encoding/xml"
+)
+
+// elementAliases maps element names used by other versions of the API to
+// the names the response types expect
+var elementAliases = map[string]string{
+	"metadata":          "metadataUrl",
+	"metadataURL":       "metadataUrl",
+	"fileURL":           "fileUrl",
+	"voiceList":         "voicesList",
+	"lexiconsList":      "lexiconList",
+	"abbreviationsList": "abbreviationList",
+	"formatsList":       "formatList",
+}
+
+// WithElementAliases decodes response elements named by the keys of aliases
+// as the elements named by their values, e.g. "audioUrl": "fileUrl", so an
+// application can follow an API change before the package does. They take
+// precedence over the aliases built in for known API versions.
+func WithElementAliases(aliases map[string]string) ClientOption {
+	return func(c *Client) {
+		c.ElementAliases = aliases
+	}
+}
+
+// elementName returns the name response types use for the element local
+func (c *Client) elementName(local string) string {
+	if name, ok := c.ElementAliases[local]; ok {
+		return name
+	}
+	if name, ok := elementAliases[local]; ok {
+		return name
+	}
+
+	return local
+}
+
+// aliasReader renames elements read from dec to the names response types
+// expect. Namespaces are left alone, as fields without one in their tag
+// match elements in any namespace.
+type aliasReader struct {
+	dec    *xml.Decoder
+	client *Client
+}
+
+func (r *aliasReader) Token() (xml.Token, error) {
+	tok, err := r.dec.Token()
+	switch t := tok.(type) {
+	case xml.StartElement:
+		t.Name.Local = r.client.elementName(t.Name.Local)
+		tok = t
+	case xml.EndElement:
+		t.Name.Local = r.client.elementName(t.Name.Local)
+		tok = t
+	}
+
+	return tok, err
+}
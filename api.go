@@ -0,0 +1,25 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import "context"
+
+// CereVoiceAPI is the set of CereVoice Cloud API operations provided by
+// Client. Code which depends on it rather than *Client can be tested with
+// the fakes in the cerevoicetest package.
+type CereVoiceAPI interface {
+	SpeakSimpleWithContext(ctx context.Context, input *SpeakSimpleInput) (*SpeakSimpleResponse, error)
+	SpeakExtendedWithContext(ctx context.Context, input *SpeakExtendedInput) (*SpeakExtendedResponse, error)
+	ListVoicesWithContext(ctx context.Context, input *ListVoicesInput) (*ListVoicesResponse, error)
+	UploadLexiconWithContext(ctx context.Context, input *UploadLexiconInput) (*UploadLexiconResponse, error)
+	ListLexiconsWithContext(ctx context.Context) (*ListLexiconsResponse, error)
+	DeleteLexiconWithContext(ctx context.Context, input *DeleteLexiconInput) (*DeleteLexiconResponse, error)
+	UploadAbbreviationsWithContext(ctx context.Context, input *UploadAbbreviationsInput) (*UploadAbbreviationsResponse, error)
+	ListAbbreviationsWithContext(ctx context.Context) (*ListAbbreviationsResponse, error)
+	DeleteAbbreviationsWithContext(ctx context.Context, input *DeleteAbbreviationsInput) (*DeleteAbbreviationsResponse, error)
+	ListAudioFormatsWithContext(ctx context.Context) (*ListAudioFormatsResponse, error)
+	GetCreditWithContext(ctx context.Context) (*GetCreditResponse, error)
+}
+
+var _ CereVoiceAPI = (*Client)(nil)
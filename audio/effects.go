@@ -0,0 +1,517 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// Effect processes decoded audio in place
+type Effect func(w *WAV) error
+
+// Process decodes a WAV file, applies effects in order and encodes the result
+func Process(b []byte, effects ...Effect) ([]byte, error) {
+	if len(effects) == 0 {
+		return b, nil
+	}
+
+	w, err := DecodeWAV(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Apply(effects...); err != nil {
+		return nil, err
+	}
+
+	return w.Bytes(), nil
+}
+
+// Apply applies effects to the audio in order
+func (w *WAV) Apply(effects ...Effect) error {
+	for _, effect := range effects {
+		if err := effect(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapSamples decodes the samples of w, calls fn and encodes the result
+func mapSamples(w *WAV, fn func(samples []float64) []float64) error {
+	samples, err := w.Samples()
+	if err != nil {
+		return err
+	}
+
+	return w.SetSamples(fn(samples))
+}
+
+// Gain returns an effect changing the volume by db decibels
+func Gain(db float64) Effect {
+	return func(w *WAV) error {
+		return mapSamples(w, func(samples []float64) []float64 {
+			scale(samples, dbToGain(db))
+			return samples
+		})
+	}
+}
+
+// Normalize returns an effect changing the volume so the integrated
+// loudness is target LUFS, e.g. -16 for speech or -23 for broadcast.
+// Peaks are limited to just below full scale. Silence is left unchanged.
+func Normalize(target float64) Effect {
+	return func(w *WAV) error {
+		return mapSamples(w, func(samples []float64) []float64 {
+			lufs := Loudness(samples, w.Format.SampleRate, w.Format.Channels)
+			if math.IsInf(lufs, -1) {
+				return samples
+			}
+
+			gain := dbToGain(target - lufs)
+			if peak := peak(samples); peak*gain > 0.999 {
+				gain = 0.999 / peak
+			}
+			scale(samples, gain)
+			return samples
+		})
+	}
+}
+
+// TrimSilence returns an effect removing leading and trailing audio quieter
+// than threshold decibels relative to full scale, e.g. -50, keeping padding
+// either side of the remaining audio
+func TrimSilence(threshold float64, padding time.Duration) Effect {
+	return func(w *WAV) error {
+		return mapSamples(w, func(samples []float64) []float64 {
+			channels := w.Format.Channels
+			if channels < 1 {
+				channels = 1
+			}
+			frames := len(samples) / channels
+			level := dbToGain(threshold)
+
+			loud := func(frame int) bool {
+				for c := 0; c < channels; c++ {
+					if math.Abs(samples[frame*channels+c]) > level {
+						return true
+					}
+				}
+				return false
+			}
+
+			start, end := 0, frames
+			for start < end && !loud(start) {
+				start++
+			}
+			for end > start && !loud(end-1) {
+				end--
+			}
+			if start == end {
+				return samples[:0]
+			}
+
+			pad := frameCount(padding, w.Format.SampleRate)
+			if start -= pad; start < 0 {
+				start = 0
+			}
+			if end += pad; end > frames {
+				end = frames
+			}
+
+			return samples[start*channels : end*channels]
+		})
+	}
+}
+
+// FadeIn returns an effect fading the start of the audio in over d
+func FadeIn(d time.Duration) Effect {
+	return fade(d, false)
+}
+
+// FadeOut returns an effect fading the end of the audio out over d
+func FadeOut(d time.Duration) Effect {
+	return fade(d, true)
+}
+
+// fade applies a linear fade to the start, or end, of the audio
+func fade(d time.Duration, out bool) Effect {
+	return func(w *WAV) error {
+		return mapSamples(w, func(samples []float64) []float64 {
+			channels := w.Format.Channels
+			if channels < 1 {
+				channels = 1
+			}
+			frames := len(samples) / channels
+			n := frameCount(d, w.Format.SampleRate)
+			if n > frames {
+				n = frames
+			}
+
+			for i := 0; i < n; i++ {
+				frame, g := i, float64(i)/float64(n)
+				if out {
+					frame = frames - 1 - i
+				}
+				for c := 0; c < channels; c++ {
+					samples[frame*channels+c] *= g
+				}
+			}
+			return samples
+		})
+	}
+}
+
+// Loudness returns the integrated loudness of interleaved samples in LUFS,
+// measured as described in ITU-R BS.1770-4 with every channel weighted
+// equally. It returns negative infinity for silence.
+func Loudness(samples []float64, sampleRate, channels int) float64 {
+	if channels < 1 {
+		channels = 1
+	}
+	frames := len(samples) / channels
+	if frames == 0 || sampleRate <= 0 {
+		return math.Inf(-1)
+	}
+
+	// K-weight each channel and square the result
+	power := make([][]float64, channels)
+	for c := range power {
+		shelf := highShelf(sampleRate)
+		pass := highPass(sampleRate)
+		power[c] = make([]float64, frames)
+		for i := 0; i < frames; i++ {
+			v := pass.filter(shelf.filter(samples[i*channels+c]))
+			power[c][i] = v * v
+		}
+	}
+
+	// Mean square of 400ms blocks overlapping by 75%
+	block := sampleRate * 2 / 5
+	step := block / 4
+	if block > frames {
+		block, step = frames, frames
+	}
+	var blocks []float64
+	for start := 0; start+block <= frames; start += step {
+		var z float64
+		for c := range power {
+			var sum float64
+			for _, p := range power[c][start : start+block] {
+				sum += p
+			}
+			z += sum / float64(block)
+		}
+		blocks = append(blocks, z)
+	}
+
+	// Absolute gate at -70 LUFS, then relative gate 10 LU below
+	gated := gate(blocks, math.Inf(-1), -70)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	gated = gate(gated, loudness(mean(gated))-10, -70)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+
+	return loudness(mean(gated))
+}
+
+// gate returns the blocks louder than both thresholds
+func gate(blocks []float64, relative, absolute float64) []float64 {
+	var out []float64
+	for _, z := range blocks {
+		if l := loudness(z); l > absolute && l > relative {
+			out = append(out, z)
+		}
+	}
+	return out
+}
+
+// loudness converts a mean square power to LUFS
+func loudness(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
+
+func mean(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x
+	}
+	return sum / float64(len(v))
+}
+
+// biquad is a second order IIR filter
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) filter(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x1, f.x2, f.y1, f.y2 = x, f.x1, y, f.y1
+	return y
+}
+
+// K-weighting filter parameters from which the ITU-R BS.1770-4 coefficients
+// are derived, so they match the tabulated 48kHz values exactly and scale to
+// other sample rates
+const (
+	shelfFreq    = 1681.974450955533
+	shelfGain    = 3.999843853973347
+	shelfQ       = 0.7071752369554196
+	shelfBlend   = 0.4996667741545416
+	highPassQ    = 0.5003270373238773
+	highPassFreq = 38.13547087602444
+)
+
+// highShelf returns the first stage of the K-weighting filter, modelling
+// the acoustic effect of the head
+func highShelf(sampleRate int) *biquad {
+	k := math.Tan(math.Pi * shelfFreq / float64(sampleRate))
+	vh := math.Pow(10, shelfGain/20)
+	vb := math.Pow(vh, shelfBlend)
+
+	a0 := 1 + k/shelfQ + k*k
+	return &biquad{
+		b0: (vh + vb*k/shelfQ + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/shelfQ + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/shelfQ + k*k) / a0,
+	}
+}
+
+// highPass returns the second stage of the K-weighting filter. As in
+// BS.1770 the numerator is left unnormalised.
+func highPass(sampleRate int) *biquad {
+	k := math.Tan(math.Pi * highPassFreq / float64(sampleRate))
+
+	a0 := 1 + k/highPassQ + k*k
+	return &biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/highPassQ + k*k) / a0,
+	}
+}
+
+func dbToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+func scale(samples []float64, gain float64) {
+	for i := range samples {
+		samples[i] *= gain
+	}
+}
+
+func peak(samples []float64) float64 {
+	var p float64
+	for _, s := range samples {
+		if a := math.Abs(s); a > p {
+			p = a
+		}
+	}
+	return p
+}
+
+// frameCount returns the number of frames in d
+func frameCount(d time.Duration, sampleRate int) int {
+	return int(d.Seconds() * float64(sampleRate))
+}
+
+// Resample returns an effect converting the audio to sampleRate. Lowering
+// the rate first removes frequencies above the new Nyquist frequency with a
+// windowed sinc low-pass, so they do not alias. Raising it interpolates
+// linearly, which is adequate for speech.
+func Resample(sampleRate int) Effect {
+	return func(w *WAV) error {
+		if sampleRate <= 0 || sampleRate == w.Format.SampleRate {
+			return nil
+		}
+
+		samples, err := w.Samples()
+		if err != nil {
+			return err
+		}
+
+		channels := w.Format.Channels
+		if channels < 1 {
+			channels = 1
+		}
+		frames := len(samples) / channels
+		ratio := float64(w.Format.SampleRate) / float64(sampleRate)
+		n := int(float64(frames) / ratio)
+
+		var out []float64
+		if ratio > 1 {
+			out = decimate(samples, channels, n, ratio)
+		} else {
+			out = interpolate(samples, channels, n, ratio)
+		}
+
+		format := w.Format
+		format.SampleRate = sampleRate
+		data, err := EncodeSamples(format, out)
+		if err != nil {
+			return err
+		}
+		w.Format, w.Data = format, data
+		return nil
+	}
+}
+
+// interpolate returns n frames of samples taken every ratio input frames,
+// interpolating linearly between them
+func interpolate(samples []float64, channels, n int, ratio float64) []float64 {
+	frames := len(samples) / channels
+	out := make([]float64, n*channels)
+	for i := 0; i < n; i++ {
+		pos := float64(i) * ratio
+		j := int(pos)
+		frac := pos - float64(j)
+		for c := 0; c < channels; c++ {
+			a := samples[j*channels+c]
+			b := a
+			if j+1 < frames {
+				b = samples[(j+1)*channels+c]
+			}
+			out[i*channels+c] = a + (b-a)*frac
+		}
+	}
+
+	return out
+}
+
+// sincZeros is the number of zero crossings either side of the centre of the
+// low-pass used when decimating
+const sincZeros = 16
+
+// decimate returns n frames of samples taken every ratio input frames,
+// filtered by a Blackman windowed sinc with its cutoff just below the output
+// Nyquist frequency
+func decimate(samples []float64, channels, n int, ratio float64) []float64 {
+	frames := len(samples) / channels
+	cutoff := 0.95 * 0.5 / ratio // Cycles per input frame
+	half := sincZeros / (2 * cutoff)
+
+	out := make([]float64, n*channels)
+	sum := make([]float64, channels)
+	for i := 0; i < n; i++ {
+		pos := float64(i) * ratio
+		first := int(math.Ceil(pos - half))
+		if first < 0 {
+			first = 0
+		}
+		last := int(math.Floor(pos + half))
+		if last >= frames {
+			last = frames - 1
+		}
+
+		for c := range sum {
+			sum[c] = 0
+		}
+		var weight float64
+		for j := first; j <= last; j++ {
+			t := float64(j) - pos
+			h := sinc(2*cutoff*t) * blackman(t/half)
+			weight += h
+			for c := 0; c < channels; c++ {
+				sum[c] += samples[j*channels+c] * h
+			}
+		}
+
+		// Normalising keeps the gain at DC exactly one, even at the edges
+		for c := 0; c < channels; c++ {
+			if weight != 0 {
+				out[i*channels+c] = sum[c] / weight
+			}
+		}
+	}
+
+	return out
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman returns the Blackman window at x, from -1 to 1
+func blackman(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
+
+// Remix returns an effect converting the audio to the given number of
+// channels. Mono is copied to every channel, and other layouts are mixed
+// down to mono first.
+func Remix(channels int) Effect {
+	return func(w *WAV) error {
+		from := w.Format.Channels
+		if from < 1 {
+			from = 1
+		}
+		if channels <= 0 || channels == from {
+			return nil
+		}
+
+		samples, err := w.Samples()
+		if err != nil {
+			return err
+		}
+
+		frames := len(samples) / from
+		out := make([]float64, frames*channels)
+		for i := 0; i < frames; i++ {
+			var sum float64
+			for c := 0; c < from; c++ {
+				sum += samples[i*from+c]
+			}
+			for c := 0; c < channels; c++ {
+				out[i*channels+c] = sum / float64(from)
+			}
+		}
+
+		format := w.Format
+		format.Channels = channels
+		data, err := EncodeSamples(format, out)
+		if err != nil {
+			return err
+		}
+		w.Format, w.Data = format, data
+		return nil
+	}
+}
+
+// Convert returns an effect re-encoding the audio with another encoding and
+// bits per sample, e.g. Convert(FormatMuLaw, 8) for telephony
+func Convert(encoding, bitsPerSample int) Effect {
+	return func(w *WAV) error {
+		format := w.Format
+		format.Encoding, format.BitsPerSample = encoding, bitsPerSample
+		if format == w.Format {
+			return nil
+		}
+
+		samples, err := w.Samples()
+		if err != nil {
+			return err
+		}
+		data, err := EncodeSamples(format, samples)
+		if err != nil {
+			return err
+		}
+		w.Format, w.Data = format, data
+		return nil
+	}
+}
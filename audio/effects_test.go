@@ -0,0 +1,167 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// sine returns d of a mono sine wave at freq Hz with the given peak amplitude
+func sine(freq, amplitude float64, sampleRate int, d time.Duration) []float64 {
+	samples := make([]float64, frameCount(d, sampleRate))
+	for i := range samples {
+		samples[i] = amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+	}
+	return samples
+}
+
+func rms(samples []float64) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += s * s
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestKWeighting(t *testing.T) {
+	// Coefficients tabulated for 48kHz in ITU-R BS.1770-4
+	tests := []struct {
+		name string
+		got  *biquad
+		want biquad
+	}{
+		{"shelf", highShelf(48000), biquad{b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285, a1: -1.69065929318241, a2: 0.73248077421585}},
+		{"high pass", highPass(48000), biquad{b0: 1, b1: -2, b2: 1, a1: -1.99004745483398, a2: 0.99007225036621}},
+	}
+
+	for _, tt := range tests {
+		got := []float64{tt.got.b0, tt.got.b1, tt.got.b2, tt.got.a1, tt.got.a2}
+		want := []float64{tt.want.b0, tt.want.b1, tt.want.b2, tt.want.a1, tt.want.a2}
+		for i := range got {
+			if math.Abs(got[i]-want[i]) > 1e-8 {
+				t.Errorf("%s: coefficients = %v, want %v", tt.name, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestLoudness(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    []float64
+		sampleRate int
+		channels   int
+		want       float64
+	}{
+		// BS.1770 calibration: a full scale 997Hz sine in one channel is -3.01
+		{"full scale 48kHz", sine(997, 1, 48000, 2*time.Second), 48000, 1, -3.01},
+		{"full scale 16kHz", sine(997, 1, 16000, 2*time.Second), 16000, 1, -3.01},
+		{"-20 dBFS", sine(997, 0.1, 48000, 2*time.Second), 48000, 1, -23.01},
+		{"silence", make([]float64, 48000), 48000, 1, math.Inf(-1)},
+		{"below absolute gate", sine(997, 0.0001, 48000, time.Second), 48000, 1, math.Inf(-1)},
+		{"empty", nil, 48000, 1, math.Inf(-1)},
+	}
+
+	for _, tt := range tests {
+		got := Loudness(tt.samples, tt.sampleRate, tt.channels)
+		if math.IsInf(tt.want, -1) {
+			if !math.IsInf(got, -1) {
+				t.Errorf("%s: Loudness = %.2f, want -Inf", tt.name, got)
+			}
+			continue
+		}
+		if math.Abs(got-tt.want) > 0.05 {
+			t.Errorf("%s: Loudness = %.2f, want %.2f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		name     string
+		freq     float64
+		from, to int
+		want     float64 // RMS of the output, relative to the input
+	}{
+		{"passband down", 500, 48000, 8000, 1},
+		{"above new Nyquist", 7000, 48000, 8000, 0},
+		{"just above new Nyquist", 4400, 22050, 8000, 0},
+		{"passband up", 500, 8000, 16000, 1},
+	}
+
+	for _, tt := range tests {
+		in := sine(tt.freq, 0.5, tt.from, time.Second)
+		w := &WAV{Format: PCM16(tt.from, 1)}
+		if err := w.SetSamples(in); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Apply(Resample(tt.to)); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if w.Format.SampleRate != tt.to {
+			t.Errorf("%s: sample rate = %d, want %d", tt.name, w.Format.SampleRate, tt.to)
+		}
+		out, err := w.Samples()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(out); n != tt.to {
+			t.Errorf("%s: %d samples, want %d", tt.name, n, tt.to)
+		}
+
+		// Skip the edges, where the filter runs off the end of the audio
+		got := rms(out[len(out)/10:len(out)*9/10]) / rms(in)
+		if math.Abs(got-tt.want) > 0.02 {
+			t.Errorf("%s: relative level = %.4f, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEffects(t *testing.T) {
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	tests := []struct {
+		name    string
+		samples []float64
+		effect  Effect
+		want    []float64
+	}{
+		{"gain", []float64{0.5, -0.25}, Gain(-6.0206), []float64{0.25, -0.125}},
+		{"trim", []float64{0, 0, 0.5, 0, 0.5, 0, 0}, TrimSilence(-50, 0), []float64{0.5, 0, 0.5}},
+		{"trim padding", []float64{0, 0, 0.5, 0, 0}, TrimSilence(-50, ms(1)), []float64{0, 0.5, 0}},
+		{"trim silence", []float64{0, 0, 0}, TrimSilence(-50, 0), nil},
+		{"fade in", []float64{1, 1, 1, 1}, FadeIn(ms(2)), []float64{0, 0.5, 1, 1}},
+		{"fade out", []float64{1, 1, 1, 1}, FadeOut(ms(2)), []float64{1, 1, 0.5, 0}},
+		{"fade longer than audio", []float64{1, 1}, FadeIn(ms(4)), []float64{0, 0.5}},
+	}
+
+	for _, tt := range tests {
+		// 1000 Hz, so each sample is 1ms
+		w := &WAV{Format: Format{Encoding: FormatFloat, Channels: 1, SampleRate: 1000, BitsPerSample: 64}}
+		if err := w.SetSamples(tt.samples); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Apply(tt.effect); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got, err := w.Samples()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: samples = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > 1e-4 {
+				t.Errorf("%s: samples = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package audio decodes and encodes the WAV and raw PCM audio produced by
+// CereVoice so it can be processed without a third party library.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+)
+
+// WAV format codes
+const (
+	FormatPCM   = 1
+	FormatFloat = 3
+	FormatALaw  = 6
+	FormatMuLaw = 7
+)
+
+var (
+	// ErrNotWAV is returned when data is not a RIFF WAV file
+	ErrNotWAV = errors.New("audio: not a WAV file")
+	// ErrUnsupportedFormat is returned when samples are requested for an
+	// encoding other than integer or float PCM
+	ErrUnsupportedFormat = errors.New("audio: unsupported sample format")
+	// ErrFormatMismatch is returned when joining audio with different formats
+	ErrFormatMismatch = errors.New("audio: formats differ")
+)
+
+// Format describes how audio samples are encoded
+type Format struct {
+	Encoding      int // WAV format code, e.g. FormatPCM
+	Channels      int
+	SampleRate    int // Samples per second per channel
+	BitsPerSample int
+}
+
+// PCM16 returns the format of 16 bit integer PCM audio, as used for raw
+// CereVoice output
+func PCM16(sampleRate, channels int) Format {
+	return Format{
+		Encoding:      FormatPCM,
+		Channels:      channels,
+		SampleRate:    sampleRate,
+		BitsPerSample: 16,
+	}
+}
+
+// BlockAlign returns the bytes per frame, one sample for every channel
+func (f Format) BlockAlign() int {
+	return f.Channels * ((f.BitsPerSample + 7) / 8)
+}
+
+// ByteRate returns the bytes of audio per second
+func (f Format) ByteRate() int {
+	return f.SampleRate * f.BlockAlign()
+}
+
+// Duration returns the length of n bytes of audio
+func (f Format) Duration(n int) time.Duration {
+	rate := f.ByteRate()
+	if rate == 0 {
+		return 0
+	}
+
+	return time.Duration(int64(n) * int64(time.Second) / int64(rate))
+}
+
+// WAV is a decoded WAV file
+type WAV struct {
+	Format Format
+	Data   []byte // Encoded samples from the data chunk
+}
+
+// DecodeWAV parses a RIFF WAV file. Chunks other than fmt and data are
+// ignored.
+func DecodeWAV(b []byte) (*WAV, error) {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, ErrNotWAV
+	}
+
+	w := &WAV{}
+	haveFormat, haveData := false, false
+
+	for p := 12; p+8 <= len(b); {
+		id := string(b[p : p+4])
+		size := int(binary.LittleEndian.Uint32(b[p+4 : p+8]))
+		p += 8
+
+		// Streamed WAV files may have a placeholder data size
+		if size < 0 || p+size > len(b) {
+			size = len(b) - p
+		}
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, ErrNotWAV
+			}
+			chunk := b[p : p+size]
+			w.Format = Format{
+				Encoding:      int(binary.LittleEndian.Uint16(chunk[0:2])),
+				Channels:      int(binary.LittleEndian.Uint16(chunk[2:4])),
+				SampleRate:    int(binary.LittleEndian.Uint32(chunk[4:8])),
+				BitsPerSample: int(binary.LittleEndian.Uint16(chunk[14:16])),
+			}
+			// WAVE_FORMAT_EXTENSIBLE stores the real format in the sub format
+			if w.Format.Encoding == 0xFFFE && size >= 26 {
+				w.Format.Encoding = int(binary.LittleEndian.Uint16(chunk[24:26]))
+			}
+			haveFormat = true
+		case "data":
+			w.Data = b[p : p+size]
+			haveData = true
+		}
+
+		p += size + size%2
+	}
+
+	if !haveFormat || !haveData {
+		return nil, ErrNotWAV
+	}
+
+	return w, nil
+}
+
+// ReadWAV reads and parses a WAV file from r
+func ReadWAV(r io.Reader) (*WAV, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeWAV(b)
+}
+
+// Duration returns the length of the audio
+func (w *WAV) Duration() time.Duration {
+	return w.Format.Duration(len(w.Data))
+}
+
+// Bytes encodes the WAV file
+func (w *WAV) Bytes() []byte {
+	var b bytes.Buffer
+	w.WriteTo(&b)
+	return b.Bytes()
+}
+
+// WriteTo writes the encoded WAV file to dst
+func (w *WAV) WriteTo(dst io.Writer) (int64, error) {
+	f := w.Format
+	var b bytes.Buffer
+
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+8+16+8+len(w.Data)+len(w.Data)%2))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, uint16(f.Encoding))
+	binary.Write(&b, binary.LittleEndian, uint16(f.Channels))
+	binary.Write(&b, binary.LittleEndian, uint32(f.SampleRate))
+	binary.Write(&b, binary.LittleEndian, uint32(f.ByteRate()))
+	binary.Write(&b, binary.LittleEndian, uint16(f.BlockAlign()))
+	binary.Write(&b, binary.LittleEndian, uint16(f.BitsPerSample))
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(w.Data)))
+
+	n, err := dst.Write(b.Bytes())
+	total := int64(n)
+	if err != nil {
+		return total, err
+	}
+
+	n, err = dst.Write(w.Data)
+	total += int64(n)
+	if err != nil {
+		return total, err
+	}
+
+	if len(w.Data)%2 == 1 {
+		n, err = dst.Write([]byte{0})
+		total += int64(n)
+	}
+
+	return total, err
+}
+
+// Samples decodes the audio into interleaved samples in the range -1 to 1
+func (w *WAV) Samples() ([]float64, error) {
+	return DecodeSamples(w.Format, w.Data)
+}
+
+// SetSamples replaces the audio with interleaved samples in the range -1 to
+// 1, encoded in the existing format
+func (w *WAV) SetSamples(samples []float64) error {
+	data, err := EncodeSamples(w.Format, samples)
+	if err != nil {
+		return err
+	}
+
+	w.Data = data
+	return nil
+}
+
+// Concat joins audio with identical formats
+func Concat(files ...*WAV) (*WAV, error) {
+	if len(files) == 0 {
+		return nil, errors.New("audio: nothing to join")
+	}
+
+	out := &WAV{Format: files[0].Format}
+	for _, f := range files {
+		if f.Format != out.Format {
+			return nil, ErrFormatMismatch
+		}
+		out.Data = append(out.Data, f.Data...)
+	}
+
+	return out, nil
+}
+
+// DecodeSamples decodes interleaved integer PCM, float PCM or μ-law into
+// samples in the range -1 to 1
+func DecodeSamples(f Format, data []byte) ([]float64, error) {
+	width := (f.BitsPerSample + 7) / 8
+	if width == 0 || !supported(f) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	samples := make([]float64, len(data)/width)
+	for i := range samples {
+		b := data[i*width : (i+1)*width]
+
+		switch {
+		case f.Encoding == FormatMuLaw:
+			samples[i] = float64(muLawDecode(b[0])) / 32768
+		case f.Encoding == FormatFloat && width == 4:
+			samples[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case f.Encoding == FormatFloat && width == 8:
+			samples[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case width == 1:
+			// 8 bit PCM is unsigned
+			samples[i] = (float64(b[0]) - 128) / 128
+		case width == 2:
+			samples[i] = float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+		case width == 3:
+			v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+			samples[i] = float64(v) / 8388608
+		case width == 4:
+			samples[i] = float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+		}
+	}
+
+	return samples, nil
+}
+
+// EncodeSamples encodes samples in the range -1 to 1 as interleaved integer
+// PCM, float PCM or μ-law. Samples outside the range are clipped.
+func EncodeSamples(f Format, samples []float64) ([]byte, error) {
+	width := (f.BitsPerSample + 7) / 8
+	if width == 0 || !supported(f) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	data := make([]byte, len(samples)*width)
+	for i, s := range samples {
+		if s > 1 {
+			s = 1
+		} else if s < -1 {
+			s = -1
+		}
+		b := data[i*width : (i+1)*width]
+
+		switch {
+		case f.Encoding == FormatMuLaw:
+			b[0] = muLawEncode(int16(math.Round(s * 32767)))
+		case f.Encoding == FormatFloat && width == 4:
+			binary.LittleEndian.PutUint32(b, math.Float32bits(float32(s)))
+		case f.Encoding == FormatFloat && width == 8:
+			binary.LittleEndian.PutUint64(b, math.Float64bits(s))
+		case width == 1:
+			b[0] = uint8(math.Round(s*127) + 128)
+		case width == 2:
+			binary.LittleEndian.PutUint16(b, uint16(int16(math.Round(s*32767))))
+		case width == 3:
+			v := int32(math.Round(s * 8388607))
+			b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+		case width == 4:
+			binary.LittleEndian.PutUint32(b, uint32(int32(math.Round(s*2147483647))))
+		}
+	}
+
+	return data, nil
+}
+
+// supported reports whether samples in format f can be decoded
+func supported(f Format) bool {
+	switch f.Encoding {
+	case FormatPCM:
+		return f.BitsPerSample >= 8 && f.BitsPerSample <= 32
+	case FormatFloat:
+		return f.BitsPerSample == 32 || f.BitsPerSample == 64
+	case FormatMuLaw:
+		return f.BitsPerSample == 8
+	}
+
+	return false
+}
+
+// MuLaw returns the format of G.711 μ-law audio, as used by telephony
+func MuLaw(sampleRate, channels int) Format {
+	return Format{
+		Encoding:      FormatMuLaw,
+		Channels:      channels,
+		SampleRate:    sampleRate,
+		BitsPerSample: 8,
+	}
+}
+
+// muLawEncode compresses a 16 bit sample to G.711 μ-law
+func muLawEncode(s int16) byte {
+	const bias, clip = 0x84, 32635
+
+	v := int(s)
+	var sign byte
+	if v < 0 {
+		v, sign = -v, 0x80
+	}
+	if v > clip {
+		v = clip
+	}
+	v += bias
+
+	exp := 7
+	for mask := 0x4000; v&mask == 0 && exp > 0; mask >>= 1 {
+		exp--
+	}
+	mantissa := (v >> uint(exp+3)) & 0x0f
+
+	return ^(sign | byte(exp<<4) | byte(mantissa))
+}
+
+// muLawDecode expands a G.711 μ-law byte to a 16 bit sample
+func muLawDecode(b byte) int16 {
+	b = ^b
+	exp := uint(b>>4) & 0x07
+	v := (int(b&0x0f)<<3+0x84)<<exp - 0x84
+	if b&0x80 != 0 {
+		return int16(-v)
+	}
+
+	return int16(v)
+}
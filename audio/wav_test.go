@@ -0,0 +1,248 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// chunk encodes a RIFF chunk
+func chunk(id string, data []byte) []byte {
+	b := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(data)))
+	b = append(b, data...)
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// fmtChunk encodes the body of a fmt chunk
+func fmtChunk(encoding, channels, rate, bits int) []byte {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint16(b[0:], uint16(encoding))
+	binary.LittleEndian.PutUint16(b[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(b[4:], uint32(rate))
+	binary.LittleEndian.PutUint32(b[8:], uint32(rate*channels*bits/8))
+	binary.LittleEndian.PutUint16(b[12:], uint16(channels*bits/8))
+	binary.LittleEndian.PutUint16(b[14:], uint16(bits))
+	return b
+}
+
+// riff encodes a WAV file from chunks
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return chunk("RIFF", body)
+}
+
+func TestDecodeWAV(t *testing.T) {
+	pcm := fmtChunk(FormatPCM, 1, 8000, 16)
+	extensible := append(fmtChunk(0xFFFE, 2, 48000, 32), 22, 0, 32, 0, 3, 0, 0, 0, 3, 0)
+	data := []byte{1, 2, 3, 4}
+
+	tests := []struct {
+		name   string
+		file   []byte
+		format Format
+		data   []byte
+		err    error
+	}{
+		{"pcm", riff(chunk("fmt ", pcm), chunk("data", data)), PCM16(8000, 1), data, nil},
+		{"other chunks", riff(chunk("LIST", []byte("abc")), chunk("fmt ", pcm), chunk("fact", []byte{0, 0, 0, 0}), chunk("data", data)), PCM16(8000, 1), data, nil},
+		{"extensible", riff(chunk("fmt ", extensible), chunk("data", data)), Format{FormatFloat, 2, 48000, 32}, data, nil},
+		{"streamed", append(riff(chunk("fmt ", pcm))[:36:36], append([]byte("data\xff\xff\xff\xff"), data...)...), PCM16(8000, 1), data, nil},
+		{"odd data", riff(chunk("fmt ", pcm), chunk("data", data[:3])), PCM16(8000, 1), data[:3], nil},
+		{"not riff", append([]byte("RIFX"), riff(chunk("fmt ", pcm), chunk("data", data))[4:]...), Format{}, nil, ErrNotWAV},
+		{"no data", riff(chunk("fmt ", pcm)), Format{}, nil, ErrNotWAV},
+		{"no format", riff(chunk("data", data)), Format{}, nil, ErrNotWAV},
+		{"short format", riff(chunk("fmt ", pcm[:12]), chunk("data", data)), Format{}, nil, ErrNotWAV},
+		{"empty", nil, Format{}, nil, ErrNotWAV},
+	}
+
+	for _, tt := range tests {
+		w, err := DecodeWAV(tt.file)
+		if err != tt.err {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if w.Format != tt.format {
+			t.Errorf("%s: format = %+v, want %+v", tt.name, w.Format, tt.format)
+		}
+		if !bytes.Equal(w.Data, tt.data) {
+			t.Errorf("%s: data = %v, want %v", tt.name, w.Data, tt.data)
+		}
+	}
+}
+
+func TestWAVRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		wav  WAV
+	}{
+		{"pcm", WAV{PCM16(16000, 1), []byte{1, 2, 3, 4}}},
+		{"stereo", WAV{PCM16(48000, 2), []byte{1, 2, 3, 4, 5, 6, 7, 8}}},
+		{"mu-law odd length", WAV{MuLaw(8000, 1), []byte{0xff, 0x80, 0x00}}},
+		{"empty", WAV{PCM16(8000, 1), []byte{}}},
+	}
+
+	for _, tt := range tests {
+		b := tt.wav.Bytes()
+		if len(b)%2 != 0 {
+			t.Errorf("%s: %d bytes, want chunks padded to even length", tt.name, len(b))
+		}
+		if size := int(binary.LittleEndian.Uint32(b[4:8])); size != len(b)-8 {
+			t.Errorf("%s: RIFF size %d, want %d", tt.name, size, len(b)-8)
+		}
+
+		w, err := DecodeWAV(b)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*w, tt.wav) {
+			t.Errorf("%s: decoded %+v, want %+v", tt.name, *w, tt.wav)
+		}
+	}
+}
+
+func TestSamplesRoundTrip(t *testing.T) {
+	samples := []float64{0, 0.5, -0.5, 0.999, -1, 0.25}
+
+	// Integer samples are within two steps, as they are scaled to one less
+	// than full scale when encoded
+	tests := []struct {
+		name      string
+		format    Format
+		tolerance float64
+	}{
+		{"8 bit", Format{FormatPCM, 1, 8000, 8}, 2.0 / 128},
+		{"16 bit", PCM16(8000, 1), 2.0 / 32768},
+		{"24 bit", Format{FormatPCM, 1, 8000, 24}, 2.0 / 8388608},
+		{"32 bit", Format{FormatPCM, 1, 8000, 32}, 2.0 / 2147483648},
+		{"float", Format{FormatFloat, 1, 8000, 32}, 1e-7},
+		{"double", Format{FormatFloat, 1, 8000, 64}, 0},
+		// μ-law steps are at most 1024 of 32768 near full scale
+		{"mu-law", MuLaw(8000, 1), 1024.0 / 32768},
+	}
+
+	for _, tt := range tests {
+		data, err := EncodeSamples(tt.format, samples)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := len(samples) * tt.format.BitsPerSample / 8; len(data) != want {
+			t.Errorf("%s: %d bytes, want %d", tt.name, len(data), want)
+		}
+		got, err := DecodeSamples(tt.format, data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for i := range samples {
+			if math.Abs(got[i]-samples[i]) > tt.tolerance+1e-9 {
+				t.Errorf("%s: sample %d = %v, want %v", tt.name, i, got[i], samples[i])
+			}
+		}
+	}
+}
+
+func TestSamplesClipped(t *testing.T) {
+	data, err := EncodeSamples(PCM16(8000, 1), []float64{2, -2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := []int16{int16(binary.LittleEndian.Uint16(data)), int16(binary.LittleEndian.Uint16(data[2:]))}; got[0] != 32767 || got[1] != -32767 {
+		t.Errorf("clipped samples = %v, want [32767 -32767]", got)
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	tests := []Format{
+		{FormatALaw, 1, 8000, 8},
+		{FormatFloat, 1, 8000, 16},
+		{FormatMuLaw, 1, 8000, 16},
+		{FormatPCM, 1, 8000, 0},
+		{FormatPCM, 1, 8000, 40},
+		{0x55, 1, 8000, 16},
+	}
+
+	for _, f := range tests {
+		if _, err := DecodeSamples(f, []byte{0, 0}); err != ErrUnsupportedFormat {
+			t.Errorf("DecodeSamples(%+v) error = %v", f, err)
+		}
+		if _, err := EncodeSamples(f, []float64{0}); err != ErrUnsupportedFormat {
+			t.Errorf("EncodeSamples(%+v) error = %v", f, err)
+		}
+	}
+}
+
+func TestMuLaw(t *testing.T) {
+	// Values of the widely used Sun G.711 implementation
+	tests := []struct {
+		sample  int16
+		encoded byte
+		decoded int16
+	}{
+		{0, 0xff, 0},
+		{1, 0xff, 0},
+		{-1, 0x7f, 0},
+		{8, 0xfe, 8},
+		{100, 0xf2, 104},
+		{1000, 0xce, 988},
+		{-1000, 0x4e, -988},
+		{10000, 0x9c, 9852},
+		{32767, 0x80, 32124},
+		{-32768, 0x00, -32124},
+	}
+
+	for _, tt := range tests {
+		if got := muLawEncode(tt.sample); got != tt.encoded {
+			t.Errorf("muLawEncode(%d) = %#02x, want %#02x", tt.sample, got, tt.encoded)
+		}
+		if got := muLawDecode(tt.encoded); got != tt.decoded {
+			t.Errorf("muLawDecode(%#02x) = %d, want %d", tt.encoded, got, tt.decoded)
+		}
+	}
+
+	// Every code other than negative zero survives a round trip
+	for b := 0; b < 256; b++ {
+		if b == 0x7f {
+			continue
+		}
+		if got := muLawEncode(muLawDecode(byte(b))); got != byte(b) {
+			t.Errorf("muLawEncode(muLawDecode(%#02x)) = %#02x", b, got)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		format Format
+		n      int
+		want   time.Duration
+	}{
+		{PCM16(8000, 1), 16000, time.Second},
+		{PCM16(48000, 2), 19200, 100 * time.Millisecond},
+		{MuLaw(8000, 1), 4000, 500 * time.Millisecond},
+		{Format{}, 100, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.format.Duration(tt.n); got != tt.want {
+			t.Errorf("Duration(%+v, %d) = %v, want %v", tt.format, tt.n, got, tt.want)
+		}
+	}
+}
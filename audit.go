@@ -0,0 +1,146 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// RedactedText replaces redacted text when a PatternAuditor has no
+// Replacement
+const RedactedText = "[REDACTED]"
+
+// ErrTextDenied is returned when a TextAuditor denies the text of a speak
+// request
+var ErrTextDenied = errors.New("cerevoicego: text denied")
+
+// TextAction is what happens to the text of a speak request
+type TextAction int
+
+// Text actions
+const (
+	// TextAllow sends the text unchanged
+	TextAllow TextAction = iota
+	// TextDeny fails the request with ErrTextDenied without sending it
+	TextDeny
+	// TextTransform sends the decision's Text in its place
+	TextTransform
+)
+
+// String returns the action name
+func (a TextAction) String() string {
+	switch a {
+	case TextDeny:
+		return "deny"
+	case TextTransform:
+		return "transform"
+	}
+
+	return "allow"
+}
+
+// TextAudit is the text of a speak request about to be sent
+type TextAudit struct {
+	Operation string // API function, e.g. speakExtended
+	Voice     string // Voice requested
+	Text      string // Text as it will be sent, after TextSteps and SpeakMode markup
+}
+
+// TextDecision is a TextAuditor's verdict on the text of a request
+type TextDecision struct {
+	Action TextAction
+	Text   string // Text sent in place of the original, for TextTransform, checked against the text limit
+	Reason string // Why the text was denied, for TextDeny
+
+	// Logged, if set, replaces the text sent in the RequestLog and Debug
+	// captures, so personal data can be kept out of them. It does not reach
+	// an HTTPClient such as cerevoicetest.Recorder, which sees the request
+	// as sent, nor the input kept by a jobs.Store.
+	Logged string
+}
+
+// TextAuditor inspects the final text of every speak request before it is
+// sent, to keep an audit trail, redact personal data from logs or block
+// disallowed content. Implementations must be safe for concurrent use.
+type TextAuditor interface {
+	AuditText(ctx context.Context, audit *TextAudit) TextDecision
+}
+
+// TextAuditorFunc adapts a function to the TextAuditor interface
+type TextAuditorFunc func(ctx context.Context, audit *TextAudit) TextDecision
+
+// AuditText calls f(ctx, audit)
+func (f TextAuditorFunc) AuditText(ctx context.Context, audit *TextAudit) TextDecision {
+	return f(ctx, audit)
+}
+
+// WithTextAuditor passes the text of every speak request to a before it is
+// sent
+func WithTextAuditor(a TextAuditor) ClientOption {
+	return func(c *Client) {
+		c.TextAuditor = a
+	}
+}
+
+// PatternAuditor is a TextAuditor which denies text matching a Deny pattern
+// and redacts matches of the Redact patterns, such as card or telephone
+// numbers, from logs, and optionally from the text sent
+type PatternAuditor struct {
+	Deny        []*regexp.Regexp // Text matching any is denied
+	Redact      []*regexp.Regexp // Matches are replaced in logs
+	Replacement string           // Replaces redacted matches, RedactedText if empty
+	RedactSent  bool             // Also replace matches in the text sent
+}
+
+// AuditText denies or redacts the text
+func (p *PatternAuditor) AuditText(ctx context.Context, audit *TextAudit) TextDecision {
+	for _, re := range p.Deny {
+		if re.MatchString(audit.Text) {
+			return TextDecision{Action: TextDeny, Reason: "matches " + re.String()}
+		}
+	}
+
+	replacement := p.Replacement
+	if replacement == "" {
+		replacement = RedactedText
+	}
+	redacted := audit.Text
+	for _, re := range p.Redact {
+		redacted = re.ReplaceAllLiteralString(redacted, replacement)
+	}
+	if redacted == audit.Text {
+		return TextDecision{Action: TextAllow}
+	}
+
+	if p.RedactSent {
+		return TextDecision{Action: TextTransform, Text: redacted}
+	}
+	return TextDecision{Action: TextAllow, Logged: redacted}
+}
+
+// auditText passes the text of req to the TextAuditor, applying its
+// decision
+func (c *Client) auditText(ctx context.Context, req *Request) error {
+	d := c.TextAuditor.AuditText(ctx, &TextAudit{
+		Operation: req.XMLName.Local,
+		Voice:     req.Voice,
+		Text:      req.Text,
+	})
+
+	switch d.Action {
+	case TextDeny:
+		if d.Reason == "" {
+			return ErrTextDenied
+		}
+		return fmt.Errorf("%w: %s", ErrTextDenied, d.Reason)
+	case TextTransform:
+		req.Text = d.Text
+	}
+	req.loggedText = d.Logged
+
+	return nil
+}
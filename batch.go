@@ -0,0 +1,312 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader carries the HMAC-SHA256 of the webhook
+	// timestamp, a dot and the body, as "sha256=" and the hex digest, when
+	// BatchInput.WebhookSecret is set
+	WebhookSignatureHeader = "X-CereVoice-Signature"
+	// WebhookTimestampHeader carries the Unix time the webhook was signed,
+	// so receivers can reject replayed deliveries
+	WebhookTimestampHeader = "X-CereVoice-Timestamp"
+)
+
+// ErrWebhookSignature is returned by VerifyWebhook when a webhook is not
+// signed with the secret or was signed too long ago
+var ErrWebhookSignature = errors.New("cerevoicego: invalid webhook signature")
+
+// BatchItem is one request of a batch
+type BatchItem struct {
+	ID    string // Identifies the item in the summary, its index if empty
+	Input *SpeakExtendedInput
+}
+
+// BatchInput contains SpeakBatch parameters
+type BatchInput struct {
+	Items       []BatchItem
+	Concurrency int       // Maximum concurrent requests, DefaultConcurrency if 0
+	Store       StoreFunc // Saves each item's audio, CereVoice's temporary URL is reported if nil
+
+	// OnProgress, if set, receives an event as each item finishes, with the
+	// bytes of audio stored if there is a Store
+	OnProgress ProgressFunc
+	// OnComplete, if set, is called with the summary once every item is
+	// done
+	OnComplete func(summary *BatchSummary)
+	// WebhookURL, if set, receives the summary as a JSON POST once every
+	// item is done
+	WebhookURL string
+	// WebhookSecret, if set, signs the webhook body in the
+	// WebhookSignatureHeader so the receiver can verify it
+	WebhookSecret string
+}
+
+// BatchResult is the outcome of one item of a batch
+type BatchResult struct {
+	ID       string `json:"id"`
+	Location string `json:"location,omitempty"` // URL of the audio
+	Chars    int    `json:"chars"`              // Characters billed
+	Error    string `json:"error,omitempty"`
+}
+
+// BatchSummary reports a completed batch
+type BatchSummary struct {
+	Items    int           `json:"items"`
+	Failures int           `json:"failures"`
+	Chars    int           `json:"chars"` // Characters billed for the whole batch
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	Results  []BatchResult `json:"results"` // In the order of the items
+}
+
+// BatchJob is a batch running in the background
+type BatchJob struct {
+	done       chan struct{}
+	summary    *BatchSummary
+	webhookErr error
+}
+
+// Done is closed when the batch, and any webhook, is complete
+func (j *BatchJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait blocks until the batch is complete and returns its summary
+func (j *BatchJob) Wait() *BatchSummary {
+	<-j.done
+	return j.summary
+}
+
+// WebhookErr returns the error delivering the webhook, once Done is closed
+func (j *BatchJob) WebhookErr() error {
+	<-j.done
+	return j.webhookErr
+}
+
+// SpeakBatch synthesises the items of input concurrently in the background,
+// so long batches can be fired and forgotten. When every item is done the
+// summary is passed to OnComplete and posted to WebhookURL. Items not
+// started before ctx ends fail with its error.
+func (c *Client) SpeakBatch(ctx context.Context, input *BatchInput) *BatchJob {
+	j := &BatchJob{done: make(chan struct{})}
+
+	// After Close the items fail with ErrClientClosed
+	ctx, done, _ := c.begin(ctx)
+
+	go func() {
+		defer close(j.done)
+		defer done()
+
+		j.summary = c.runBatch(ctx, input)
+		if input.OnComplete != nil {
+			input.OnComplete(j.summary)
+		}
+		if input.WebhookURL != "" {
+			j.webhookErr = c.postWebhook(input, j.summary)
+		}
+	}()
+
+	return j
+}
+
+// runBatch synthesises every item and summarises the results
+func (c *Client) runBatch(ctx context.Context, input *BatchInput) *BatchSummary {
+	concurrency := input.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	summary := &BatchSummary{
+		Items:   len(input.Items),
+		Started: time.Now(),
+		Results: make([]BatchResult, len(input.Items)),
+	}
+
+	chars := 0
+	for _, item := range input.Items {
+		if item.Input != nil {
+			chars += len([]rune(item.Input.Text))
+		}
+	}
+	prog := newProgressTracker(input.OnProgress, ProgressSpeakBatch, len(input.Items), chars)
+
+	finish := func(i int, id string, in *SpeakExtendedInput, res BatchResult, n int64, err error) {
+		if err != nil {
+			res.Error = err.Error()
+		}
+		summary.Results[i] = res
+
+		text := 0
+		if in != nil {
+			text = len([]rune(in.Text))
+		}
+		prog.finish(i, id, text, n, err)
+	}
+
+	// The semaphore is taken before starting each goroutine, so a large
+	// batch does not start a goroutine per item up front
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, item := range input.Items {
+		id := item.ID
+		if id == "" {
+			id = strconv.Itoa(i)
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			finish(i, id, item.Input, BatchResult{ID: id}, 0, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, id string, in *SpeakExtendedInput) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// Count the audio stored for the progress events
+			var n int64
+			store := input.Store
+			if store != nil {
+				store = func(ctx context.Context, key string, audio []byte, contentType string) (string, error) {
+					n = int64(len(audio))
+					return input.Store(ctx, key, audio, contentType)
+				}
+			}
+
+			res := BatchResult{ID: id}
+			var err error
+			res.Location, res.Chars, err = c.speakBatchItem(ctx, in, store)
+			finish(i, id, in, res, n, err)
+		}(i, id, item.Input)
+	}
+	wg.Wait()
+
+	for _, res := range summary.Results {
+		summary.Chars += res.Chars
+		if res.Error != "" {
+			summary.Failures++
+		}
+	}
+	summary.Finished = time.Now()
+
+	return summary
+}
+
+// speakBatchItem synthesises one item, returning the location of its audio
+// and the characters billed
+func (c *Client) speakBatchItem(ctx context.Context, input *SpeakExtendedInput, store StoreFunc) (string, int, error) {
+	if input == nil {
+		return "", 0, fmt.Errorf("%w: no input", ErrValidation)
+	}
+	if store != nil {
+		if err := c.checkEffects(c.profile(input)); err != nil {
+			return "", 0, err
+		}
+	}
+
+	r, err := c.SpeakExtendedWithContext(ctx, input)
+	if err != nil {
+		return "", 0, err
+	}
+	chars := r.Chars
+
+	if store == nil {
+		return r.FileURL, chars, nil
+	}
+
+	audio, err := c.readAudio(ctx, r, input)
+	if err != nil {
+		return "", chars, err
+	}
+	if audio, err = c.postProcess(audio); err != nil {
+		return "", chars, err
+	}
+
+	location, err := store(ctx, StorageKey(input), audio, input.AudioFormat.ContentType())
+	return location, chars, err
+}
+
+// postWebhook posts the summary to the batch webhook. Only the User-Agent
+// is sent with it, not the Client's Header, which is meant for the API. It is sent even if the
+// batch context has ended, bounded by DefaultTimeout.
+func (c *Client) postWebhook(input *BatchInput, summary *BatchSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, input.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	c.setUserAgent(req)
+	req.Header.Set("Content-Type", "application/json")
+	if input.WebhookSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, signWebhook(input.WebhookSecret, timestamp, body))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("cerevoicego: webhook %s: %s", input.WebhookURL, resp.Status)
+	}
+
+	return nil
+}
+
+// signWebhook returns the signature of a webhook body sent at timestamp
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature of a batch webhook received with
+// header and body, and that it was signed within tolerance of now, or at
+// any time if tolerance is 0. It returns ErrWebhookSignature if not.
+func VerifyWebhook(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp := header.Get(WebhookTimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrWebhookSignature, timestamp)
+	}
+	if age := time.Since(time.Unix(unix, 0)); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return fmt.Errorf("%w: signed %s ago", ErrWebhookSignature, age.Round(time.Second))
+	}
+
+	want := signWebhook(secret, timestamp, body)
+	if !hmac.Equal([]byte(header.Get(WebhookSignatureHeader)), []byte(want)) {
+		return ErrWebhookSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestBatchWebhook(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	c.Apply(cerevoicego.WithHeader("X-Proxy-Token", "secret"))
+
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	deliveries := make(chan delivery, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		deliveries <- delivery{r.Header.Clone(), body}
+	}))
+	defer hook.Close()
+
+	job := c.SpeakBatch(context.Background(), &cerevoicego.BatchInput{
+		Items:         []cerevoicego.BatchItem{{Input: &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}}},
+		WebhookURL:    hook.URL,
+		WebhookSecret: "hush",
+	})
+	job.Wait()
+	if err := job.WebhookErr(); err != nil {
+		t.Fatal(err)
+	}
+	d := <-deliveries
+
+	if got := d.header.Get("X-Proxy-Token"); got != "" {
+		t.Errorf("X-Proxy-Token = %q, want none", got)
+	}
+	if err := cerevoicego.VerifyWebhook("hush", d.header, d.body, time.Minute); err != nil {
+		t.Errorf("VerifyWebhook = %v", err)
+	}
+	if err := cerevoicego.VerifyWebhook("wrong", d.header, d.body, time.Minute); !errors.Is(err, cerevoicego.ErrWebhookSignature) {
+		t.Errorf("VerifyWebhook with the wrong secret = %v", err)
+	}
+	tampered := append([]byte(nil), d.body...)
+	tampered[len(tampered)-2]++
+	if err := cerevoicego.VerifyWebhook("hush", d.header, tampered, time.Minute); !errors.Is(err, cerevoicego.ErrWebhookSignature) {
+		t.Errorf("VerifyWebhook of a tampered body = %v", err)
+	}
+
+	// A replay with an old timestamp fails, as the signature covers it
+	replay := d.header.Clone()
+	replay.Set(cerevoicego.WebhookTimestampHeader, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	if err := cerevoicego.VerifyWebhook("hush", replay, d.body, 0); !errors.Is(err, cerevoicego.ErrWebhookSignature) {
+		t.Errorf("VerifyWebhook with a changed timestamp = %v", err)
+	}
+}
+
+func TestBatchCancelled(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := make([]cerevoicego.BatchItem, 10)
+	for i := range items {
+		items[i].Input = &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}
+	}
+	summary := c.SpeakBatch(ctx, &cerevoicego.BatchInput{Items: items, Concurrency: 2}).Wait()
+
+	if summary.Failures != len(items) {
+		t.Errorf("Failures = %d, want %d", summary.Failures, len(items))
+	}
+	for i, res := range summary.Results {
+		if res.ID != strconv.Itoa(i) || res.Error == "" {
+			t.Errorf("Results[%d] = %+v", i, res)
+		}
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d requests sent", n)
+	}
+}
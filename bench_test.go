@@ -0,0 +1,82 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+// Run with -race to check a Client shared between goroutines:
+//
+//	go test -race -run - -bench . -cpu 1,4,16
+
+// benchClient returns a test server and a Client for it using the package's
+// shared default transport
+func benchClient(b *testing.B) (*cerevoicetest.Server, *cerevoicego.Client) {
+	srv := cerevoicetest.NewServer()
+	c := srv.Client()
+	c.HTTPClient = nil
+
+	b.ReportAllocs()
+	return srv, c
+}
+
+func BenchmarkSpeakExtendedParallel(b *testing.B) {
+	srv, c := benchClient(b)
+	defer srv.Close()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello world"}); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSpeakAudioCachedParallel(b *testing.B) {
+	srv, c := benchClient(b)
+	defer srv.Close()
+	c.Apply(
+		cerevoicego.WithCache(cerevoicego.NewMemoryCache(64, 0, 0)),
+		cerevoicego.WithRetry(cerevoicego.DefaultRetryPolicy),
+	)
+
+	var n uint64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			// Repeat a small set of texts so both hits and misses are exercised
+			text := "Prompt " + strconv.FormatUint(atomic.AddUint64(&n, 1)%100, 10)
+			if _, err := c.SpeakAudio(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: text, AudioFormat: cerevoicego.FormatWAV}); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSpeakExtendedGuardedParallel(b *testing.B) {
+	srv, c := benchClient(b)
+	defer srv.Close()
+	c.Apply(
+		cerevoicego.WithRateLimit(1e6, 1000),
+		cerevoicego.WithFallbackURLs(srv.URL+"/rest"),
+		cerevoicego.WithLogger(cerevoicego.LoggerFunc(func(*cerevoicego.RequestLog) {})),
+		cerevoicego.WithTextSteps(cerevoicego.TextStep(func(s string) string { return s })),
+	)
+	c.EndpointCooldown = time.Second
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello world"}); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,276 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBreakerThreshold is the default number of consecutive failures
+	// which open a CircuitBreaker
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerCooldown is the default time a CircuitBreaker stays open
+	// before letting a trial request through
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without contacting the API while the Client's
+// CircuitBreaker is open
+var ErrCircuitOpen = errors.New("cerevoicego: circuit breaker open")
+
+// BreakerState is the state of a CircuitBreaker
+type BreakerState int
+
+// Breaker states
+const (
+	// BreakerClosed lets requests through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects requests with ErrCircuitOpen
+	BreakerOpen
+	// BreakerHalfOpen lets trial requests through to see whether the API
+	// has recovered
+	BreakerHalfOpen
+)
+
+// String returns the state name
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return "closed"
+}
+
+// CircuitBreaker stops API requests for a while after consecutive failures,
+// so an outage fails fast rather than every caller waiting on timeouts.
+// Failures are errors suggesting the API is down: network errors, timeouts,
+// server error and 429 statuses and busy or internal error result codes.
+// Only an answer from the API is a success; other errors are ignored. Rejected
+// requests fail with ErrCircuitOpen, which a Fallback Synthesizer falls back
+// on straight away. It is safe for concurrent use and may be shared by
+// several Clients.
+type CircuitBreaker struct {
+	Threshold int           // Consecutive failures which open the breaker, DefaultBreakerThreshold if 0
+	Cooldown  time.Duration // Time open before a trial request, DefaultBreakerCooldown if 0
+	Trials    int           // Concurrent trial requests while half open, 1 if 0
+
+	// Fallback, if set, synthesises the audio of SpeakAudio and Synthesize
+	// calls rejected while the breaker is open, e.g. a local engine
+	Fallback Synthesizer
+	// OnStateChange, if set, is called when the state changes. It must not
+	// call the breaker.
+	OnStateChange func(from, to BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	opened   time.Time
+	trials   int
+	stats    BreakerStats
+}
+
+// BreakerStats contains the state of a CircuitBreaker and counts since it
+// was created or Reset
+type BreakerStats struct {
+	State     BreakerState
+	Failures  int       // Consecutive failures so far
+	Since     time.Time // When the state last changed, zero if never
+	Opens     uint64    // Times the breaker opened
+	Rejected  uint64    // Requests rejected while open
+	Succeeded uint64    // Requests the API answered
+	Failed    uint64    // Requests which failed as if the API were down
+	Ignored   uint64    // Requests which said nothing about the API, such as cancelled ones
+}
+
+// WithCircuitBreaker fails API requests fast using b, which may be shared
+func WithCircuitBreaker(b *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.Breaker = b
+	}
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() BreakerState {
+	return b.Stats().State
+}
+
+// Stats returns the state and counts so far
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats.State, b.stats.Failures = b.state, b.failures
+	return b.stats
+}
+
+// Reset closes the breaker and clears its counts
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	from := b.state
+	b.state, b.failures, b.trials = BreakerClosed, 0, 0
+	b.stats = BreakerStats{}
+	onChange := b.OnStateChange
+	b.mu.Unlock()
+
+	if onChange != nil && from != BreakerClosed {
+		onChange(from, BreakerClosed)
+	}
+}
+
+// allow returns ErrCircuitOpen if a request may not be sent now, otherwise
+// a function to be called with its outcome
+func (b *CircuitBreaker) allow() (done func(err error), err error) {
+	b.mu.Lock()
+	from := b.state
+	if b.state == BreakerOpen && time.Since(b.opened) >= b.cooldown() {
+		b.setState(BreakerHalfOpen)
+	}
+
+	trial := false
+	switch b.state {
+	case BreakerOpen:
+		err = ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.trials >= b.maxTrials() {
+			err = ErrCircuitOpen
+		} else {
+			b.trials++
+			trial = true
+		}
+	}
+	if err != nil {
+		b.stats.Rejected++
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(err error) { b.record(trial, err) }, nil
+}
+
+// record updates the state with the outcome of a request
+func (b *CircuitBreaker) record(trial bool, err error) {
+	b.mu.Lock()
+	from := b.state
+	if trial {
+		b.trials--
+	}
+
+	switch outcome(err) {
+	case breakerIgnored:
+		b.stats.Ignored++
+	case breakerFailed:
+		b.stats.Failed++
+		b.failures++
+		if b.state != BreakerOpen && (b.state == BreakerHalfOpen || b.failures >= b.threshold()) {
+			b.opened = time.Now()
+			b.stats.Opens++
+			b.setState(BreakerOpen)
+		}
+	case breakerSucceeded:
+		b.stats.Succeeded++
+		b.failures = 0
+		if trial {
+			b.setState(BreakerClosed)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// setState changes the state, with b.mu held
+func (b *CircuitBreaker) setState(state BreakerState) {
+	if b.state != state {
+		b.state = state
+		b.stats.Since = time.Now()
+	}
+}
+
+// notify calls OnStateChange if the state changed
+func (b *CircuitBreaker) notify(from, to BreakerState) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(from, to)
+	}
+}
+
+func (b *CircuitBreaker) threshold() int {
+	if b.Threshold > 0 {
+		return b.Threshold
+	}
+
+	return DefaultBreakerThreshold
+}
+
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown > 0 {
+		return b.Cooldown
+	}
+
+	return DefaultBreakerCooldown
+}
+
+func (b *CircuitBreaker) maxTrials() int {
+	if b.Trials > 0 {
+		return b.Trials
+	}
+
+	return 1
+}
+
+// breakerOutcome is what the result of a request says about the API
+type breakerOutcome int
+
+const (
+	breakerIgnored   breakerOutcome = iota // Nothing
+	breakerSucceeded                       // The API answered
+	breakerFailed                          // The API may be down
+)
+
+// outcome classifies err, the result of a request. Only an answer from the
+// API is a success and only errors suggesting it is down are failures;
+// local errors, such as cancellation, rate limiting or failing to encode the
+// request, and client error statuses say nothing about it.
+func outcome(err error) breakerOutcome {
+	var httpErr *HTTPError
+	var apiErr *APIError
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return breakerSucceeded
+	case errors.Is(err, context.Canceled), errors.Is(err, ErrRateLimited):
+		return breakerIgnored
+	case errors.Is(err, context.DeadlineExceeded):
+		return breakerFailed
+	case errors.As(err, &httpErr):
+		if httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests {
+			return breakerFailed
+		}
+		return breakerIgnored
+	case errors.As(err, &apiErr):
+		if apiErr.Code().IsRetryable() {
+			return breakerFailed
+		}
+		return breakerSucceeded
+	case errors.As(err, &netErr):
+		return breakerFailed
+	}
+
+	return breakerIgnored
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+// statusClient answers every request with an HTTP status
+type statusClient int
+
+func (s statusClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: int(s),
+		Status:     http.StatusText(int(s)),
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("<html>error</html>")),
+		Request:    req,
+	}, nil
+}
+
+// failingCodec fails to encode every request
+type failingCodec struct{ cerevoicego.Codec }
+
+func (failingCodec) Encode(req *cerevoicego.Request) ([]byte, error) {
+	return nil, errors.New("encoding failed")
+}
+
+func TestBreakerOutcomes(t *testing.T) {
+	// A limiter whose only token is spent
+	limiter := cerevoicego.NewRateLimiter(0.001, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    []cerevoicego.ClientOption
+		setup   func(srv *cerevoicetest.Server)
+		ctx     func() (context.Context, context.CancelFunc)
+		want    cerevoicego.BreakerStats
+		wantErr bool
+	}{
+		{name: "answered", want: cerevoicego.BreakerStats{Succeeded: 1}},
+		{
+			name:    "result code",
+			setup:   func(srv *cerevoicetest.Server) { srv.SetError("speakSimple", -3, "Invalid voice") },
+			want:    cerevoicego.BreakerStats{Succeeded: 1},
+			wantErr: true,
+		},
+		{
+			name:    "server error",
+			opts:    []cerevoicego.ClientOption{cerevoicego.WithHTTPClient(statusClient(http.StatusBadGateway))},
+			want:    cerevoicego.BreakerStats{State: cerevoicego.BreakerOpen, Failures: 1, Opens: 1, Failed: 1},
+			wantErr: true,
+		},
+		{
+			name:    "too many requests",
+			opts:    []cerevoicego.ClientOption{cerevoicego.WithHTTPClient(statusClient(http.StatusTooManyRequests))},
+			want:    cerevoicego.BreakerStats{State: cerevoicego.BreakerOpen, Failures: 1, Opens: 1, Failed: 1},
+			wantErr: true,
+		},
+		{
+			name:    "client error",
+			opts:    []cerevoicego.ClientOption{cerevoicego.WithHTTPClient(statusClient(http.StatusNotFound))},
+			want:    cerevoicego.BreakerStats{Ignored: 1},
+			wantErr: true,
+		},
+		{
+			name:    "encoding",
+			opts:    []cerevoicego.ClientOption{cerevoicego.WithCodec(failingCodec{})},
+			want:    cerevoicego.BreakerStats{Ignored: 1},
+			wantErr: true,
+		},
+		{
+			name: "rate limited",
+			opts: []cerevoicego.ClientOption{cerevoicego.WithRateLimiter(limiter)},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Second)
+			},
+			want:    cerevoicego.BreakerStats{Ignored: 1},
+			wantErr: true,
+		},
+		{name: "dry run", opts: []cerevoicego.ClientOption{cerevoicego.WithDryRun()}},
+	}
+
+	for _, tt := range tests {
+		srv := cerevoicetest.NewServer()
+		breaker := &cerevoicego.CircuitBreaker{Threshold: 1}
+		c := srv.Client()
+		c.Apply(cerevoicego.WithRetry(cerevoicego.RetryPolicy{MaxAttempts: 1}), cerevoicego.WithCircuitBreaker(breaker))
+		c.Apply(tt.opts...)
+		if tt.setup != nil {
+			tt.setup(srv)
+		}
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if tt.ctx != nil {
+			ctx, cancel = tt.ctx()
+		}
+
+		_, err := c.SpeakSimpleWithContext(ctx, &cerevoicego.SpeakSimpleInput{Voice: "Heather", Text: "Hello"})
+		if tt.wantErr != (err != nil) {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+		got := breaker.Stats()
+		got.Since = time.Time{}
+		if got != tt.want {
+			t.Errorf("%s: stats = %+v, want %+v", tt.name, got, tt.want)
+		}
+		cancel()
+		srv.Close()
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bganderson/cerevoicego/internal/atomicfile"
+)
+
+// Cache stores synthesised audio. A Client keys it by the CacheKey of the
+// text as sent, the account and the API URL, so Clients of several accounts
+// can share one. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, audio []byte)
+}
+
+// CacheStats contains cache hit metrics
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// cacheStats records metrics atomically
+type cacheStats struct {
+	hits, misses, evictions uint64
+}
+
+func (s *cacheStats) hit()   { atomic.AddUint64(&s.hits, 1) }
+func (s *cacheStats) miss()  { atomic.AddUint64(&s.misses, 1) }
+func (s *cacheStats) evict() { atomic.AddUint64(&s.evictions, 1) }
+
+func (s *cacheStats) snapshot() CacheStats {
+	return CacheStats{
+		Hits:      atomic.LoadUint64(&s.hits),
+		Misses:    atomic.LoadUint64(&s.misses),
+		Evictions: atomic.LoadUint64(&s.evictions),
+	}
+}
+
+// CacheKey returns the cache key for the audio produced by input
+func CacheKey(input *SpeakExtendedInput) string {
+	h := sha256.New()
+	fields := []string{
+		input.Voice,
+		input.Text,
+		string(input.AudioFormat),
+		string(input.SampleRate),
+		strconv.FormatBool(input.Audio3D),
+	}
+	if len(input.FallbackVoices) > 0 {
+		fields = append(fields, strings.Join(input.FallbackVoices, ","))
+	}
+	for _, field := range append(fields, input.SpeakMode.cacheFields()...) {
+		h.Write([]byte(strconv.Itoa(len(field))))
+		h.Write([]byte{':'})
+		h.Write([]byte(field))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheKey returns the key the Client caches the audio of input under: its
+// CacheKey once the TextSteps are applied to the text, with the account and
+// API URL, as lexicons and custom voices differ between accounts. It reports
+// false if the account is not known.
+func (c *Client) cacheKey(ctx context.Context, input *SpeakExtendedInput) (string, bool) {
+	creds, ok := requestCredentials(ctx)
+	switch {
+	case ok:
+	case c.Credentials != nil:
+		var err error
+		if creds, err = c.Credentials.Credentials(ctx); err != nil {
+			return "", false
+		}
+	default:
+		creds.AccountID = c.AccountID
+	}
+
+	in := *input
+	in.Text = c.PreprocessText(ctx, input.Text)
+	h := sha256.Sum256([]byte(creds.AccountID + "\x00" + c.apiURL() + "\x00" + CacheKey(&in)))
+
+	return hex.EncodeToString(h[:]), true
+}
+
+// SpeakAudio synthesises input and returns the audio, after any
+// AudioEffects. When the Client has a Cache, previously synthesised audio is
+// returned from it without making a request, and therefore without spending
+// credit.
+func (c *Client) SpeakAudio(input *SpeakExtendedInput) ([]byte, error) {
+	return c.SpeakAudioWithContext(context.Background(), input)
+}
+
+// SpeakAudioWithContext is the same as SpeakAudio with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) SpeakAudioWithContext(ctx context.Context, input *SpeakExtendedInput) ([]byte, error) {
+	input = c.profile(input)
+	if err := c.checkEffects(input); err != nil {
+		return nil, err
+	}
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	ctx, cancel := c.budget(ctx)
+	defer cancel()
+
+	var key string
+	cache := c.Cache != nil
+	if cache {
+		key, cache = c.cacheKey(ctx, input)
+	}
+	if cache {
+		if audio, ok := c.Cache.Get(key); ok {
+			return c.postProcess(cloneBytes(audio))
+		}
+	}
+
+	v, shared, err := c.deduplicate(ctx, "speakAudio", input, func(ctx context.Context) (interface{}, error) {
+		audio, err := c.speakAudio(ctx, input)
+		if err == nil && cache {
+			c.Cache.Set(key, cloneBytes(audio))
+		}
+		return audio, err
+	})
+	if err != nil {
+		if errors.Is(err, ErrCircuitOpen) && c.Breaker.Fallback != nil {
+			return c.Breaker.Fallback.Synthesize(ctx, input)
+		}
+		return nil, err
+	}
+
+	// Each caller and the Cache gets its own copy of the audio, so none can
+	// change another's
+	audio := v.([]byte)
+	if shared {
+		audio = cloneBytes(audio)
+	}
+
+	return c.postProcess(audio)
+}
+
+// cloneBytes returns a copy of b
+func cloneBytes(b []byte) []byte {
+	return append([]byte(nil), b...)
+}
+
+// speakAudio synthesises input and downloads the audio, in several requests
+// if the text is over the limit under LengthSplit
+func (c *Client) speakAudio(ctx context.Context, input *SpeakExtendedInput) ([]byte, error) {
+	if c.splitLong(input) {
+		r, err := c.speakSplit(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		return r.Audio, nil
+	}
+
+	r, err := c.speakExtended(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.readAudio(ctx, r, input)
+}
+
+// MemoryCache is an in-memory least recently used Cache
+type MemoryCache struct {
+	MaxEntries int           // Maximum number of entries, 0 for no limit
+	MaxBytes   int64         // Maximum total size of audio, 0 for no limit
+	TTL        time.Duration // Maximum age of an entry, 0 for no limit
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	size  int64
+	stats cacheStats
+}
+
+type memoryCacheEntry struct {
+	key     string
+	audio   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns a MemoryCache with the given limits
+func NewMemoryCache(maxEntries int, maxBytes int64, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{MaxEntries: maxEntries, MaxBytes: maxBytes, TTL: ttl}
+}
+
+// Get returns the audio stored for key
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		e := el.Value.(*memoryCacheEntry)
+		if e.expires.IsZero() || time.Now().Before(e.expires) {
+			m.ll.MoveToFront(el)
+			m.stats.hit()
+			return e.audio, true
+		}
+		m.remove(el)
+	}
+
+	m.stats.miss()
+	return nil, false
+}
+
+// Set stores audio for key, evicting the least recently used entries if a
+// limit is exceeded
+func (m *MemoryCache) Set(key string, audio []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.items == nil {
+		m.ll = list.New()
+		m.items = make(map[string]*list.Element)
+	}
+
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+
+	e := &memoryCacheEntry{key: key, audio: audio}
+	if m.TTL > 0 {
+		e.expires = time.Now().Add(m.TTL)
+	}
+	m.items[key] = m.ll.PushFront(e)
+	m.size += int64(len(audio))
+
+	for m.ll.Len() > 0 &&
+		((m.MaxEntries > 0 && m.ll.Len() > m.MaxEntries) ||
+			(m.MaxBytes > 0 && m.size > m.MaxBytes)) {
+		m.remove(m.ll.Back())
+		m.stats.evict()
+	}
+}
+
+// Len returns the number of entries in the cache
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.items)
+}
+
+// Stats returns the cache hit metrics
+func (m *MemoryCache) Stats() CacheStats {
+	return m.stats.snapshot()
+}
+
+func (m *MemoryCache) remove(el *list.Element) {
+	e := m.ll.Remove(el).(*memoryCacheEntry)
+	delete(m.items, e.key)
+	m.size -= int64(len(e.audio))
+}
+
+// DiskCache is a Cache storing audio as files named after their keys, with
+// the extension .audio, in a directory. Entries older than TTL are ignored
+// and the oldest entries are removed when their total size exceeds MaxBytes.
+// Other files in the directory are left alone.
+type DiskCache struct {
+	Dir      string        // Directory to store audio in, created if needed
+	MaxBytes int64         // Maximum total size of audio, 0 for no limit
+	TTL      time.Duration // Maximum age of an entry, 0 for no limit
+
+	mu    sync.Mutex
+	stats cacheStats
+}
+
+// NewDiskCache returns a DiskCache storing audio in dir
+func NewDiskCache(dir string, maxBytes int64, ttl time.Duration) *DiskCache {
+	return &DiskCache{Dir: dir, MaxBytes: maxBytes, TTL: ttl}
+}
+
+// Get returns the audio stored for key
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	path := d.path(key)
+
+	info, err := os.Stat(path)
+	if err != nil || (d.TTL > 0 && time.Since(info.ModTime()) > d.TTL) {
+		d.stats.miss()
+		return nil, false
+	}
+
+	audio, err := ioutil.ReadFile(path)
+	if err != nil {
+		d.stats.miss()
+		return nil, false
+	}
+
+	d.stats.hit()
+	return audio, true
+}
+
+// Set stores audio for key. Errors writing to disk are ignored, the entry is
+// simply not cached.
+func (d *DiskCache) Set(key string, audio []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return
+	}
+
+	if err := atomicfile.WriteFile(d.path(key), audio, 0600); err != nil {
+		return
+	}
+
+	d.prune()
+}
+
+// Stats returns the cache hit metrics
+func (d *DiskCache) Stats() CacheStats {
+	return d.stats.snapshot()
+}
+
+// prune removes expired entries and the oldest entries while the cache is
+// larger than MaxBytes, d.mu must be held
+func (d *DiskCache) prune() {
+	if d.MaxBytes <= 0 && d.TTL <= 0 {
+		return
+	}
+
+	all, err := ioutil.ReadDir(d.Dir)
+	if err != nil {
+		return
+	}
+
+	// Only entries are counted and removed, other files in Dir are left
+	var infos []os.FileInfo
+	for _, info := range all {
+		if info.Mode().IsRegular() && strings.HasSuffix(info.Name(), ".audio") {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	var size int64
+	for _, info := range infos {
+		size += info.Size()
+	}
+
+	for _, info := range infos {
+		expired := d.TTL > 0 && time.Since(info.ModTime()) > d.TTL
+		if !expired && (d.MaxBytes <= 0 || size <= d.MaxBytes) {
+			continue
+		}
+		if os.Remove(filepath.Join(d.Dir, info.Name())) == nil {
+			size -= info.Size()
+			d.stats.evict()
+		}
+	}
+}
+
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.Dir, key+".audio")
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestDiskCacheLeavesOtherFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cerevoicego")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	other := filepath.Join(dir, "notes.txt")
+	if err := ioutil.WriteFile(other, make([]byte, 1000), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := cerevoicego.NewDiskCache(dir, 250, 0)
+	for _, key := range []string{"a", "b", "c"} {
+		cache.Set(key, make([]byte, 100))
+	}
+
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("other file removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub")); err != nil {
+		t.Errorf("directory removed: %v", err)
+	}
+	if _, ok := cache.Get("a"); ok {
+		t.Error("oldest entry kept over MaxBytes")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("entry %q removed, although the entries are within MaxBytes", key)
+		}
+	}
+	if s := cache.Stats(); s.Evictions != 1 {
+		t.Errorf("%d evictions, want 1", s.Evictions)
+	}
+}
+
+func TestCacheKeptPerAccountAndText(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	c.Apply(cerevoicego.WithCache(cerevoicego.NewMemoryCache(0, 0, 0)))
+	input := &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}
+
+	speakers := []struct {
+		name string
+		c    *cerevoicego.Client
+		ctx  context.Context
+		sent bool
+	}{
+		{"first", c, context.Background(), true},
+		{"again", c, context.Background(), false},
+		{"clone", c.Clone(), context.Background(), false},
+		{"other account", c.ForAccount("other", "secret"), context.Background(), true},
+		{"request credentials", c, cerevoicego.WithRequestCredentials(context.Background(), cerevoicego.Credentials{AccountID: "third", Password: "secret"}), true},
+		{"text steps", c.Clone(cerevoicego.WithTextSteps(strings.ToUpper)), context.Background(), true},
+		{"text steps again", c.Clone(cerevoicego.WithTextSteps(strings.ToUpper)), context.Background(), false},
+	}
+
+	for _, s := range speakers {
+		before := len(srv.Requests())
+		if _, err := s.c.SpeakAudioWithContext(s.ctx, input); err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if sent := len(srv.Requests()) > before; sent != s.sent {
+			t.Errorf("%s: request sent = %v, want %v", s.name, sent, s.sent)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package captions builds SRT and WebVTT subtitles from CereVoice word
+// timings, so synthesised audio can be paired with matching captions.
+//
+//	meta, err := cerevoice.GetMetadata(res.Metadata)
+//	if err != nil {
+//		return err
+//	}
+//
+//	caps := captions.FromMetadata(meta, nil)
+//	err = captions.WriteVTT(f, caps)
+package captions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// Defaults used for unset Options fields, following common broadcast
+// subtitle guidelines
+const (
+	DefaultMaxLineLength = 42
+	DefaultMaxLines      = 2
+	DefaultMaxDuration   = 7 * time.Second
+	DefaultMinDuration   = time.Second
+	DefaultPauseBreak    = 800 * time.Millisecond
+)
+
+// Options controls how words are grouped into captions
+type Options struct {
+	MaxLineLength int           // Maximum characters per line, DefaultMaxLineLength if 0
+	MaxLines      int           // Maximum lines per caption, DefaultMaxLines if 0
+	MaxDuration   time.Duration // Maximum time a caption is shown, DefaultMaxDuration if 0
+	MinDuration   time.Duration // Minimum time a caption is shown, if the next allows, DefaultMinDuration if 0
+	PauseBreak    time.Duration // A pause this long between words starts a new caption, DefaultPauseBreak if 0
+}
+
+func (o *Options) withDefaults() Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.MaxLineLength <= 0 {
+		opts.MaxLineLength = DefaultMaxLineLength
+	}
+	if opts.MaxLines <= 0 {
+		opts.MaxLines = DefaultMaxLines
+	}
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = DefaultMaxDuration
+	}
+	if opts.MinDuration <= 0 {
+		opts.MinDuration = DefaultMinDuration
+	}
+	if opts.PauseBreak <= 0 {
+		opts.PauseBreak = DefaultPauseBreak
+	}
+
+	return opts
+}
+
+// Caption is a block of text shown for a period of the audio
+type Caption struct {
+	Start time.Duration // Offset from the start of the audio
+	End   time.Duration // Offset from the start of the audio
+	Lines []string
+}
+
+// Text returns the caption lines joined by newlines
+func (c Caption) Text() string {
+	return strings.Join(c.Lines, "\n")
+}
+
+// FromMetadata groups the word events of m into captions using opts, or the
+// defaults if nil
+func FromMetadata(m *cerevoicego.Metadata, opts *Options) []Caption {
+	return FromWords(m.Words(), opts)
+}
+
+// FromWords groups timed words into captions using opts, or the defaults if
+// nil. A new caption starts when the lines are full, the caption would be
+// shown for longer than MaxDuration or there is a pause of PauseBreak.
+func FromWords(words []cerevoicego.MetadataEvent, opts *Options) []Caption {
+	o := opts.withDefaults()
+
+	var caps []Caption
+	var cur *Caption
+	for _, w := range words {
+		token := strings.TrimSpace(w.Token)
+		if token == "" {
+			continue
+		}
+
+		if cur != nil && (w.Start-cur.End >= o.PauseBreak || w.End-cur.Start > o.MaxDuration || !fits(cur, token, o)) {
+			caps = append(caps, *cur)
+			cur = nil
+		}
+		if cur == nil {
+			cur = &Caption{Start: w.Start, Lines: []string{token}}
+		} else if last := len(cur.Lines) - 1; len([]rune(cur.Lines[last]))+1+len([]rune(token)) <= o.MaxLineLength {
+			cur.Lines[last] += " " + token
+		} else {
+			cur.Lines = append(cur.Lines, token)
+		}
+		cur.End = w.End
+	}
+	if cur != nil {
+		caps = append(caps, *cur)
+	}
+
+	// Hold short captions on screen for MinDuration, without overlapping the
+	// next
+	for i := range caps {
+		end := caps[i].Start + o.MinDuration
+		if i+1 < len(caps) && end > caps[i+1].Start {
+			end = caps[i+1].Start
+		}
+		if end > caps[i].End {
+			caps[i].End = end
+		}
+	}
+
+	return caps
+}
+
+// fits reports whether token can be added to c without exceeding the line
+// limits
+func fits(c *Caption, token string, o Options) bool {
+	last := c.Lines[len(c.Lines)-1]
+	if len([]rune(last))+1+len([]rune(token)) <= o.MaxLineLength {
+		return true
+	}
+
+	return len(c.Lines) < o.MaxLines
+}
+
+// WriteSRT writes caps in SubRip format
+func WriteSRT(w io.Writer, caps []Caption) error {
+	bw := bufio.NewWriter(w)
+	for i, c := range caps {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.Start, ','), timestamp(c.End, ','), c.Text())
+	}
+
+	return bw.Flush()
+}
+
+// WriteVTT writes caps in WebVTT format
+func WriteVTT(w io.Writer, caps []Caption) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	for _, c := range caps {
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n", timestamp(c.Start, '.'), timestamp(c.End, '.'), vttEscape.Replace(c.Text()))
+	}
+
+	return bw.Flush()
+}
+
+// SRT returns caps in SubRip format
+func SRT(caps []Caption) string {
+	var b strings.Builder
+	WriteSRT(&b, caps)
+	return b.String()
+}
+
+// VTT returns caps in WebVTT format
+func VTT(caps []Caption) string {
+	var b strings.Builder
+	WriteVTT(&b, caps)
+	return b.String()
+}
+
+// vttEscape escapes characters with special meaning in WebVTT cue text
+var vttEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// timestamp formats d as hh:mm:ss with milliseconds after sep
+func timestamp(d time.Duration, sep byte) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := int64(d / time.Millisecond)
+
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
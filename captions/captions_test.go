@@ -0,0 +1,131 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package captions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+const ms = time.Millisecond
+
+// words returns word events of the given tokens and times in milliseconds
+func words(events ...interface{}) []cerevoicego.MetadataEvent {
+	var out []cerevoicego.MetadataEvent
+	for i := 0; i < len(events); i += 3 {
+		out = append(out, cerevoicego.MetadataEvent{
+			Type:  cerevoicego.EventWord,
+			Token: events[i].(string),
+			Start: time.Duration(events[i+1].(int)) * ms,
+			End:   time.Duration(events[i+2].(int)) * ms,
+		})
+	}
+	return out
+}
+
+func TestFromWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []cerevoicego.MetadataEvent
+		opts  *Options
+		want  []Caption
+	}{
+		{
+			"one caption",
+			words("Hello", 0, 400, "world.", 450, 1200),
+			nil,
+			[]Caption{{0, 1200 * ms, []string{"Hello world."}}},
+		},
+		{
+			"min duration",
+			words("Hi.", 100, 300),
+			nil,
+			[]Caption{{100 * ms, 1100 * ms, []string{"Hi."}}},
+		},
+		{
+			"min duration stops at next",
+			words("Hi.", 0, 300, "There.", 1500, 1800),
+			&Options{PauseBreak: time.Second},
+			[]Caption{{0, 1000 * ms, []string{"Hi."}}, {1500 * ms, 2500 * ms, []string{"There."}}},
+		},
+		{
+			"pause breaks",
+			words("One.", 0, 1000, "Two.", 2000, 3000),
+			nil,
+			[]Caption{{0, 1000 * ms, []string{"One."}}, {2000 * ms, 3000 * ms, []string{"Two."}}},
+		},
+		{
+			"lines wrap",
+			words("one", 0, 500, "two", 500, 1000, "three", 1000, 1500, "four", 1500, 2000),
+			&Options{MaxLineLength: 9},
+			[]Caption{{0, 1500 * ms, []string{"one two", "three"}}, {1500 * ms, 2500 * ms, []string{"four"}}},
+		},
+		{
+			"max duration",
+			words("a", 0, 3000, "b", 3000, 6000, "c", 6000, 9000),
+			nil,
+			[]Caption{{0, 6000 * ms, []string{"a b"}}, {6000 * ms, 9000 * ms, []string{"c"}}},
+		},
+		{
+			"blank words skipped",
+			words(" ", 0, 100, "Yes", 100, 1200, "", 1200, 1300),
+			nil,
+			[]Caption{{100 * ms, 1200 * ms, []string{"Yes"}}},
+		},
+		{"no words", nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		if got := FromWords(tt.words, tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: captions = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		sep  byte
+		want string
+	}{
+		{0, ',', "00:00:00,000"},
+		{1500 * ms, '.', "00:00:01.500"},
+		{61*time.Second + 7*ms, ',', "00:01:01,007"},
+		{2*time.Hour + 3*time.Minute + 4*time.Second + 999*ms + 999*time.Microsecond, '.', "02:03:04.999"},
+		{-time.Second, ',', "00:00:00,000"},
+	}
+
+	for _, tt := range tests {
+		if got := timestamp(tt.d, tt.sep); got != tt.want {
+			t.Errorf("timestamp(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormats(t *testing.T) {
+	caps := []Caption{
+		{0, 1500 * ms, []string{"Fish & chips", "<b>now</b>"}},
+		{61 * time.Second, 62*time.Second + 250*ms, []string{"Done."}},
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"srt", SRT(caps), "1\n00:00:00,000 --> 00:00:01,500\nFish & chips\n<b>now</b>\n\n2\n00:01:01,000 --> 00:01:02,250\nDone.\n\n"},
+		{"vtt", VTT(caps), "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\nFish &amp; chips\n&lt;b&gt;now&lt;/b&gt;\n\n00:01:01.000 --> 00:01:02.250\nDone.\n\n"},
+		{"empty srt", SRT(nil), ""},
+		{"empty vtt", VTT(nil), "WEBVTT\n\n"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
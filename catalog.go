@@ -0,0 +1,145 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import "strings"
+
+// Sex of a voice
+type Sex string
+
+// Voice sexes, an empty Sex matches either
+const (
+	Female Sex = "female"
+	Male   Sex = "male"
+)
+
+// VoiceCatalog is a list of voices with filtering and selection helpers
+type VoiceCatalog []Voice
+
+// Catalog returns the voices as a VoiceCatalog
+func (r *ListVoicesResponse) Catalog() VoiceCatalog {
+	return VoiceCatalog(r.VoiceList)
+}
+
+// Filter returns the voices for which fn returns true
+func (vc VoiceCatalog) Filter(fn func(Voice) bool) VoiceCatalog {
+	var voices VoiceCatalog
+	for _, v := range vc {
+		if fn(v) {
+			voices = append(voices, v)
+		}
+	}
+
+	return voices
+}
+
+// ByLanguage returns the voices for an ISO 639 language code, e.g. "en"
+func (vc VoiceCatalog) ByLanguage(language string) VoiceCatalog {
+	return vc.Filter(func(v Voice) bool {
+		return strings.EqualFold(v.LanguageCodeISO, language)
+	})
+}
+
+// ByCountry returns the voices for an ISO 3166 country code, e.g. "GB"
+func (vc VoiceCatalog) ByCountry(country string) VoiceCatalog {
+	return vc.Filter(func(v Voice) bool {
+		return strings.EqualFold(v.CountryCodeISO, country)
+	})
+}
+
+// ByAccent returns the voices whose accent code or accent name matches
+func (vc VoiceCatalog) ByAccent(accent string) VoiceCatalog {
+	return vc.Filter(func(v Voice) bool {
+		return strings.EqualFold(v.AccentCode, accent) || strings.EqualFold(v.Accent, accent)
+	})
+}
+
+// BySex returns the voices of the given sex
+func (vc VoiceCatalog) BySex(sex Sex) VoiceCatalog {
+	return vc.Filter(func(v Voice) bool {
+		return v.IsSex(sex)
+	})
+}
+
+// BySampleRate returns the voices with the given sample rate, e.g. "48000"
+func (vc VoiceCatalog) BySampleRate(rate string) VoiceCatalog {
+	return vc.Filter(func(v Voice) bool {
+		return v.SampleRate == rate
+	})
+}
+
+// Lookup returns the voice with the given name
+func (vc VoiceCatalog) Lookup(name string) (Voice, bool) {
+	for _, v := range vc {
+		if strings.EqualFold(v.VoiceName, name) {
+			return v, true
+		}
+	}
+
+	return Voice{}, false
+}
+
+// Find returns the voice best matching a BCP 47 language tag such as
+// "en-GB" and sex. The language must match; voices from the tag's region and
+// of the requested sex are preferred. An empty sex matches either.
+func (vc VoiceCatalog) Find(tag string, sex Sex) (Voice, bool) {
+	language, region := parseLanguageTag(tag)
+
+	best, bestScore := -1, -1
+	for i, v := range vc {
+		if !strings.EqualFold(v.LanguageCodeISO, language) {
+			continue
+		}
+
+		score := 0
+		if region != "" && strings.EqualFold(v.CountryCodeISO, region) {
+			score += 2
+		}
+		if sex != "" && v.IsSex(sex) {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	if best < 0 {
+		return Voice{}, false
+	}
+
+	return vc[best], true
+}
+
+// IsSex reports whether the voice is of the given sex. An empty sex matches
+// any voice.
+func (v Voice) IsSex(sex Sex) bool {
+	if sex == "" {
+		return true
+	}
+	if v.Sex == "" {
+		return false
+	}
+
+	return strings.EqualFold(v.Sex[:1], string(sex)[:1])
+}
+
+// parseLanguageTag returns the language and region subtags of a BCP 47 tag
+func parseLanguageTag(tag string) (language, region string) {
+	parts := strings.FieldsFunc(tag, func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	if len(parts) == 0 {
+		return "", ""
+	}
+
+	language = parts[0]
+	for _, p := range parts[1:] {
+		// Regions are two letters or three digits, scripts are four letters
+		if len(p) == 2 || (len(p) == 3 && p[0] >= '0' && p[0] <= '9') {
+			return language, p
+		}
+	}
+
+	return language, ""
+}
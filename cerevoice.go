@@ -1,6 +1,5 @@
-// CereVoice Cloud API Library for Go
+// Package cerevoicego is a client for the CereVoice Cloud API.
 // https://www.cereproc.com/files/CereVoiceCloudGuide.pdf
-// This is a pre-release version and is subject to change
 
 // Copyright 2018 Bryan Anderson (https://www.bganderson.com)
 // Relesed under a BSD-style license which can be found in the LICENSE file
@@ -8,387 +7,292 @@
 package cerevoicego
 
 import (
-	"bytes"
-	"encoding/xml"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
 )
 
-const (
-	// VERSION is the global package version
-	VERSION = "0.3.0"
-	// DefaultRESTAPIURL is the default CereVoice Cloud REST API endpoint
-	DefaultRESTAPIURL = "https://cerevoice.com/rest/rest_1_1.php"
-)
-
-// Client API connection settings
-type Client struct {
-	AccountID       string // CereVoice Cloud API AccountID
-	Password        string // CereVoice Cloud API Password
-	CereVoiceAPIURL string // CereVoice Cloud API URL
-}
-
-// Request to CereVoice Cloud API
-type Request struct {
-	XMLName          xml.Name
-	AccountID        string `xml:"accountID"`
-	Password         string `xml:"password"`
-	Voice            string `xml:"voice,omitempty"`
-	Text             string `xml:"text,omitempty"`
-	AudioFormat      string `xml:"audioFormat,omitempty"`
-	SampleRate       string `xml:"sampleRate,omitempty"`
-	Audio3D          bool   `xml:"audio3D,omitempty"`
-	Metadata         bool   `xml:"metadata,omitempty"`
-	LexiconFile      string `xml:"lexiconFile,omitempty"`
-	AbbreviationFile string `xml:"abbreviationFile,omitempty"`
-	Language         string `xml:"language,omitempty"`
-	Accent           string `xml:"accent,omitempty"`
-}
-
-// Response from CereVoice Cloud API
-type Response struct {
-	Raw   []byte
-	Error error
-}
-
-// SpeakSimpleInput contains speakSimple parameters
-type SpeakSimpleInput struct {
-	Voice string
-	Text  string
-}
-
-// SpeakExtendedInput contains speakExtended parameters
-type SpeakExtendedInput struct {
-	Voice       string
-	Text        string
-	AudioFormat string
-	SampleRate  string
-	Audio3D     bool
-	Metadata    bool
-}
-
-// UploadLexiconInput contains uploadLexicon paramters
-type UploadLexiconInput struct {
-	LexiconFile string
-	Language    string
-	Accent      string
-}
-
-// UploadAbbreviationsInput contains uploadAbbreviations parameters
-type UploadAbbreviationsInput struct {
-	AbbreviationFile string
-	Language         string
-}
-
-// SpeakSimpleResponse contains response from speakSimple
-type SpeakSimpleResponse struct {
-	FileURL           string `xml:"fileUrl"`
-	CharCount         string `xml:"charCount"`
-	ResultCode        string `xml:"resultCode"`
-	ResultDescription string `xml:"resultDescription"`
-	Error             error
-}
-
-// SpeakExtendedResponse contains response from speakExtended
-type SpeakExtendedResponse struct {
-	FileURL           string `xml:"fileUrl"`
-	CharCount         string `xml:"charCount"`
-	ResultCode        string `xml:"resultCode"`
-	ResultDescription string `xml:"resultDescription"`
-	Metadata          string `xml:"metadataUrl"`
-	Error             error
-}
-
-// ListVoicesResponse contains response from listVoices
-type ListVoicesResponse struct {
-	VoiceList []Voice `xml:"voicesList>voice"`
-	Error     error
-}
-
-// UploadLexiconResponse contains response from uploadLexicon
-type UploadLexiconResponse struct {
-	ResultCode        int    `xml:"resultCode"`
-	ResultDescription string `xml:"resultDescription"`
-	Error             error
-}
-
-// ListLexiconsResponse contains response from listLexicons
-type ListLexiconsResponse struct {
-	LexiconList []Lexicon `xml:"lexiconList>lexiconFile"`
-	Error       error
+// SpeakSimple synthesises input text with the selected voice
+func (c *Client) SpeakSimple(input *SpeakSimpleInput) (*SpeakSimpleResponse, error) {
+	return c.SpeakSimpleWithContext(context.Background(), input)
 }
 
-// UploadAbbreviationsResponse contains response from uploadAbbreviations
-type UploadAbbreviationsResponse struct {
-	ResultCode        int    `xml:"resultCode"`
-	ResultDescription string `xml:"resultDescription"`
-	Error             error
-}
+// SpeakSimpleWithContext is the same as SpeakSimple with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) SpeakSimpleWithContext(ctx context.Context, input *SpeakSimpleInput) (*SpeakSimpleResponse, error) {
+	input = c.simpleProfile(input)
+	key := &SpeakExtendedInput{Voice: input.Voice, Text: input.Text, SpeakMode: input.SpeakMode}
+	v, shared, err := c.deduplicate(ctx, "speakSimple", key, func(ctx context.Context) (interface{}, error) {
+		return c.speakSimple(ctx, input)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-// ListAbbreviationsResponse contains response from listAbbreviations
-type ListAbbreviationsResponse struct {
-	AbbreviationList []Abbreviation `xml:"abbreviationList>abbreviationFile"`
-	Error            error
-}
+	r := v.(*SpeakSimpleResponse)
+	if shared {
+		copy := *r
+		r = &copy
+	}
 
-// ListAudioFormatsResponse contains response from listAudioFormats
-type ListAudioFormatsResponse struct {
-	AudioFormats []string `xml:"formatList>format"`
-	Error        error
+	return r, nil
 }
 
-// GetCreditResponse contains response from getCredit
-type GetCreditResponse struct {
-	Credit Credit `xml:"credit"`
-	Error  error
-}
+func (c *Client) speakSimple(ctx context.Context, input *SpeakSimpleInput) (*SpeakSimpleResponse, error) {
+	voice, err := c.detectVoice(ctx, input.Voice, input.Text)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkEmotion(voice, input.Emotion); err != nil {
+		return nil, err
+	}
 
-// Voice contains details about a voice
-type Voice struct {
-	SampleRate            string `xml:"sampleRate"`
-	VoiceName             string `xml:"voiceName"`
-	LanguageCodeISO       string `xml:"languageCodeISO"`
-	CountryCodeISO        string `xml:"countryCodeISO"`
-	AccentCode            string `xml:"accentCode"`
-	Sex                   string `xml:"sex"`
-	LanguageCodeMicrosoft string `xml:"languageCodeMicrosoft"`
-	Country               string `xml:"country"`
-	Region                string `xml:"region"`
-	Accent                string `xml:"accent"`
-}
+	r := &SpeakSimpleResponse{}
+	if err := c.call(ctx, &speakSimpleRequest{
+		Voice: voice,
+		Text:  input.Text,
+		Mode:  input.SpeakMode,
+	}, r); err != nil {
+		return nil, err
+	}
 
-// Lexicon contains details about a lexicon
-type Lexicon struct {
-	URL          string `xml:"url"`
-	Language     string `xml:"language"`
-	Accent       string `xml:"accent"`
-	LastModified string `xml:"lastModified"`
-	Size         string `xml:"size"`
-}
+	r.client = c.fileClient()
 
-// Abbreviation contains details about an abbreviation
-type Abbreviation struct {
-	URL          string `xml:"url"`
-	Language     string `xml:"language"`
-	LastModified string `xml:"lastModified"`
-	Size         string `xml:"size"`
+	return r, nil
 }
 
-// Credit contains details about CereVoice Cloud credits
-type Credit struct {
-	FreeCredit     string `xml:"freeCredit"`
-	PaidCredit     string `xml:"paidCredit"`
-	CharsAvailable string `xml:"charsAvailable"`
+// SpeakExtended allows for more control over the audio output
+func (c *Client) SpeakExtended(input *SpeakExtendedInput) (*SpeakExtendedResponse, error) {
+	return c.SpeakExtendedWithContext(context.Background(), input)
 }
 
-// SpeakSimple synthesises input text with the selected voice
-func (c *Client) SpeakSimple(input *SpeakSimpleInput) (r *SpeakSimpleResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:   xml.Name{Local: "speakSimple"},
-		AccountID: c.AccountID,
-		Password:  c.Password,
-		Voice:     input.Voice,
-		Text:      input.Text,
+// SpeakExtendedWithContext is the same as SpeakExtended with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) SpeakExtendedWithContext(ctx context.Context, input *SpeakExtendedInput) (*SpeakExtendedResponse, error) {
+	input = c.profile(input)
+	v, shared, err := c.deduplicate(ctx, "speakExtended", input, func(ctx context.Context) (interface{}, error) {
+		return c.speakExtended(ctx, input)
 	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
+	if err != nil {
+		return nil, err
 	}
 
-	if err := xml.Unmarshal(resp.Raw, &r); err != nil {
-		r.Error = err
+	r := v.(*SpeakExtendedResponse)
+	if shared {
+		copy := *r
+		r = &copy
 	}
 
-	return
+	return r, nil
 }
 
-// SpeakExtended allows for more control over the audio output
-func (c *Client) SpeakExtended(input *SpeakExtendedInput) (r *SpeakExtendedResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:     xml.Name{Local: "speakExtended"},
-		AccountID:   c.AccountID,
-		Password:    c.Password,
-		Voice:       input.Voice,
-		Text:        input.Text,
-		AudioFormat: input.AudioFormat,
-		SampleRate:  input.SampleRate,
-		Audio3D:     input.Audio3D,
-		Metadata:    input.Metadata,
-	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
+func (c *Client) speakExtended(ctx context.Context, input *SpeakExtendedInput) (*SpeakExtendedResponse, error) {
+	voice, err := c.detectVoice(ctx, input.Voice, input.Text)
+	if err != nil {
+		return nil, err
+	}
+	if voice != input.Voice {
+		detected := *input
+		detected.Voice = voice
+		input = &detected
 	}
 
-	if err := xml.Unmarshal(resp.Raw, &r); err != nil {
-		r.Error = err
+	if err := c.checkFormat(ctx, input); err != nil {
+		return nil, err
 	}
 
-	return
+	voices := input.voices()
+	for i, voice := range voices {
+		mode := c.voiceMode(input, voice)
+		if err := c.checkEmotion(voice, mode.Emotion); err != nil {
+			if i == len(voices)-1 {
+				return nil, err
+			}
+			continue
+		}
+
+		r := &SpeakExtendedResponse{}
+		err := c.call(ctx, &speakExtendedRequest{
+			Voice:       voice,
+			Text:        input.Text,
+			AudioFormat: input.AudioFormat,
+			SampleRate:  input.SampleRate,
+			Audio3D:     input.Audio3D,
+			Metadata:    input.Metadata,
+			Mode:        mode,
+		}, r)
+		if err == nil {
+			r.client = c.fileClient()
+			return r, nil
+		}
+		if i == len(voices)-1 || !errors.Is(err, ErrInvalidVoice) {
+			return nil, err
+		}
+	}
+
+	return nil, ErrInvalidVoice
 }
 
-// ListVoices outputs information about the available voices
-func (c *Client) ListVoices() (r *ListVoicesResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:   xml.Name{Local: "listVoices"},
-		AccountID: c.AccountID,
-		Password:  c.Password,
-	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
+// ListVoices outputs information about the available voices. input may be
+// nil to list every voice.
+func (c *Client) ListVoices(input *ListVoicesInput) (*ListVoicesResponse, error) {
+	return c.ListVoicesWithContext(context.Background(), input)
+}
+
+// ListVoicesWithContext is the same as ListVoices with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) ListVoicesWithContext(ctx context.Context, input *ListVoicesInput) (*ListVoicesResponse, error) {
+	if input == nil {
+		input = &ListVoicesInput{}
 	}
 
-	if err := xml.Unmarshal(resp.Raw, &r); err != nil {
-		r.Error = err
+	r := &ListVoicesResponse{}
+	if err := c.call(ctx, &listVoicesRequest{
+		Language: input.Language,
+		Accent:   input.Accent,
+		Sex:      input.Sex,
+	}, r); err != nil {
+		return nil, err
 	}
 
-	return
+	return r, nil
 }
 
 // UploadLexicon uploads and stores a custom lexicon file
-func (c *Client) UploadLexicon(input *UploadLexiconInput) (r *UploadLexiconResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:     xml.Name{Local: "uploadLexicon"},
-		AccountID:   c.AccountID,
-		Password:    c.Password,
+func (c *Client) UploadLexicon(input *UploadLexiconInput) (*UploadLexiconResponse, error) {
+	return c.UploadLexiconWithContext(context.Background(), input)
+}
+
+// UploadLexiconWithContext is the same as UploadLexicon with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) UploadLexiconWithContext(ctx context.Context, input *UploadLexiconInput) (*UploadLexiconResponse, error) {
+	r := &UploadLexiconResponse{}
+	if err := c.call(ctx, &uploadLexiconRequest{
 		LexiconFile: input.LexiconFile,
 		Language:    input.Language,
 		Accent:      input.Accent,
-	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
+	}, r); err != nil {
+		return nil, err
 	}
 
-	if err := xml.Unmarshal(resp.Raw, &r); err != nil {
-		r.Error = err
-	}
-
-	return
-
+	return r, nil
 }
 
 // ListLexicons lists custom lexicon file(s)
-func (c *Client) ListLexicons() (r *ListLexiconsResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:   xml.Name{Local: "listLexicons"},
-		AccountID: c.AccountID,
-		Password:  c.Password,
-	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
-	}
+func (c *Client) ListLexicons() (*ListLexiconsResponse, error) {
+	return c.ListLexiconsWithContext(context.Background())
+}
 
-	if err := xml.Unmarshal(resp.Raw, &r); err != nil {
-		r.Error = err
+// ListLexiconsWithContext is the same as ListLexicons with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) ListLexiconsWithContext(ctx context.Context) (*ListLexiconsResponse, error) {
+	r := &ListLexiconsResponse{}
+	if err := c.call(ctx, noInputRequest("listLexicons"), r); err != nil {
+		return nil, err
 	}
 
-	return
+	return r, nil
 }
 
-// UploadAbbreviations uploads and stores a custom abbreviation file
-func (c *Client) UploadAbbreviations(input *UploadAbbreviationsInput) (r *UploadAbbreviationsResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:     xml.Name{Local: "uploadAbbreviations"},
-		AccountID:   c.AccountID,
-		Password:    c.Password,
-		LexiconFile: input.AbbreviationFile,
-		Language:    input.Language,
-	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
-	}
+// DeleteLexicon deletes the custom lexicon file for a language and accent
+func (c *Client) DeleteLexicon(input *DeleteLexiconInput) (*DeleteLexiconResponse, error) {
+	return c.DeleteLexiconWithContext(context.Background(), input)
+}
 
-	if err := xml.Unmarshal(resp.Raw, &r); err != nil {
-		r.Error = err
+// DeleteLexiconWithContext is the same as DeleteLexicon with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) DeleteLexiconWithContext(ctx context.Context, input *DeleteLexiconInput) (*DeleteLexiconResponse, error) {
+	r := &DeleteLexiconResponse{}
+	if err := c.call(ctx, &deleteLexiconRequest{
+		Language: input.Language,
+		Accent:   input.Accent,
+	}, r); err != nil {
+		return nil, err
 	}
 
-	return
+	return r, nil
 }
 
-// ListAbbreviations lists custom abbreviation file(s)
-func (c *Client) ListAbbreviations() (r *ListAbbreviationsResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:   xml.Name{Local: "listAbbreviations"},
-		AccountID: c.AccountID,
-		Password:  c.Password,
-	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
-	}
+// UploadAbbreviations uploads and stores a custom abbreviation file
+func (c *Client) UploadAbbreviations(input *UploadAbbreviationsInput) (*UploadAbbreviationsResponse, error) {
+	return c.UploadAbbreviationsWithContext(context.Background(), input)
+}
 
-	err := xml.Unmarshal(resp.Raw, &r)
-	if err != nil {
-		r.Error = err
+// UploadAbbreviationsWithContext is the same as UploadAbbreviations with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) UploadAbbreviationsWithContext(ctx context.Context, input *UploadAbbreviationsInput) (*UploadAbbreviationsResponse, error) {
+	r := &UploadAbbreviationsResponse{}
+	if err := c.call(ctx, &uploadAbbreviationsRequest{
+		AbbreviationFile: input.AbbreviationFile,
+		Language:         input.Language,
+	}, r); err != nil {
+		return nil, err
 	}
 
-	return
+	return r, nil
 }
 
-// ListAudioFormats lists the available audio encoding formats
-func (c *Client) ListAudioFormats() (r *ListAudioFormatsResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:   xml.Name{Local: "listAudioFormats"},
-		AccountID: c.AccountID,
-		Password:  c.Password,
-	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
-	}
+// ListAbbreviations lists custom abbreviation file(s)
+func (c *Client) ListAbbreviations() (*ListAbbreviationsResponse, error) {
+	return c.ListAbbreviationsWithContext(context.Background())
+}
 
-	if err := xml.Unmarshal(resp.Raw, &r); err != nil {
-		r.Error = err
+// ListAbbreviationsWithContext is the same as ListAbbreviations with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) ListAbbreviationsWithContext(ctx context.Context) (*ListAbbreviationsResponse, error) {
+	r := &ListAbbreviationsResponse{}
+	if err := c.call(ctx, noInputRequest("listAbbreviations"), r); err != nil {
+		return nil, err
 	}
 
-	return
+	return r, nil
 }
 
-// GetCredit retrieves the credit information for the given account
-func (c *Client) GetCredit() (r *GetCreditResponse) {
-	resp := c.queryAPI(&Request{
-		XMLName:   xml.Name{Local: "getCredit"},
-		AccountID: c.AccountID,
-		Password:  c.Password,
-	})
-	if resp.Error != nil {
-		r.Error = resp.Error
-		return
-	}
+// DeleteAbbreviations deletes the custom abbreviation file for a language
+func (c *Client) DeleteAbbreviations(input *DeleteAbbreviationsInput) (*DeleteAbbreviationsResponse, error) {
+	return c.DeleteAbbreviationsWithContext(context.Background(), input)
+}
 
-	if err := xml.Unmarshal(resp.Raw, &r); err != nil {
-		r.Error = err
+// DeleteAbbreviationsWithContext is the same as DeleteAbbreviations with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) DeleteAbbreviationsWithContext(ctx context.Context, input *DeleteAbbreviationsInput) (*DeleteAbbreviationsResponse, error) {
+	r := &DeleteAbbreviationsResponse{}
+	if err := c.call(ctx, &deleteAbbreviationsRequest{
+		Language: input.Language,
+	}, r); err != nil {
+		return nil, err
 	}
 
-	return
+	return r, nil
 }
 
-// Query CereVoice Cloud API
-func (c *Client) queryAPI(req *Request) (r *Response) {
-	output, err := xml.MarshalIndent(req, "", "    ")
-	if err != nil {
-		r.Error = err
-		return
-	}
+// ListAudioFormats lists the available audio encoding formats
+func (c *Client) ListAudioFormats() (*ListAudioFormatsResponse, error) {
+	return c.ListAudioFormatsWithContext(context.Background())
+}
 
-	request := bytes.NewReader(append([]byte(xml.Header), output...))
-	resp, err := http.Post(c.CereVoiceAPIURL, "text/xml", request)
-	if err != nil {
-		r.Error = err
-		return
+// ListAudioFormatsWithContext is the same as ListAudioFormats with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) ListAudioFormatsWithContext(ctx context.Context) (*ListAudioFormatsResponse, error) {
+	r := &ListAudioFormatsResponse{}
+	if err := c.call(ctx, noInputRequest("listAudioFormats"), r); err != nil {
+		return nil, err
 	}
 
-	defer resp.Body.Close()
+	return r, nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		r.Error = err
-		return
+// GetCredit retrieves the credit information for the given account
+func (c *Client) GetCredit() (*GetCreditResponse, error) {
+	return c.GetCreditWithContext(context.Background())
+}
+
+// GetCreditWithContext is the same as GetCredit with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) GetCreditWithContext(ctx context.Context) (*GetCreditResponse, error) {
+	r := &GetCreditResponse{}
+	if err := c.call(ctx, noInputRequest("getCredit"), r); err != nil {
+		return nil, err
 	}
 
-	return &Response{Raw: body}
+	return r, nil
 }
@@ -0,0 +1,103 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicetest
+
+import (
+	"strconv"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// Voices is the canned voice list returned by Fake and Server
+var Voices = []cerevoicego.Voice{
+	{
+		SampleRate:            "48000",
+		Hz:                    48000,
+		VoiceName:             "Heather",
+		LanguageCodeISO:       "en",
+		CountryCodeISO:        "GB",
+		AccentCode:            "SCO",
+		Sex:                   "female",
+		LanguageCodeMicrosoft: "2057",
+		Country:               "Scotland",
+		Region:                "Edinburgh",
+		Accent:                "Scottish",
+	},
+	{
+		SampleRate:            "48000",
+		Hz:                    48000,
+		VoiceName:             "William",
+		LanguageCodeISO:       "en",
+		CountryCodeISO:        "GB",
+		AccentCode:            "RP",
+		Sex:                   "male",
+		LanguageCodeMicrosoft: "2057",
+		Country:               "England",
+		Region:                "South",
+		Accent:                "Southern English",
+	},
+	{
+		SampleRate:            "48000",
+		Hz:                    48000,
+		VoiceName:             "Isabella",
+		LanguageCodeISO:       "en",
+		CountryCodeISO:        "US",
+		AccentCode:            "GA",
+		Sex:                   "female",
+		LanguageCodeMicrosoft: "1033",
+		Country:               "United States",
+		Region:                "",
+		Accent:                "General American",
+	},
+}
+
+// AudioFormats is the canned audio format list returned by Fake and Server
+var AudioFormats = []string{"wav", "ogg", "mp3", "aiff", "raw"}
+
+// Credit is the canned credit returned by Fake and Server
+var Credit = cerevoicego.Credit{
+	FreeCredit:     "0",
+	PaidCredit:     "10.00",
+	CharsAvailable: "500000",
+	Paid:           1000,
+	Available:      500000,
+}
+
+// Metadata is the canned metadata file served by Server
+const Metadata = `<?xml version="1.0" encoding="UTF-8"?>
+<trans>
+  <word name="hello" start="0.050" end="0.420"/>
+  <phone name="h" start="0.050" end="0.110"/>
+  <phone name="@" start="0.110" end="0.170"/>
+  <phone name="l" start="0.170" end="0.250"/>
+  <phone name="ou" start="0.250" end="0.420"/>
+  <word name="world" start="0.420" end="0.880"/>
+</trans>
+`
+
+// SilentWAV returns a mono 16 bit PCM WAV file containing n samples of
+// silence at the given sample rate
+func SilentWAV(sampleRate, n int) []byte {
+	w := &audio.WAV{
+		Format: audio.PCM16(sampleRate, 1),
+		Data:   make([]byte, n*2),
+	}
+
+	return w.Bytes()
+}
+
+// charCount returns the number of characters in text as a string
+func charCount(text string) string {
+	return strconv.Itoa(len([]rune(text)))
+}
+
+// format returns the audio format, defaulting to wav
+func format(f string) string {
+	if f == "" {
+		return "wav"
+	}
+
+	return f
+}
@@ -0,0 +1,243 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package cerevoicetest provides a fake cerevoicego.CereVoiceAPI, an HTTP
+// server serving canned CereVoice Cloud responses and a Recorder replaying
+// real ones, so code using cerevoicego can be tested without calling the
+// paid API.
+package cerevoicetest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// Call records a call made to a Fake
+type Call struct {
+	Operation string      // API function, e.g. speakSimple
+	Input     interface{} // Input passed, nil for operations without one
+}
+
+// Fake is a configurable cerevoicego.CereVoiceAPI. Each operation calls the
+// corresponding function field if set and otherwise returns a successful
+// canned response. It is safe for concurrent use.
+type Fake struct {
+	SpeakSimpleFunc         func(*cerevoicego.SpeakSimpleInput) (*cerevoicego.SpeakSimpleResponse, error)
+	SpeakExtendedFunc       func(*cerevoicego.SpeakExtendedInput) (*cerevoicego.SpeakExtendedResponse, error)
+	ListVoicesFunc          func(*cerevoicego.ListVoicesInput) (*cerevoicego.ListVoicesResponse, error)
+	UploadLexiconFunc       func(*cerevoicego.UploadLexiconInput) (*cerevoicego.UploadLexiconResponse, error)
+	ListLexiconsFunc        func() (*cerevoicego.ListLexiconsResponse, error)
+	DeleteLexiconFunc       func(*cerevoicego.DeleteLexiconInput) (*cerevoicego.DeleteLexiconResponse, error)
+	UploadAbbreviationsFunc func(*cerevoicego.UploadAbbreviationsInput) (*cerevoicego.UploadAbbreviationsResponse, error)
+	ListAbbreviationsFunc   func() (*cerevoicego.ListAbbreviationsResponse, error)
+	DeleteAbbreviationsFunc func(*cerevoicego.DeleteAbbreviationsInput) (*cerevoicego.DeleteAbbreviationsResponse, error)
+	ListAudioFormatsFunc    func() (*cerevoicego.ListAudioFormatsResponse, error)
+	GetCreditFunc           func() (*cerevoicego.GetCreditResponse, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ cerevoicego.CereVoiceAPI = (*Fake)(nil)
+
+// Calls returns the calls made so far
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+func (f *Fake) record(operation string, input interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Operation: operation, Input: input})
+}
+
+// SpeakSimpleWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) SpeakSimpleWithContext(ctx context.Context, input *cerevoicego.SpeakSimpleInput) (*cerevoicego.SpeakSimpleResponse, error) {
+	f.record("speakSimple", input)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.SpeakSimpleFunc != nil {
+		return f.SpeakSimpleFunc(input)
+	}
+
+	return &cerevoicego.SpeakSimpleResponse{
+		FileURL:           "https://cerevoice.invalid/audio/fake.ogg",
+		CharCount:         charCount(input.Text),
+		ResponseMeta:      cerevoicego.ResponseMeta{Chars: len([]rune(input.Text))},
+		ResultCode:        cerevoicego.ResultSuccess,
+		ResultDescription: "OK",
+	}, nil
+}
+
+// SpeakExtendedWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) SpeakExtendedWithContext(ctx context.Context, input *cerevoicego.SpeakExtendedInput) (*cerevoicego.SpeakExtendedResponse, error) {
+	f.record("speakExtended", input)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.SpeakExtendedFunc != nil {
+		return f.SpeakExtendedFunc(input)
+	}
+
+	r := &cerevoicego.SpeakExtendedResponse{
+		FileURL:           "https://cerevoice.invalid/audio/fake." + format(string(input.AudioFormat)),
+		CharCount:         charCount(input.Text),
+		ResponseMeta:      cerevoicego.ResponseMeta{Chars: len([]rune(input.Text))},
+		ResultCode:        cerevoicego.ResultSuccess,
+		ResultDescription: "OK",
+	}
+	if input.Metadata {
+		r.Metadata = "https://cerevoice.invalid/audio/fake.xml"
+	}
+
+	return r, nil
+}
+
+// ListVoicesWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) ListVoicesWithContext(ctx context.Context, input *cerevoicego.ListVoicesInput) (*cerevoicego.ListVoicesResponse, error) {
+	f.record("listVoices", input)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.ListVoicesFunc != nil {
+		return f.ListVoicesFunc(input)
+	}
+
+	voices := cerevoicego.VoiceCatalog(Voices)
+	if input != nil {
+		if input.Language != "" {
+			voices = voices.ByLanguage(input.Language)
+		}
+		if input.Accent != "" {
+			voices = voices.ByAccent(input.Accent)
+		}
+		if input.Sex != "" {
+			voices = voices.BySex(input.Sex)
+		}
+	}
+
+	return &cerevoicego.ListVoicesResponse{VoiceList: voices}, nil
+}
+
+// UploadLexiconWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) UploadLexiconWithContext(ctx context.Context, input *cerevoicego.UploadLexiconInput) (*cerevoicego.UploadLexiconResponse, error) {
+	f.record("uploadLexicon", input)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.UploadLexiconFunc != nil {
+		return f.UploadLexiconFunc(input)
+	}
+
+	return &cerevoicego.UploadLexiconResponse{
+		ResultCode:        cerevoicego.ResultSuccess,
+		ResultDescription: "OK",
+	}, nil
+}
+
+// ListLexiconsWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) ListLexiconsWithContext(ctx context.Context) (*cerevoicego.ListLexiconsResponse, error) {
+	f.record("listLexicons", nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.ListLexiconsFunc != nil {
+		return f.ListLexiconsFunc()
+	}
+
+	return &cerevoicego.ListLexiconsResponse{}, nil
+}
+
+// DeleteLexiconWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) DeleteLexiconWithContext(ctx context.Context, input *cerevoicego.DeleteLexiconInput) (*cerevoicego.DeleteLexiconResponse, error) {
+	f.record("deleteLexicon", input)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.DeleteLexiconFunc != nil {
+		return f.DeleteLexiconFunc(input)
+	}
+
+	return &cerevoicego.DeleteLexiconResponse{
+		ResultCode:        cerevoicego.ResultSuccess,
+		ResultDescription: "OK",
+	}, nil
+}
+
+// UploadAbbreviationsWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) UploadAbbreviationsWithContext(ctx context.Context, input *cerevoicego.UploadAbbreviationsInput) (*cerevoicego.UploadAbbreviationsResponse, error) {
+	f.record("uploadAbbreviations", input)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.UploadAbbreviationsFunc != nil {
+		return f.UploadAbbreviationsFunc(input)
+	}
+
+	return &cerevoicego.UploadAbbreviationsResponse{
+		ResultCode:        cerevoicego.ResultSuccess,
+		ResultDescription: "OK",
+	}, nil
+}
+
+// ListAbbreviationsWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) ListAbbreviationsWithContext(ctx context.Context) (*cerevoicego.ListAbbreviationsResponse, error) {
+	f.record("listAbbreviations", nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.ListAbbreviationsFunc != nil {
+		return f.ListAbbreviationsFunc()
+	}
+
+	return &cerevoicego.ListAbbreviationsResponse{}, nil
+}
+
+// DeleteAbbreviationsWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) DeleteAbbreviationsWithContext(ctx context.Context, input *cerevoicego.DeleteAbbreviationsInput) (*cerevoicego.DeleteAbbreviationsResponse, error) {
+	f.record("deleteAbbreviations", input)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.DeleteAbbreviationsFunc != nil {
+		return f.DeleteAbbreviationsFunc(input)
+	}
+
+	return &cerevoicego.DeleteAbbreviationsResponse{
+		ResultCode:        cerevoicego.ResultSuccess,
+		ResultDescription: "OK",
+	}, nil
+}
+
+// ListAudioFormatsWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) ListAudioFormatsWithContext(ctx context.Context) (*cerevoicego.ListAudioFormatsResponse, error) {
+	f.record("listAudioFormats", nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.ListAudioFormatsFunc != nil {
+		return f.ListAudioFormatsFunc()
+	}
+
+	return &cerevoicego.ListAudioFormatsResponse{AudioFormats: AudioFormats}, nil
+}
+
+// GetCreditWithContext implements cerevoicego.CereVoiceAPI
+func (f *Fake) GetCreditWithContext(ctx context.Context) (*cerevoicego.GetCreditResponse, error) {
+	f.record("getCredit", nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.GetCreditFunc != nil {
+		return f.GetCreditFunc()
+	}
+
+	return &cerevoicego.GetCreditResponse{Credit: Credit}, nil
+}
@@ -0,0 +1,355 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// EnvRecord is the environment variable which, when set to a non-empty
+// value, makes ModeFromEnv return ModeRecord
+const EnvRecord = "CEREVOICETEST_RECORD"
+
+// Mode selects whether a Recorder calls the real API
+type Mode int
+
+// Recorder modes
+const (
+	// ModeReplay answers requests from the cassette only
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real API and records the responses
+	ModeRecord
+	// ModeAuto replays if the cassette exists and records otherwise
+	ModeAuto
+)
+
+// ModeFromEnv returns ModeRecord if EnvRecord is set and ModeReplay
+// otherwise, so CI replays while developers can re-record with
+// CEREVOICETEST_RECORD=1 go test
+func ModeFromEnv() Mode {
+	if os.Getenv(EnvRecord) != "" {
+		return ModeRecord
+	}
+
+	return ModeReplay
+}
+
+// ErrNoInteraction is returned when replaying a request with no matching
+// recorded interaction
+var ErrNoInteraction = errors.New("cerevoicetest: no recorded interaction matches request")
+
+// Interaction is a recorded request and its response. Text bodies are kept
+// as text so cassettes can be read and reviewed; binary bodies, such as
+// audio, are base64 encoded.
+type Interaction struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Request     string `json:"request,omitempty"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body,omitempty"`
+	BodyBase64  []byte `json:"bodyBase64,omitempty"`
+}
+
+// Recorder is a cerevoicego.HTTPClient which records real API traffic to a
+// cassette file and replays it, so integration tests can run without
+// credentials or spending credit. Credentials are scrubbed from recorded
+// requests, in XML or, with cerevoicego.JSONCodec, JSON, but the text is
+// recorded as sent: a TextAuditor's Logged text does not apply, so use Scrub
+// to redact it. Set it as the Client's HTTPClient:
+//
+//	rec, err := cerevoicetest.NewRecorder("testdata/speak.json", cerevoicetest.ModeFromEnv())
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Save()
+//
+//	client := cerevoicego.NewClient(accountID, password, cerevoicego.WithHTTPClient(rec))
+type Recorder struct {
+	Path   string                 // Cassette file
+	Mode   Mode                   // ModeReplay, ModeRecord or ModeAuto
+	Client cerevoicego.HTTPClient // Sends requests when recording, http.DefaultClient if nil
+
+	// Scrub, if set, is applied to request and response bodies before they
+	// are recorded, in addition to the credential scrubbing
+	Scrub func(body []byte) []byte
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder returns a Recorder for the cassette at path. In ModeReplay the
+// cassette must exist; in ModeAuto it is replayed if it exists.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{Path: path, Mode: mode}
+
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err) && mode == ModeAuto:
+		r.Mode = ModeRecord
+		return r, nil
+	case mode == ModeRecord:
+		return r, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &r.interactions); err != nil {
+		return nil, fmt.Errorf("cerevoicetest: reading %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.interactions))
+	if mode == ModeAuto {
+		r.Mode = ModeReplay
+	}
+
+	return r, nil
+}
+
+// Do records or replays req
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if r.Mode == ModeRecord {
+		return r.record(req, body)
+	}
+
+	return r.replay(req, body)
+}
+
+// Interactions returns the recorded interactions
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the cassette if recording
+func (r *Recorder) Save() error {
+	if r.Mode != ModeRecord {
+		return nil
+	}
+
+	// Leave markup unescaped so recorded XML stays readable
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	r.mu.Lock()
+	err := enc.Encode(r.interactions)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.Path, b.Bytes(), 0644)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	in := Interaction{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Request:     string(r.scrub(body, req.Header.Get("Content-Type"))),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if recorded := r.scrubResponse(respBody); isText(in.ContentType) && utf8.Valid(recorded) {
+		in.Body = string(recorded)
+	} else {
+		in.BodyBase64 = recorded
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.used = append(r.used, true)
+	r.mu.Unlock()
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	scrubbed := string(r.scrub(body, req.Header.Get("Content-Type")))
+	url := req.URL.String()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Prefer an exact match of the request body, then fall back to the next
+	// unused interaction for the same operation, so recordings survive
+	// changes to the text being spoken
+	match := -1
+	for pass := 0; pass < 2 && match < 0; pass++ {
+		for i, in := range r.interactions {
+			if r.used[i] || in.Method != req.Method || in.URL != url {
+				continue
+			}
+			if (pass == 0 && in.Request == scrubbed) || (pass == 1 && operation(in.Request) == operation(scrubbed)) {
+				match = i
+				break
+			}
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("%w: %s %s %s", ErrNoInteraction, req.Method, url, operation(scrubbed))
+	}
+	r.used[match] = true
+
+	in := r.interactions[match]
+	respBody := []byte(in.Body)
+	if in.BodyBase64 != nil {
+		respBody = in.BodyBase64
+	}
+
+	header := make(http.Header)
+	if in.ContentType != "" {
+		header.Set("Content-Type", in.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		StatusCode:    in.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// credentials matches the credential elements of an API request
+var credentials = regexp.MustCompile(`<(accountID|password|token)>[^<]*</(accountID|password|token)>`)
+
+// scrub removes credentials from a request body sent with Content-Type ct
+// and applies Scrub
+func (r *Recorder) scrub(body []byte, ct string) []byte {
+	if isJSON(ct) {
+		body = scrubJSON(body)
+	} else {
+		body = credentials.ReplaceAll(body, []byte("<$1>"+cerevoicego.RedactedPassword+"</$2>"))
+	}
+	if r.Scrub != nil {
+		body = r.Scrub(body)
+	}
+
+	return body
+}
+
+// jsonRequest is a request encoded by cerevoicego.JSONCodec
+type jsonRequest struct {
+	Operation string `json:"operation"`
+	cerevoicego.Request
+}
+
+// scrubJSON removes credentials from a request encoded by
+// cerevoicego.JSONCodec, encoding it again as the codec does. A body which
+// is not such a request is replaced, as it may hold credentials anywhere.
+func scrubJSON(body []byte) []byte {
+	var req jsonRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return []byte(cerevoicego.RedactedPassword)
+	}
+	req.XMLName.Local = req.Operation
+	for _, field := range []*string{&req.AccountID, &req.Password, &req.Token} {
+		if *field != "" {
+			*field = cerevoicego.RedactedPassword
+		}
+	}
+
+	scrubbed, err := cerevoicego.JSONCodec{}.Encode(&req.Request)
+	if err != nil {
+		return []byte(cerevoicego.RedactedPassword)
+	}
+
+	return scrubbed
+}
+
+// scrubResponse applies Scrub to a response body
+func (r *Recorder) scrubResponse(body []byte) []byte {
+	if r.Scrub != nil {
+		return r.Scrub(body)
+	}
+
+	return body
+}
+
+// rootElement matches the first element of an API request body
+var rootElement = regexp.MustCompile(`<([A-Za-z]+)>`)
+
+// operation returns the root element name of an API request body, or its
+// operation if it is JSON
+func operation(body string) string {
+	if strings.HasPrefix(strings.TrimSpace(body), "{") {
+		var req jsonRequest
+		json.Unmarshal([]byte(body), &req)
+		return req.Operation
+	}
+
+	body = strings.TrimPrefix(strings.TrimSpace(body), "<?xml")
+	if m := rootElement.FindStringSubmatch(body); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// isJSON reports whether a body with content type ct is JSON
+func isJSON(ct string) bool {
+	mediaType, _, _ := mime.ParseMediaType(ct)
+	return strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json")
+}
+
+// isText reports whether a body with content type ct is text
+func isText(ct string) bool {
+	mediaType, _, _ := mime.ParseMediaType(ct)
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "/xml") ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		strings.HasSuffix(mediaType, "/json")
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicetest_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+// jsonAPI answers every request with a JSON listVoices response
+type jsonAPI struct{}
+
+func (jsonAPI) Do(req *http.Request) (*http.Response, error) {
+	body := `{"voices":[{"voiceName":"Heather","languageCodeISO":"en"}]}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestRecorderScrubsJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voices.json")
+	rec, err := cerevoicetest.NewRecorder(path, cerevoicetest.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Client = jsonAPI{}
+
+	c := cerevoicego.NewClient("account-1234", "s3cret-password",
+		cerevoicego.WithHTTPClient(rec), cerevoicego.WithCodec(cerevoicego.JSONCodec{}))
+	if _, err := c.ListVoices(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cassette, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"account-1234", "s3cret-password"} {
+		if bytes.Contains(cassette, []byte(secret)) {
+			t.Errorf("cassette contains %q:\n%s", secret, cassette)
+		}
+	}
+	if !bytes.Contains(cassette, []byte(`\"operation\":\"listVoices\"`)) {
+		t.Errorf("cassette lost the request:\n%s", cassette)
+	}
+
+	// The cassette replays with other credentials
+	rec, err = cerevoicetest.NewRecorder(path, cerevoicetest.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = cerevoicego.NewClient("other", "password",
+		cerevoicego.WithHTTPClient(rec), cerevoicego.WithCodec(cerevoicego.JSONCodec{}))
+	res, err := c.ListVoices(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.VoiceList) != 1 || res.VoiceList[0].VoiceName != "Heather" {
+		t.Errorf("replayed voices = %+v", res.VoiceList)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicetest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// Server is an httptest.Server emulating the CereVoice Cloud REST API. It
+// answers every operation with a canned successful response, serves Audio
+// for the returned file URLs and Metadata for metadata URLs. Responses can
+// be overridden per operation.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	audio     []byte
+	responses map[string]string
+	requests  []cerevoicego.Request
+}
+
+// NewServer starts a Server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		audio:     SilentWAV(8000, 800),
+		responses: make(map[string]string),
+	}
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+// Client returns a Client configured to use the server
+func (s *Server) Client() *cerevoicego.Client {
+	return cerevoicego.NewClient("test", "test",
+		cerevoicego.WithAPIURL(s.URL+"/rest"),
+		cerevoicego.WithHTTPClient(s.Server.Client()))
+}
+
+// SetAudio sets the audio served for file URLs
+func (s *Server) SetAudio(audio []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audio = audio
+}
+
+// SetResponse overrides the XML response body for an operation, e.g.
+// "speakSimple"
+func (s *Server) SetResponse(operation, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses[operation] = body
+}
+
+// SetError makes an operation fail with the given result code and
+// description
+func (s *Server) SetError(operation string, code cerevoicego.ResultCode, description string) {
+	s.SetResponse(operation, fmt.Sprintf(
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<%[1]sResponse><resultCode>%[2]d</resultCode><resultDescription>%[3]s</resultDescription></%[1]sResponse>",
+		operation, int(code), escape(description)))
+}
+
+// Reset removes all response overrides and recorded requests
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses = make(map[string]string)
+	s.requests = nil
+}
+
+// Requests returns the API requests received so far
+func (s *Server) Requests() []cerevoicego.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]cerevoicego.Request(nil), s.requests...)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/rest":
+		s.serveAPI(w, r)
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && strings.HasPrefix(r.URL.Path, "/audio/"):
+		s.serveFile(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req cerevoicego.Request
+	if err := xml.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	operation := req.XMLName.Local
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	override, ok := s.responses[operation]
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	if ok {
+		fmt.Fprint(w, override)
+		return
+	}
+
+	resp := s.response(&req)
+	if resp == nil {
+		fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<%[1]sResponse><resultCode>%[2]d</resultCode><resultDescription>Unknown operation</resultDescription></%[1]sResponse>",
+			escape(operation), int(cerevoicego.ResultInvalidParameter))
+		return
+	}
+
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.EncodeElement(resp, xml.StartElement{Name: xml.Name{Local: operation + "Response"}})
+}
+
+// response returns the canned response for req, nil for an unknown
+// operation
+func (s *Server) response(req *cerevoicego.Request) interface{} {
+	ok := func() (cerevoicego.ResultCode, string) {
+		return cerevoicego.ResultSuccess, "OK"
+	}
+
+	switch req.XMLName.Local {
+	case "speakSimple":
+		r := &cerevoicego.SpeakSimpleResponse{
+			FileURL:   s.URL + "/audio/speak.ogg",
+			CharCount: charCount(req.Text),
+		}
+		r.ResultCode, r.ResultDescription = ok()
+		return r
+	case "speakExtended":
+		r := &cerevoicego.SpeakExtendedResponse{
+			FileURL:   s.URL + "/audio/speak." + format(req.AudioFormat),
+			CharCount: charCount(req.Text),
+		}
+		if req.Metadata {
+			r.Metadata = s.URL + "/audio/speak.xml"
+		}
+		r.ResultCode, r.ResultDescription = ok()
+		return r
+	case "listVoices":
+		return &cerevoicego.ListVoicesResponse{VoiceList: Voices}
+	case "uploadLexicon":
+		r := &cerevoicego.UploadLexiconResponse{}
+		r.ResultCode, r.ResultDescription = ok()
+		return r
+	case "listLexicons":
+		return &cerevoicego.ListLexiconsResponse{}
+	case "deleteLexicon":
+		r := &cerevoicego.DeleteLexiconResponse{}
+		r.ResultCode, r.ResultDescription = ok()
+		return r
+	case "uploadAbbreviations":
+		r := &cerevoicego.UploadAbbreviationsResponse{}
+		r.ResultCode, r.ResultDescription = ok()
+		return r
+	case "listAbbreviations":
+		return &cerevoicego.ListAbbreviationsResponse{}
+	case "deleteAbbreviations":
+		r := &cerevoicego.DeleteAbbreviationsResponse{}
+		r.ResultCode, r.ResultDescription = ok()
+		return r
+	case "listAudioFormats":
+		return &cerevoicego.ListAudioFormatsResponse{AudioFormats: AudioFormats}
+	case "getCredit":
+		return &cerevoicego.GetCreditResponse{Credit: Credit}
+	}
+
+	return nil
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	ext := path.Ext(r.URL.Path)
+	if ext == ".xml" {
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, Metadata)
+		return
+	}
+
+	ct := mime.TypeByExtension(ext)
+	if ct == "" || !strings.HasPrefix(ct, "audio/") {
+		ct = "audio/" + strings.TrimPrefix(ext, ".")
+	}
+
+	s.mu.Lock()
+	audio := s.audio
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", ct)
+	w.Write(audio)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,206 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bganderson/cerevoicego/audio"
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+const (
+	// Version is the package version
+	Version = "1.0.0"
+	// DefaultAPIURL is the default CereVoice Cloud REST API endpoint
+	DefaultAPIURL = "https://cerevoice.com/rest/rest_1_1.php"
+	// DefaultTimeout is the overall request timeout of the default HTTP client
+	DefaultTimeout = 60 * time.Second
+)
+
+// defaultHTTPClient is used by any Client without an HTTPClient set. Its
+// transport is shared by every such Client, so connections to the API are
+// pooled across Clients and goroutines.
+var defaultHTTPClient = &http.Client{
+	Timeout:   DefaultTimeout,
+	Transport: NewTransport(),
+}
+
+// NewTransport returns a transport tuned for the API, as used by the default
+// HTTP client. Connections are kept alive and reused, HTTP/2 is used where
+// the server supports it, and the whole idle pool is available to the API
+// host. Use it as the base of a custom HTTPClient to keep this tuning.
+func NewTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2: true,
+		MaxIdleConns:      100,
+		// Requests almost all go to one host, so allow it the whole pool
+		// rather than the default of 2 idle connections
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// HTTPClient is the interface used to send requests to the CereVoice Cloud
+// API. *http.Client satisfies it.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client API connection settings.
+//
+// A Client is safe for concurrent use by multiple goroutines, and should be
+// shared rather than created per request so connections are reused. Its
+// fields must be set before first use and not modified afterwards; use
+// Clone to derive a Client with different settings. Values shared between
+// Clients, such as a Cache, RateLimiter or CreditGuard, must also be safe
+// for concurrent use, as all those in this package are.
+type Client struct {
+	AccountID    string              // CereVoice Cloud API AccountID
+	Password     string              // CereVoice Cloud API Password
+	Credentials  CredentialsProvider // Per request credentials, overrides AccountID and Password if set
+	APIURL       string              // CereVoice Cloud API URL, defaults to DefaultAPIURL when empty
+	HTTPClient   HTTPClient          // HTTP client, defaults to one with timeouts when nil
+	RetryPolicy  *RetryPolicy        // Retry behaviour for transient failures, nil disables retries
+	Cache        Cache               // Cache for SpeakAudio, nil disables caching
+	Logger       Logger              // Receives a RequestLog for every API request, may be nil
+	Tracer       Tracer              // Traces and measures every API request, may be nil
+	UserAgent    string              // User-Agent header sent with requests, DefaultUserAgent if empty
+	Header       http.Header         // Extra headers sent with every API request
+	RateLimiter  *RateLimiter        // Limits the rate of API requests, nil for no limit
+	Breaker      *CircuitBreaker     // Fails requests fast while the API is down, may be nil
+	Dedup        *SpeakGroup         // Shares one request between identical concurrent speak requests, may be nil
+	CreditGuard  *CreditGuard        // Refuses speak requests exceeding the credit, may be nil
+	CheckFormats bool                // Check audio formats against listAudioFormats before speaking
+	VerifyAudio  bool                // Check downloaded audio is complete and as requested
+	Middleware   []Middleware        // Wraps the sending of every API request
+	DryRun       bool                // Answer speak requests locally with the estimated charCount
+	TextSteps    []TextStep          // Applied in order to the text of speak requests
+	TextAuditor  TextAuditor         // Allows, denies or transforms the final text of speak requests, may be nil
+	CustomVoices []string            // Names of the account's custom voices, such as CereVoice Me voices
+	AudioEffects []audio.Effect      // Applied in order to WAV audio from SpeakAudio, SpeakTo and SpeakToFile
+
+	Codec          Codec             // Wire format of requests and responses, XML if nil
+	DecodeMode     DecodeMode        // How strictly responses are decoded
+	CharsetReader  CharsetReaderFunc // Converts responses in other charsets to UTF-8, may be nil
+	ElementAliases map[string]string // Response element names mapped to those expected, for API changes
+
+	FallbackURLs     []string      // API URLs to fail over to when APIURL can not be reached
+	EndpointCooldown time.Duration // How long a failed endpoint is avoided, DefaultEndpointCooldown when 0
+
+	Timeouts Timeouts // Per operation deadlines and the budget for composite operations
+
+	LanguageDetection *LanguageDetection // Chooses voices by the language of the text, may be nil
+	CheckEmotions     bool               // Refuse an Emotion the voice is not known to have
+	VoiceProfiles     VoiceProfiles      // Default settings of speak requests by voice, may be nil
+	Ledger            Ledger             // Records the credit used by every speak request, may be nil
+	Quota             *Quota             // Limits the characters used by each tenant, may be nil
+	Debug             *Debug             // Captures raw requests and responses, may be nil
+	ConnTracker       *ConnTracker       // Accumulates connection reuse and timing stats, may be nil
+	MaxTextLength     int                // Characters of text per speak request, DefaultMaxTextLength if 0
+	LengthPolicy      LengthPolicy       // What happens to text over MaxTextLength
+	TLSConfig         *tls.Config        // TLS settings for the default transport, ignored with an HTTPClient
+	Proxy             *url.URL           // Proxy for the default transport instead of the environment's
+	Dialer            ContextDialer      // Opens connections for the default transport, may be nil
+
+	// EmotionalVoices are the emotional styles of voices by lower case name
+	// for CheckEmotions, EmotionalVoices() if nil
+	EmotionalVoices map[string][]ssml.Emotion
+
+	// Deprecated: use APIURL. CereVoiceAPIURL is used when APIURL is empty.
+	CereVoiceAPIURL string
+
+	mu        sync.Mutex
+	formats   []string             // cached listAudioFormats result
+	down      map[string]time.Time // when each failed endpoint last failed
+	previews  map[string][]byte    // PreviewVoice audio by lower case voice name
+	transport *http.Client         // default client with the TLSConfig, Proxy and Dialer
+	work      map[*work]struct{}   // requests, batches and streams in progress
+	closing   bool                 // Close has been called
+	drained   chan struct{}        // closed once closing with no work in progress
+}
+
+// httpClient returns the configured HTTP client or the package default
+func (c *Client) httpClient() HTTPClient {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	if c.customTransport() {
+		return c.transportClient()
+	}
+	if !c.Timeouts.isZero() {
+		return untimedHTTPClient
+	}
+
+	return defaultHTTPClient
+}
+
+// transportClient returns the HTTP client of a Client whose transport settings
+// differ from the default, creating it on first use so its connections are
+// pooled
+func (c *Client) transportClient() *http.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.transport == nil {
+		t := NewTransport()
+		t.TLSClientConfig = c.TLSConfig
+		if c.Proxy != nil {
+			t.Proxy = http.ProxyURL(c.Proxy)
+		}
+		if c.Dialer != nil {
+			t.DialContext = c.Dialer.DialContext
+		}
+
+		c.transport = &http.Client{Transport: t}
+		if c.Timeouts.isZero() {
+			c.transport.Timeout = DefaultTimeout
+		}
+	}
+
+	return c.transport
+}
+
+// customTransport reports whether the Client needs a transport other than the
+// default one
+func (c *Client) customTransport() bool {
+	return c.HTTPClient == nil && (c.TLSConfig != nil || c.Proxy != nil || c.Dialer != nil)
+}
+
+// sameTransport reports whether a transport built for c suits other
+func (c *Client) sameTransport(other *Client) bool {
+	sameDialer := c.Dialer == nil && other.Dialer == nil
+	if c.Dialer != nil && other.Dialer != nil && reflect.TypeOf(c.Dialer).Comparable() && reflect.TypeOf(other.Dialer).Comparable() {
+		sameDialer = c.Dialer == other.Dialer
+	}
+
+	return sameDialer && c.HTTPClient == other.HTTPClient && c.TLSConfig == other.TLSConfig &&
+		c.Proxy == other.Proxy && c.Timeouts.isZero() == other.Timeouts.isZero()
+}
+
+// apiURL returns the configured API URL or the package default
+func (c *Client) apiURL() string {
+	switch {
+	case c.APIURL != "":
+		return c.APIURL
+	case c.CereVoiceAPIURL != "":
+		return c.CereVoiceAPIURL
+	}
+
+	return DefaultAPIURL
+}
@@ -0,0 +1,93 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		code   cerevoicego.ResultCode
+		desc   string
+		target error
+		want   bool
+	}{
+		{cerevoicego.ResultInvalidCredentials, "Invalid credentials", cerevoicego.ErrAuth, true},
+		{cerevoicego.ResultInsufficientCredit, "No credit", cerevoicego.ErrInsufficientCredit, true},
+		{cerevoicego.ResultInvalidVoice, "Unknown voice", cerevoicego.ErrInvalidVoice, true},
+		{cerevoicego.ResultInvalidVoice, "Unknown voice", cerevoicego.ErrAuth, false},
+		{cerevoicego.ResultServerBusy, "Account busy, try again", cerevoicego.ErrAuth, false},
+		{cerevoicego.ResultTextTooLong, "Text too long for voice", cerevoicego.ErrInvalidVoice, false},
+		{cerevoicego.ResultFailure, "Out of credit", cerevoicego.ErrInsufficientCredit, true},
+		{cerevoicego.ResultFailure, "Invalid account ID or password", cerevoicego.ErrAuth, true},
+		{cerevoicego.ResultFailure, "Voice not found: Jess", cerevoicego.ErrInvalidVoice, true},
+		{cerevoicego.ResultFailure, "Account busy, try again", cerevoicego.ErrAuth, false},
+		{cerevoicego.ResultFailure, "Voice Heather failed to load", cerevoicego.ErrInvalidVoice, false},
+		{cerevoicego.ResultInvalidParameter, "Invalid voice parameter", cerevoicego.ErrInvalidVoice, false},
+	}
+
+	for _, tt := range tests {
+		err := &cerevoicego.APIError{Operation: "speakExtended", ResultCode: tt.code, Description: tt.desc}
+		if got := errors.Is(err, tt.target); got != tt.want {
+			t.Errorf("errors.Is(%v, %v) = %v, want %v", err, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestAPIErrorCode(t *testing.T) {
+	tests := []struct {
+		code      cerevoicego.ResultCode
+		desc      string
+		want      cerevoicego.ResultCode
+		retryable bool
+	}{
+		{cerevoicego.ResultInvalidVoice, "Insufficient credit", cerevoicego.ResultInvalidVoice, false},
+		{cerevoicego.ResultFailure, "Insufficient credit", cerevoicego.ResultInsufficientCredit, false},
+		{cerevoicego.ResultFailure, "Server busy, please try again later", cerevoicego.ResultServerBusy, true},
+		{cerevoicego.ResultFailure, "Text too long", cerevoicego.ResultTextTooLong, false},
+		{cerevoicego.ResultFailure, "Something went wrong", cerevoicego.ResultFailure, false},
+	}
+
+	for _, tt := range tests {
+		err := &cerevoicego.APIError{Operation: "speakExtended", ResultCode: tt.code, Description: tt.desc}
+		if got := err.Code(); got != tt.want {
+			t.Errorf("Code(%d, %q) = %v, want %v", tt.code, tt.desc, got, tt.want)
+		}
+		if got := err.Code().IsRetryable(); got != tt.retryable {
+			t.Errorf("Code(%d, %q).IsRetryable() = %v", tt.code, tt.desc, got)
+		}
+	}
+}
+
+func TestCreditGuardUnparsableCredit(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	srv.SetResponse("getCredit", `<getCreditResponse><credit><freeCredit>0</freeCredit><paidCredit>0</paidCredit><charsAvailable>n/a</charsAvailable></credit></getCreditResponse>`)
+
+	c := srv.Client()
+	c.Apply(cerevoicego.WithCreditGuard(&cerevoicego.CreditGuard{}))
+	_, err := c.SpeakAudio(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"})
+	if err == nil || errors.Is(err, cerevoicego.ErrInsufficientCredit) {
+		t.Fatalf("SpeakAudio error = %v, want a parse error", err)
+	}
+}
+
+func TestCereVoiceAPIURL(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+
+	c := cerevoicego.NewClient("test", "test", cerevoicego.WithHTTPClient(srv.Server.Client()))
+	c.CereVoiceAPIURL = srv.URL + "/rest"
+	if _, err := c.GetCredit(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("server received %d requests, want 1", n)
+	}
+}
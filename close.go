@@ -0,0 +1,136 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientClosed is returned by requests made after Client.Close
+var ErrClientClosed = errors.New("cerevoicego: client closed")
+
+// flusher is a Ledger, Logger or Cache which buffers what it is given
+type flusher interface {
+	Flush() error
+}
+
+// work is an API request, SpeakAudio, SpeakTo or SpeakLong call, SpeakBatch
+// or Stream in progress
+type work struct {
+	client *Client
+	parent *work // Work whose context this was started with, if any
+	cancel context.CancelFunc
+}
+
+// of reports whether w, or work it was started within, belongs to c
+func (w *work) of(c *Client) bool {
+	for ; w != nil; w = w.parent {
+		if w.client == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+type workKey struct{}
+
+// Close shuts the Client down. New requests fail with ErrClientClosed, while
+// API requests, SpeakBatch jobs and Streams in progress are left to finish,
+// including the requests they go on to make, as are SpeakAudio, SpeakTo and
+// SpeakLong calls, including their downloads. Then the background refresh of
+// the LanguageDetection VoiceCache is stopped, the Ledger, Logger and Cache
+// are flushed if they have a Flush() error method, and the idle connections
+// of the transport built for the TLSConfig, Proxy or Dialer are closed. An
+// HTTPClient, and the default one every other Client shares, are left open.
+//
+// If ctx ends first the work in progress is cancelled, the shutdown
+// completed without waiting for it and the context's error returned. As
+// shared components are shut down too, close only the last Client using
+// them; Clones are closed separately.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closing = true
+	if c.drained == nil {
+		c.drained = make(chan struct{})
+		if len(c.work) == 0 {
+			close(c.drained)
+		}
+	}
+	drained := c.drained
+	c.mu.Unlock()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+		c.mu.Lock()
+		for w := range c.work {
+			w.cancel()
+		}
+		c.mu.Unlock()
+	}
+
+	if c.LanguageDetection != nil && c.LanguageDetection.Voices != nil {
+		c.LanguageDetection.Voices.Stop()
+	}
+	for _, v := range []interface{}{c.Ledger, c.Logger, c.Cache} {
+		if f, ok := v.(flusher); ok {
+			if ferr := f.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}
+	c.mu.Lock()
+	transport := c.transport
+	c.mu.Unlock()
+	if transport != nil {
+		transport.CloseIdleConnections()
+	}
+
+	return err
+}
+
+// begin tracks work started with ctx until done is called, returning a
+// context cancelled by a Close which times out. Once Close is called it
+// returns ErrClientClosed, unless ctx belongs to work of c already in
+// progress.
+func (c *Client) begin(ctx context.Context) (_ context.Context, done func(), err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	parent, _ := ctx.Value(workKey{}).(*work)
+	if c.closing && !parent.of(c) {
+		return ctx, func() {}, ErrClientClosed
+	}
+
+	w := &work{client: c, parent: parent}
+	ctx, w.cancel = context.WithCancel(context.WithValue(ctx, workKey{}, w))
+	if c.work == nil {
+		c.work = make(map[*work]struct{})
+	}
+	c.work[w] = struct{}{}
+
+	return ctx, func() { c.end(w) }, nil
+}
+
+// end stops tracking w, completing a Close waiting for it
+func (c *Client) end(w *work) {
+	w.cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.work, w)
+	if c.drained == nil || len(c.work) > 0 {
+		return
+	}
+	select {
+	case <-c.drained:
+	default:
+		close(c.drained)
+	}
+}
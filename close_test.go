@@ -0,0 +1,171 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+// holdDownload holds the first audio download until released or its
+// context ends
+type holdDownload struct {
+	next    cerevoicego.HTTPClient
+	arrived chan struct{}
+	release chan struct{}
+	held    bool
+}
+
+func (h *holdDownload) Do(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.Path, "/audio/") && !h.held {
+		h.held = true
+		close(h.arrived)
+		select {
+		case <-h.release:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return h.next.Do(req)
+}
+
+func newHeldClient(srv *cerevoicetest.Server) (*cerevoicego.Client, *holdDownload) {
+	hold := &holdDownload{next: srv.Server.Client(), arrived: make(chan struct{}), release: make(chan struct{})}
+	c := srv.Client()
+	c.Apply(cerevoicego.WithHTTPClient(hold))
+	return c, hold
+}
+
+func TestCloseWaitsForDownloads(t *testing.T) {
+	input := &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}
+	speakers := map[string]func(c *cerevoicego.Client) error{
+		"SpeakAudio": func(c *cerevoicego.Client) error {
+			_, err := c.SpeakAudio(input)
+			return err
+		},
+		"SpeakTo": func(c *cerevoicego.Client) error {
+			var buf bytes.Buffer
+			_, err := c.SpeakTo(&buf, input)
+			return err
+		},
+		"SpeakLong": func(c *cerevoicego.Client) error {
+			_, err := c.SpeakLong(&cerevoicego.SpeakLongInput{SpeakExtendedInput: *input, Concurrency: 1})
+			return err
+		},
+	}
+
+	for name, speak := range speakers {
+		srv := cerevoicetest.NewServer()
+		c, hold := newHeldClient(srv)
+
+		spoken := make(chan error, 1)
+		go func() { spoken <- speak(c) }()
+		<-hold.arrived
+
+		closed := make(chan error, 1)
+		go func() { closed <- c.Close(context.Background()) }()
+		select {
+		case err := <-closed:
+			t.Errorf("%s: Close = %v before the download finished", name, err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		if _, err := c.SpeakAudio(input); !errors.Is(err, cerevoicego.ErrClientClosed) {
+			t.Errorf("%s: SpeakAudio after Close = %v, want ErrClientClosed", name, err)
+		}
+
+		close(hold.release)
+		if err := <-spoken; err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if err := <-closed; err != nil {
+			t.Errorf("%s: Close = %v", name, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestCloseCancelsDownloads(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c, hold := newHeldClient(srv)
+
+	spoken := make(chan error, 1)
+	go func() {
+		var buf bytes.Buffer
+		_, err := c.SpeakTo(&buf, &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"})
+		spoken <- err
+	}()
+	<-hold.arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close = %v, want context.DeadlineExceeded", err)
+	}
+	if err := <-spoken; !errors.Is(err, context.Canceled) {
+		t.Errorf("SpeakTo = %v, want the download cancelled", err)
+	}
+}
+
+// nestedClient makes a request with another Client from within each request
+// it sends, and counts calls to CloseIdleConnections
+type nestedClient struct {
+	next   cerevoicego.HTTPClient
+	other  *cerevoicego.Client
+	err    error
+	closed int
+}
+
+func (n *nestedClient) Do(req *http.Request) (*http.Response, error) {
+	if n.other != nil {
+		_, n.err = n.other.SpeakSimpleWithContext(req.Context(), &cerevoicego.SpeakSimpleInput{Voice: "Heather", Text: "Hi"})
+	}
+	return n.next.Do(req)
+}
+
+func (n *nestedClient) CloseIdleConnections() {
+	n.closed++
+}
+
+func TestCloseRejectsWorkOfOtherClients(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	closed := srv.Client()
+	if err := closed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	nested := &nestedClient{next: srv.Server.Client(), other: closed}
+	c := srv.Client()
+	c.Apply(cerevoicego.WithHTTPClient(nested))
+	if _, err := c.SpeakSimple(&cerevoicego.SpeakSimpleInput{Voice: "Heather", Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(nested.err, cerevoicego.ErrClientClosed) {
+		t.Errorf("closed Client request within another's = %v, want ErrClientClosed", nested.err)
+	}
+}
+
+func TestCloseLeavesHTTPClientOpen(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	shared := &nestedClient{next: srv.Server.Client()}
+	c := srv.Client()
+	c.Apply(cerevoicego.WithHTTPClient(shared))
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if shared.closed != 0 {
+		t.Errorf("CloseIdleConnections called %d times on the HTTPClient", shared.closed)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Command cerevoice-proxy is a caching HTTP proxy for CereVoice speech.
+// Audio is stored by voice, text and format, so repeated prompts are served
+// from the cache and only new text spends CereVoice credit. Identical
+// requests arriving together share a single CereVoice request.
+//
+// Usage:
+//
+//	cerevoice-proxy [flags]
+//
+// Endpoints:
+//
+//	GET  /speak     synthesise the voice, text, format and rate query parameters
+//	POST /speak     synthesise a JSON body with the same fields
+//	GET  /stats     cache hits, misses and shared requests
+//	POST /token     issue a temporary token to use in place of the API key
+//	POST /rest      relay CereVoice Cloud API requests authenticated with a token
+//	GET  /healthz   liveness check, no API key required
+//
+// GET requests allow the proxy URL to be used directly as a prompt URL, for
+// example in TwiML <Play>. Responses carry an ETag and an X-Cache header of
+// HIT or MISS.
+//
+// Clients authenticate with one of the API keys in the -keys file (one per
+// line) or the CEREVOICE_PROXY_API_KEYS environment variable (comma
+// separated), given as "Authorization: Bearer <key>", "X-API-Key: <key>" or,
+// for prompt URLs, the key query parameter. The proxy listens on localhost
+// unless -addr says otherwise. Services which should not hold a permanent
+// key can instead be given tokens from /token, valid for -token-ttl and in
+// the same way, which the proxy accepts until it restarts. Only an API key
+// can request a token.
+//
+// /rest accepts the requests of a cerevoicego Client using WithTokens and an
+// HTTPTokenSource of /token, sending them to CereVoice with the proxy's
+// credentials, so the service never holds the CereVoice password either.
+//
+// Audio is cached in the S3 bucket -s3-bucket if set, in -cache-dir if set,
+// otherwise in memory. S3 credentials are read from AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN. CereVoice credentials are
+// read in the same way as the cerevoice command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/internal/apikey"
+	"github.com/bganderson/cerevoicego/storage"
+)
+
+// EnvAPIKeys is the environment variable holding comma separated API keys
+const EnvAPIKeys = "CEREVOICE_PROXY_API_KEYS"
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "cerevoice-proxy:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	var cfg cerevoicego.Config
+
+	fs := flag.NewFlagSet("cerevoice-proxy", flag.ContinueOnError)
+	fs.StringVar(&cfg.AccountID, "account", "", "CereVoice Cloud account ID")
+	fs.StringVar(&cfg.Password, "password", "", "CereVoice Cloud password")
+	fs.StringVar(&cfg.APIURL, "url", "", "CereVoice Cloud REST API URL")
+	configPath := fs.String("config", cerevoicego.DefaultConfigPath(), "config file")
+	profile := fs.String("profile", os.Getenv(cerevoicego.EnvProfile), "config file profile")
+	addr := fs.String("addr", "127.0.0.1:8080", "listen address")
+	keysPath := fs.String("keys", "", "file of API keys, one per line")
+	tokenTTL := fs.Duration("token-ttl", 15*time.Minute, "lifetime of issued tokens, 0 to issue none")
+	s3Bucket := fs.String("s3-bucket", "", "S3 bucket to cache audio in")
+	s3Region := fs.String("s3-region", os.Getenv("AWS_REGION"), "region of -s3-bucket")
+	s3Endpoint := fs.String("s3-endpoint", "", "URL of an S3 compatible service")
+	s3Prefix := fs.String("s3-prefix", "cerevoice-proxy/", "prefix of the keys of audio cached in -s3-bucket")
+	cacheDir := fs.String("cache-dir", "", "directory to cache audio in, memory if empty")
+	cacheSize := fs.Int64("cache-size", 1<<30, "maximum bytes of cached audio, 0 for no limit")
+	ttl := fs.Duration("ttl", 0, "maximum age of cached audio, 0 for no limit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	keys, err := apikey.Load(EnvAPIKeys, *keysPath)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no API keys, set -keys or " + EnvAPIKeys)
+	}
+
+	if err := cfg.Resolve(*configPath, *profile); err != nil {
+		return err
+	}
+	client, err := cfg.Client()
+	if err != nil {
+		return err
+	}
+
+	var tokens *apikey.Issuer
+	if *tokenTTL > 0 {
+		if tokens, err = apikey.NewIssuer(*tokenTTL); err != nil {
+			return err
+		}
+	}
+
+	var cache stats
+	switch {
+	case *s3Bucket != "":
+		s3 := storage.NewS3(*s3Bucket, *s3Region)
+		s3.Endpoint = *s3Endpoint
+		cache = cerevoicego.NewStorageCache(s3, *s3Prefix)
+	case *cacheDir != "":
+		cache = cerevoicego.NewDiskCache(*cacheDir, *cacheSize, *ttl)
+	default:
+		cache = cerevoicego.NewMemoryCache(0, *cacheSize, *ttl)
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(client, cache, keys, tokens),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	log.Printf("cerevoice-proxy: listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
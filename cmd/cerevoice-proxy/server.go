@@ -0,0 +1,273 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/internal/apikey"
+)
+
+// maxRequestBytes limits the size of a speak request body
+const maxRequestBytes = 1 << 20
+
+// maxRelayBytes limits the size of a relayed API request, which may carry a
+// lexicon file
+const maxRelayBytes = 16 << 20
+
+// stats is a Cache which reports its hit metrics
+type stats interface {
+	cerevoicego.Cache
+	Stats() cerevoicego.CacheStats
+}
+
+// server serves cached speech
+type server struct {
+	client *cerevoicego.Client
+	cache  stats
+	group  *cerevoicego.SpeakGroup
+	keys   apikey.Set
+	tokens *apikey.Issuer // nil if no tokens are issued
+	mux    *http.ServeMux
+}
+
+// newServer returns a server synthesising with client, which is set to
+// share identical requests in progress
+func newServer(client *cerevoicego.Client, cache stats, keys apikey.Set, tokens *apikey.Issuer) *server {
+	s := &server{
+		client: client,
+		cache:  cache,
+		group:  &cerevoicego.SpeakGroup{},
+		keys:   keys,
+		tokens: tokens,
+		mux:    http.NewServeMux(),
+	}
+	client.Apply(cerevoicego.WithDeduplication(s.group))
+
+	s.mux.HandleFunc("/healthz", s.health)
+	s.mux.Handle("/speak", s.auth(s.speak))
+	s.mux.Handle("/stats", s.auth(s.stats))
+	if tokens != nil {
+		s.mux.Handle("/token", apikey.TokenHandler(keys, tokens, client.AccountID))
+		s.mux.HandleFunc("/rest", s.relay)
+	}
+	return s
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// auth rejects requests without a valid API key or token. Either may also
+// be given as the key query parameter, for players which fetch prompt URLs
+// without custom headers.
+func (s *server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := apikey.FromRequest(r)
+		if key == "" {
+			key = r.URL.Query().Get("key")
+		}
+
+		if !s.keys.Valid(key) && !s.tokens.Valid(key) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("invalid API key"))
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+func (s *server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// speakRequest is the body of a POST speak request
+type speakRequest struct {
+	Voice       string `json:"voice"`
+	Text        string `json:"text"`
+	AudioFormat string `json:"audioFormat,omitempty"`
+	SampleRate  string `json:"sampleRate,omitempty"`
+}
+
+// speak serves audio from the cache, synthesising it on a miss
+func (s *server) speak(w http.ResponseWriter, r *http.Request) {
+	var req speakRequest
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		q := r.URL.Query()
+		req = speakRequest{
+			Voice:       q.Get("voice"),
+			Text:        q.Get("text"),
+			AudioFormat: q.Get("format"),
+			SampleRate:  q.Get("rate"),
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if req.Voice == "" || strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("voice and text are required"))
+		return
+	}
+
+	input := &cerevoicego.SpeakExtendedInput{
+		Voice:       req.Voice,
+		Text:        req.Text,
+		AudioFormat: cerevoicego.AudioFormat(strings.ToLower(req.AudioFormat)),
+		SampleRate:  cerevoicego.SampleRate(req.SampleRate),
+	}
+	if input.AudioFormat == "" {
+		input.AudioFormat = cerevoicego.FormatWAV
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	key := cerevoicego.CacheKey(input)
+	etag := `"` + key + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	audio, ok := s.cache.Get(key)
+	if ok {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		var err error
+		audio, err = s.synthesise(r.Context(), key, input)
+		if err != nil {
+			w.Header().Del("ETag")
+			w.Header().Del("Cache-Control")
+			writeError(w, statusCode(err), err)
+			return
+		}
+		w.Header().Set("X-Cache", "MISS")
+	}
+
+	w.Header().Set("Content-Type", input.AudioFormat.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(len(audio)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(audio); err != nil {
+		log.Printf("cerevoice-proxy: speak: %v", err)
+	}
+}
+
+// synthesise fetches audio from CereVoice and caches it. Identical requests
+// in flight share one CereVoice request through the client's SpeakGroup; if
+// the client which started it goes away, the others make their own.
+func (s *server) synthesise(ctx context.Context, key string, input *cerevoicego.SpeakExtendedInput) ([]byte, error) {
+	audio, err := s.client.SpeakAudioWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Set(key, audio)
+	return audio, nil
+}
+
+// relay forwards CereVoice Cloud API requests from Clients using tokens
+// issued by the proxy, with the proxy's credentials in place of the token
+func (s *server) relay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	var req cerevoicego.Request
+	if err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxRelayBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Rejected as CereVoice rejects a password, so the Client replaces
+	// the token
+	if !s.tokens.Valid(req.Token) {
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		fmt.Fprintf(w, "%s<%[2]sResponse><resultCode>%[3]d</resultCode><resultDescription>Invalid token</resultDescription></%[2]sResponse>",
+			xml.Header, req.XMLName.Local, int(cerevoicego.ResultInvalidCredentials))
+		return
+	}
+
+	resp, err := s.client.Relay(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	contentType := resp.ContentType
+	if contentType == "" {
+		contentType = "text/xml"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(resp.StatusCode)
+	if _, err := w.Write(resp.Raw); err != nil {
+		log.Printf("cerevoice-proxy: relay: %v", err)
+	}
+}
+
+// stats reports cache metrics
+func (s *server) stats(w http.ResponseWriter, r *http.Request) {
+	st := s.cache.Stats()
+	writeJSON(w, http.StatusOK, map[string]uint64{
+		"hits":      st.Hits,
+		"misses":    st.Misses,
+		"evictions": st.Evictions,
+		"shared":    s.group.Stats().Shared,
+	})
+}
+
+// statusCode maps a client error to an HTTP status code
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, cerevoicego.ErrValidation),
+		errors.Is(err, cerevoicego.ErrInvalidVoice),
+		errors.Is(err, cerevoicego.ErrInvalidAudioFormat),
+		errors.Is(err, cerevoicego.ErrInvalidSampleRate):
+		return http.StatusBadRequest
+	case errors.Is(err, cerevoicego.ErrInsufficientCredit):
+		return http.StatusPaymentRequired
+	case errors.Is(err, cerevoicego.ErrRateLimited):
+		return http.StatusTooManyRequests
+	}
+
+	var apiErr *cerevoicego.APIError
+	if errors.As(err, &apiErr) && apiErr.ResultCode == cerevoicego.ResultInvalidParameter {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusBadGateway
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
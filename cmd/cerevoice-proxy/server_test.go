@@ -0,0 +1,111 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/internal/apikey"
+)
+
+func TestServer(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	tokens, err := apikey.NewIssuer(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, _, err := tokens.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(srv.Client(), cerevoicego.NewMemoryCache(0, 0, 0), apikey.Set{"secret"}, tokens)
+
+	etag := `"` + cerevoicego.CacheKey(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello", AudioFormat: cerevoicego.FormatWAV}) + `"`
+	relay := func(token string) string {
+		return `<getCredit><accountID></accountID><password></password><token>` + token + `</token></getCredit>`
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		key      string
+		header   [2]string
+		body     string
+		status   int
+		cache    string // X-Cache header
+		want     string // Part of the body
+		upstream int    // Requests sent to CereVoice
+	}{
+		{name: "health", method: "GET", target: "/healthz", status: 200, want: `"ok"`},
+		{name: "no key", method: "GET", target: "/speak?voice=Heather&text=Hello", status: 401},
+		{name: "wrong key", method: "GET", target: "/speak?voice=Heather&text=Hello", key: "guess", status: 401},
+		{name: "miss", method: "GET", target: "/speak?voice=Heather&text=Hello", key: "secret", status: 200, cache: "MISS", want: "RIFF", upstream: 1},
+		{name: "hit", method: "GET", target: "/speak?voice=Heather&text=Hello&key=secret", status: 200, cache: "HIT", want: "RIFF"},
+		{name: "token", method: "POST", target: "/speak", key: token, body: `{"voice":"Heather","text":"Hello"}`, status: 200, cache: "HIT"},
+		{name: "not modified", method: "GET", target: "/speak?voice=Heather&text=Hello", key: "secret", header: [2]string{"If-None-Match", etag}, status: 304},
+		{name: "no text", method: "GET", target: "/speak?voice=Heather", key: "secret", status: 400},
+		{name: "bad rate", method: "GET", target: "/speak?voice=Heather&text=Hi&rate=123", key: "secret", status: 400},
+		{name: "bad body", method: "POST", target: "/speak", key: "secret", body: `{`, status: 400},
+		{name: "method", method: "DELETE", target: "/speak", key: "secret", status: 405},
+		{name: "stats", method: "GET", target: "/stats", key: "secret", status: 200, want: `"hits":2`},
+		{name: "relay", method: "POST", target: "/rest", body: relay(token), status: 200, want: "<charsAvailable>", upstream: 1},
+		{name: "relay bad token", method: "POST", target: "/rest", body: relay("forged"), status: 200, want: "<resultCode>-1</resultCode>"},
+		{name: "relay api key", method: "POST", target: "/rest", body: relay("secret"), status: 200, want: "<resultCode>-1</resultCode>"},
+		{name: "issue token", method: "POST", target: "/token", key: "secret", status: 200, want: `"accountID":"test"`},
+		{name: "token for token", method: "POST", target: "/token", key: token, status: 401},
+	}
+
+	for _, tt := range tests {
+		srv.Reset()
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+		}
+		if tt.header[0] != "" {
+			req.Header.Set(tt.header[0], tt.header[1])
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+		}
+		if got := rec.Header().Get("X-Cache"); got != tt.cache {
+			t.Errorf("%s: X-Cache %q, want %q", tt.name, got, tt.cache)
+		}
+		if !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: body %q does not contain %q", tt.name, rec.Body, tt.want)
+		}
+		if n := len(srv.Requests()); n != tt.upstream {
+			t.Errorf("%s: %d CereVoice requests, want %d", tt.name, n, tt.upstream)
+		}
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{cerevoicego.ErrInvalidVoice, http.StatusBadRequest},
+		{cerevoicego.ErrInsufficientCredit, http.StatusPaymentRequired},
+		{cerevoicego.ErrRateLimited, http.StatusTooManyRequests},
+		{&cerevoicego.APIError{ResultCode: cerevoicego.ResultInvalidParameter}, http.StatusBadRequest},
+		{&cerevoicego.APIError{ResultCode: cerevoicego.ResultServerBusy}, http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		if got := statusCode(tt.err); got != tt.want {
+			t.Errorf("statusCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/abbrev"
+	"github.com/bganderson/cerevoicego/lexicon"
+)
+
+// speak synthesises text from args, or stdin if there are none
+func speak(client *cerevoicego.Client, args []string) error {
+	input := &cerevoicego.SpeakExtendedInput{}
+
+	fs := flag.NewFlagSet("speak", flag.ContinueOnError)
+	fs.StringVar(&input.Voice, "voice", "Heather", "voice name")
+	fallback := fs.String("fallback", "", "comma separated voices to use if the voice is invalid")
+	format := fs.String("format", "wav", "audio format")
+	rate := fs.String("rate", "", "sample rate")
+	fs.BoolVar(&input.Audio3D, "3d", false, "3D audio")
+	out := fs.String("o", "-", "output file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *fallback != "" {
+		input.FallbackVoices = strings.Split(*fallback, ",")
+	}
+	input.AudioFormat = cerevoicego.AudioFormat(*format)
+	input.SampleRate = cerevoicego.SampleRate(*rate)
+
+	if fs.NArg() > 0 {
+		input.Text = strings.Join(fs.Args(), " ")
+	} else {
+		text, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		input.Text = string(text)
+	}
+	if strings.TrimSpace(input.Text) == "" {
+		return errors.New("speak: no text given")
+	}
+
+	if *out != "-" {
+		res, err := client.SpeakToFile(input, *out)
+		if err != nil || !jsonOutput {
+			return err
+		}
+		return printJSON(res)
+	}
+
+	_, err := client.SpeakTo(os.Stdout, input)
+	return err
+}
+
+// voices lists the available voices
+func voices(client *cerevoicego.Client, args []string) error {
+	input := &cerevoicego.ListVoicesInput{}
+
+	fs := flag.NewFlagSet("voices", flag.ContinueOnError)
+	fs.StringVar(&input.Language, "lang", "", "language code, e.g. en")
+	fs.StringVar(&input.Accent, "accent", "", "accent code")
+	sex := fs.String("sex", "", "female or male")
+	diff := fs.String("diff", "", "compare with a saved catalogue instead of listing")
+	custom := fs.String("custom", "", "comma separated custom voices to show the status of instead of listing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	input.Sex = cerevoicego.Sex(*sex)
+
+	if *diff != "" {
+		return diffVoices(client, *diff)
+	}
+	if *custom != "" {
+		return customVoices(client, strings.Split(*custom, ","))
+	}
+
+	res, err := client.ListVoices(input)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(res)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLANGUAGE\tACCENT\tSEX\tSAMPLE RATE")
+	for _, v := range res.VoiceList {
+		fmt.Fprintf(w, "%s\t%s-%s\t%s\t%s\t%s\n", v.VoiceName,
+			v.LanguageCodeISO, v.CountryCodeISO, v.Accent, v.Sex, v.SampleRate)
+	}
+
+	return w.Flush()
+}
+
+// diffVoices prints the differences between the catalogue saved at path and
+// the voices available now
+func diffVoices(client *cerevoicego.Client, path string) error {
+	saved, err := cerevoicego.LoadVoiceCatalog(path)
+	if err != nil {
+		return err
+	}
+
+	diff, err := client.DiffVoices(context.Background(), saved)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(diff)
+	}
+
+	fmt.Print(diff)
+	return nil
+}
+
+// customVoices prints whether each of the named custom voices is ready
+func customVoices(client *cerevoicego.Client, names []string) error {
+	client.Apply(cerevoicego.WithCustomVoices(names...))
+	voices, err := client.ListCustomVoices()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(voices)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tLANGUAGE\tSEX")
+	for _, v := range voices {
+		if v.Voice == nil {
+			fmt.Fprintf(w, "%s\t%s\t\t\n", v.Name, v.Status)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s-%s\t%s\n", v.Name, v.Status,
+			v.Voice.LanguageCodeISO, v.Voice.CountryCodeISO, v.Voice.Sex)
+	}
+
+	return w.Flush()
+}
+
+// formats lists the available audio formats
+func formats(client *cerevoicego.Client, args []string) error {
+	res, err := client.ListAudioFormats()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(res)
+	}
+
+	for _, format := range res.AudioFormats {
+		fmt.Println(format)
+	}
+
+	return nil
+}
+
+// credit shows the account credit
+func credit(client *cerevoicego.Client, args []string) error {
+	res, err := client.GetCredit()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(res)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Free credit:\t%s\n", res.Credit.FreeCredit)
+	fmt.Fprintf(w, "Paid credit:\t%s\n", res.Credit.PaidCredit)
+	fmt.Fprintf(w, "Characters available:\t%s\n", res.Credit.CharsAvailable)
+
+	return w.Flush()
+}
+
+// files uploads or lists lexicon or abbreviation files
+func files(client *cerevoicego.Client, kind, action string, args []string) error {
+	switch action {
+	case "upload":
+		return upload(client, kind, args)
+	case "list":
+		return list(client, kind)
+	case "download":
+		return downloadFile(client, kind, args)
+	case "delete":
+		return deleteFile(client, kind, args)
+	case "test":
+		if kind == "lexicon" {
+			return testLexicon(client, args)
+		}
+	case "sync":
+		if kind == "lexicon" {
+			return syncLexicon(client, args)
+		}
+	}
+
+	return fmt.Errorf("%s: unknown action %s", kind, action)
+}
+
+// upload uploads a lexicon or abbreviation file
+func upload(client *cerevoicego.Client, kind string, args []string) error {
+	fs := flag.NewFlagSet(kind+" upload", flag.ContinueOnError)
+	language := fs.String("lang", "", "language code, e.g. en")
+	accent := fs.String("accent", "", "accent code (lexicon only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%s upload: expected one file", kind)
+	}
+
+	contents, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var desc string
+	if kind == "lexicon" {
+		if err := lexicon.Validate(bytes.NewReader(contents)); err != nil {
+			if errs, ok := err.(lexicon.Errors); ok {
+				for _, e := range errs {
+					fmt.Fprintf(os.Stderr, "%s:%v\n", fs.Arg(0), e)
+				}
+			}
+			return err
+		}
+
+		res, err := client.UploadLexiconFromReader(bytes.NewReader(contents), *language, *accent)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(res)
+		}
+		desc = res.ResultDescription
+	} else {
+		if err := abbrev.Validate(bytes.NewReader(contents)); err != nil {
+			if errs, ok := err.(abbrev.Errors); ok {
+				for _, e := range errs {
+					fmt.Fprintf(os.Stderr, "%s:%v\n", fs.Arg(0), e)
+				}
+			}
+			return err
+		}
+
+		res, err := client.UploadAbbreviationsFromReader(bytes.NewReader(contents), *language)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(res)
+		}
+		desc = res.ResultDescription
+	}
+
+	fmt.Println(desc)
+	return nil
+}
+
+// list lists lexicon or abbreviation files
+func list(client *cerevoicego.Client, kind string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if kind == "lexicon" {
+		res, err := client.ListLexicons()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(res)
+		}
+		fmt.Fprintln(w, "URL\tLANGUAGE\tACCENT\tMODIFIED\tSIZE")
+		for _, l := range res.LexiconList {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", l.URL, l.Language, l.Accent, l.LastModified, l.Size)
+		}
+	} else {
+		res, err := client.ListAbbreviations()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(res)
+		}
+		fmt.Fprintln(w, "URL\tLANGUAGE\tMODIFIED\tSIZE")
+		for _, a := range res.AbbreviationList {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.URL, a.Language, a.LastModified, a.Size)
+		}
+	}
+
+	return w.Flush()
+}
+
+// downloadFile writes a lexicon or abbreviation file to stdout
+func downloadFile(client *cerevoicego.Client, kind string, args []string) error {
+	fs := flag.NewFlagSet(kind+" download", flag.ContinueOnError)
+	language := fs.String("lang", "", "language code, e.g. en")
+	accent := fs.String("accent", "", "accent code (lexicon only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var body io.ReadCloser
+	if kind == "lexicon" {
+		res, err := client.ListLexicons()
+		if err != nil {
+			return err
+		}
+		for i, l := range res.LexiconList {
+			if l.Language == *language && (*accent == "" || l.Accent == *accent) {
+				body, err = client.DownloadLexicon(&res.LexiconList[i])
+				if err != nil {
+					return err
+				}
+				break
+			}
+		}
+	} else {
+		res, err := client.ListAbbreviations()
+		if err != nil {
+			return err
+		}
+		for i, a := range res.AbbreviationList {
+			if a.Language == *language {
+				body, err = client.DownloadAbbreviations(&res.AbbreviationList[i])
+				if err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	if body == nil {
+		return fmt.Errorf("%s download: no file found for language %q", kind, *language)
+	}
+	defer body.Close()
+
+	_, err := io.Copy(os.Stdout, body)
+	return err
+}
+
+// deleteFile deletes a lexicon or abbreviation file
+func deleteFile(client *cerevoicego.Client, kind string, args []string) error {
+	fs := flag.NewFlagSet(kind+" delete", flag.ContinueOnError)
+	language := fs.String("lang", "", "language code, e.g. en")
+	accent := fs.String("accent", "", "accent code (lexicon only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var desc string
+	if kind == "lexicon" {
+		res, err := client.DeleteLexicon(&cerevoicego.DeleteLexiconInput{
+			Language: *language,
+			Accent:   *accent,
+		})
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(res)
+		}
+		desc = res.ResultDescription
+	} else {
+		res, err := client.DeleteAbbreviations(&cerevoicego.DeleteAbbreviationsInput{
+			Language: *language,
+		})
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(res)
+		}
+		desc = res.ResultDescription
+	}
+
+	fmt.Println(desc)
+	return nil
+}
+
+// testLexicon synthesises a word list with and without a candidate lexicon
+// and writes the pairs of audio files to a directory
+func testLexicon(client *cerevoicego.Client, args []string) error {
+	input := &cerevoicego.PronunciationTestInput{}
+
+	fs := flag.NewFlagSet("lexicon test", flag.ContinueOnError)
+	fs.StringVar(&input.Voice, "voice", "Heather", "voice name")
+	fs.StringVar(&input.Language, "lang", "", "language code, that of the voice if empty")
+	fs.StringVar(&input.Accent, "accent", "", "accent code")
+	format := fs.String("format", "wav", "audio format")
+	wordsPath := fs.String("words", "", "word list, one word or phrase per line")
+	out := fs.String("o", "pronunciations", "output directory")
+	fs.BoolVar(&input.Phones, "phones", false, "compare the phones spoken")
+	fs.BoolVar(&input.Replace, "replace", false, "test the candidate as the whole lexicon rather than merged")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *wordsPath == "" {
+		return errors.New("lexicon test: expected -words and one lexicon file")
+	}
+	input.AudioFormat = cerevoicego.AudioFormat(*format)
+
+	words, err := ioutil.ReadFile(*wordsPath)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(words), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			input.Words = append(input.Words, line)
+		}
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	input.Lexicon, err = lexicon.Parse(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	report, err := client.TestPronunciations(context.Background(), input)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteFiles(*out); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(report)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORD\tBEFORE\tAFTER\tCHANGED")
+	for _, r := range report.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", r.Word, r.BeforeFile, r.AfterFile, r.Changed)
+	}
+
+	return w.Flush()
+}
+
+// syncLexicon uploads a lexicon file if it differs from the uploaded one,
+// printing the differences
+func syncLexicon(client *cerevoicego.Client, args []string) error {
+	fs := flag.NewFlagSet("lexicon sync", flag.ContinueOnError)
+	language := fs.String("lang", "", "language code, e.g. en")
+	accent := fs.String("accent", "", "accent code")
+	dryRun := fs.Bool("n", false, "print the differences without uploading")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("lexicon sync: expected one file")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	lex, err := lexicon.Parse(f)
+	f.Close()
+	if err != nil {
+		if errs, ok := err.(lexicon.Errors); ok {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "%s:%v\n", fs.Arg(0), e)
+			}
+		}
+		return err
+	}
+
+	var diff *lexicon.Diff
+	if *dryRun {
+		diff, err = client.DiffLexicon(lex, *language, *accent)
+	} else {
+		diff, err = client.SyncLexicon(lex, *language, *accent)
+	}
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(diff)
+	}
+
+	if diff.Empty() {
+		fmt.Println("Lexicon up to date")
+		return nil
+	}
+	fmt.Print(diff)
+
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
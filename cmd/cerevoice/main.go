@@ -0,0 +1,138 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Command cerevoice is a command line interface to the CereVoice Cloud API.
+//
+// Usage:
+//
+//	cerevoice [global flags] <command> [flags] [args]
+//
+// Commands:
+//
+//	speak              synthesise text from args or stdin
+//	repl               synthesise lines as they are typed
+//	voices             list available voices
+//	formats            list available audio formats
+//	credit             show account credit
+//	lexicon upload     upload a lexicon file
+//	lexicon list       list lexicon files
+//	lexicon download   write a lexicon file to stdout
+//	lexicon delete     delete a lexicon file
+//	lexicon test       synthesise words with and without a candidate lexicon
+//	lexicon sync       upload a lexicon file if it differs from the uploaded one
+//	abbrev upload      upload an abbreviation file
+//	abbrev list        list abbreviation files
+//	abbrev download    write an abbreviation file to stdout
+//	abbrev delete      delete an abbreviation file
+//
+// Credentials are read from the -account and -password flags, then the
+// CEREVOICE_ACCOUNT_ID and CEREVOICE_PASSWORD environment variables, then the
+// -profile section of the config file (default ~/.cerevoice/config)
+//
+//	[default]
+//	account_id = "<YOUR_ACCOUNTID>"
+//	password = "<YOUR_PASSWORD>"
+//
+// With -json, responses are printed as JSON for processing with tools such
+// as jq.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+const usage = `usage: cerevoice [global flags] <command> [flags] [args]
+
+commands:
+  speak              synthesise text from args or stdin
+  repl               synthesise lines as they are typed
+  voices             list available voices
+  formats            list available audio formats
+  credit             show account credit
+  lexicon upload     upload a lexicon file
+  lexicon list       list lexicon files
+  lexicon download   write a lexicon file to stdout
+  lexicon delete     delete a lexicon file
+  lexicon test       synthesise words with and without a candidate lexicon
+  lexicon sync       upload a lexicon file if it differs from the uploaded one
+  abbrev upload      upload an abbreviation file
+  abbrev list        list abbreviation files
+  abbrev download    write an abbreviation file to stdout
+  abbrev delete      delete an abbreviation file
+
+global flags:
+`
+
+// jsonOutput prints responses as JSON instead of tables
+var jsonOutput bool
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "cerevoice:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	var cfg cerevoicego.Config
+
+	fs := flag.NewFlagSet("cerevoice", flag.ContinueOnError)
+	fs.StringVar(&cfg.AccountID, "account", "", "CereVoice Cloud account ID")
+	fs.StringVar(&cfg.Password, "password", "", "CereVoice Cloud password")
+	fs.StringVar(&cfg.APIURL, "url", "", "CereVoice Cloud REST API URL")
+	fs.StringVar(&cfg.CAFile, "ca", "", "PEM file of certificate authorities to trust")
+	fs.StringVar(&cfg.CertFile, "cert", "", "PEM client certificate for mutual TLS")
+	fs.StringVar(&cfg.KeyFile, "key", "", "PEM key of the client certificate")
+	fs.StringVar(&cfg.Proxy, "proxy", "", "proxy URL, e.g. socks5://host:1080")
+	configPath := fs.String("config", cerevoicego.DefaultConfigPath(), "config file")
+	profile := fs.String("profile", os.Getenv(cerevoicego.EnvProfile), "config file profile")
+	fs.BoolVar(&jsonOutput, "json", false, "print responses as JSON")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	if err := cfg.Resolve(*configPath, *profile); err != nil {
+		return err
+	}
+	client, err := cfg.Client()
+	if err != nil {
+		return err
+	}
+
+	cmd, args := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "speak":
+		return speak(client, args)
+	case "repl":
+		return runREPL(client, args)
+	case "voices":
+		return voices(client, args)
+	case "formats":
+		return formats(client, args)
+	case "credit":
+		return credit(client, args)
+	case "lexicon", "abbrev":
+		if len(args) == 0 {
+			return fmt.Errorf("%s: expected upload, list, download, delete or test", cmd)
+		}
+		return files(client, cmd, args[0], args[1:])
+	}
+
+	return errors.New("unknown command " + cmd)
+}
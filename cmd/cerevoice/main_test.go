@@ -0,0 +1,97 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+// capture returns what fn writes to stdout
+func capture(t *testing.T, fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		out <- string(b)
+	}()
+
+	err = fn()
+	w.Close()
+	return <-out, err
+}
+
+func TestRun(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	dir := t.TempDir()
+	lex := filepath.Join(dir, "lexicon.txt")
+	if err := ioutil.WriteFile(lex, []byte("tomato n t @0 m aa1 t ou0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wav := filepath.Join(dir, "hello.wav")
+
+	tests := []struct {
+		name string
+		args []string
+		op   string // API operation called
+		out  []string
+		err  string
+	}{
+		{name: "voices", args: []string{"voices"}, op: "listVoices", out: []string{"NAME", "Heather"}},
+		{name: "voices json", args: []string{"-json", "voices"}, op: "listVoices", out: []string{`"voiceName"`}},
+		{name: "formats", args: []string{"formats"}, op: "listAudioFormats", out: []string{"wav"}},
+		{name: "credit", args: []string{"credit"}, op: "getCredit", out: []string{"Free credit:", "Characters available:"}},
+		{name: "speak", args: []string{"speak", "-o", wav, "Hello"}, op: "speakExtended"},
+		{name: "speak stdout", args: []string{"speak", "Hello"}, op: "speakExtended", out: []string{"RIFF"}},
+		{name: "lexicon upload", args: []string{"lexicon", "upload", "-lang", "en", "-accent", "scot", lex}, op: "uploadLexicon"},
+		{name: "lexicon list", args: []string{"lexicon", "list"}, op: "listLexicons", out: []string{"URL"}},
+		{name: "abbrev list", args: []string{"abbrev", "list"}, op: "listAbbreviations", out: []string{"URL"}},
+		{name: "lexicon no action", args: []string{"lexicon"}, err: "lexicon: expected"},
+		{name: "unknown", args: []string{"shout"}, err: "unknown command shout"},
+		{name: "no command", args: nil, err: "flag: help requested"},
+	}
+
+	for _, tt := range tests {
+		srv.Reset()
+		args := append([]string{"-config", filepath.Join(dir, "none"), "-account", "test", "-password", "test", "-url", srv.URL + "/rest"}, tt.args...)
+		jsonOutput = false
+
+		out, err := capture(t, func() error { return run(args) })
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for _, s := range tt.out {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s: output %q does not contain %q", tt.name, out, s)
+			}
+		}
+		if reqs := srv.Requests(); len(reqs) == 0 || reqs[0].XMLName.Local != tt.op {
+			t.Errorf("%s: requests %v, want %s", tt.name, reqs, tt.op)
+		}
+	}
+
+	if _, err := os.Stat(wav); err != nil {
+		t.Errorf("speak -o: %v", err)
+	}
+}
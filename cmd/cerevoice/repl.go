@@ -0,0 +1,236 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/playback"
+)
+
+const replHelp = `Type text to synthesise it, or a command:
+  :voice [name]    show or set the voice
+  :voices          list available voices
+  :format [name]   show or set the audio format
+  :rate [hz]       show or set the sample rate, empty for the voice's
+  :metadata        toggle printing word timings
+  :play            toggle playback
+  :dir [path]      show or set the directory each line is saved to, - for none
+  :save <file>     save the last audio
+  :help            show this help
+  :quit            exit
+`
+
+// repl is an interactive session reusing one Client, and so its credentials
+// and connections, for every line
+type repl struct {
+	client *cerevoicego.Client
+	input  cerevoicego.SpeakExtendedInput
+	voices cerevoicego.VoiceCatalog
+	play   bool
+	dir    string
+	saved  int
+	last   []byte
+	out    io.Writer
+}
+
+// runREPL reads lines of text from stdin, synthesising each, until :quit or
+// the end of input
+func runREPL(client *cerevoicego.Client, args []string) error {
+	r := &repl{client: client, out: os.Stdout}
+
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	fs.StringVar(&r.input.Voice, "voice", "Heather", "voice name")
+	format := fs.String("format", "wav", "audio format")
+	rate := fs.String("rate", "", "sample rate")
+	fs.BoolVar(&r.input.Metadata, "metadata", false, "print word timings")
+	fs.StringVar(&r.dir, "o", "", "directory to save each line to")
+	noPlay := fs.Bool("noplay", false, "do not play the audio")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	r.input.AudioFormat = cerevoicego.AudioFormat(*format)
+	r.input.SampleRate = cerevoicego.SampleRate(*rate)
+	r.play = !*noPlay
+
+	// Listing the voices checks the credentials and opens the connection
+	// before the first line is typed
+	res, err := client.ListVoices(nil)
+	if err != nil {
+		return err
+	}
+	r.voices = res.Catalog()
+	if _, ok := r.voices.Lookup(r.input.Voice); !ok {
+		return fmt.Errorf("repl: unknown voice %s", r.input.Voice)
+	}
+
+	fmt.Fprintf(r.out, "Speaking as %s, :help for commands\n", r.input.Voice)
+	return r.run(os.Stdin)
+}
+
+// run executes each line read from in
+func (r *repl) run(in io.Reader) error {
+	s := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(r.out, "> ")
+		if !s.Scan() {
+			fmt.Fprintln(r.out)
+			return s.Err()
+		}
+
+		line := strings.TrimSpace(s.Text())
+		var err error
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, ":"):
+			fields := strings.Fields(line[1:])
+			if len(fields) == 0 {
+				continue
+			}
+			if fields[0] == "quit" || fields[0] == "q" {
+				return nil
+			}
+			err = r.command(fields[0], strings.Join(fields[1:], " "))
+		default:
+			err = r.speak(line)
+		}
+		if err != nil {
+			fmt.Fprintln(r.out, "error:", err)
+		}
+	}
+}
+
+// command executes a : command with its argument, if any
+func (r *repl) command(name, arg string) error {
+	switch name {
+	case "voice":
+		if arg != "" {
+			v, ok := r.voices.Lookup(arg)
+			if !ok {
+				return fmt.Errorf("unknown voice %s", arg)
+			}
+			r.input.Voice = v.VoiceName
+		}
+		fmt.Fprintln(r.out, "voice:", r.input.Voice)
+	case "voices":
+		for _, v := range r.voices {
+			fmt.Fprintf(r.out, "%s\t%s-%s\t%s\n", v.VoiceName, v.LanguageCodeISO, v.CountryCodeISO, v.Sex)
+		}
+	case "format":
+		if arg != "" {
+			if !cerevoicego.AudioFormat(arg).Valid() {
+				return fmt.Errorf("unknown format %s", arg)
+			}
+			r.input.AudioFormat = cerevoicego.AudioFormat(strings.ToLower(arg))
+		}
+		fmt.Fprintln(r.out, "format:", r.format())
+	case "rate":
+		if arg != "" && !cerevoicego.SampleRate(arg).Valid() {
+			return fmt.Errorf("unsupported sample rate %s", arg)
+		}
+		r.input.SampleRate = cerevoicego.SampleRate(arg)
+		fmt.Fprintln(r.out, "rate:", r.input.SampleRate)
+	case "metadata":
+		r.input.Metadata = !r.input.Metadata
+		fmt.Fprintln(r.out, "metadata:", onOff(r.input.Metadata))
+	case "play":
+		r.play = !r.play
+		fmt.Fprintln(r.out, "play:", onOff(r.play))
+	case "dir":
+		if arg == "-" {
+			r.dir = ""
+		} else if arg != "" {
+			r.dir = arg
+		}
+		fmt.Fprintln(r.out, "dir:", r.dir)
+	case "save":
+		if arg == "" {
+			return errors.New("save: expected a file")
+		}
+		if r.last == nil {
+			return errors.New("save: nothing spoken yet")
+		}
+		return ioutil.WriteFile(arg, r.last, 0644)
+	case "help":
+		fmt.Fprint(r.out, replHelp)
+	default:
+		return fmt.Errorf("unknown command :%s, :help for commands", name)
+	}
+
+	return nil
+}
+
+// speak synthesises text, then plays it, saves it and prints its word
+// timings as the session is set up to
+func (r *repl) speak(text string) error {
+	input := r.input
+	input.Text = text
+
+	var b bytes.Buffer
+	res, err := r.client.SpeakTo(&b, &input)
+	if err != nil {
+		return err
+	}
+	r.last = b.Bytes()
+
+	if r.dir != "" {
+		if err := os.MkdirAll(r.dir, 0755); err != nil {
+			return err
+		}
+		r.saved++
+		path := filepath.Join(r.dir, fmt.Sprintf("%03d.%s", r.saved, r.format()))
+		if err := ioutil.WriteFile(path, r.last, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, "saved", path)
+	}
+
+	if input.Metadata && res != nil && res.Metadata != "" {
+		meta, err := r.client.GetMetadata(res.Metadata)
+		if err != nil {
+			return err
+		}
+		for _, w := range meta.Words() {
+			fmt.Fprintf(r.out, "%8s %8s  %s\n", w.Start, w.End, w.Token)
+		}
+	}
+
+	if r.play {
+		err := playback.Play(context.Background(), r.last, string(r.format()))
+		if err == playback.ErrNoPlayer {
+			r.play = false
+			return errors.New("no audio player found, playback turned off")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// format returns the audio format requested
+func (r *repl) format() cerevoicego.AudioFormat {
+	if r.input.AudioFormat == "" {
+		return cerevoicego.FormatWAV
+	}
+	return r.input.AudioFormat
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,260 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bganderson/cerevoicego/internal/apikey"
+)
+
+// grpcService is the path prefix of the methods of the CereVoice service in
+// cerevoiced.proto
+const grpcService = "/cerevoiced.v1.CereVoice/"
+
+// speakChunk is the most audio carried by each message streamed by Speak
+const speakChunk = 32 << 10
+
+// gRPC status codes
+const (
+	grpcOK                 = 0
+	grpcCanceled           = 1
+	grpcInvalidArgument    = 3
+	grpcDeadlineExceeded   = 4
+	grpcResourceExhausted  = 8
+	grpcFailedPrecondition = 9
+	grpcUnimplemented      = 12
+	grpcUnavailable        = 14
+	grpcUnauthenticated    = 16
+)
+
+// grpcError is an error with a gRPC status code
+type grpcError struct {
+	code int
+	msg  string
+}
+
+func (e *grpcError) Error() string {
+	return e.msg
+}
+
+// grpc serves the gRPC methods, which gRPC clients can only reach over
+// HTTP/2, so when the server has a TLS certificate
+func (s *server) grpc(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "application/grpc" && !strings.HasPrefix(ct, "application/grpc+proto") {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("expected application/grpc"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	code, msg := grpcStatus(s.grpcCall(w, r))
+	w.Header().Set("Grpc-Status", strconv.Itoa(code))
+	if msg != "" {
+		w.Header().Set("Grpc-Message", grpcEscape(msg))
+	}
+}
+
+// grpcCall authenticates and runs a gRPC method, writing its responses
+func (s *server) grpcCall(w http.ResponseWriter, r *http.Request) error {
+	if key := apikey.FromRequest(r); !s.keys.Valid(key) && !s.tokens.Valid(key) {
+		return &grpcError{grpcUnauthenticated, "invalid API key"}
+	}
+
+	ctx := r.Context()
+	if t := r.Header.Get("Grpc-Timeout"); t != "" {
+		d, err := grpcTimeout(t)
+		if err != nil {
+			return &grpcError{grpcInvalidArgument, err.Error()}
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	msg, err := readGRPC(r.Body)
+	if err != nil {
+		return err
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, grpcService) {
+	case "Speak":
+		return s.grpcSpeak(ctx, w, msg)
+	case "ListVoices":
+		var req voicesRequest
+		if err := req.unmarshalProto(msg); err != nil {
+			return &grpcError{grpcInvalidArgument, err.Error()}
+		}
+		res, err := s.listVoices(ctx, req.Lang, req.Accent, req.Sex)
+		if err != nil {
+			return err
+		}
+		return writeGRPC(w, res.marshalProto())
+	case "GetCredit":
+		res, err := s.getCredit(ctx)
+		if err != nil {
+			return err
+		}
+		return writeGRPC(w, res.marshalProto())
+	}
+
+	return &grpcError{grpcUnimplemented, "unknown method " + r.URL.Path}
+}
+
+// grpcSpeak synthesises the requested text and streams back the audio
+func (s *server) grpcSpeak(ctx context.Context, w http.ResponseWriter, msg []byte) error {
+	var req speakRequest
+	if err := req.unmarshalProto(msg); err != nil {
+		return &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	input, err := req.input()
+	if err != nil {
+		return &grpcError{grpcInvalidArgument, err.Error()}
+	}
+
+	res, err := s.client.SpeakExtendedWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+	audio, err := res.Download(ctx)
+	if err != nil {
+		return err
+	}
+	defer audio.Close()
+
+	m := &speakResponse{ContentType: input.AudioFormat.ContentType(), CharCount: res.Chars}
+	buf := make([]byte, speakChunk)
+	for {
+		n, err := io.ReadFull(audio, buf)
+		if n > 0 || m.ContentType != "" {
+			m.Audio = buf[:n]
+			if err := writeGRPC(w, m.marshalProto()); err != nil {
+				return err
+			}
+			m = &speakResponse{}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readGRPC reads the single length prefixed message of a unary or server
+// streaming request
+func readGRPC(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "missing request message"}
+	}
+	if prefix[0] != 0 {
+		return nil, &grpcError{grpcUnimplemented, "compressed messages are not supported"}
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxRequestBytes {
+		return nil, &grpcError{grpcResourceExhausted, "request message too large"}
+	}
+
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "truncated request message"}
+	}
+
+	return msg, nil
+}
+
+// writeGRPC writes a length prefixed response message and flushes it to the
+// client
+func writeGRPC(w http.ResponseWriter, msg []byte) error {
+	b := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)))
+	if _, err := w.Write(append(b, msg...)); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	return nil
+}
+
+// grpcStatus returns the status code and message of err, mapping client
+// errors as statusCode does for the JSON API
+func grpcStatus(err error) (int, string) {
+	var gErr *grpcError
+	switch {
+	case err == nil:
+		return grpcOK, ""
+	case errors.As(err, &gErr):
+		return gErr.code, gErr.msg
+	case errors.Is(err, context.Canceled):
+		return grpcCanceled, err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return grpcDeadlineExceeded, err.Error()
+	}
+
+	switch statusCode(err) {
+	case http.StatusBadRequest:
+		return grpcInvalidArgument, err.Error()
+	case http.StatusPaymentRequired:
+		return grpcFailedPrecondition, err.Error()
+	case http.StatusTooManyRequests:
+		return grpcResourceExhausted, err.Error()
+	}
+
+	return grpcUnavailable, err.Error()
+}
+
+// grpcTimeout parses a grpc-timeout header, e.g. 100m for 100ms
+func grpcTimeout(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}
+
+	if len(s) < 2 || len(s) > 9 {
+		return 0, fmt.Errorf("invalid grpc-timeout %q", s)
+	}
+	unit, ok := units[s[len(s)-1]]
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if !ok || err != nil {
+		return 0, fmt.Errorf("invalid grpc-timeout %q", s)
+	}
+	if n > uint64(math.MaxInt64/unit) {
+		return math.MaxInt64, nil
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+// grpcEscape percent encodes a grpc-message
+func grpcEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,267 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/internal/apikey"
+)
+
+// grpcFrame frames a request message, with the compressed flag if set
+func grpcFrame(msg []byte, compressed bool) []byte {
+	b := make([]byte, 5, 5+len(msg))
+	if compressed {
+		b[0] = 1
+	}
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)))
+	return append(b, msg...)
+}
+
+// grpcMessages splits a response body into its messages
+func grpcMessages(body []byte) ([][]byte, error) {
+	var msgs [][]byte
+	r := bytes.NewReader(body)
+	for r.Len() > 0 {
+		msg, err := readGRPC(r)
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+func TestGRPC(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	tokens, err := apikey.NewIssuer(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, _, err := tokens.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewUnstartedServer(newServer(srv.Client(), apikey.Set{"secret"}, tokens, time.Hour))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	var speak, voices protoWriter
+	speak.string(1, "Heather")
+	speak.string(2, "Hello")
+	voices.string(1, "en")
+	var noText protoWriter
+	noText.string(1, "Heather")
+
+	tests := []struct {
+		name       string
+		method     string
+		key        string
+		msg        []byte
+		compressed bool
+		apiErr     cerevoicego.ResultCode // Of speakExtended, if set
+		status     string
+		want       string // Part of the first response message
+		upstream   []string
+	}{
+		{name: "speak", method: "Speak", key: "secret", msg: speak.b, status: "0", want: "audio/wav", upstream: []string{"speakExtended"}},
+		{name: "speak token", method: "Speak", key: token, msg: speak.b, status: "0", want: "RIFF", upstream: []string{"speakExtended"}},
+		{name: "speak no text", method: "Speak", key: "secret", msg: noText.b, status: "3"},
+		{name: "speak invalid voice", method: "Speak", key: "secret", msg: speak.b, apiErr: cerevoicego.ResultInvalidParameter, status: "3", upstream: []string{"speakExtended"}},
+		{name: "voices", method: "ListVoices", key: "secret", msg: voices.b, status: "0", want: "Heather", upstream: []string{"listVoices"}},
+		{name: "credit", method: "GetCredit", key: "secret", status: "0", want: "10.00", upstream: []string{"getCredit"}},
+		{name: "no key", method: "GetCredit", status: "16"},
+		{name: "wrong key", method: "GetCredit", key: "guess", status: "16"},
+		{name: "unknown method", method: "Delete", key: "secret", status: "12"},
+		{name: "compressed", method: "GetCredit", key: "secret", compressed: true, status: "12"},
+		{name: "malformed", method: "ListVoices", key: "secret", msg: []byte{0x0a, 0x05, 'e'}, status: "3"},
+	}
+
+	for _, tt := range tests {
+		srv.Reset()
+		if tt.apiErr != 0 {
+			srv.SetError("speakExtended", tt.apiErr, "invalid voice")
+		}
+
+		req, err := http.NewRequest("POST", ts.URL+grpcService+tt.method, bytes.NewReader(grpcFrame(tt.msg, tt.compressed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("Te", "trailers")
+		if tt.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if resp.ProtoMajor != 2 {
+			t.Errorf("%s: served over %s, want HTTP/2", tt.name, resp.Proto)
+		}
+		if got := resp.Trailer.Get("Grpc-Status"); got != tt.status {
+			t.Errorf("%s: grpc-status %q, want %q: %s", tt.name, got, tt.status, resp.Trailer.Get("Grpc-Message"))
+		}
+		msgs, err := grpcMessages(body)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if tt.want != "" && (len(msgs) == 0 || !bytes.Contains(msgs[0], []byte(tt.want))) {
+			t.Errorf("%s: response %q does not contain %q", tt.name, msgs, tt.want)
+		}
+		var ops []string
+		for _, r := range srv.Requests() {
+			ops = append(ops, r.XMLName.Local)
+		}
+		if strings.Join(ops, ",") != strings.Join(tt.upstream, ",") {
+			t.Errorf("%s: CereVoice requests %v, want %v", tt.name, ops, tt.upstream)
+		}
+	}
+}
+
+func TestGRPCSpeakStream(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	s := newServer(srv.Client(), apikey.Set{"secret"}, nil, time.Hour)
+
+	var p protoWriter
+	p.string(1, "Heather")
+	p.string(2, "Hello")
+	req := httptest.NewRequest("POST", grpcService+"Speak", bytes.NewReader(grpcFrame(p.b, false)))
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("X-API-Key", "secret")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if got := rec.Result().Trailer.Get("Grpc-Status"); got != "0" {
+		t.Fatalf("grpc-status %q, want 0: %s", got, rec.Result().Trailer.Get("Grpc-Message"))
+	}
+	msgs, err := grpcMessages(rec.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var audio []byte
+	for i, msg := range msgs {
+		var m speakResponse
+		err := protoFields(msg, func(f protoField) error {
+			switch f.num {
+			case 1:
+				m.Audio = f.data
+			case 2:
+				m.ContentType, _ = f.string()
+			case 3:
+				m.CharCount = int(f.value)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && (m.ContentType != "audio/wav" || m.CharCount != 5) {
+			t.Errorf("first message has content type %q and %d characters, want audio/wav and 5", m.ContentType, m.CharCount)
+		}
+		if i > 0 && (m.ContentType != "" || m.CharCount != 0) {
+			t.Errorf("message %d repeats the content type or characters", i)
+		}
+		if len(m.Audio) > speakChunk {
+			t.Errorf("message %d carries %d bytes of audio, more than %d", i, len(m.Audio), speakChunk)
+		}
+		audio = append(audio, m.Audio...)
+	}
+	if !bytes.HasPrefix(audio, []byte("RIFF")) {
+		t.Errorf("streamed audio does not start with RIFF: %q", audio[:min(len(audio), 16)])
+	}
+}
+
+func TestProtoFields(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+		want speakRequest
+		err  bool
+	}{
+		{name: "empty", msg: nil},
+		{name: "fields", msg: []byte{0x0a, 2, 'J', 'o', 0x12, 2, 'H', 'i', 0x28, 1}, want: speakRequest{Voice: "Jo", Text: "Hi", Audio3D: true}},
+		{name: "unknown fields skipped", msg: []byte{0x30, 7, 0x3d, 1, 2, 3, 4, 0x41, 1, 2, 3, 4, 5, 6, 7, 8, 0x12, 1, 'a'}, want: speakRequest{Text: "a"}},
+		{name: "truncated", msg: []byte{0x0a, 5, 'J'}, err: true},
+		{name: "truncated fixed", msg: []byte{0x3d, 1}, err: true},
+		{name: "bad varint", msg: []byte{0x28, 0x80}, err: true},
+		{name: "field zero", msg: []byte{0x00, 1}, err: true},
+		{name: "group", msg: []byte{0x0b}, err: true},
+		{name: "wrong type", msg: []byte{0x08, 1}, err: true},
+		{name: "invalid UTF-8", msg: []byte{0x12, 1, 0xff}, err: true},
+	}
+
+	for _, tt := range tests {
+		var req speakRequest
+		err := req.unmarshalProto(tt.msg)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error %v, want error %t", tt.name, err, tt.err)
+			continue
+		}
+		if !tt.err && req != tt.want {
+			t.Errorf("%s: %+v, want %+v", tt.name, req, tt.want)
+		}
+	}
+}
+
+func TestGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{in: "100m", want: 100 * time.Millisecond},
+		{in: "2S", want: 2 * time.Second},
+		{in: "1H", want: time.Hour},
+		{in: "99999999H", want: 1<<63 - 1},
+		{in: "5", err: true},
+		{in: "5x", err: true},
+		{in: "-5m", err: true},
+		{in: "123456789m", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := grpcTimeout(tt.in)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("grpcTimeout(%q) = %v, %v, want %v, error %t", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestGRPCEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"invalid API key", "invalid API key"},
+		{"100% done", "100%25 done"},
+		{"line\nbreak", "line%0Abreak"},
+		{"café", "caf%C3%A9"},
+	}
+
+	for _, tt := range tests {
+		if got := grpcEscape(tt.in); got != tt.want {
+			t.Errorf("grpcEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Command cerevoiced serves the CereVoice Cloud API as a small JSON/HTTP and
+// gRPC service, so internal services can synthesise speech without holding
+// the CereVoice credentials themselves.
+//
+// Usage:
+//
+//	cerevoiced [flags]
+//
+// Endpoints:
+//
+//	POST /v1/speak     synthesise text, responding with the audio
+//	GET  /v1/voices    list voices, filtered by lang, accent and sex
+//	GET  /v1/credit    show account credit
+//	POST /v1/token     issue a temporary token to use in place of the API key
+//	GET  /healthz      liveness check, no API key required
+//	GET  /readyz       readiness check of the CereVoice account, no API key required
+//	GET  /openapi.json OpenAPI 3 description of the endpoints, no API key required
+//
+// /readyz checks CereVoice at most once per -ready-interval and serves the
+// last result in between, so unauthenticated probes can not make the server
+// call CereVoice on demand.
+//
+// The OpenAPI description can also be printed with -openapi, without
+// credentials or keys, for generating clients in other languages.
+//
+// The gRPC service described by cerevoiced.proto is served on the same
+// address. gRPC needs HTTP/2, which the server speaks when given a TLS
+// certificate with -tls-cert and -tls-key.
+//
+// Clients authenticate with one of the API keys in the -keys file (one per
+// line) or the CEREVOICED_API_KEYS environment variable (comma separated),
+// given as "Authorization: Bearer <key>" or "X-API-Key: <key>", which gRPC
+// clients send as metadata. Services which should not hold a permanent key
+// can instead be given tokens from /v1/token, valid for -token-ttl and in
+// the same way, which the server accepts until it restarts. Only an API key
+// can request a token.
+//
+// CereVoice credentials are read in the same way as the cerevoice command.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/internal/apikey"
+)
+
+// EnvAPIKeys is the environment variable holding comma separated API keys
+const EnvAPIKeys = "CEREVOICED_API_KEYS"
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "cerevoiced:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	var cfg cerevoicego.Config
+
+	fs := flag.NewFlagSet("cerevoiced", flag.ContinueOnError)
+	fs.StringVar(&cfg.AccountID, "account", "", "CereVoice Cloud account ID")
+	fs.StringVar(&cfg.Password, "password", "", "CereVoice Cloud password")
+	fs.StringVar(&cfg.APIURL, "url", "", "CereVoice Cloud REST API URL")
+	configPath := fs.String("config", cerevoicego.DefaultConfigPath(), "config file")
+	profile := fs.String("profile", os.Getenv(cerevoicego.EnvProfile), "config file profile")
+	addr := fs.String("addr", ":8080", "listen address")
+	tlsCert := fs.String("tls-cert", "", "TLS certificate file, to serve HTTPS, HTTP/2 and gRPC")
+	tlsKey := fs.String("tls-key", "", "TLS key file")
+	keysPath := fs.String("keys", "", "file of API keys, one per line")
+	tokenTTL := fs.Duration("token-ttl", 15*time.Minute, "lifetime of issued tokens, 0 to issue none")
+	readyInterval := fs.Duration("ready-interval", 30*time.Second, "minimum time between the CereVoice checks of /readyz")
+	spec := fs.Bool("openapi", false, "print the OpenAPI description and exit")
+	serverURL := fs.String("openapi-url", "", "server URL given in the printed OpenAPI description")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *spec {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(openAPI(*serverURL))
+	}
+
+	keys, err := apikey.Load(EnvAPIKeys, *keysPath)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no API keys, set -keys or " + EnvAPIKeys)
+	}
+
+	if err := cfg.Resolve(*configPath, *profile); err != nil {
+		return err
+	}
+	client, err := cfg.Client()
+	if err != nil {
+		return err
+	}
+
+	var tokens *apikey.Issuer
+	if *tokenTTL > 0 {
+		if tokens, err = apikey.NewIssuer(*tokenTTL); err != nil {
+			return err
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(client, keys, tokens, *readyInterval),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	log.Printf("cerevoiced: listening on %s", *addr)
+	if *tlsCert != "" || *tlsKey != "" {
+		err = srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
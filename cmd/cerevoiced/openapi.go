@@ -0,0 +1,278 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// object is a JSON object of the OpenAPI description
+type object = map[string]interface{}
+
+// spec serves the OpenAPI description, with the server as seen by the
+// client
+func (s *server) spec(w http.ResponseWriter, r *http.Request) {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	writeJSON(w, http.StatusOK, openAPI(scheme+"://"+r.Host))
+}
+
+// openAPI returns the OpenAPI 3 description of the endpoints, naming
+// serverURL as the server if it is set. Schemas are derived from the types
+// the handlers encode, so they cannot drift apart.
+func openAPI(serverURL string) object {
+	speak := schemaOf(reflect.TypeOf(speakRequest{}))
+	props := speak["properties"].(object)
+	var formats, rates []interface{}
+	for _, f := range cerevoicego.AudioFormats {
+		formats = append(formats, string(f))
+	}
+	for _, r := range cerevoicego.SampleRates {
+		rates = append(rates, string(r))
+	}
+	props["audioFormat"].(object)["enum"] = formats
+	props["audioFormat"].(object)["default"] = string(cerevoicego.FormatWAV)
+	props["sampleRate"].(object)["enum"] = rates
+
+	audio := object{}
+	for _, f := range cerevoicego.AudioFormats {
+		audio[f.ContentType()] = object{"schema": object{"type": "string", "format": "binary"}}
+	}
+
+	spec := object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "cerevoiced",
+			"version":     cerevoicego.Version,
+			"description": "Speech synthesis through the CereVoice Cloud API, authenticated with an API key instead of the CereVoice credentials.",
+		},
+		"security": []interface{}{object{"bearer": []string{}}, object{"apiKey": []string{}}},
+		"paths": object{
+			"/v1/speak": object{
+				"post": object{
+					"operationId": "speak",
+					"summary":     "Synthesise text, responding with the audio",
+					"requestBody": object{
+						"required": true,
+						"content":  object{"application/json": object{"schema": ref("SpeakRequest")}},
+					},
+					"responses": responses(object{
+						"200": object{
+							"description": "The audio, in the requested format",
+							"headers": object{
+								"X-CereVoice-Char-Count": object{
+									"description": "Characters billed",
+									"schema":      object{"type": "string"},
+								},
+							},
+							"content": audio,
+						},
+					}, 400, 401, 402, 405, 429, 502, 503),
+				},
+			},
+			"/v1/voices": object{
+				"get": object{
+					"operationId": "listVoices",
+					"summary":     "List voices",
+					"parameters": []interface{}{
+						query("lang", "Language code, e.g. en", nil),
+						query("accent", "Accent code", nil),
+						query("sex", "Voice sex", []interface{}{string(cerevoicego.Female), string(cerevoicego.Male)}),
+					},
+					"responses": responses(object{"200": jsonResponse("The matching voices", "Voices")}, 401, 429, 502, 503),
+				},
+			},
+			"/v1/credit": object{
+				"get": object{
+					"operationId": "getCredit",
+					"summary":     "Show account credit",
+					"responses":   responses(object{"200": jsonResponse("The account credit", "Credit")}, 401, 429, 502, 503),
+				},
+			},
+			"/v1/token": object{
+				"post": object{
+					"operationId": "issueToken",
+					"summary":     "Issue a temporary token to use in place of the API key",
+					"description": "Requires an API key, not a token. Tokens are valid until their expiry or the server restarts.",
+					"responses":   responses(object{"200": jsonResponse("The token", "Token")}, 401, 405),
+				},
+			},
+			"/healthz": object{
+				"get": object{
+					"operationId": "health",
+					"summary":     "Liveness check",
+					"security":    []interface{}{},
+					"responses":   object{"200": jsonResponse("The server is running", "Health")},
+				},
+			},
+			"/readyz": object{
+				"get": object{
+					"operationId": "ready",
+					"summary":     "Readiness check of the CereVoice account",
+					"description": "CereVoice is checked at most once per -ready-interval, and the last result served in between.",
+					"security":    []interface{}{},
+					"responses": object{
+						"200": jsonResponse("Speak requests can be expected to succeed", "Readiness"),
+						"503": jsonResponse("The API is unreachable, the credentials rejected or the credit used up", "Readiness"),
+					},
+				},
+			},
+			"/openapi.json": object{
+				"get": object{
+					"operationId": "openAPI",
+					"summary":     "This OpenAPI description",
+					"security":    []interface{}{},
+					"responses": object{
+						"200": object{
+							"description": "The OpenAPI description",
+							"content":     object{"application/json": object{"schema": object{"type": "object"}}},
+						},
+					},
+				},
+			},
+		},
+		"components": object{
+			"securitySchemes": object{
+				"bearer": object{"type": "http", "scheme": "bearer"},
+				"apiKey": object{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+			"schemas": object{
+				"SpeakRequest": speak,
+				"Voices":       schemaOf(reflect.TypeOf(voicesResponse{})),
+				"Credit":       schemaOf(reflect.TypeOf(creditResponse{})),
+				"Token":        schemaOf(reflect.TypeOf(cerevoicego.Token{})),
+				"Health":       schemaOf(reflect.TypeOf(healthResponse{})),
+				"Readiness":    schemaOf(reflect.TypeOf(cerevoicego.Health{})),
+				"Error":        schemaOf(reflect.TypeOf(errorResponse{})),
+			},
+		},
+	}
+	if serverURL != "" {
+		spec["servers"] = []interface{}{object{"url": serverURL}}
+	}
+
+	return spec
+}
+
+// statusDescriptions describe the error responses
+var statusDescriptions = map[int]string{
+	http.StatusBadRequest:         "The request or its parameters are invalid",
+	http.StatusUnauthorized:       "The API key or token is missing or invalid",
+	http.StatusPaymentRequired:    "The CereVoice account has insufficient credit",
+	http.StatusMethodNotAllowed:   "The method is not allowed",
+	http.StatusTooManyRequests:    "CereVoice is rate limiting requests",
+	http.StatusBadGateway:         "CereVoice failed or could not be reached",
+	http.StatusServiceUnavailable: "CereVoice is failing and requests are paused",
+}
+
+// responses adds error responses with the given status codes to ok
+func responses(ok object, codes ...int) object {
+	for _, code := range codes {
+		ok[strconv.Itoa(code)] = jsonResponse(statusDescriptions[code], "Error")
+	}
+	return ok
+}
+
+// jsonResponse is a response with a JSON body of the named schema
+func jsonResponse(description, schema string) object {
+	return object{
+		"description": description,
+		"content":     object{"application/json": object{"schema": ref(schema)}},
+	}
+}
+
+// query is an optional query parameter
+func query(name, description string, enum []interface{}) object {
+	schema := object{"type": "string"}
+	if enum != nil {
+		schema["enum"] = enum
+	}
+	return object{"name": name, "in": "query", "description": description, "schema": schema}
+}
+
+func ref(schema string) object {
+	return object{"$ref": "#/components/schemas/" + schema}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	amountType   = reflect.TypeOf(cerevoicego.Amount(0))
+)
+
+// schemaOf returns the JSON schema of the values of t as encoding/json
+// encodes them
+func schemaOf(t reflect.Type) object {
+	switch t {
+	case timeType:
+		return object{"type": "string", "format": "date-time"}
+	case durationType:
+		return object{"type": "integer", "format": "int64", "description": "Nanoseconds"}
+	case amountType:
+		return object{"type": "number", "description": "Credit in whole units, to hundredths"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return object{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Struct:
+		props := object{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, opts := f.Name, ""
+			if tag, ok := f.Tag.Lookup("json"); ok {
+				if tag == "-" {
+					continue
+				}
+				if i := strings.Index(tag, ","); i >= 0 {
+					tag, opts = tag[:i], tag[i:]
+				}
+				if tag != "" {
+					name = tag
+				}
+			}
+			props[name] = schemaOf(f.Type)
+			if !strings.Contains(opts, ",omitempty") {
+				required = append(required, name)
+			}
+		}
+
+		schema := object{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+
+	// Interfaces, such as error, may encode as anything
+	return object{}
+}
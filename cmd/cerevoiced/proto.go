@@ -0,0 +1,222 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf8"
+)
+
+// Protocol buffer wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errMalformed = errors.New("malformed protocol buffer message")
+
+// protoWriter encodes a protocol buffer message. Fields with their zero
+// value are omitted, as in proto3.
+type protoWriter struct {
+	b []byte
+}
+
+func (p *protoWriter) key(field, wire int) {
+	p.b = binary.AppendUvarint(p.b, uint64(field)<<3|uint64(wire))
+}
+
+// bytes writes a bytes field, omitted if empty
+func (p *protoWriter) bytes(field int, b []byte) {
+	if len(b) > 0 {
+		p.message(field, b)
+	}
+}
+
+// string writes a string field, omitted if empty
+func (p *protoWriter) string(field int, s string) {
+	p.bytes(field, []byte(s))
+}
+
+// int writes an int32 or int64 field, omitted if zero
+func (p *protoWriter) int(field int, v int64) {
+	if v != 0 {
+		p.key(field, wireVarint)
+		p.b = binary.AppendUvarint(p.b, uint64(v))
+	}
+}
+
+// bool writes a bool field, omitted if false
+func (p *protoWriter) bool(field int, v bool) {
+	if v {
+		p.int(field, 1)
+	}
+}
+
+// message writes an embedded message, even if empty, as for each element of
+// a repeated field
+func (p *protoWriter) message(field int, m []byte) {
+	p.key(field, wireBytes)
+	p.b = binary.AppendUvarint(p.b, uint64(len(m)))
+	p.b = append(p.b, m...)
+}
+
+// protoField is a decoded field of a protocol buffer message
+type protoField struct {
+	num   int
+	wire  int
+	value uint64 // Varint fields
+	data  []byte // Length delimited fields
+}
+
+func (f protoField) string() (string, error) {
+	if f.wire != wireBytes || !utf8.Valid(f.data) {
+		return "", errMalformed
+	}
+	return string(f.data), nil
+}
+
+func (f protoField) int() (int64, error) {
+	if f.wire != wireVarint {
+		return 0, errMalformed
+	}
+	return int64(f.value), nil
+}
+
+func (f protoField) bool() (bool, error) {
+	v, err := f.int()
+	return v != 0, err
+}
+
+// protoFields calls fn with each field of msg in order. Fixed width fields,
+// which no message here uses, are skipped.
+func protoFields(msg []byte, fn func(f protoField) error) error {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 || key>>3 == 0 {
+			return errMalformed
+		}
+		msg = msg[n:]
+		f := protoField{num: int(key >> 3), wire: int(key & 7)}
+
+		switch f.wire {
+		case wireVarint:
+			if f.value, n = binary.Uvarint(msg); n <= 0 {
+				return errMalformed
+			}
+			msg = msg[n:]
+		case wireBytes:
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || size > uint64(len(msg)-n) {
+				return errMalformed
+			}
+			f.data, msg = msg[n:n+int(size)], msg[n+int(size):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if f.wire == wireFixed32 {
+				size = 4
+			}
+			if len(msg) < size {
+				return errMalformed
+			}
+			msg = msg[size:]
+			continue
+		default:
+			return errMalformed
+		}
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The messages of cerevoiced.proto, using the JSON types where the fields
+// match
+
+func (req *speakRequest) unmarshalProto(msg []byte) error {
+	return protoFields(msg, func(f protoField) (err error) {
+		switch f.num {
+		case 1:
+			req.Voice, err = f.string()
+		case 2:
+			req.Text, err = f.string()
+		case 3:
+			req.AudioFormat, err = f.string()
+		case 4:
+			req.SampleRate, err = f.string()
+		case 5:
+			req.Audio3D, err = f.bool()
+		}
+		return err
+	})
+}
+
+// speakResponse is a message of the audio streamed by Speak. Only the first
+// carries the content type and characters billed.
+type speakResponse struct {
+	Audio       []byte
+	ContentType string
+	CharCount   int
+}
+
+func (r *speakResponse) marshalProto() []byte {
+	var p protoWriter
+	p.bytes(1, r.Audio)
+	p.string(2, r.ContentType)
+	p.int(3, int64(r.CharCount))
+	return p.b
+}
+
+// voicesRequest filters the voices listed by ListVoices
+type voicesRequest struct {
+	Lang   string
+	Accent string
+	Sex    string
+}
+
+func (req *voicesRequest) unmarshalProto(msg []byte) error {
+	return protoFields(msg, func(f protoField) (err error) {
+		switch f.num {
+		case 1:
+			req.Lang, err = f.string()
+		case 2:
+			req.Accent, err = f.string()
+		case 3:
+			req.Sex, err = f.string()
+		}
+		return err
+	})
+}
+
+func (v *voice) marshalProto() []byte {
+	var p protoWriter
+	p.string(1, v.Name)
+	p.string(2, v.Language)
+	p.string(3, v.Country)
+	p.string(4, v.Accent)
+	p.string(5, v.Sex)
+	p.string(6, v.SampleRate)
+	return p.b
+}
+
+func (r *voicesResponse) marshalProto() []byte {
+	var p protoWriter
+	for i := range r.Voices {
+		p.message(1, r.Voices[i].marshalProto())
+	}
+	return p.b
+}
+
+func (r *creditResponse) marshalProto() []byte {
+	var p protoWriter
+	p.string(1, r.FreeCredit)
+	p.string(2, r.PaidCredit)
+	p.int(3, int64(r.CharsAvailable))
+	return p.b
+}
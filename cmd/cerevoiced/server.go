@@ -0,0 +1,264 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/internal/apikey"
+)
+
+// maxRequestBytes limits the size of a speak request body
+const maxRequestBytes = 1 << 20
+
+// server serves the JSON/HTTP API
+type server struct {
+	client *cerevoicego.Client
+	keys   apikey.Set
+	tokens *apikey.Issuer // nil if no tokens are issued
+	mux    *http.ServeMux
+}
+
+// newServer returns a server for client. Readiness probes check CereVoice
+// at most once per readyInterval.
+func newServer(client *cerevoicego.Client, keys apikey.Set, tokens *apikey.Issuer, readyInterval time.Duration) *server {
+	s := &server{client: client, keys: keys, tokens: tokens, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/healthz", s.health)
+	s.mux.Handle("/readyz", client.CachedHealthHandler(readyInterval))
+	s.mux.HandleFunc("/openapi.json", s.spec)
+	s.mux.Handle("/v1/speak", s.auth(s.speak))
+	s.mux.Handle("/v1/voices", s.auth(s.voices))
+	s.mux.Handle("/v1/credit", s.auth(s.credit))
+	s.mux.HandleFunc(grpcService, s.grpc)
+	if tokens != nil {
+		s.mux.Handle("/v1/token", apikey.TokenHandler(keys, tokens, client.AccountID))
+	}
+	return s
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// auth rejects requests without a valid API key or token
+func (s *server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := apikey.FromRequest(r); !s.keys.Valid(key) && !s.tokens.Valid(key) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("invalid API key"))
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// healthResponse is the body of a liveness check
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+func (s *server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// speakRequest is the body of a speak request
+type speakRequest struct {
+	Voice       string `json:"voice"`
+	Text        string `json:"text"`
+	AudioFormat string `json:"audioFormat,omitempty"`
+	SampleRate  string `json:"sampleRate,omitempty"`
+	Audio3D     bool   `json:"audio3D,omitempty"`
+}
+
+// input returns the validated input of the request
+func (req *speakRequest) input() (*cerevoicego.SpeakExtendedInput, error) {
+	if req.Voice == "" || strings.TrimSpace(req.Text) == "" {
+		return nil, errors.New("voice and text are required")
+	}
+
+	input := &cerevoicego.SpeakExtendedInput{
+		Voice:       req.Voice,
+		Text:        req.Text,
+		AudioFormat: cerevoicego.AudioFormat(req.AudioFormat),
+		SampleRate:  cerevoicego.SampleRate(req.SampleRate),
+		Audio3D:     req.Audio3D,
+	}
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	return input, nil
+}
+
+// speak synthesises the requested text and streams back the audio
+func (s *server) speak(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	var req speakRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	input, err := req.input()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := s.client.SpeakExtendedWithContext(r.Context(), input)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	audio, err := res.Download(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	defer audio.Close()
+
+	w.Header().Set("Content-Type", input.AudioFormat.ContentType())
+	w.Header().Set("X-CereVoice-Char-Count", res.CharCount)
+	if _, err := io.Copy(w, audio); err != nil {
+		log.Printf("cerevoiced: speak: %v", err)
+	}
+}
+
+// voice is a voice in a voices response
+type voice struct {
+	Name       string `json:"name"`
+	Language   string `json:"language"`
+	Country    string `json:"country"`
+	Accent     string `json:"accent"`
+	Sex        string `json:"sex"`
+	SampleRate string `json:"sampleRate"`
+}
+
+// voicesResponse is the body of a voices response
+type voicesResponse struct {
+	Voices []voice `json:"voices"`
+}
+
+// voices lists voices matching the lang, accent and sex query parameters
+func (s *server) voices(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	res, err := s.listVoices(r.Context(), q.Get("lang"), q.Get("accent"), q.Get("sex"))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// listVoices lists voices matching the filters, which may be empty
+func (s *server) listVoices(ctx context.Context, lang, accent, sex string) (*voicesResponse, error) {
+	res, err := s.client.ListVoicesWithContext(ctx, &cerevoicego.ListVoicesInput{
+		Language: lang,
+		Accent:   accent,
+		Sex:      cerevoicego.Sex(sex),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	voices := []voice{}
+	for _, v := range res.VoiceList {
+		voices = append(voices, voice{
+			Name:       v.VoiceName,
+			Language:   v.LanguageCodeISO,
+			Country:    v.CountryCodeISO,
+			Accent:     v.Accent,
+			Sex:        v.Sex,
+			SampleRate: v.SampleRate,
+		})
+	}
+
+	return &voicesResponse{Voices: voices}, nil
+}
+
+// creditResponse is the body of a credit response
+type creditResponse struct {
+	FreeCredit     string `json:"freeCredit"`
+	PaidCredit     string `json:"paidCredit"`
+	CharsAvailable int    `json:"charsAvailable"`
+}
+
+// credit shows the account credit
+func (s *server) credit(w http.ResponseWriter, r *http.Request) {
+	res, err := s.getCredit(r.Context())
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// getCredit returns the account credit
+func (s *server) getCredit(ctx context.Context) (*creditResponse, error) {
+	res, err := s.client.GetCreditWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &creditResponse{
+		FreeCredit:     res.Credit.FreeCredit,
+		PaidCredit:     res.Credit.PaidCredit,
+		CharsAvailable: res.Credit.Available,
+	}, nil
+}
+
+// statusCode maps a client error to an HTTP status code
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, cerevoicego.ErrValidation),
+		errors.Is(err, cerevoicego.ErrInvalidVoice),
+		errors.Is(err, cerevoicego.ErrInvalidAudioFormat),
+		errors.Is(err, cerevoicego.ErrInvalidSampleRate):
+		return http.StatusBadRequest
+	case errors.Is(err, cerevoicego.ErrInsufficientCredit):
+		return http.StatusPaymentRequired
+	case errors.Is(err, cerevoicego.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, cerevoicego.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	}
+
+	var apiErr *cerevoicego.APIError
+	if errors.As(err, &apiErr) && apiErr.ResultCode == cerevoicego.ResultInvalidParameter {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusBadGateway
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
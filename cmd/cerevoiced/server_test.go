@@ -0,0 +1,100 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/internal/apikey"
+)
+
+func TestServer(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	tokens, err := apikey.NewIssuer(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, _, err := tokens.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(srv.Client(), apikey.Set{"secret"}, tokens, time.Hour)
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		key      string
+		body     string
+		status   int
+		want     string // Part of the body
+		upstream []string
+	}{
+		{name: "health", method: "GET", target: "/healthz", status: 200, want: `"ok"`},
+		{name: "ready", method: "GET", target: "/readyz", status: 200, want: `"ready":true`, upstream: []string{"getCredit"}},
+		{name: "ready cached", method: "GET", target: "/readyz", status: 200, want: `"ready":true`},
+		{name: "openapi", method: "GET", target: "/openapi.json", status: 200, want: `"openapi"`},
+		{name: "no key", method: "POST", target: "/v1/speak", body: `{"voice":"Heather","text":"Hello"}`, status: 401},
+		{name: "wrong key", method: "GET", target: "/v1/credit", key: "guess", status: 401},
+		{name: "speak", method: "POST", target: "/v1/speak", key: "secret", body: `{"voice":"Heather","text":"Hello"}`, status: 200, want: "RIFF", upstream: []string{"speakExtended"}},
+		{name: "speak token", method: "POST", target: "/v1/speak", key: token, body: `{"voice":"Heather","text":"Hello"}`, status: 200, want: "RIFF", upstream: []string{"speakExtended"}},
+		{name: "speak no text", method: "POST", target: "/v1/speak", key: "secret", body: `{"voice":"Heather"}`, status: 400},
+		{name: "speak bad body", method: "POST", target: "/v1/speak", key: "secret", body: `{`, status: 400},
+		{name: "speak method", method: "GET", target: "/v1/speak", key: "secret", status: 405},
+		{name: "voices", method: "GET", target: "/v1/voices?lang=en", key: "secret", status: 200, want: `"voices":[`, upstream: []string{"listVoices"}},
+		{name: "credit", method: "GET", target: "/v1/credit", key: "secret", status: 200, want: `"charsAvailable":500000`, upstream: []string{"getCredit"}},
+		{name: "token", method: "POST", target: "/v1/token", key: "secret", status: 200, want: `"token"`},
+		{name: "token for token", method: "POST", target: "/v1/token", key: token, status: 401},
+	}
+
+	for _, tt := range tests {
+		srv.Reset()
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+		}
+		if !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: body %q does not contain %q", tt.name, rec.Body, tt.want)
+		}
+		var ops []string
+		for _, r := range srv.Requests() {
+			ops = append(ops, r.XMLName.Local)
+		}
+		if strings.Join(ops, ",") != strings.Join(tt.upstream, ",") {
+			t.Errorf("%s: CereVoice requests %v, want %v", tt.name, ops, tt.upstream)
+		}
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{cerevoicego.ErrInvalidVoice, 400},
+		{cerevoicego.ErrInsufficientCredit, 402},
+		{cerevoicego.ErrRateLimited, 429},
+		{cerevoicego.ErrCircuitOpen, 503},
+		{&cerevoicego.APIError{ResultCode: cerevoicego.ResultInvalidParameter}, 400},
+		{&cerevoicego.APIError{ResultCode: cerevoicego.ResultServerBusy}, 502},
+	}
+
+	for _, tt := range tests {
+		if got := statusCode(tt.err); got != tt.want {
+			t.Errorf("statusCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
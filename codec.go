@@ -0,0 +1,98 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Codec is the wire format of API requests and responses. The CereVoice Cloud
+// API speaks XML, which is used when a Client has no Codec, so a Codec is
+// only needed for other transports, such as a JSON API or a proxy in front
+// of it. Implementations must be safe for concurrent use.
+type Codec interface {
+	// ContentType returns the Content-Type of encoded requests
+	ContentType() string
+	// Encode returns the body of req
+	Encode(req *Request) ([]byte, error)
+	// Decode decodes the response to operation in raw into v. operation is
+	// empty when only the result status common to every response is
+	// decoded, which must not be rejected for missing fields.
+	Decode(operation string, raw []byte, v interface{}) error
+	// Match reports whether raw is in the codec's format, so API responses
+	// sent with an error status can be told apart from error pages
+	Match(raw []byte) bool
+}
+
+// WithCodec sets the wire format of API requests and responses. DecodeMode,
+// CharsetReader and ElementAliases only apply to the default XML format.
+func WithCodec(codec Codec) ClientOption {
+	return func(c *Client) {
+		c.Codec = codec
+	}
+}
+
+// codec returns the Codec of the Client, XML if it has none
+func (c *Client) codec() Codec {
+	if c.Codec != nil {
+		return c.Codec
+	}
+
+	return xmlCodec{c}
+}
+
+// xmlCodec is the XML format of the CereVoice Cloud API, decoded with the
+// settings of the Client
+type xmlCodec struct {
+	c *Client
+}
+
+func (x xmlCodec) ContentType() string {
+	return "text/xml"
+}
+
+func (x xmlCodec) Encode(req *Request) ([]byte, error) {
+	return encodeRequest(req, true), nil
+}
+
+func (x xmlCodec) Decode(operation string, raw []byte, v interface{}) error {
+	if operation == "" {
+		return x.c.unmarshal(raw, v)
+	}
+
+	return x.c.decode(operation, raw, v)
+}
+
+func (x xmlCodec) Match(raw []byte) bool {
+	return isXML(raw)
+}
+
+// JSONCodec encodes requests as a JSON object of the Request fields and the
+// operation, e.g. {"operation":"speakSimple","accountID":...}, and decodes
+// responses as a JSON object of the response fields
+type JSONCodec struct{}
+
+// ContentType returns application/json
+func (JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+// Encode returns req as JSON
+func (JSONCodec) Encode(req *Request) ([]byte, error) {
+	return json.Marshal(struct {
+		Operation string `json:"operation"`
+		*Request
+	}{req.XMLName.Local, req})
+}
+
+// Decode decodes the JSON in raw into v
+func (JSONCodec) Decode(operation string, raw []byte, v interface{}) error {
+	return json.Unmarshal(raw, v)
+}
+
+// Match reports whether raw looks like a JSON object
+func (JSONCodec) Match(raw []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{"))
+}
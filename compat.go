@@ -0,0 +1,13 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+// Names from the pre-release API, kept so existing code continues to build
+// while it is migrated. They will be removed in a future major version.
+const (
+	// Deprecated: use Version
+	VERSION = Version
+	// Deprecated: use DefaultAPIURL
+	DefaultRESTAPIURL = DefaultAPIURL
+)
@@ -0,0 +1,329 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by ConfigFromEnv
+const (
+	EnvAccountID = "CEREVOICE_ACCOUNT_ID"
+	EnvPassword  = "CEREVOICE_PASSWORD"
+	EnvAPIURL    = "CEREVOICE_API_URL"
+	EnvProfile   = "CEREVOICE_PROFILE"
+	EnvConfig    = "CEREVOICE_CONFIG_FILE"
+	EnvCAFile    = "CEREVOICE_CA_FILE"
+	EnvCertFile  = "CEREVOICE_CERT_FILE"
+	EnvKeyFile   = "CEREVOICE_KEY_FILE"
+	EnvProxy     = "CEREVOICE_PROXY"
+)
+
+// DefaultProfile is the config file profile used when none is given
+const DefaultProfile = "default"
+
+// Config contains the settings needed to create a Client
+type Config struct {
+	AccountID string
+	Password  string
+	APIURL    string
+	CAFile    string // PEM certificate authorities to trust instead of the system ones
+	CertFile  string // PEM client certificate for mutual TLS
+	KeyFile   string // PEM key of CertFile
+	Proxy     string // Proxy URL for API requests, e.g. socks5://egress:1080
+
+	// VoiceProfiles are read from the [voice <name>] tables of a config
+	// file, which are shared by every profile
+	VoiceProfiles VoiceProfiles
+}
+
+// ConfigFromEnv reads settings from the CEREVOICE_ACCOUNT_ID,
+// CEREVOICE_PASSWORD, CEREVOICE_API_URL, CEREVOICE_CA_FILE,
+// CEREVOICE_CERT_FILE, CEREVOICE_KEY_FILE and CEREVOICE_PROXY environment
+// variables
+func ConfigFromEnv() *Config {
+	return &Config{
+		AccountID: os.Getenv(EnvAccountID),
+		Password:  os.Getenv(EnvPassword),
+		APIURL:    os.Getenv(EnvAPIURL),
+		CAFile:    os.Getenv(EnvCAFile),
+		CertFile:  os.Getenv(EnvCertFile),
+		KeyFile:   os.Getenv(EnvKeyFile),
+		Proxy:     os.Getenv(EnvProxy),
+	}
+}
+
+// DefaultConfigPath returns the config file path, CEREVOICE_CONFIG_FILE if
+// set or ~/.cerevoice/config otherwise
+func DefaultConfigPath() string {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".cerevoice", "config")
+}
+
+// LoadConfig reads a profile from a config file. The file uses a subset of
+// TOML with one table per profile; settings before the first table belong to
+// the default profile.
+//
+//	[default]
+//	account_id = "<YOUR_ACCOUNTID>"
+//	password = "<YOUR_PASSWORD>"
+//
+//	[onprem]
+//	account_id = "<YOUR_ACCOUNTID>"
+//	password = "<YOUR_PASSWORD>"
+//	api_url = "https://cerevoice.example.com/rest/rest_1_1.php"
+//	ca_file = "/etc/ssl/corp-ca.pem"
+//	cert_file = "/etc/cerevoice/client.pem"
+//	key_file = "/etc/cerevoice/client-key.pem"
+//	proxy = "socks5://egress.example.com:1080"
+//
+//	[voice Heather]
+//	audio_format = "ogg"
+//	sample_rate = "22050"
+//	metadata = true
+//	emotion = "calm"
+//
+// A profile which is not in the file is an error only if it was named; an
+// empty profile reads the default profile if there is one, so a file holding
+// only voice tables or other profiles can still be loaded.
+func LoadConfig(path, profile string) (*Config, error) {
+	explicit := profile != ""
+	if !explicit {
+		profile = DefaultProfile
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := &Config{}
+	found := false
+	section := DefaultProfile
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: malformed profile header", path, n)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			section = strings.TrimPrefix(section, "profile ")
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, n)
+		}
+		voice := strings.HasPrefix(section, "voice ")
+		if section != profile && !voice {
+			continue
+		}
+
+		key := strings.TrimSpace(kv[0])
+		value, err := configValue(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+
+		if voice {
+			name := strings.TrimSpace(strings.TrimPrefix(section, "voice "))
+			if cfg.VoiceProfiles == nil {
+				cfg.VoiceProfiles = make(VoiceProfiles)
+			}
+			p := cfg.VoiceProfiles[name]
+			if err := p.set(key, value); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+			}
+			cfg.VoiceProfiles[name] = p
+			continue
+		}
+		found = true
+
+		switch key {
+		case "account_id":
+			cfg.AccountID = value
+		case "password":
+			cfg.Password = value
+		case "api_url":
+			cfg.APIURL = value
+		case "ca_file":
+			cfg.CAFile = value
+		case "cert_file":
+			cfg.CertFile = value
+		case "key_file":
+			cfg.KeyFile = value
+		case "proxy":
+			cfg.Proxy = value
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown key %q", path, n, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found && explicit {
+		return nil, fmt.Errorf("%s: profile %q not found", path, profile)
+	}
+
+	return cfg, nil
+}
+
+// Merge fills any settings not set in c from other
+func (c *Config) Merge(other *Config) {
+	if other == nil {
+		return
+	}
+	if c.AccountID == "" {
+		c.AccountID = other.AccountID
+	}
+	if c.Password == "" {
+		c.Password = other.Password
+	}
+	if c.APIURL == "" {
+		c.APIURL = other.APIURL
+	}
+	if c.CAFile == "" {
+		c.CAFile = other.CAFile
+	}
+	if c.CertFile == "" && c.KeyFile == "" {
+		c.CertFile, c.KeyFile = other.CertFile, other.KeyFile
+	}
+	if c.Proxy == "" {
+		c.Proxy = other.Proxy
+	}
+	if c.VoiceProfiles == nil {
+		c.VoiceProfiles = other.VoiceProfiles
+	}
+}
+
+// Resolve fills the settings c leaves unset from the environment, then from
+// profile of the config file at path, if path is set. The file need not
+// exist unless a profile is named. Commands use it so their flags take
+// precedence over both.
+func (c *Config) Resolve(path, profile string) error {
+	c.Merge(ConfigFromEnv())
+
+	if path != "" {
+		file, err := LoadConfig(path, profile)
+		if err != nil && !(os.IsNotExist(err) && profile == "") {
+			return err
+		}
+		c.Merge(file)
+	}
+
+	return nil
+}
+
+// Client returns a Client for the settings, using DefaultAPIURL if no API
+// URL is set
+func (c *Config) Client() (*Client, error) {
+	if c.AccountID == "" || c.Password == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	client := NewClient(c.AccountID, c.Password)
+	if c.APIURL != "" {
+		client.APIURL = c.APIURL
+	}
+	if c.CAFile != "" {
+		pool, err := LoadRootCAs(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		client.Apply(WithRootCAs(pool))
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cerevoicego: loading client certificate: %v", err)
+		}
+		client.Apply(WithClientCertificate(cert))
+	}
+	if c.Proxy != "" {
+		proxy, err := url.Parse(c.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("cerevoicego: invalid proxy URL %q", c.Proxy)
+		}
+		client.Apply(WithProxy(proxy))
+	}
+	if c.VoiceProfiles != nil {
+		client.Apply(WithVoiceProfiles(c.VoiceProfiles))
+	}
+
+	return client, nil
+}
+
+// NewClientFromEnv returns a Client configured from environment variables
+func NewClientFromEnv() (*Client, error) {
+	return ConfigFromEnv().Client()
+}
+
+// NewClientFromConfig returns a Client configured from the config file at
+// path, using the profile named by CEREVOICE_PROFILE or the default profile.
+// Settings in environment variables take precedence over the file.
+func NewClientFromConfig(path string) (*Client, error) {
+	return NewClientFromConfigProfile(path, os.Getenv(EnvProfile))
+}
+
+// NewClientFromConfigProfile is the same as NewClientFromConfig but uses the
+// given profile
+func NewClientFromConfigProfile(path, profile string) (*Client, error) {
+	file, err := LoadConfig(path, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := ConfigFromEnv()
+	cfg.Merge(file)
+
+	return cfg.Client()
+}
+
+// configValue parses a bare or quoted config value, ignoring any trailing
+// comment after a quoted value
+func configValue(s string) (string, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, `"`) {
+		end := strings.LastIndex(s, `"`)
+		if end == 0 {
+			return "", errors.New("unterminated string")
+		}
+		return strconv.Unquote(s[:end+1])
+	}
+	if strings.HasPrefix(s, "'") {
+		end := strings.LastIndex(s, "'")
+		if end == 0 {
+			return "", errors.New("unterminated string")
+		}
+		return s[1:end], nil
+	}
+
+	return s, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+func TestLoadConfigProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	err := ioutil.WriteFile(path, []byte("[onprem]\naccount_id = \"onprem\"\n\n[voice Heather]\naudio_format = \"ogg\"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := cerevoicego.LoadConfig(path, "")
+	if err != nil {
+		t.Fatalf("LoadConfig without a default profile: %v", err)
+	}
+	if cfg.AccountID != "" || cfg.VoiceProfiles["Heather"].AudioFormat != cerevoicego.FormatOGG {
+		t.Errorf("LoadConfig default = %+v", cfg)
+	}
+
+	cfg, err = cerevoicego.LoadConfig(path, "onprem")
+	if err != nil || cfg.AccountID != "onprem" {
+		t.Errorf("LoadConfig onprem = %+v, %v", cfg, err)
+	}
+
+	if _, err := cerevoicego.LoadConfig(path, "default"); err == nil {
+		t.Error("LoadConfig of a named missing profile succeeded")
+	}
+}
+
+func TestConfigResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	err := ioutil.WriteFile(path, []byte("account_id = \"file\"\npassword = \"file\"\napi_url = \"https://file.example.com\"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(cerevoicego.EnvAccountID, "")
+	t.Setenv(cerevoicego.EnvPassword, "env")
+	t.Setenv(cerevoicego.EnvAPIURL, "")
+
+	cfg := &cerevoicego.Config{AccountID: "flag"}
+	if err := cfg.Resolve(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AccountID != "flag" || cfg.Password != "env" || cfg.APIURL != "https://file.example.com" {
+		t.Errorf("Resolve = %+v, want flags, then the environment, then the file", cfg)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	if err := (&cerevoicego.Config{}).Resolve(missing, ""); err != nil {
+		t.Errorf("Resolve without a config file = %v", err)
+	}
+	if err := (&cerevoicego.Config{}).Resolve(missing, "onprem"); err == nil {
+		t.Error("Resolve of a named profile without a config file succeeded")
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ConnInfo describes the connection used by an API request and where its
+// time went. DNS, Connect and TLS are zero for reused connections.
+type ConnInfo struct {
+	Reused    bool          // An existing connection was reused
+	WasIdle   bool          // The reused connection was idle in the pool
+	IdleTime  time.Duration // How long the reused connection was idle
+	Protocol  string        // Protocol of the response, e.g. HTTP/2.0
+	DNS       time.Duration // DNS lookup
+	Connect   time.Duration // TCP connection
+	TLS       time.Duration // TLS handshake
+	FirstByte time.Duration // From the request being written to the first response byte
+}
+
+// traceConn returns ctx with an httptrace.ClientTrace filling in info. The
+// returned function must be called once the response has been received, and
+// sets the protocol from resp if it is not nil.
+func traceConn(ctx context.Context, info *ConnInfo) (context.Context, func(resp *http.Response)) {
+	var mu sync.Mutex
+	var dnsStart, connectStart, tlsStart, wrote time.Time
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(c httptrace.GotConnInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			info.Reused, info.WasIdle, info.IdleTime = c.Reused, c.WasIdle, c.IdleTime
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			info.DNS = time.Since(dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			if connectStart.IsZero() {
+				connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				info.Connect = time.Since(connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			info.TLS = time.Since(tlsStart)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			wrote = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if !wrote.IsZero() {
+				info.FirstByte = time.Since(wrote)
+			}
+		},
+	}
+
+	return httptrace.WithClientTrace(ctx, trace), func(resp *http.Response) {
+		mu.Lock()
+		defer mu.Unlock()
+		if resp != nil {
+			info.Protocol = resp.Proto
+		}
+	}
+}
+
+// ConnStats contains connection reuse and timing totals for API requests
+type ConnStats struct {
+	Requests  uint64        // Requests which received a connection
+	Reused    uint64        // Requests on a reused connection
+	New       uint64        // Requests which opened a new connection
+	HTTP2     uint64        // Responses over HTTP/2
+	DNS       time.Duration // Total DNS lookup time
+	Connect   time.Duration // Total TCP connection time
+	TLS       time.Duration // Total TLS handshake time
+	FirstByte time.Duration // Total time to first response byte
+}
+
+// ReuseRatio returns the fraction of requests which reused a connection
+func (s ConnStats) ReuseRatio() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+
+	return float64(s.Reused) / float64(s.Requests)
+}
+
+// AvgSetup returns the mean DNS, connection and TLS time of new connections
+func (s ConnStats) AvgSetup() (dns, connect, tls time.Duration) {
+	if s.New == 0 {
+		return 0, 0, 0
+	}
+
+	n := time.Duration(s.New)
+	return s.DNS / n, s.Connect / n, s.TLS / n
+}
+
+// AvgFirstByte returns the mean time to first response byte
+func (s ConnStats) AvgFirstByte() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+
+	return s.FirstByte / time.Duration(s.Requests)
+}
+
+// ConnTracker accumulates the ConnInfo of API requests. It is safe for
+// concurrent use and may be shared by several Clients.
+type ConnTracker struct {
+	mu    sync.Mutex
+	stats ConnStats
+}
+
+// WithConnTracker accumulates connection stats of every API request in t
+func WithConnTracker(t *ConnTracker) ClientOption {
+	return func(c *Client) {
+		c.ConnTracker = t
+	}
+}
+
+// Stats returns the totals so far
+func (t *ConnTracker) Stats() ConnStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.stats
+}
+
+// Reset clears the totals
+func (t *ConnTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stats = ConnStats{}
+}
+
+// add records one request
+func (t *ConnTracker) add(info *ConnInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := &t.stats
+	s.Requests++
+	if info.Reused {
+		s.Reused++
+	} else {
+		s.New++
+	}
+	if info.Protocol == "HTTP/2.0" {
+		s.HTTP2++
+	}
+	s.DNS += info.DNS
+	s.Connect += info.Connect
+	s.TLS += info.TLS
+	s.FirstByte += info.FirstByte
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrMissingCredentials is returned when no account ID or password is set
+var ErrMissingCredentials = errors.New("cerevoicego: account ID and password are required")
+
+// Credentials authenticate requests to the CereVoice Cloud API
+type Credentials struct {
+	AccountID string
+	Password  string
+	Token     string // Temporary token sent in place of the Password, if set
+}
+
+// CredentialsProvider supplies the credentials for each API request, so
+// they can be rotated or fetched from a secret store without recreating the
+// Client. Implementations must be safe for concurrent use.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsFunc adapts a function to the CredentialsProvider interface
+type CredentialsFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials calls f(ctx)
+func (f CredentialsFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// WithCredentials sets the provider used for the credentials of every
+// request, in place of AccountID and Password
+func WithCredentials(p CredentialsProvider) ClientOption {
+	return func(c *Client) {
+		c.Credentials = p
+	}
+}
+
+// StaticCredentials returns a provider which always returns the given
+// account ID and password
+func StaticCredentials(accountID, password string) CredentialsProvider {
+	return CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		return Credentials{AccountID: accountID, Password: password}, nil
+	})
+}
+
+// EnvCredentials returns a provider which reads CEREVOICE_ACCOUNT_ID and
+// CEREVOICE_PASSWORD for every request
+func EnvCredentials() CredentialsProvider {
+	return CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		return Credentials{
+			AccountID: os.Getenv(EnvAccountID),
+			Password:  os.Getenv(EnvPassword),
+		}, nil
+	})
+}
+
+// FileCredentials returns a provider which reads the profile from the config
+// file at path for every request, so the file can be rewritten to rotate
+// the credentials
+func FileCredentials(path, profile string) CredentialsProvider {
+	return CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		cfg, err := LoadConfig(path, profile)
+		if err != nil {
+			return Credentials{}, err
+		}
+
+		return Credentials{AccountID: cfg.AccountID, Password: cfg.Password}, nil
+	})
+}
+
+// CachedCredentials returns a provider which reuses the credentials from p
+// for ttl, for providers which are slow or rate limited such as a remote
+// secret store
+func CachedCredentials(p CredentialsProvider, ttl time.Duration) CredentialsProvider {
+	var (
+		mu      sync.Mutex
+		creds   Credentials
+		fetched time.Time
+	)
+
+	return CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !fetched.IsZero() && time.Since(fetched) < ttl {
+			return creds, nil
+		}
+
+		c, err := p.Credentials(ctx)
+		if err != nil {
+			return Credentials{}, err
+		}
+		creds, fetched = c, time.Now()
+
+		return creds, nil
+	})
+}
+
+type credentialsKey struct{}
+
+// WithRequestCredentials returns a context which overrides the Client
+// credentials for requests made with it, for serving several accounts from
+// one Client. The CreditGuard is not applied to these requests, as it tracks
+// the balance of the Client account.
+func WithRequestCredentials(ctx context.Context, creds Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, creds)
+}
+
+// requestCredentials returns the credentials set by WithRequestCredentials
+func requestCredentials(ctx context.Context) (Credentials, bool) {
+	creds, ok := ctx.Value(credentialsKey{}).(Credentials)
+	return creds, ok
+}
+
+// authenticate sets the credentials of req from the context, the provider,
+// or AccountID and Password, in that order
+func (c *Client) authenticate(ctx context.Context, req *Request) error {
+	creds, ok := requestCredentials(ctx)
+	if !ok {
+		creds = Credentials{AccountID: c.AccountID, Password: c.Password}
+	}
+	if !ok && c.Credentials != nil {
+		var err error
+		if creds, err = c.Credentials.Credentials(ctx); err != nil {
+			return err
+		}
+	}
+
+	if creds.AccountID == "" || creds.Password == "" && creds.Token == "" {
+		return ErrMissingCredentials
+	}
+	req.AccountID, req.Password, req.Token = creds.AccountID, creds.Password, creds.Token
+
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CreditGuard tracks the characters available to the account and stops
+// speak requests which would exceed them, returning ErrInsufficientCredit
+// without calling the API. The balance is fetched with getCredit when first
+// needed, and again after RefreshInterval, and reduced by the charCount of
+// each successful speak request in between.
+//
+// Concurrent requests are checked against the same balance, so a burst of
+// requests may still overrun it slightly.
+type CreditGuard struct {
+	// RefreshInterval is how often the balance is fetched, 0 to fetch it
+	// only once
+	RefreshInterval time.Duration
+	// Warn, if set, is called instead of refusing a request which would
+	// exceed the balance
+	Warn func(required, available int)
+
+	mu        sync.Mutex
+	available int
+	fetched   time.Time
+}
+
+// WithCreditGuard enables the CreditGuard g
+func WithCreditGuard(g *CreditGuard) ClientOption {
+	return func(c *Client) {
+		c.CreditGuard = g
+	}
+}
+
+// Available returns the last known number of characters available and
+// whether it is known
+func (g *CreditGuard) Available() (int, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.available, !g.fetched.IsZero()
+}
+
+// Invalidate forces the balance to be fetched before the next request, for
+// example after topping up the account
+func (g *CreditGuard) Invalidate() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.fetched = time.Time{}
+}
+
+// check returns ErrInsufficientCredit if text would exceed the balance
+func (g *CreditGuard) check(ctx context.Context, c *Client, text string) error {
+	g.mu.Lock()
+	stale := g.fetched.IsZero() ||
+		(g.RefreshInterval > 0 && time.Since(g.fetched) > g.RefreshInterval)
+	g.mu.Unlock()
+
+	if stale {
+		r, err := c.GetCreditWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		if err := g.update(r.Credit.CharsAvailable); err != nil {
+			return err
+		}
+	}
+
+	required := BilledChars(text)
+
+	g.mu.Lock()
+	available := g.available
+	g.mu.Unlock()
+
+	if required <= available {
+		return nil
+	}
+	if g.Warn != nil {
+		g.Warn(required, available)
+		return nil
+	}
+
+	return ErrInsufficientCredit
+}
+
+// update sets the balance from the charsAvailable returned by getCredit. A
+// value which can not be parsed is an error, rather than a balance of 0
+// refusing every request, and leaves the balance to be fetched again.
+func (g *CreditGuard) update(charsAvailable string) error {
+	chars, err := strconv.Atoi(strings.TrimSpace(charsAvailable))
+	if err != nil {
+		return fmt.Errorf("cerevoicego: parsing charsAvailable %q: %w", charsAvailable, err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.available = chars
+	g.fetched = time.Now()
+	return nil
+}
+
+// consume reduces the balance by the characters billed for a request
+func (g *CreditGuard) consume(chars int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.available -= chars
+}
+
+// isSpeak reports whether operation synthesises text and is billed
+func isSpeak(operation string) bool {
+	return operation == "speakSimple" || operation == "speakExtended"
+}
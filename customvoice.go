@@ -0,0 +1,114 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DefaultCustomVoicePoll is how often WaitForCustomVoice checks for a voice
+// when no interval is given
+const DefaultCustomVoicePoll = time.Minute
+
+// CustomVoiceStatus is the provisioning state of a custom voice
+type CustomVoiceStatus string
+
+// Custom voice states
+const (
+	// CustomVoiceReady voices are listed by listVoices and can be spoken with
+	CustomVoiceReady CustomVoiceStatus = "ready"
+	// CustomVoicePending voices are not listed yet, e.g. while CereProc
+	// builds them
+	CustomVoicePending CustomVoiceStatus = "pending"
+)
+
+// CustomVoice is a voice made for the account, such as a CereVoice Me voice.
+//
+// The Cloud API has no endpoints to create custom voices or upload their
+// recordings; CereProc builds them and adds them to the account, after
+// which listVoices returns them like any other. Their names are set on the
+// Client with WithCustomVoices so they can be told apart from stock voices.
+type CustomVoice struct {
+	Name   string            `json:"name"`
+	Status CustomVoiceStatus `json:"status"`
+	Voice  *Voice            `json:"voice,omitempty"` // Details from listVoices, nil while pending
+}
+
+// WithCustomVoices sets the names of the account's custom voices
+func WithCustomVoices(names ...string) ClientOption {
+	return func(c *Client) {
+		c.CustomVoices = names
+	}
+}
+
+// ListCustomVoices returns the status of each of the Client's CustomVoices
+func (c *Client) ListCustomVoices() ([]CustomVoice, error) {
+	return c.ListCustomVoicesWithContext(context.Background())
+}
+
+// ListCustomVoicesWithContext is the same as ListCustomVoices with the
+// addition of the ability to pass a context for cancellation and timeouts
+func (c *Client) ListCustomVoicesWithContext(ctx context.Context) ([]CustomVoice, error) {
+	if len(c.CustomVoices) == 0 {
+		return nil, nil
+	}
+
+	res, err := c.ListVoicesWithContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	catalog := res.Catalog()
+
+	voices := make([]CustomVoice, len(c.CustomVoices))
+	for i, name := range c.CustomVoices {
+		voices[i] = CustomVoice{Name: name, Status: CustomVoicePending}
+		if v, ok := catalog.Lookup(name); ok {
+			voices[i].Status, voices[i].Voice = CustomVoiceReady, &v
+		}
+	}
+
+	return voices, nil
+}
+
+// WaitForCustomVoice checks listVoices every interval, or every
+// DefaultCustomVoicePoll if it is 0, until the voice name is listed, and
+// returns its details. Any VoiceCache of the LanguageDetection is
+// invalidated so the voice can be chosen at once.
+func (c *Client) WaitForCustomVoice(ctx context.Context, name string, interval time.Duration) (Voice, error) {
+	if interval <= 0 {
+		interval = DefaultCustomVoicePoll
+	}
+
+	for {
+		res, err := c.ListVoicesWithContext(ctx, nil)
+		if err != nil {
+			return Voice{}, err
+		}
+		if v, ok := res.Catalog().Lookup(name); ok {
+			if c.LanguageDetection != nil && c.LanguageDetection.Voices != nil {
+				c.LanguageDetection.Voices.Invalidate()
+			}
+			return v, nil
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return Voice{}, err
+		}
+	}
+}
+
+// Custom returns the voices of the catalogue named in names, such as the
+// Client's CustomVoices
+func (vc VoiceCatalog) Custom(names []string) VoiceCatalog {
+	return vc.Filter(func(v Voice) bool {
+		for _, name := range names {
+			if strings.EqualFold(v.VoiceName, name) {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDebugSize is the default number of exchanges kept by Debug
+const DefaultDebugSize = 20
+
+// DebugExchange is the raw request and response of one API call attempt
+type DebugExchange struct {
+	Time       time.Time     `json:"time"`
+	Operation  string        `json:"operation"`
+	Endpoint   string        `json:"endpoint"`
+	Request    []byte        `json:"request"`              // Request XML with the password redacted
+	StatusCode int           `json:"statusCode,omitempty"` // HTTP status, 0 if no response was received
+	Response   []byte        `json:"response,omitempty"`   // Response body as received
+	Duration   time.Duration `json:"duration"`
+	Err        string        `json:"error,omitempty"` // Transport error, if any
+}
+
+// Debug captures the raw requests and responses of API calls, with passwords
+// redacted, for diagnosing problems with the API or reporting them to
+// CereProc support. It is safe for concurrent use.
+type Debug struct {
+	Size int    // Exchanges kept in memory, DefaultDebugSize if 0
+	Dir  string // If set, every exchange is also written to this directory
+
+	// OnError, if set, receives errors writing to Dir, which are otherwise
+	// dropped
+	OnError func(err error)
+
+	mu        sync.Mutex
+	exchanges []DebugExchange
+	next      int
+	seq       uint64
+}
+
+// WithDebug captures the raw API exchanges of the Client in d
+func WithDebug(d *Debug) ClientOption {
+	return func(c *Client) {
+		c.Debug = d
+	}
+}
+
+// DebugDump returns the last exchanges captured by the Client Debug, oldest
+// first, or nil if it has none
+func (c *Client) DebugDump() []DebugExchange {
+	if c.Debug == nil {
+		return nil
+	}
+
+	return c.Debug.Exchanges()
+}
+
+// Exchanges returns the exchanges kept, oldest first
+func (d *Debug) Exchanges() []DebugExchange {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	exchanges := make([]DebugExchange, 0, len(d.exchanges))
+	exchanges = append(exchanges, d.exchanges[d.next:]...)
+	exchanges = append(exchanges, d.exchanges[:d.next]...)
+
+	return exchanges
+}
+
+// WriteFiles writes the exchanges kept to dir, as by Dir
+func (d *Debug) WriteFiles(dir string) error {
+	for i, e := range d.Exchanges() {
+		if err := writeExchange(dir, uint64(i+1), &e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// capture records one attempt of req
+func (d *Debug) capture(req *Request, endpoint string, status int, raw []byte, err error, start time.Time) {
+	e := DebugExchange{
+		Time:       start,
+		Operation:  req.XMLName.Local,
+		Endpoint:   endpoint,
+		Request:    encodeRequest(redacted(req), true),
+		StatusCode: status,
+		Response:   raw,
+		Duration:   time.Since(start),
+	}
+	if err != nil {
+		e.Err = err.Error()
+	}
+
+	d.mu.Lock()
+	size := d.Size
+	if size <= 0 {
+		size = DefaultDebugSize
+	}
+	if len(d.exchanges) < size {
+		d.exchanges = append(d.exchanges, e)
+	} else {
+		d.exchanges[d.next] = e
+		d.next = (d.next + 1) % len(d.exchanges)
+	}
+	d.seq++
+	seq := d.seq
+	d.mu.Unlock()
+
+	if d.Dir != "" {
+		if err := writeExchange(d.Dir, seq, &e); err != nil && d.OnError != nil {
+			d.OnError(err)
+		}
+	}
+}
+
+// writeExchange writes e to dir as a request and a response file named after
+// its time, sequence number and operation
+func writeExchange(dir string, seq uint64, e *DebugExchange) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%s-%04d-%s", e.Time.UTC().Format("20060102T150405.000Z"), seq, e.Operation))
+	if err := ioutil.WriteFile(base+".request.xml", e.Request, 0600); err != nil {
+		return err
+	}
+
+	response := e.Response
+	if e.Err != "" {
+		response = append(append([]byte(nil), response...), "\n<!-- error: "+strings.ReplaceAll(e.Err, "--", "- -")+" -->\n"...)
+	}
+
+	return ioutil.WriteFile(base+".response.xml", response, 0600)
+}
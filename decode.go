@@ -0,0 +1,266 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// DecodeMode controls how strictly API responses are decoded
+type DecodeMode int
+
+// Decode modes
+const (
+	// DecodeDefault requires well-formed XML and ignores unknown elements
+	DecodeDefault DecodeMode = iota
+	// DecodeStrict also requires the root element to match the operation
+	// and rejects elements the response type does not define, to detect API
+	// changes early
+	DecodeStrict
+	// DecodeLenient tolerates malformed markup, HTML entities and truncated
+	// responses, returning whatever fields could be decoded
+	DecodeLenient
+)
+
+// String returns the mode name
+func (m DecodeMode) String() string {
+	switch m {
+	case DecodeStrict:
+		return "strict"
+	case DecodeLenient:
+		return "lenient"
+	}
+
+	return "default"
+}
+
+// WithDecodeMode sets how strictly API responses are decoded
+func WithDecodeMode(mode DecodeMode) ClientOption {
+	return func(c *Client) {
+		c.DecodeMode = mode
+	}
+}
+
+// CharsetReaderFunc converts input in charset to UTF-8, as used by
+// xml.Decoder.CharsetReader
+type CharsetReaderFunc func(charset string, input io.Reader) (io.Reader, error)
+
+// WithCharsetReader sets the converter used for responses declaring an
+// encoding other than UTF-8, such as charset.NewReaderLabel from
+// golang.org/x/net/html/charset. ISO-8859-1, Windows-1252 and US-ASCII are
+// supported without one.
+func WithCharsetReader(fn CharsetReaderFunc) ClientOption {
+	return func(c *Client) {
+		c.CharsetReader = fn
+	}
+}
+
+// ErrUnexpectedResponse is returned in DecodeStrict mode when a response
+// does not have the expected structure
+var ErrUnexpectedResponse = errors.New("cerevoicego: unexpected response structure")
+
+// decode decodes an API response for operation into v using the Client's
+// DecodeMode and CharsetReader
+func (c *Client) decode(operation string, raw []byte, v interface{}) error {
+	if err := c.unmarshal(raw, v); err != nil {
+		return err
+	}
+
+	if c.DecodeMode == DecodeStrict {
+		return checkStructure(operation, c.newDecoder(raw), reflect.TypeOf(v))
+	}
+
+	return nil
+}
+
+// unmarshal decodes raw into v, tolerating malformed XML in DecodeLenient
+// mode
+func (c *Client) unmarshal(raw []byte, v interface{}) error {
+	if err := c.newDecoder(raw).Decode(v); err != nil {
+		var syntax *xml.SyntaxError
+		if c.DecodeMode == DecodeLenient && (errors.As(err, &syntax) || err == io.ErrUnexpectedEOF) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// newDecoder returns an XML decoder for raw using the Client's DecodeMode
+// and CharsetReader, or the built in one, which renames aliased elements
+func (c *Client) newDecoder(raw []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	dec.CharsetReader = c.CharsetReader
+	if dec.CharsetReader == nil {
+		dec.CharsetReader = charsetReader
+	}
+	if c.DecodeMode == DecodeLenient {
+		dec.Strict = false
+		dec.AutoClose = xml.HTMLAutoClose
+		dec.Entity = xml.HTMLEntity
+	}
+
+	return xml.NewTokenDecoder(&aliasReader{dec: dec, client: c})
+}
+
+// element describes the child elements allowed by a response type
+type element struct {
+	children map[string]*element
+	any      bool // any children are allowed
+}
+
+// resultElements may appear in any response, as failures of operations
+// without a result code still report one
+var resultElements = []string{"resultCode", "resultDescription"}
+
+// checkStructure checks the root element of the response is named for
+// operation and every element is defined by typ
+func checkStructure(operation string, dec *xml.Decoder, typ reflect.Type) error {
+	root := schema(typ)
+	for _, name := range resultElements {
+		if root.children[name] == nil {
+			root.children[name] = &element{}
+		}
+	}
+
+	var stack []*element
+	var path []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var el *element
+			switch {
+			case len(stack) == 0:
+				if want := operation + "Response"; t.Name.Local != want {
+					return fmt.Errorf("%w: root element <%s>, expected <%s>", ErrUnexpectedResponse, t.Name.Local, want)
+				}
+				el = root
+			case stack[len(stack)-1].any:
+				el = &element{any: true}
+			default:
+				el = stack[len(stack)-1].children[t.Name.Local]
+				if el == nil {
+					return fmt.Errorf("%w: unexpected element <%s> in <%s>", ErrUnexpectedResponse, t.Name.Local, strings.Join(path, "/"))
+				}
+			}
+			stack = append(stack, el)
+			path = append(path, t.Name.Local)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			path = path[:len(path)-1]
+		}
+	}
+}
+
+// schema returns the elements allowed by the xml tags of typ
+func schema(typ reflect.Type) *element {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+
+	el := &element{children: make(map[string]*element)}
+	if typ.Kind() != reflect.Struct {
+		return el
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		tag := f.Tag.Get("xml")
+		name := strings.Split(tag, ",")[0]
+		switch {
+		case tag == "-" || strings.Contains(tag, ",attr") || strings.Contains(tag, ",chardata"):
+			continue
+		case strings.Contains(tag, ",any") || strings.Contains(tag, ",innerxml"):
+			el.any = true
+			continue
+		case f.Name == "XMLName":
+			continue
+		case name == "":
+			name = f.Name
+		}
+
+		// Nested paths such as voicesList>voice
+		parent := el
+		segments := strings.Split(name, ">")
+		for _, s := range segments[:len(segments)-1] {
+			if parent.children[s] == nil {
+				parent.children[s] = &element{children: make(map[string]*element)}
+			}
+			parent = parent.children[s]
+		}
+		parent.children[segments[len(segments)-1]] = schema(f.Type)
+	}
+
+	return el
+}
+
+// charsetReader converts the single byte charsets likely to be declared by
+// the API to UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "l1":
+		return &singleByteReader{r: input}, nil
+	case "windows-1252", "cp1252":
+		return &singleByteReader{r: input, high: &windows1252}, nil
+	}
+
+	return nil, fmt.Errorf("cerevoicego: unsupported charset %q, set a CharsetReader", charset)
+}
+
+// windows1252 maps bytes 0x80-0x9f to runes, other bytes match ISO-8859-1
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// singleByteReader converts a single byte charset to UTF-8
+type singleByteReader struct {
+	r    io.Reader
+	high *[32]rune // runes for 0x80-0x9f, ISO-8859-1 if nil
+	buf  []byte    // converted bytes not yet read
+}
+
+func (s *singleByteReader) Read(p []byte) (int, error) {
+	if len(s.buf) == 0 {
+		in := make([]byte, len(p)/2+1)
+		n, err := s.r.Read(in)
+		var enc [utf8.UTFMax]byte
+		for _, b := range in[:n] {
+			r := rune(b)
+			if s.high != nil && b >= 0x80 && b <= 0x9f {
+				r = s.high[b-0x80]
+			}
+			s.buf = append(s.buf, enc[:utf8.EncodeRune(enc[:], r)]...)
+		}
+		if n == 0 {
+			return 0, err
+		}
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
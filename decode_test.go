@@ -0,0 +1,205 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var decodeSeeds = []string{
+	`<?xml version="1.0" encoding="UTF-8"?><speakExtendedResponse><fileUrl>https://cerevoice.s3.amazonaws.com/a.wav</fileUrl><charCount>11</charCount><resultCode>1</resultCode><resultDescription>OK</resultDescription><metadataUrl></metadataUrl></speakExtendedResponse>`,
+	`<listVoicesResponse><voicesList><voice><sampleRate>48000</sampleRate><voiceName>Heather</voiceName><languageCodeISO>en</languageCodeISO></voice></voicesList></listVoicesResponse>`,
+	`<getCreditResponse><credit><freeCredit>0</freeCredit><paidCredit>10.00</paidCredit><charsAvailable>500000</charsAvailable></credit></getCreditResponse>`,
+	`<?xml version="1.0" encoding="ISO-8859-1"?><speakSimpleResponse><fileUrl>caf` + "\xe9" + `</fileUrl><resultCode>0</resultCode><resultDescription>Invalid voice</resultDescription></speakSimpleResponse>`,
+	`<speakSimpleResponse><fileUrl>a&nbsp;b<br></fileUrl><resultCode>1`,
+	`<html><body>502 Bad Gateway</body></html>`,
+	``,
+}
+
+// FuzzDecode checks no response, however malformed, makes decoding panic in
+// any mode
+func FuzzDecode(f *testing.F) {
+	for _, seed := range decodeSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		for _, mode := range []DecodeMode{DecodeDefault, DecodeStrict, DecodeLenient} {
+			c := &Client{DecodeMode: mode}
+			c.checkResult("speakExtended", &Response{Raw: raw, StatusCode: 200})
+			c.decode("speakExtended", raw, &SpeakExtendedResponse{})
+			c.decode("listVoices", raw, &ListVoicesResponse{})
+			c.decode("getCredit", raw, &GetCreditResponse{})
+		}
+	})
+}
+
+// FuzzParseMetadata checks malformed metadata never makes parsing panic
+func FuzzParseMetadata(f *testing.F) {
+	f.Add([]byte(`<trans><word name="hello" start="0.050" end="0.420"/><phone start="0.1" end="0.2">h</phone></trans>`))
+	f.Add([]byte(`<trans><word start="x" end="1"/></trans>`))
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		m, err := ParseMetadata(bytes.NewReader(raw))
+		if err == nil && m == nil {
+			t.Fatal("nil metadata without error")
+		}
+	})
+}
+
+// FuzzCharsetReader checks single byte charsets always convert to valid
+// UTF-8 with one rune per input byte
+func FuzzCharsetReader(f *testing.F) {
+	f.Add([]byte("caf\xe9 \x80\x9f"))
+
+	f.Fuzz(func(t *testing.T, in []byte) {
+		for _, charset := range []string{"ISO-8859-1", "windows-1252"} {
+			r, err := charsetReader(charset, bytes.NewReader(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !utf8.Valid(out) || utf8.RuneCount(out) != len(in) {
+				t.Fatalf("%s: %q converted to %q", charset, in, out)
+			}
+		}
+	})
+}
+
+// speakShapes are speakExtended responses in the shapes seen from different
+// API versions and deployments, all decoding to speakWant
+var speakShapes = []struct {
+	name   string
+	raw    string
+	strict bool // decodes in DecodeStrict mode
+}{
+	{"current", `<?xml version="1.0" encoding="UTF-8"?><speakExtendedResponse><fileUrl>https://cerevoice.s3.amazonaws.com/a.wav</fileUrl><charCount>11</charCount><resultCode>1</resultCode><resultDescription>OK</resultDescription><metadataUrl>https://cerevoice.s3.amazonaws.com/a.xml</metadataUrl></speakExtendedResponse>`, true},
+	{"default namespace", `<speakExtendedResponse xmlns="http://www.cereproc.com/cloud"><fileUrl>https://cerevoice.s3.amazonaws.com/a.wav</fileUrl><charCount>11</charCount><resultCode>1</resultCode><resultDescription>OK</resultDescription><metadataUrl>https://cerevoice.s3.amazonaws.com/a.xml</metadataUrl></speakExtendedResponse>`, true},
+	{"prefixed namespace", `<cv:speakExtendedResponse xmlns:cv="http://www.cereproc.com/cloud"><cv:fileUrl>https://cerevoice.s3.amazonaws.com/a.wav</cv:fileUrl><cv:charCount>11</cv:charCount><cv:resultCode>1</cv:resultCode><cv:resultDescription>OK</cv:resultDescription><cv:metadataUrl>https://cerevoice.s3.amazonaws.com/a.xml</cv:metadataUrl></cv:speakExtendedResponse>`, true},
+	{"undeclared prefix", `<cv:speakExtendedResponse><cv:fileUrl>https://cerevoice.s3.amazonaws.com/a.wav</cv:fileUrl><cv:charCount>11</cv:charCount><cv:resultCode>1</cv:resultCode><cv:resultDescription>OK</cv:resultDescription><cv:metadataUrl>https://cerevoice.s3.amazonaws.com/a.xml</cv:metadataUrl></cv:speakExtendedResponse>`, true},
+	{"old element names", `<speakExtendedResponse><fileURL>https://cerevoice.s3.amazonaws.com/a.wav</fileURL><charCount>11</charCount><resultCode>1</resultCode><resultDescription>OK</resultDescription><metadata>https://cerevoice.s3.amazonaws.com/a.xml</metadata></speakExtendedResponse>`, true},
+	{"unknown elements", `<speakExtendedResponse><requestId>7f3a</requestId><fileUrl>https://cerevoice.s3.amazonaws.com/a.wav</fileUrl><charCount>11</charCount><resultCode>1</resultCode><resultDescription>OK</resultDescription><metadataUrl>https://cerevoice.s3.amazonaws.com/a.xml</metadataUrl><audio><duration>0.9</duration></audio></speakExtendedResponse>`, false},
+	{"whitespace and comments", "<speakExtendedResponse>\n  <!-- generated -->\n  <fileUrl>https://cerevoice.s3.amazonaws.com/a.wav</fileUrl>\n  <charCount>11</charCount>\n  <resultCode>1</resultCode>\n  <resultDescription>OK</resultDescription>\n  <metadataUrl><![CDATA[https://cerevoice.s3.amazonaws.com/a.xml]]></metadataUrl>\n</speakExtendedResponse>\n", true},
+}
+
+func TestDecodeResponseShapes(t *testing.T) {
+	want := SpeakExtendedResponse{
+		FileURL:           "https://cerevoice.s3.amazonaws.com/a.wav",
+		CharCount:         "11",
+		ResultCode:        ResultSuccess,
+		ResultDescription: "OK",
+		Metadata:          "https://cerevoice.s3.amazonaws.com/a.xml",
+		ResponseMeta:      ResponseMeta{Chars: 11},
+	}
+
+	for _, shape := range speakShapes {
+		for _, mode := range []DecodeMode{DecodeDefault, DecodeStrict, DecodeLenient} {
+			c := &Client{DecodeMode: mode}
+
+			if _, err := c.checkResult("speakExtended", &Response{Raw: []byte(shape.raw), StatusCode: 200}); err != nil {
+				t.Errorf("%s, %s: checkResult: %v", shape.name, mode, err)
+			}
+
+			var got SpeakExtendedResponse
+			err := c.decode("speakExtended", []byte(shape.raw), &got)
+			if mode == DecodeStrict && !shape.strict {
+				if !errors.Is(err, ErrUnexpectedResponse) {
+					t.Errorf("%s, %s: got error %v, want ErrUnexpectedResponse", shape.name, mode, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s, %s: %v", shape.name, mode, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s, %s: got %+v, want %+v", shape.name, mode, got, want)
+			}
+		}
+	}
+}
+
+func TestDecodeListShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"current", `<listVoicesResponse><voicesList><voice><sampleRate>48000</sampleRate><voiceName>Heather</voiceName></voice><voice><sampleRate>22050</sampleRate><voiceName>William</voiceName></voice></voicesList></listVoicesResponse>`},
+		{"namespaced", `<listVoicesResponse xmlns="http://www.cereproc.com/cloud" xmlns:v="http://www.cereproc.com/voice"><voicesList><v:voice><v:sampleRate>48000</v:sampleRate><v:voiceName>Heather</v:voiceName></v:voice><v:voice><v:sampleRate>22050</v:sampleRate><v:voiceName>William</v:voiceName></v:voice></voicesList></listVoicesResponse>`},
+		{"old list name", `<listVoicesResponse><voiceList><voice><sampleRate>48000</sampleRate><voiceName>Heather</voiceName></voice><voice><sampleRate>22050</sampleRate><voiceName>William</voiceName></voice></voiceList></listVoicesResponse>`},
+		{"unknown elements", `<listVoicesResponse><count>2</count><voicesList><voice><sampleRate>48000</sampleRate><voiceName>Heather</voiceName><style>neutral</style></voice><voice><sampleRate>22050</sampleRate><voiceName>William</voiceName></voice></voicesList></listVoicesResponse>`},
+	}
+
+	want := []Voice{
+		{SampleRate: "48000", VoiceName: "Heather", Hz: 48000},
+		{SampleRate: "22050", VoiceName: "William", Hz: 22050},
+	}
+	for _, tt := range tests {
+		var got ListVoicesResponse
+		if err := (&Client{}).decode("listVoices", []byte(tt.raw), &got); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.VoiceList, want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got.VoiceList, want)
+		}
+	}
+}
+
+func TestElementAliases(t *testing.T) {
+	raw := []byte(`<speakSimpleResponse><audioUrl>https://cerevoice.s3.amazonaws.com/a.ogg</audioUrl><resultCode>1</resultCode></speakSimpleResponse>`)
+
+	c := NewClient("", "", WithDecodeMode(DecodeStrict), WithElementAliases(map[string]string{
+		"audioUrl": "fileUrl",
+	}))
+	var got SpeakSimpleResponse
+	if err := c.decode("speakSimple", raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.FileURL != "https://cerevoice.s3.amazonaws.com/a.ogg" {
+		t.Errorf("got FileURL %q", got.FileURL)
+	}
+}
+
+func TestParsedFieldsJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"voice", &Voice{SampleRate: "48000", Hz: 48000}, `"hz":48000`},
+		{"lexicon", &Lexicon{Size: "1024", Bytes: 1024}, `"bytes":1024`},
+		{"credit", &Credit{FreeCredit: "10.5", Free: 1050, Paid: 2, Available: 300}, `"free":10.50,"paid":0.02,"available":300`},
+	}
+
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.v)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !strings.Contains(string(b), tt.want) {
+			t.Errorf("%s: JSON %s, want %s", tt.name, b, tt.want)
+		}
+
+		// The typed fields survive a round trip without the raw strings
+		decoded := reflect.New(reflect.TypeOf(tt.v).Elem()).Interface()
+		if err := json.Unmarshal([]byte("{"+tt.want+"}"), decoded); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		again, _ := json.Marshal(decoded)
+		if !strings.Contains(string(again), tt.want) {
+			t.Errorf("%s: decoded JSON %s, want %s", tt.name, again, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// SpeakGroup collapses identical speak requests made at the same time into a
+// single API request, whose result is shared, so bursts of the same prompt
+// are only billed once. Requests are identical when their input, account and
+// API URL are, so share a SpeakGroup only between Clients which prepare text
+// and audio the same way. It is safe for concurrent use.
+type SpeakGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+	stats SpeakGroupStats
+}
+
+// SpeakGroupStats contains counts of the requests made through a SpeakGroup
+type SpeakGroupStats struct {
+	Calls  uint64 // Requests made rather than shared
+	Shared uint64 // Requests given the result of another
+}
+
+// flight is a request in progress, shared by identical requests
+type flight struct {
+	done chan struct{}
+	v    interface{}
+	err  error
+}
+
+// WithDeduplication shares one API request between identical SpeakAudio,
+// SpeakSimple and SpeakExtended calls in progress at the same time, using g
+func WithDeduplication(g *SpeakGroup) ClientOption {
+	return func(c *Client) {
+		c.Dedup = g
+	}
+}
+
+// Stats returns the counts so far
+func (g *SpeakGroup) Stats() SpeakGroupStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.stats
+}
+
+// do calls fn once for the callers with the same key at the same time,
+// returning its result to each of them. shared reports whether another
+// caller's result was returned. A caller waiting on a request cancelled by
+// the caller which made it makes its own.
+func (g *SpeakGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (v interface{}, shared bool, err error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*flight)
+		}
+		f, ok := g.calls[key]
+		if !ok {
+			f = &flight{done: make(chan struct{})}
+			g.calls[key] = f
+			g.stats.Calls++
+		}
+		g.mu.Unlock()
+
+		if !ok {
+			f.v, f.err = fn(ctx)
+
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(f.done)
+
+			return f.v, false, f.err
+		}
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+
+		if f.err != nil && ctx.Err() == nil &&
+			(errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)) {
+			continue
+		}
+
+		g.mu.Lock()
+		g.stats.Shared++
+		g.mu.Unlock()
+
+		return f.v, true, f.err
+	}
+}
+
+// deduplicate calls fn through the Dedup group, if there is one, keyed by
+// op and input
+func (c *Client) deduplicate(ctx context.Context, op string, input *SpeakExtendedInput, fn func(ctx context.Context) (interface{}, error)) (v interface{}, shared bool, err error) {
+	if c.Dedup != nil {
+		if key, ok := c.dedupKey(ctx, op, input); ok {
+			return c.Dedup.do(ctx, key, fn)
+		}
+	}
+
+	v, err = fn(ctx)
+	return v, false, err
+}
+
+// dedupKey identifies a speak request to a SpeakGroup. It reports false if
+// the account making the request is not known.
+func (c *Client) dedupKey(ctx context.Context, op string, input *SpeakExtendedInput) (string, bool) {
+	key, ok := c.cacheKey(ctx, input)
+	if !ok {
+		return "", false
+	}
+
+	return op + "\x00" + strconv.FormatBool(input.Metadata) + "\x00" + key, true
+}
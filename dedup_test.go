@@ -0,0 +1,136 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+// gateClient holds the first API request until its context ends, and counts
+// the API requests
+type gateClient struct {
+	next    *http.Client
+	arrived chan struct{}
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (g *gateClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/rest" {
+		g.mu.Lock()
+		g.calls++
+		first := g.calls == 1
+		g.mu.Unlock()
+		if first {
+			close(g.arrived)
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+	}
+	return g.next.Do(req)
+}
+
+func (g *gateClient) count() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+func TestDeduplicationCopiesAudio(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	cache := cerevoicego.NewMemoryCache(0, 0, 0)
+	c := srv.Client()
+	c.Apply(cerevoicego.WithDeduplication(&cerevoicego.SpeakGroup{}), cerevoicego.WithCache(cache))
+
+	input := &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}
+	results := make([][]byte, 8)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			audio, err := c.SpeakAudio(input)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results[i] = audio
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("%d API requests, want 1", n)
+	}
+	want := append([]byte(nil), results[0]...)
+
+	// Changing one caller's audio changes no other's, nor the cache's
+	for i := range results {
+		for j := range results[i] {
+			results[i][j] = byte(i)
+		}
+	}
+	for i, audio := range results {
+		if !bytes.Equal(audio, bytes.Repeat([]byte{byte(i)}, len(want))) {
+			t.Errorf("results[%d] was changed by another caller", i)
+		}
+	}
+	cached, err := c.SpeakAudio(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cached, want) {
+		t.Error("cached audio was changed by a caller")
+	}
+}
+
+func TestDeduplicationLeaderCancelled(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	gate := &gateClient{next: srv.Server.Client(), arrived: make(chan struct{})}
+	group := &cerevoicego.SpeakGroup{}
+	c := srv.Client()
+	c.Apply(cerevoicego.WithHTTPClient(gate), cerevoicego.WithDeduplication(group))
+	input := &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := c.SpeakAudioWithContext(ctx, input)
+		leader <- err
+	}()
+	<-gate.arrived
+
+	follower := make(chan error, 1)
+	go func() {
+		_, err := c.SpeakAudio(input)
+		follower <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader = %v, want context.Canceled", err)
+	}
+	if err := <-follower; err != nil {
+		t.Errorf("follower = %v, want its own request to succeed", err)
+	}
+	if n := gate.count(); n != 2 {
+		t.Errorf("%d API requests, want 2", n)
+	}
+	if shared := group.Stats().Shared; shared != 0 {
+		t.Errorf("Shared = %d, want the cancelled result not shared", shared)
+	}
+}
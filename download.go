@@ -0,0 +1,227 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Download retrieves the synthesised audio file. The caller must close the
+// returned ReadCloser.
+func (r *SpeakSimpleResponse) Download(ctx context.Context) (io.ReadCloser, error) {
+	return download(ctx, r.client, r.FileURL)
+}
+
+// Download retrieves the synthesised audio file. The caller must close the
+// returned ReadCloser.
+func (r *SpeakExtendedResponse) Download(ctx context.Context) (io.ReadCloser, error) {
+	return download(ctx, r.client, r.FileURL)
+}
+
+// SpeakTo synthesises input and streams the audio into w as it is
+// downloaded, without buffering the whole file in memory unless the Client
+// has AudioEffects to apply or VerifyAudio set
+func (c *Client) SpeakTo(w io.Writer, input *SpeakExtendedInput) (*SpeakExtendedResponse, error) {
+	return c.SpeakToWithContext(context.Background(), w, input)
+}
+
+// SpeakToWithContext is the same as SpeakTo with the addition of the ability
+// to pass a context for cancellation and timeouts
+func (c *Client) SpeakToWithContext(ctx context.Context, w io.Writer, input *SpeakExtendedInput) (*SpeakExtendedResponse, error) {
+	input = c.profile(input)
+	if err := c.checkEffects(input); err != nil {
+		return nil, err
+	}
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	ctx, cancel := c.budget(ctx)
+	defer cancel()
+
+	if c.splitLong(input) {
+		return c.speakSplitTo(ctx, w, input)
+	}
+
+	r, err := c.SpeakExtendedWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.VerifyAudio {
+		audio, err := c.readAudio(ctx, r, input)
+		if err == nil {
+			audio, err = c.postProcess(audio)
+		}
+		if err != nil {
+			return r, err
+		}
+		_, err = w.Write(audio)
+		return r, err
+	}
+
+	body, err := r.Download(ctx)
+	if err != nil {
+		return r, err
+	}
+	defer body.Close()
+
+	if len(c.AudioEffects) > 0 {
+		return r, c.copyProcessed(w, body)
+	}
+
+	_, err = io.Copy(w, body)
+	return r, err
+}
+
+// speakSplitTo writes the joined audio of input synthesised in several
+// requests to w. The response has the total charCount and no FileURL, as no
+// single file holds the audio.
+func (c *Client) speakSplitTo(ctx context.Context, w io.Writer, input *SpeakExtendedInput) (*SpeakExtendedResponse, error) {
+	long, err := c.speakSplit(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	r := &SpeakExtendedResponse{
+		CharCount:         strconv.Itoa(long.CharCount),
+		ResponseMeta:      ResponseMeta{Chars: long.CharCount},
+		ResultCode:        ResultSuccess,
+		ResultDescription: long.Chunks[0].ResultDescription,
+	}
+
+	audio, err := c.postProcess(long.Audio)
+	if err != nil {
+		return r, err
+	}
+
+	_, err = w.Write(audio)
+	return r, err
+}
+
+// SpeakToFile synthesises input text and writes the resulting audio to path
+func (c *Client) SpeakToFile(input *SpeakExtendedInput, path string) (*SpeakExtendedResponse, error) {
+	return c.SpeakToFileWithContext(context.Background(), input, path)
+}
+
+// SpeakToFileWithContext is the same as SpeakToFile with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) SpeakToFileWithContext(ctx context.Context, input *SpeakExtendedInput, path string) (*SpeakExtendedResponse, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.SpeakToWithContext(ctx, f, input)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return r, err
+	}
+
+	return r, f.Close()
+}
+
+// DownloadLexicon retrieves the contents of a lexicon file returned by
+// ListLexicons. The caller must close the returned ReadCloser.
+func (c *Client) DownloadLexicon(lexicon *Lexicon) (io.ReadCloser, error) {
+	return c.DownloadLexiconWithContext(context.Background(), lexicon)
+}
+
+// DownloadLexiconWithContext is the same as DownloadLexicon with the addition
+// of the ability to pass a context for cancellation and timeouts
+func (c *Client) DownloadLexiconWithContext(ctx context.Context, lexicon *Lexicon) (io.ReadCloser, error) {
+	return fetch(ctx, c.fileClient(), lexicon.URL, isTextContentType)
+}
+
+// DownloadAbbreviations retrieves the contents of an abbreviation file
+// returned by ListAbbreviations. The caller must close the returned
+// ReadCloser.
+func (c *Client) DownloadAbbreviations(abbreviation *Abbreviation) (io.ReadCloser, error) {
+	return c.DownloadAbbreviationsWithContext(context.Background(), abbreviation)
+}
+
+// DownloadAbbreviationsWithContext is the same as DownloadAbbreviations with
+// the addition of the ability to pass a context for cancellation and timeouts
+func (c *Client) DownloadAbbreviationsWithContext(ctx context.Context, abbreviation *Abbreviation) (io.ReadCloser, error) {
+	return fetch(ctx, c.fileClient(), abbreviation.URL, isTextContentType)
+}
+
+// download fetches url and verifies the response looks like audio
+func download(ctx context.Context, client HTTPClient, url string) (io.ReadCloser, error) {
+	return fetch(ctx, client, url, isAudioContentType)
+}
+
+// fetch retrieves url, rejecting the response if its content type is not
+// accepted
+func fetch(ctx context.Context, client HTTPClient, url string, accept func(ct string) bool) (io.ReadCloser, error) {
+	if url == "" {
+		return nil, errors.New("cerevoicego: no URL to download")
+	}
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("cerevoicego: downloading %s: %s", url, resp.Status)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); !accept(ct) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("cerevoicego: downloading %s: unexpected content type %q", url, ct)
+	}
+
+	return resp.Body, nil
+}
+
+// isAudioContentType reports whether ct is acceptable for an audio file.
+// Servers that omit the header or send a generic binary type are accepted.
+func isAudioContentType(ct string) bool {
+	if ct == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "audio/") ||
+		mediaType == "application/ogg" ||
+		mediaType == "application/octet-stream"
+}
+
+// isTextContentType reports whether ct is acceptable for a lexicon or
+// abbreviation file
+func isTextContentType(ct string) bool {
+	if ct == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+
+	return mediaType != "text/html"
+}
@@ -0,0 +1,356 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bganderson/cerevoicego/internal/atomicfile"
+)
+
+// DefaultDownloadAttempts is the default number of attempts made per file by
+// DownloadFiles, each resuming where the last was interrupted
+const DefaultDownloadAttempts = 3
+
+// ErrChecksum is returned when a downloaded file does not match its SHA256
+var ErrChecksum = errors.New("cerevoicego: checksum mismatch")
+
+// DownloadItem is one file for DownloadFiles
+type DownloadItem struct {
+	URL    string // Audio URL, e.g. SpeakExtendedResponse.FileURL
+	Path   string // Destination file
+	SHA256 string // Expected hex digest of the file, not checked if empty
+}
+
+// DownloadInput contains DownloadFiles parameters
+type DownloadInput struct {
+	Items       []DownloadItem
+	Concurrency int // Maximum concurrent downloads, DefaultConcurrency if 0
+	Attempts    int // Attempts per file, DefaultDownloadAttempts if 0
+
+	// OnProgress, if set, receives events as each file is downloaded
+	OnProgress ProgressFunc
+
+	// Progress, if set, is called as each file is downloaded. Calls are not
+	// concurrent.
+	//
+	// Deprecated: use OnProgress, which reports every long operation alike
+	Progress func(p DownloadProgress)
+}
+
+// DownloadProgress reports how far DownloadFiles has got.
+//
+// Deprecated: use ProgressEvent
+type DownloadProgress struct {
+	Item       int    // Index of the item which progressed
+	Path       string // Its destination
+	Bytes      int64  // Its bytes written so far, including any resumed
+	Size       int64  // Its total size, -1 if not yet known
+	Done       bool   // The item has finished
+	Err        error  // Why the item failed, if Done
+	ItemsDone  int    // Items finished so far
+	Items      int    // Total items
+	TotalBytes int64  // Bytes downloaded so far across all items
+}
+
+// DownloadResult is the outcome of one item of DownloadFiles
+type DownloadResult struct {
+	Path    string
+	Bytes   int64  // Size of the file
+	SHA256  string // Hex digest of the file
+	Resumed bool   // A partial download was resumed
+	Err     error
+}
+
+// DownloadFiles downloads the items concurrently, such as the audio of a
+// batch. Each file is written to its path with ".part" appended and renamed
+// once complete and verified. Interrupted transfers are resumed with Range
+// requests, within a call or from a .part left by an earlier one. The ETag or
+// Last-Modified of the file is kept beside the .part with ".validator"
+// appended and sent as If-Range, so a file which changed is downloaded
+// again rather than spliced; a .part without one is not resumed. The results
+// are in the order of the items, and an error is returned if any failed.
+func (c *Client) DownloadFiles(ctx context.Context, input *DownloadInput) ([]DownloadResult, error) {
+	concurrency := input.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	results := make([]DownloadResult, len(input.Items))
+	prog := newProgressTracker(input.progressFunc(), ProgressDownload, len(input.Items), 0)
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range input.Items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			res := &results[i]
+			res.Path = input.Items[i].Path
+			select {
+			case sem <- struct{}{}:
+				c.downloadFile(ctx, input, i, res, prog)
+				<-sem
+			case <-ctx.Done():
+				res.Err = ctx.Err()
+			}
+			prog.finish(i, res.Path, 0, res.Bytes, res.Err)
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	var first error
+	for _, res := range results {
+		if res.Err != nil {
+			if first == nil {
+				first = res.Err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("cerevoicego: %d of %d downloads failed: %w", failed, len(results), first)
+	}
+
+	return results, nil
+}
+
+// downloadFile downloads item i, resuming after interruptions
+func (c *Client) downloadFile(ctx context.Context, input *DownloadInput, i int, res *DownloadResult, prog *progressTracker) {
+	item := input.Items[i]
+	attempts := input.Attempts
+	if attempts <= 0 {
+		attempts = DefaultDownloadAttempts
+	}
+
+	part := item.Path + ".part"
+	validator := readValidator(part) // ETag or Last-Modified of the part
+	for attempt := 1; ; attempt++ {
+		var resumed bool
+		resumed, validator, res.Err = c.downloadPart(ctx, item.URL, part, validator, func(n, size int64) {
+			prog.update(i, item.Path, n, size)
+		})
+		res.Resumed = res.Resumed || resumed
+		if res.Err == nil || attempt >= attempts || ctx.Err() != nil || !resumable(res.Err) {
+			break
+		}
+	}
+	if res.Err != nil {
+		return
+	}
+
+	res.Bytes, res.SHA256, res.Err = hashFile(part)
+	if res.Err != nil {
+		return
+	}
+	if item.SHA256 != "" && !strings.EqualFold(item.SHA256, res.SHA256) {
+		os.Remove(part)
+		os.Remove(part + ".validator")
+		res.Err = fmt.Errorf("%w: %s is %s, expected %s", ErrChecksum, item.Path, res.SHA256, item.SHA256)
+		return
+	}
+
+	if res.Err = os.Rename(part, item.Path); res.Err == nil {
+		os.Remove(part + ".validator")
+	}
+}
+
+// downloadPart appends the rest of url to part, returning whether an
+// existing part was resumed and the validator identifying the file. A part
+// is only resumed with the validator of the file it holds.
+func (c *Client) downloadPart(ctx context.Context, url, part, validator string, progress func(n, size int64)) (bool, string, error) {
+	f, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return false, validator, err
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, validator, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return false, validator, err
+	}
+	if offset > 0 && validator != "" {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		req.Header.Set("If-Range", validator)
+	}
+
+	resp, err := c.fileClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return false, validator, &interruptedError{err: err}
+	}
+	defer resp.Body.Close()
+
+	resumed := false
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && req.Header.Get("Range") != "":
+		resumed = true
+	case resp.StatusCode == http.StatusOK:
+		// Not resumable, the server ignored the range or the file changed,
+		// so start again and keep the validator of the new file
+		if err := f.Truncate(0); err != nil {
+			return false, validator, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return false, validator, err
+		}
+		offset = 0
+		validator = responseValidator(resp)
+		if err := writeValidator(part, validator); err != nil {
+			return false, validator, err
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && req.Header.Get("Range") != "":
+		// The part is already complete
+		return true, validator, nil
+	default:
+		return false, validator, fmt.Errorf("cerevoicego: downloading %s: %s", url, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !isAudioContentType(ct) {
+		return false, validator, fmt.Errorf("cerevoicego: downloading %s: unexpected content type %q", url, ct)
+	}
+
+	size := int64(-1)
+	if resp.ContentLength >= 0 {
+		size = offset + resp.ContentLength
+	}
+
+	n := offset
+	progress(n, size)
+	buf := make([]byte, 32*1024)
+	for {
+		m, rerr := resp.Body.Read(buf)
+		if m > 0 {
+			if _, err := f.Write(buf[:m]); err != nil {
+				return resumed, validator, err
+			}
+			n += int64(m)
+			progress(n, size)
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return resumed, validator, &interruptedError{err: rerr}
+		}
+	}
+	if size >= 0 && n < size {
+		return resumed, validator, &interruptedError{err: io.ErrUnexpectedEOF}
+	}
+
+	return resumed, validator, nil
+}
+
+// responseValidator returns the ETag or Last-Modified identifying the file
+// of resp for If-Range, empty if it has neither. Weak ETags cannot be used.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+
+	return resp.Header.Get("Last-Modified")
+}
+
+// readValidator returns the validator kept beside part, empty if there is
+// none
+func readValidator(part string) string {
+	b, err := ioutil.ReadFile(part + ".validator")
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+// writeValidator keeps the validator of part beside it, or removes it if
+// there is none so the part is not resumed
+func writeValidator(part, validator string) error {
+	path := part + ".validator"
+	if validator == "" {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return atomicfile.WriteFile(path, []byte(validator), 0644)
+}
+
+// interruptedError is a transfer which stopped part way and can be resumed
+type interruptedError struct {
+	err error
+}
+
+func (e *interruptedError) Error() string {
+	return "cerevoicego: download interrupted: " + e.err.Error()
+}
+
+func (e *interruptedError) Unwrap() error {
+	return e.err
+}
+
+// resumable reports whether a download failing with err should be resumed
+func resumable(err error) bool {
+	var interrupted *interruptedError
+	return errors.As(err, &interrupted)
+}
+
+// hashFile returns the size and SHA256 of the file at path
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// progressFunc returns the ProgressFunc reporting to OnProgress and
+// Progress, nil if neither is set
+func (in *DownloadInput) progressFunc() ProgressFunc {
+	if in.Progress == nil {
+		return in.OnProgress
+	}
+
+	return func(e ProgressEvent) {
+		if in.OnProgress != nil {
+			in.OnProgress(e)
+		}
+		in.Progress(DownloadProgress{
+			Item:       e.Item,
+			Path:       e.ID,
+			Bytes:      e.Bytes,
+			Size:       e.Size,
+			Done:       e.Done,
+			Err:        e.Err,
+			ItemsDone:  e.ItemsDone,
+			Items:      e.Items,
+			TotalBytes: e.TotalBytes,
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+func TestDownloadResumeValidator(t *testing.T) {
+	audio := bytes.Repeat([]byte("0123456789"), 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "audio.wav", time.Time{}, bytes.NewReader(audio))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		part      []byte
+		validator string // kept beside the part, none if empty
+		resumed   bool
+	}{
+		{"same file", audio[:300], `"v2"`, true},
+		{"changed file", bytes.Repeat([]byte("x"), 300), `"v1"`, false},
+		{"no validator", bytes.Repeat([]byte("x"), 300), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audio.wav")
+			if err := ioutil.WriteFile(path+".part", tt.part, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.validator != "" {
+				if err := ioutil.WriteFile(path+".part.validator", []byte(tt.validator), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c := cerevoicego.NewClient("account", "password")
+			results, err := c.DownloadFiles(context.Background(), &cerevoicego.DownloadInput{
+				Items: []cerevoicego.DownloadItem{{URL: srv.URL, Path: path}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if results[0].Resumed != tt.resumed {
+				t.Errorf("Resumed = %t, want %t", results[0].Resumed, tt.resumed)
+			}
+			got, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, audio) {
+				t.Errorf("downloaded %q..., want %q...", got[:20], audio[:20])
+			}
+			if _, err := os.Stat(path + ".part.validator"); !os.IsNotExist(err) {
+				t.Errorf("validator kept after the download: %v", err)
+			}
+		})
+	}
+}
+
+func TestDownloadKeepsValidatorForResume(t *testing.T) {
+	audio := bytes.Repeat([]byte("0123456789"), 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", "1000")
+		w.Write(audio[:400]) // cut short
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "audio.wav")
+	c := cerevoicego.NewClient("account", "password")
+	if _, err := c.DownloadFiles(context.Background(), &cerevoicego.DownloadInput{
+		Items:    []cerevoicego.DownloadItem{{URL: srv.URL, Path: path}},
+		Attempts: 1,
+	}); err == nil {
+		t.Fatal("interrupted download succeeded")
+	}
+
+	b, err := ioutil.ReadFile(path + ".part.validator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"v1"` {
+		t.Errorf("validator = %s, want \"v1\"", b)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+// ErrUnsupportedEmotion is returned under WithEmotionCheck when the voice is
+// not known to have the requested Emotion
+var ErrUnsupportedEmotion = errors.New("cerevoicego: emotion not supported by voice")
+
+// knownEmotions lists the emotional styles of CereProc voices known to have
+// them, by lower case voice name. It is never modified.
+var knownEmotions = map[string][]ssml.Emotion{
+	"caitlin": ssml.Emotions,
+	"heather": ssml.Emotions,
+	"jess":    ssml.Emotions,
+	"sarah":   ssml.Emotions,
+	"william": ssml.Emotions,
+}
+
+// EmotionalVoices returns a copy of the emotional styles of CereProc voices
+// known to have them, by lower case voice name. listVoices does not report
+// them, so add voices published by CereProc since, or your own, to the copy
+// and set it with WithEmotionalVoices.
+func EmotionalVoices() map[string][]ssml.Emotion {
+	return copyEmotions(knownEmotions)
+}
+
+// WithEmotionalVoices sets the emotional styles of voices, by voice name,
+// used by WithEmotionCheck in place of EmotionalVoices. voices is copied, so
+// changing it afterwards does not affect the Client.
+func WithEmotionalVoices(voices map[string][]ssml.Emotion) ClientOption {
+	return func(c *Client) {
+		c.EmotionalVoices = copyEmotions(voices)
+	}
+}
+
+// copyEmotions returns a copy of voices with lower case names
+func copyEmotions(voices map[string][]ssml.Emotion) map[string][]ssml.Emotion {
+	c := make(map[string][]ssml.Emotion, len(voices))
+	for name, emotions := range voices {
+		c[strings.ToLower(name)] = append([]ssml.Emotion(nil), emotions...)
+	}
+
+	return c
+}
+
+// Emotions returns the emotional styles the voice is known to have, from
+// EmotionalVoices
+func (v Voice) Emotions() []ssml.Emotion {
+	return knownEmotions[strings.ToLower(v.VoiceName)]
+}
+
+// SupportsEmotion reports whether the voice is known to have emotion, from
+// EmotionalVoices
+func (v Voice) SupportsEmotion(emotion ssml.Emotion) bool {
+	return hasEmotion(v.Emotions(), emotion)
+}
+
+// ByEmotion returns the voices known to have emotion, from EmotionalVoices
+func (vc VoiceCatalog) ByEmotion(emotion ssml.Emotion) VoiceCatalog {
+	return vc.Filter(func(v Voice) bool {
+		return v.SupportsEmotion(emotion)
+	})
+}
+
+// WithEmotionCheck refuses speak requests with an Emotion the voice is not
+// known to have, in EmotionalVoices or those set with WithEmotionalVoices,
+// with ErrUnsupportedEmotion, rather than
+// letting it be spoken without one. FallbackVoices are tried in its place.
+func WithEmotionCheck() ClientOption {
+	return func(c *Client) {
+		c.CheckEmotions = true
+	}
+}
+
+// checkEmotion returns ErrUnsupportedEmotion if CheckEmotions is set and
+// voice is not known to have emotion
+func (c *Client) checkEmotion(voice string, emotion ssml.Emotion) error {
+	if !c.CheckEmotions || emotion == "" || hasEmotion(c.voiceEmotions(voice), emotion) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s is not known to sound %s", ErrUnsupportedEmotion, voice, emotion)
+}
+
+// voiceEmotions returns the emotional styles of voice known to the Client
+func (c *Client) voiceEmotions(voice string) []ssml.Emotion {
+	voices := c.EmotionalVoices
+	if voices == nil {
+		voices = knownEmotions
+	}
+
+	return voices[strings.ToLower(voice)]
+}
+
+func hasEmotion(emotions []ssml.Emotion, emotion ssml.Emotion) bool {
+	for _, e := range emotions {
+		if e == emotion {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+func TestWithEmotionalVoices(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	c.Apply(cerevoicego.WithEmotionCheck())
+	input := &cerevoicego.SpeakExtendedInput{Voice: "Isabella", Text: "Hello", SpeakMode: cerevoicego.SpeakMode{Emotion: ssml.Happy}}
+
+	if _, err := c.SpeakExtended(input); !errors.Is(err, cerevoicego.ErrUnsupportedEmotion) {
+		t.Fatalf("SpeakExtended = %v, want ErrUnsupportedEmotion", err)
+	}
+
+	voices := cerevoicego.EmotionalVoices()
+	voices["Isabella"] = []ssml.Emotion{ssml.Happy}
+	if (cerevoicego.Voice{VoiceName: "Isabella"}).SupportsEmotion(ssml.Happy) {
+		t.Error("changing the copy from EmotionalVoices changed the known voices")
+	}
+
+	c.Apply(cerevoicego.WithEmotionalVoices(voices))
+	delete(voices, "Isabella")
+	if _, err := c.SpeakExtended(input); err != nil {
+		t.Errorf("SpeakExtended with the voice added = %v", err)
+	}
+	if reqs := srv.Requests(); len(reqs) != 1 || reqs[0].Text != `<speak><usel genre="happy">Hello</usel></speak>` {
+		t.Errorf("requests = %+v", reqs)
+	}
+}
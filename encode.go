@@ -0,0 +1,123 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// encodeRequest returns the canonical XML of req. Elements are always written
+// in the same order, one per line when indent is set, so identical requests
+// produce identical bodies. Text is escaped, so markup embedded in it reaches
+// the API as text for the engine to interpret.
+func encodeRequest(req *Request, indent bool) []byte {
+	var buf bytes.Buffer
+	if indent {
+		buf.WriteString(xml.Header)
+	}
+
+	root := req.XMLName.Local
+	buf.WriteString("<" + root + ">")
+
+	element := func(name, value string, omitEmpty bool) {
+		if omitEmpty && value == "" {
+			return
+		}
+		if indent {
+			buf.WriteString("\n    ")
+		}
+		buf.WriteString("<" + name + ">")
+		escapeXML(&buf, value)
+		buf.WriteString("</" + name + ">")
+	}
+	flag := func(name string, value bool) {
+		if value {
+			element(name, "true", false)
+		}
+	}
+
+	element("accountID", req.AccountID, false)
+	element("password", req.Password, req.Token != "")
+	element("token", req.Token, true)
+	element("voice", req.Voice, true)
+	element("text", req.Text, true)
+	element("audioFormat", req.AudioFormat, true)
+	element("sampleRate", req.SampleRate, true)
+	flag("audio3D", req.Audio3D)
+	flag("metadata", req.Metadata)
+	element("lexiconFile", req.LexiconFile, true)
+	element("abbreviationFile", req.AbbreviationFile, true)
+	element("language", req.Language, true)
+	element("accent", req.Accent, true)
+	element("gender", req.Gender, true)
+
+	if indent {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("</" + root + ">")
+
+	return buf.Bytes()
+}
+
+// escapeXML writes s as XML character data. The characters &, < and > are
+// escaped, carriage returns are kept as references so they survive line end
+// normalisation, and characters XML cannot carry are replaced by spaces.
+func escapeXML(w io.Writer, s string) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '\r':
+			b.WriteString("&#xD;")
+		case !isXMLChar(r):
+			b.WriteByte(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	io.WriteString(w, b.String())
+}
+
+// isXMLChar reports whether r is allowed in an XML 1.0 document
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= utf8.MaxRune
+}
+
+// markupStart matches the start of a tag, comment or processing instruction
+var markupStart = regexp.MustCompile(`<[A-Za-z/!?]`)
+
+// checkMarkup checks markup embedded in text, such as SSML, is well formed.
+// The engine parses text containing tags as markup, so a stray & or an
+// unclosed tag would otherwise fail on the server with an unhelpful error.
+// Text without tags is not checked.
+func checkMarkup(text string) error {
+	if !markupStart.MatchString(text) {
+		return nil
+	}
+
+	dec := xml.NewDecoder(strings.NewReader("<text>" + text + "</text>"))
+	dec.Entity = xml.HTMLEntity
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func request(op string, r Request) *Request {
+	r.XMLName = xml.Name{Local: op}
+	r.AccountID, r.Password = "ACCOUNT", "PASSWORD"
+	return &r
+}
+
+var encodeCases = []struct {
+	name string
+	req  *Request
+}{
+	{"speak_simple", request("speakSimple", Request{Voice: "Heather", Text: "Hello world!"})},
+	{"speak_extended", request("speakExtended", Request{
+		Voice:       "William",
+		Text:        "Hello world!",
+		AudioFormat: "mp3",
+		SampleRate:  "22050",
+		Audio3D:     true,
+		Metadata:    true,
+	})},
+	{"escaping", request("speakExtended", Request{
+		Voice: "Heather",
+		Text:  `Fish & chips < £5 > "cheap" isn't it?`,
+	})},
+	{"markup", request("speakExtended", Request{
+		Voice: "Heather",
+		Text:  `<speak>Your code is <say-as interpret-as="characters">4711</say-as>.<break time="1s"/> Bye &amp; thanks</speak>`,
+	})},
+	{"whitespace_control", request("speakExtended", Request{
+		Voice: "Isabella",
+		Text:  "Line one\r\nLine\ttwo\x00\x0bthree \U0001F600",
+	})},
+	{"list_voices", request("listVoices", Request{Language: "en", Accent: "SCO", Gender: "female"})},
+	{"upload_lexicon", request("uploadLexicon", Request{
+		LexiconFile: "cereproc\tn\ts e1 r @0 p r o0 k\ntomato\tn\tt @0 m aa1 t ou0\n",
+		Language:    "en",
+		Accent:      "gb",
+	})},
+	{"get_credit", request("getCredit", Request{})},
+}
+
+// TestEncodeRequestGolden compares request bodies with the files in
+// testdata/requests. Run with -update to rewrite them.
+func TestEncodeRequestGolden(t *testing.T) {
+	for _, tc := range encodeCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := encodeRequest(tc.req, true)
+			path := filepath.Join("testdata", "requests", tc.name+".xml")
+
+			if *update {
+				if err := ioutil.WriteFile(path, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("body differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+// TestEncodeRequestRoundTrip checks encoded requests decode to the same
+// request, apart from characters XML cannot carry
+func TestEncodeRequestRoundTrip(t *testing.T) {
+	for _, tc := range encodeCases {
+		var got Request
+		if err := xml.Unmarshal(encodeRequest(tc.req, true), &got); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+
+		want := *tc.req
+		if tc.name == "whitespace_control" {
+			want.Text = "Line one\r\nLine\ttwo  three \U0001F600"
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: decoded %+v, want %+v", tc.name, got, want)
+		}
+	}
+}
+
+// TestEncodeRequestDeterministic checks the same request always encodes
+// identically
+func TestEncodeRequestDeterministic(t *testing.T) {
+	for _, tc := range encodeCases {
+		first := encodeRequest(tc.req, true)
+		for i := 0; i < 10; i++ {
+			if !bytes.Equal(encodeRequest(tc.req, true), first) {
+				t.Fatalf("%s: encoding changed between calls", tc.name)
+			}
+		}
+	}
+}
+
+func TestCheckMarkup(t *testing.T) {
+	tests := []struct {
+		text string
+		ok   bool
+	}{
+		{"Hello world", true},
+		{"Fish & chips", true}, // no tags, sent as plain text
+		{"1 < 2 and 3 > 2", true},
+		{`Hello <break time="1s"/> world`, true},
+		{"<speak>Caf&eacute; &amp; bar</speak>", true},
+		{"<!-- note --> Hello", true},
+		{`Hello <break time="1s"> world`, false},
+		{`Fish & chips <break/>`, false},
+		{"<speak>Hello</voice>", false},
+		{`<say-as interpret-as=characters>A</say-as>`, false},
+	}
+
+	for _, tt := range tests {
+		err := checkMarkup(tt.text)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkMarkup(%q) = %v, want ok %t", tt.text, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultEndpointCooldown is how long an endpoint which could not be reached
+// is tried after the others
+const DefaultEndpointCooldown = 30 * time.Second
+
+// WithFallbackURLs sets API URLs to fail over to, in order, when the primary
+// endpoint can not be reached
+func WithFallbackURLs(urls ...string) ClientOption {
+	return func(c *Client) {
+		c.FallbackURLs = urls
+	}
+}
+
+// endpoints returns the API URLs to try in order. Endpoints which recently
+// failed are moved to the end, so they are still tried if all others fail.
+func (c *Client) endpoints() []string {
+	all := append([]string{c.apiURL()}, c.FallbackURLs...)
+	if len(all) == 1 {
+		return all
+	}
+
+	cooldown := c.EndpointCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultEndpointCooldown
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]string, 0, len(all))
+	var down []string
+	for _, url := range all {
+		if t, ok := c.down[url]; ok && now.Sub(t) < cooldown {
+			down = append(down, url)
+			continue
+		}
+		healthy = append(healthy, url)
+	}
+
+	return append(healthy, down...)
+}
+
+// markDown records that endpoint could not be reached
+func (c *Client) markDown(endpoint string) {
+	if len(c.FallbackURLs) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.down == nil {
+		c.down = make(map[string]time.Time)
+	}
+	c.down[endpoint] = time.Now()
+}
+
+// failover reports whether err means the endpoint could not be reached or is
+// failing, rather than the request being rejected
+func failover(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+
+	return true
+}
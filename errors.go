@@ -0,0 +1,220 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrAuth is returned when the AccountID or Password is rejected
+	ErrAuth = errors.New("cerevoicego: authentication failed")
+	// ErrInsufficientCredit is returned when the account has run out of credit
+	ErrInsufficientCredit = errors.New("cerevoicego: insufficient credit")
+	// ErrInvalidVoice is returned when the requested voice does not exist
+	ErrInvalidVoice = errors.New("cerevoicego: invalid voice")
+	// ErrRateLimited is returned when the client rate limit would delay a
+	// request beyond its context deadline
+	ErrRateLimited = errors.New("cerevoicego: rate limit exceeded")
+)
+
+// APIError is returned when the CereVoice Cloud API reports a failure. It
+// matches ErrAuth, ErrInsufficientCredit and ErrInvalidVoice with errors.Is
+// by its Code.
+type APIError struct {
+	Operation   string     // API function that failed, e.g. speakSimple
+	ResultCode  ResultCode // resultCode returned by the API
+	Description string     // resultDescription returned by the API
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("cerevoicego: %s failed with result code %d: %s",
+		e.Operation, int(e.ResultCode), e.Description)
+}
+
+// Code returns the result code identifying the failure. That is the
+// ResultCode, unless it is the ResultFailure documented by the API, when
+// the Description is matched against the phrases the API uses for known
+// failures. Descriptions which match none leave ResultFailure, as free text
+// may mention an account or voice whatever the failure.
+func (e *APIError) Code() ResultCode {
+	if e.ResultCode != ResultFailure {
+		return e.ResultCode
+	}
+
+	return describedCode(e.Description)
+}
+
+// Is reports whether the API error corresponds to target by its Code
+func (e *APIError) Is(target error) bool {
+	err := e.Code().err()
+	return err != nil && err == target
+}
+
+// HTTPError is returned when the CereVoice Cloud API responds with a server
+// error status, or another error status without an API response
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       string // Start of the response body
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body == "" {
+		return "cerevoicego: unexpected HTTP status: " + e.Status
+	}
+
+	return fmt.Sprintf("cerevoicego: unexpected HTTP status: %s: %q", e.Status, e.Body)
+}
+
+// DecodeError is returned when a response can not be decoded, for example
+// an HTML error page from a proxy in front of the API
+type DecodeError struct {
+	Operation   string // API function, e.g. speakSimple
+	StatusCode  int    // HTTP status code
+	ContentType string // Content-Type header
+	Body        string // Start of the response body
+	Err         error  // Underlying decoding error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("cerevoicego: decoding %s response (HTTP %d, %s): %v: %q",
+		e.Operation, e.StatusCode, e.ContentType, e.Err, e.Body)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// errHTMLResponse is the DecodeError cause for an HTML page in place of XML
+var errHTMLResponse = errors.New("unexpected HTML response")
+
+// maxSnippet is the length of response body kept in errors
+const maxSnippet = 256
+
+// maxErrorBody is the length of response body read for an error status
+const maxErrorBody = 64 << 10
+
+// snippet returns the start of raw for error messages, cut on a rune
+// boundary and with any invalid UTF-8 replaced
+func snippet(raw []byte) string {
+	s := strings.TrimSpace(string(raw))
+	suffix := ""
+	if len(s) > maxSnippet {
+		n := maxSnippet
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		s, suffix = s[:n], "..."
+	}
+
+	return strings.ToValidUTF8(s, "\uFFFD") + suffix
+}
+
+// isXML reports whether raw looks like an XML document rather than HTML
+func isXML(raw []byte) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(raw)), "<") && !isHTML(raw)
+}
+
+// isHTML reports whether raw looks like an HTML page
+func isHTML(raw []byte) bool {
+	if len(raw) > 512 {
+		raw = raw[:512]
+	}
+
+	s := strings.ToLower(strings.TrimSpace(string(raw)))
+	return strings.HasPrefix(s, "<!doctype html") || strings.HasPrefix(s, "<html") ||
+		strings.Contains(s, "<body")
+}
+
+// decodeError returns a DecodeError describing why r could not be decoded
+func (r *Response) decodeError(operation string, err error) error {
+	return &DecodeError{
+		Operation:   operation,
+		StatusCode:  r.StatusCode,
+		ContentType: r.ContentType,
+		Body:        snippet(r.Raw),
+		Err:         err,
+	}
+}
+
+// result is the status common to all CereVoice Cloud API responses
+type result struct {
+	ResultCode        string `xml:"resultCode"`
+	ResultDescription string `xml:"resultDescription"`
+	CharCount         string `xml:"charCount"`
+}
+
+// UnmarshalJSON decodes the status, which may be given as numbers or strings
+func (r *result) UnmarshalJSON(data []byte) error {
+	var v struct {
+		ResultCode        json.RawMessage `json:"resultCode"`
+		ResultDescription string          `json:"resultDescription"`
+		CharCount         json.RawMessage `json:"charCount"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	r.ResultCode = strings.Trim(string(v.ResultCode), `"`)
+	r.ResultDescription = v.ResultDescription
+	r.CharCount = strings.Trim(string(v.CharCount), `"`)
+	return nil
+}
+
+// code returns the parsed result code, ok is false if there is none
+func (r *result) code() (code ResultCode, ok bool, err error) {
+	s := strings.TrimSpace(r.ResultCode)
+	if s == "" {
+		return 0, false, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return ResultCode(n), true, nil
+}
+
+// chars returns the parsed character count, 0 if there is none
+func (r *result) chars() int {
+	n, _ := strconv.Atoi(strings.TrimSpace(r.CharCount))
+	return n
+}
+
+// checkResult decodes the status of raw and returns an APIError if it is
+// unsuccessful. Responses without a result code, such as listVoices, are
+// treated as successful.
+func (c *Client) checkResult(operation string, resp *Response) (*result, error) {
+	mediaType, _, _ := mime.ParseMediaType(resp.ContentType)
+	if mediaType == "text/html" || isHTML(resp.Raw) {
+		return nil, resp.decodeError(operation, errHTMLResponse)
+	}
+
+	res := &result{}
+	if err := resp.decode(c, "", res); err != nil {
+		return nil, resp.decodeError(operation, err)
+	}
+
+	code, ok, err := res.code()
+	if err != nil {
+		return nil, err
+	}
+	if !ok || code.IsSuccess() {
+		return res, nil
+	}
+
+	return res, &APIError{
+		Operation:   operation,
+		ResultCode:  code,
+		Description: strings.TrimSpace(res.ResultDescription),
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("a", maxSnippet-1) + "é" + strings.Repeat("b", 10)
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"short", "  <html>502</html>\n", "<html>502</html>"},
+		{"rune boundary", long, strings.Repeat("a", maxSnippet-1) + "..."},
+		{"invalid short", "bad \xff byte", "bad \uFFFD byte"},
+		{"invalid long", "\xff" + strings.Repeat("c", maxSnippet), "\uFFFD" + strings.Repeat("c", maxSnippet-1) + "..."},
+	}
+
+	for _, tt := range tests {
+		got := snippet([]byte(tt.raw))
+		if got != tt.want {
+			t.Errorf("%s: snippet = %q, want %q", tt.name, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: snippet %q is not valid UTF-8", tt.name, got)
+		}
+	}
+}
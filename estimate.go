@@ -0,0 +1,59 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strconv"
+)
+
+// markup matches SSML and other XML tags in text
+var markup = regexp.MustCompile(`<[^<>]*>`)
+
+// BilledChars returns the number of characters CereVoice bills for text.
+// Markup such as SSML tags is not billed, and entities count as the single
+// character they represent.
+func BilledChars(text string) int {
+	return len([]rune(html.UnescapeString(markup.ReplaceAllString(text, ""))))
+}
+
+// Estimate is the projected credit usage of a batch of speak requests
+type Estimate struct {
+	Chars    int   // Characters billed for the whole batch
+	Requests []int // Characters billed for each request, in order
+}
+
+// Estimate returns the characters which would be billed for the inputs
+// after preprocessing, without calling the API
+func (c *Client) Estimate(inputs ...*SpeakExtendedInput) *Estimate {
+	e := &Estimate{Requests: make([]int, len(inputs))}
+	for i, input := range inputs {
+		e.Requests[i] = BilledChars(c.PreprocessText(context.Background(), input.Text))
+		e.Chars += e.Requests[i]
+	}
+
+	return e
+}
+
+// WithDryRun stops speak requests reaching the API. They succeed with the
+// charCount the request would have been billed and no fileUrl, so code can
+// be exercised without spending credit. A CreditGuard neither checks nor
+// reduces its balance for them.
+func WithDryRun() ClientOption {
+	return func(c *Client) {
+		c.DryRun = true
+	}
+}
+
+// dryRun returns the response to a speak request in dry run mode
+func dryRun(req *Request) *Response {
+	op := req.XMLName.Local
+	return &Response{Raw: []byte("<" + op + "Response>" +
+		"<resultCode>1</resultCode>" +
+		"<resultDescription>Dry run</resultDescription>" +
+		"<charCount>" + strconv.Itoa(BilledChars(req.Text)) + "</charCount>" +
+		"</" + op + "Response>")}
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestBilledChars(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"Hello world", 11},
+		{`Hello <break time="1s"/>world`, 11},
+		{"<speak><emphasis>Big</emphasis></speak>", 3},
+		{"Fish &amp; chips", 12},
+		{"café", 4},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := cerevoicego.BilledChars(tt.text); got != tt.want {
+			t.Errorf("BilledChars(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDryRunSkipsCreditGuard(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	guard := &cerevoicego.CreditGuard{}
+	c := srv.Client()
+	c.Apply(cerevoicego.WithDryRun(), cerevoicego.WithCreditGuard(guard))
+
+	r, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Chars != 5 {
+		t.Errorf("charCount %d, want 5", r.Chars)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d API requests, want none", n)
+	}
+	if _, known := guard.Available(); known {
+		t.Error("dry run fetched the credit balance")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/lexicon"
+)
+
+// The examples run against cerevoicetest; in use, create the Client with
+// NewClient or NewClientFromEnv. Complete programs are in the examples
+// directory.
+
+func ExampleClient_SpeakAudio() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	cerevoice := srv.Client()
+
+	audio, err := cerevoice.SpeakAudio(&cerevoicego.SpeakExtendedInput{
+		Voice:       "Heather",
+		Text:        "Hello world!",
+		AudioFormat: cerevoicego.FormatWAV,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(len(audio), "bytes")
+	// Output:
+	// 1644 bytes
+}
+
+func ExampleClient_SpeakBatch() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	cerevoice := srv.Client()
+
+	input := &cerevoicego.BatchInput{}
+	for _, text := range []string{"Thank you for calling.", "Please hold."} {
+		input.Items = append(input.Items, cerevoicego.BatchItem{
+			Input: &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: text},
+		})
+	}
+
+	summary := cerevoice.SpeakBatch(context.Background(), input).Wait()
+	fmt.Println(summary.Items, "items,", summary.Failures, "failures,", summary.Chars, "characters")
+	// Output:
+	// 2 items, 0 failures, 34 characters
+}
+
+func ExampleClient_SpeakTo() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	cerevoice := srv.Client()
+
+	// Any io.Writer, such as a file or http.ResponseWriter
+	var w bytes.Buffer
+	res, err := cerevoice.SpeakTo(&w, &cerevoicego.SpeakExtendedInput{
+		Voice: "Heather",
+		Text:  "Hello world!",
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Println(w.Len(), "bytes for", res.Chars, "characters")
+	// Output:
+	// 1644 bytes for 12 characters
+}
+
+func ExampleClient_SyncLexicon() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	cerevoice := srv.Client()
+
+	lex, err := lexicon.Parse(strings.NewReader("tomato n t @0 m aa1 t ou0\n"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	diff, err := cerevoice.SyncLexicon(lex, "en", "gb")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Print(diff)
+	// Output:
+	// + tomato	n	t @0 m aa1 t ou0
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Command batch synthesises a set of IVR prompts concurrently, saving the
+// audio under the prompts directory
+//
+//	go run ./examples/batch
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/storage"
+)
+
+// prompts are the texts to synthesise by ID
+var prompts = []struct{ ID, Text string }{
+	{"welcome", "Thank you for calling."},
+	{"hold", "Please hold."},
+	{"goodbye", "Goodbye."},
+}
+
+func main() {
+	cerevoice, err := cerevoicego.NewClientFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := run(context.Background(), cerevoice, "prompts", os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run synthesises the prompts into dir and prints the characters billed for
+// each
+func run(ctx context.Context, cerevoice *cerevoicego.Client, dir string, out io.Writer) error {
+	input := &cerevoicego.BatchInput{
+		Concurrency: 2,
+		Store:       cerevoicego.StoreIn(storage.NewDir(dir, "")),
+	}
+	for _, p := range prompts {
+		input.Items = append(input.Items, cerevoicego.BatchItem{
+			ID:    p.ID,
+			Input: &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: p.Text},
+		})
+	}
+
+	summary := cerevoice.SpeakBatch(ctx, input).Wait()
+	for _, res := range summary.Results {
+		if res.Error != "" {
+			fmt.Fprintf(out, "%s: %s\n", res.ID, res.Error)
+			continue
+		}
+		fmt.Fprintf(out, "%s: %d characters\n", res.ID, res.Chars)
+	}
+	if summary.Failures > 0 {
+		return fmt.Errorf("%d of %d prompts failed", summary.Failures, summary.Items)
+	}
+
+	fmt.Fprintf(out, "%d characters in total\n", summary.Chars)
+	return nil
+}
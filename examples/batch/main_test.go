@@ -0,0 +1,33 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func Example() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "prompts")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := run(context.Background(), srv.Client(), dir, os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+	// Output:
+	// welcome: 22 characters
+	// hold: 12 characters
+	// goodbye: 8 characters
+	// 42 characters in total
+}
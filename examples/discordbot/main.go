@@ -0,0 +1,80 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Command discordbot speaks "!say" chat commands into a Discord voice
+// channel. To stay free of dependencies it reads the commands from stdin
+// and counts the Opus frames it would send; in a real bot, pass each
+// message's content to handle and use the discordgo VoiceConnection's
+// OpusSend channel as frames.
+//
+// Encoding needs libopus and the opus build tag:
+//
+//	go run -tags opus ./examples/discordbot
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/integrations/discord"
+)
+
+func main() {
+	cerevoice, err := cerevoicego.NewClientFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := run(context.Background(), cerevoice, os.Stdin, os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run handles each line of in as a chat message, sending the frames spoken
+// to a stand-in for the voice connection
+func run(ctx context.Context, cerevoice *cerevoicego.Client, in io.Reader, out io.Writer) error {
+	frames := make(chan []byte)
+	sent := make(chan int)
+	go func() {
+		n := 0
+		for range frames {
+			n++
+		}
+		sent <- n
+	}()
+
+	s := bufio.NewScanner(in)
+	for s.Scan() {
+		if err := handle(ctx, cerevoice, s.Text(), frames); err != nil {
+			close(frames)
+			return err
+		}
+	}
+	close(frames)
+
+	fmt.Fprintf(out, "Sent %d frames\n", <-sent)
+	return s.Err()
+}
+
+// handle speaks the text of a "!say" message, ignoring other messages
+func handle(ctx context.Context, cerevoice *cerevoicego.Client, message string, opus chan<- []byte) error {
+	if !strings.HasPrefix(message, "!say ") {
+		return nil
+	}
+
+	packets, err := discord.Speak(ctx, cerevoice, &cerevoicego.SpeakExtendedInput{
+		Voice: "Heather",
+		Text:  strings.TrimPrefix(message, "!say "),
+	})
+	if err != nil {
+		return err
+	}
+
+	return discord.Send(ctx, opus, packets)
+}
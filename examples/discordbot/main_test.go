@@ -0,0 +1,28 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build opus
+
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func Example() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+
+	// The server's 100ms of audio is five 20ms frames
+	chat := "!say Hello Discord!\nnot for the bot\n!say Goodbye!\n"
+	if err := run(context.Background(), srv.Client(), strings.NewReader(chat), os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+	// Output:
+	// Sent 10 frames
+}
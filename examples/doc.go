@@ -0,0 +1,17 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package examples contains runnable programs showing the main features of
+// cerevoicego, one per directory:
+//
+//	speak        synthesise text to a file
+//	batch        synthesise many prompts concurrently
+//	stream       stream audio to a file as it is downloaded
+//	lexiconsync  upload a lexicon only if it has changed
+//	discordbot   speak chat commands into a Discord voice channel
+//
+// Each reads credentials from CEREVOICE_ACCOUNT_ID and CEREVOICE_PASSWORD,
+// e.g. go run ./examples/speak "Hello world!". Their Example tests run them
+// against the cerevoicetest server, so the documented output is checked by
+// go test.
+package examples
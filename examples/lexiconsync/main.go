@@ -0,0 +1,61 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Command lexiconsync uploads a British English lexicon file if it differs
+// from the one already uploaded, printing the differences, e.g. from a
+// deployment pipeline
+//
+//	go run ./examples/lexiconsync my.lex
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/lexicon"
+)
+
+func main() {
+	cerevoice, err := cerevoicego.NewClientFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(os.Args) != 2 {
+		log.Fatalln("usage: lexiconsync <file>")
+	}
+
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+
+	if err := run(context.Background(), cerevoice, f, os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run parses a lexicon file from r and syncs it
+func run(ctx context.Context, cerevoice *cerevoicego.Client, r io.Reader, out io.Writer) error {
+	lex, err := lexicon.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	diff, err := cerevoice.SyncLexiconWithContext(ctx, lex, "en", "gb")
+	if err != nil {
+		return err
+	}
+
+	if diff.Empty() {
+		fmt.Fprintln(out, "Lexicon up to date")
+		return nil
+	}
+	fmt.Fprint(out, diff)
+
+	return nil
+}
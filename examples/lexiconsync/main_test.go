@@ -0,0 +1,29 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func Example() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+
+	lex := `# headword  pos  transcription
+cereproc    n    s e1 r @0 p r o0 k
+tomato      n    t @0 m aa1 t ou0
+`
+	if err := run(context.Background(), srv.Client(), strings.NewReader(lex), os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+	// Output:
+	// + cereproc	n	s e1 r @0 p r o0 k
+	// + tomato	n	t @0 m aa1 t ou0
+}
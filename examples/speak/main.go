@@ -0,0 +1,50 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Command speak synthesises its arguments to hello.wav
+//
+//	go run ./examples/speak "Hello world!"
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+func main() {
+	cerevoice, err := cerevoicego.NewClientFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	text := strings.Join(os.Args[1:], " ")
+	if err := run(context.Background(), cerevoice, text, "hello.wav", os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run synthesises text with Heather and saves the audio to path
+func run(ctx context.Context, cerevoice *cerevoicego.Client, text, path string, out io.Writer) error {
+	audio, err := cerevoice.SpeakAudioWithContext(ctx, &cerevoicego.SpeakExtendedInput{
+		Voice:       "Heather",
+		Text:        text,
+		AudioFormat: cerevoicego.FormatWAV,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, audio, 0644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Saved %d bytes of audio\n", len(audio))
+	return nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func Example() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "speak")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = run(context.Background(), srv.Client(), "Hello world!", filepath.Join(dir, "hello.wav"), os.Stdout)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// Output:
+	// Saved 1644 bytes of audio
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Command stream synthesises text read from stdin and writes the audio to
+// speech.mp3 as it is downloaded, without holding it all in memory
+//
+//	go run ./examples/stream < chapter.txt
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+func main() {
+	cerevoice, err := cerevoicego.NewClientFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	text, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := run(context.Background(), cerevoice, string(text), "speech.mp3", os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run synthesises text as MP3 and streams the audio into the file at path
+func run(ctx context.Context, cerevoice *cerevoicego.Client, text, path string, out io.Writer) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := &countingWriter{w: f}
+	res, err := cerevoice.SpeakToWithContext(ctx, w, &cerevoicego.SpeakExtendedInput{
+		Voice:       "Heather",
+		Text:        text,
+		AudioFormat: cerevoicego.FormatMP3,
+	})
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Streamed %d bytes for %d characters\n", w.n, res.Chars)
+	return nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,32 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func Example() {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "stream")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer os.RemoveAll(dir)
+
+	text := "It was a bright cold day in April, and the clocks were striking thirteen."
+	if err := run(context.Background(), srv.Client(), text, filepath.Join(dir, "speech.mp3"), os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+	// Output:
+	// Streamed 1644 bytes for 73 characters
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// SynthesizerFunc adapts a function, such as a call to another text to
+// speech provider, to a Synthesizer with no voices of its own
+type SynthesizerFunc func(ctx context.Context, input *SpeakExtendedInput) ([]byte, error)
+
+// Synthesize calls f(ctx, input)
+func (f SynthesizerFunc) Synthesize(ctx context.Context, input *SpeakExtendedInput) ([]byte, error) {
+	return f(ctx, input)
+}
+
+// Voices returns no voices
+func (f SynthesizerFunc) Voices(ctx context.Context) (VoiceCatalog, error) {
+	return nil, nil
+}
+
+// Fallback is a Synthesizer trying each of its Synthesizers in order until
+// one succeeds, so a CereVoice failure can fall back to another engine or
+// to pre-recorded Prompts
+type Fallback struct {
+	Synthesizers []Synthesizer
+
+	// ShouldFallback reports whether the next Synthesizer is tried after
+	// err. When nil every error falls back except invalid requests and
+	// the context ending.
+	ShouldFallback func(err error) bool
+	// OnFallback, if set, is called when the Synthesizer at index i fails
+	// and the next is tried
+	OnFallback func(i int, err error)
+}
+
+var _ Synthesizer = (*Fallback)(nil)
+
+// NewFallback returns a Fallback trying synthesizers in order
+func NewFallback(synthesizers ...Synthesizer) *Fallback {
+	return &Fallback{Synthesizers: synthesizers}
+}
+
+// FallbackError is returned when every Synthesizer of a Fallback fails. It
+// matches each of their errors with errors.Is and errors.As.
+type FallbackError struct {
+	Errs []error // Error from each Synthesizer tried, in order
+}
+
+func (e *FallbackError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+
+	return "cerevoicego: every synthesizer failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns Errs
+func (e *FallbackError) Unwrap() []error {
+	return e.Errs
+}
+
+// Synthesize returns the audio from the first Synthesizer to succeed
+func (f *Fallback) Synthesize(ctx context.Context, input *SpeakExtendedInput) ([]byte, error) {
+	if len(f.Synthesizers) == 0 {
+		return nil, errors.New("cerevoicego: no synthesizers")
+	}
+
+	var errs []error
+	for i, s := range f.Synthesizers {
+		audio, err := s.Synthesize(ctx, input)
+		if err == nil {
+			return audio, nil
+		}
+		errs = append(errs, err)
+
+		if i == len(f.Synthesizers)-1 || ctx.Err() != nil || !f.shouldFallback(err) {
+			break
+		}
+		if f.OnFallback != nil {
+			f.OnFallback(i, err)
+		}
+	}
+
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+
+	return nil, &FallbackError{Errs: errs}
+}
+
+// shouldFallback reports whether to try the next Synthesizer after err
+func (f *Fallback) shouldFallback(err error) bool {
+	if f.ShouldFallback != nil {
+		return f.ShouldFallback(err)
+	}
+
+	return !errors.Is(err, ErrValidation) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// Voices returns the voices of every Synthesizer, the first of any with the
+// same name. An error is returned only if no Synthesizer lists its voices.
+func (f *Fallback) Voices(ctx context.Context) (VoiceCatalog, error) {
+	var voices VoiceCatalog
+	var errs []error
+	seen := make(map[string]bool)
+
+	for _, s := range f.Synthesizers {
+		vc, err := s.Voices(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, v := range vc {
+			if name := strings.ToLower(v.VoiceName); !seen[name] {
+				seen[name] = true
+				voices = append(voices, v)
+			}
+		}
+	}
+
+	if len(errs) == len(f.Synthesizers) && len(errs) > 0 {
+		if len(errs) == 1 {
+			return nil, errs[0]
+		}
+		return nil, &FallbackError{Errs: errs}
+	}
+
+	return voices, nil
+}
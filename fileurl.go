@@ -0,0 +1,148 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/bganderson/cerevoicego/internal/atomicfile"
+)
+
+// URLStore maps CacheKey hashes to the current CereVoice file URL of the
+// audio. Implementations must be safe for concurrent use.
+type URLStore interface {
+	LoadURL(key string) (string, bool)
+	StoreURL(key, url string) error
+}
+
+// URLMap is an in-memory URLStore, saved as JSON to Path on every change if
+// Path is set
+type URLMap struct {
+	Path string
+
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+// LoadURLMap returns a URLMap saved to path, loading any existing entries.
+// A missing file is not an error.
+func LoadURLMap(path string) (*URLMap, error) {
+	m := &URLMap{Path: path, urls: make(map[string]string)}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &m.urls); err != nil {
+		return nil, fmt.Errorf("cerevoicego: reading %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// LoadURL returns the URL stored for key
+func (m *URLMap) LoadURL(key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	url, ok := m.urls[key]
+	return url, ok
+}
+
+// StoreURL records url for key, saving the map if Path is set
+func (m *URLMap) StoreURL(key, url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.urls == nil {
+		m.urls = make(map[string]string)
+	}
+	m.urls[key] = url
+
+	if m.Path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(m.urls, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(m.Path, b, 0600)
+}
+
+// URLExpired reports whether a CereVoice file URL can no longer be fetched,
+// checking it with a HEAD request. Errors other than the file being gone,
+// such as network failures, are returned rather than reported as expiry.
+func (c *Client) URLExpired(url string) (bool, error) {
+	return c.URLExpiredWithContext(context.Background(), url)
+}
+
+// URLExpiredWithContext is the same as URLExpired with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) URLExpiredWithContext(ctx context.Context, url string) (bool, error) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.fileClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// An HTML page in place of the file is an error page
+		return !isAudioContentType(resp.Header.Get("Content-Type")), nil
+	case http.StatusNotFound, http.StatusGone, http.StatusForbidden:
+		return true, nil
+	}
+
+	return false, fmt.Errorf("cerevoicego: checking %s: %s", url, resp.Status)
+}
+
+// SpeakURL returns a fetchable CereVoice file URL for the audio of input.
+// The URL recorded in store is reused while it can still be fetched, and
+// the input synthesised again, with the new URL recorded, once it has
+// expired.
+func (c *Client) SpeakURL(input *SpeakExtendedInput, store URLStore) (string, error) {
+	return c.SpeakURLWithContext(context.Background(), input, store)
+}
+
+// SpeakURLWithContext is the same as SpeakURL with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) SpeakURLWithContext(ctx context.Context, input *SpeakExtendedInput, store URLStore) (string, error) {
+	key := CacheKey(input)
+
+	if url, ok := store.LoadURL(key); ok {
+		expired, err := c.URLExpiredWithContext(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		if !expired {
+			return url, nil
+		}
+	}
+
+	r, err := c.SpeakExtendedWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if err := store.StoreURL(key, r.FileURL); err != nil {
+		return "", err
+	}
+
+	return r.FileURL, nil
+}
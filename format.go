@@ -0,0 +1,166 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AudioFormat is an audio encoding accepted by speakExtended
+type AudioFormat string
+
+// Audio formats supported by the CereVoice Cloud API
+const (
+	FormatWAV  AudioFormat = "wav"
+	FormatOGG  AudioFormat = "ogg"
+	FormatMP3  AudioFormat = "mp3"
+	FormatFLAC AudioFormat = "flac"
+	FormatAIFF AudioFormat = "aiff"
+	FormatRaw  AudioFormat = "raw"
+)
+
+// AudioFormats lists the supported audio formats
+var AudioFormats = []AudioFormat{FormatWAV, FormatOGG, FormatMP3, FormatFLAC, FormatAIFF, FormatRaw}
+
+// SampleRate is an output sample rate in Hz accepted by speakExtended
+type SampleRate string
+
+// Sample rates supported by the CereVoice Cloud API
+const (
+	SampleRate8k  SampleRate = "8000"
+	SampleRate16k SampleRate = "16000"
+	SampleRate22k SampleRate = "22050"
+	SampleRate24k SampleRate = "24000"
+	SampleRate32k SampleRate = "32000"
+	SampleRate44k SampleRate = "44100"
+	SampleRate48k SampleRate = "48000"
+)
+
+// SampleRates lists the supported sample rates
+var SampleRates = []SampleRate{
+	SampleRate8k, SampleRate16k, SampleRate22k, SampleRate24k,
+	SampleRate32k, SampleRate44k, SampleRate48k,
+}
+
+var (
+	// ErrInvalidAudioFormat is returned when an unsupported audio format is
+	// requested
+	ErrInvalidAudioFormat = errors.New("cerevoicego: invalid audio format")
+	// ErrInvalidSampleRate is returned when an unsupported sample rate is
+	// requested
+	ErrInvalidSampleRate = errors.New("cerevoicego: invalid sample rate")
+)
+
+// Valid reports whether f is a supported audio format
+func (f AudioFormat) Valid() bool {
+	for _, format := range AudioFormats {
+		if strings.EqualFold(string(f), string(format)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContentType returns the MIME type of audio in f, that of WAV if f is
+// empty
+func (f AudioFormat) ContentType() string {
+	switch AudioFormat(strings.ToLower(string(f))) {
+	case FormatOGG:
+		return "audio/ogg"
+	case FormatMP3:
+		return "audio/mpeg"
+	case FormatFLAC:
+		return "audio/flac"
+	case FormatAIFF:
+		return "audio/aiff"
+	case FormatRaw:
+		return "application/octet-stream"
+	}
+
+	return "audio/wav"
+}
+
+// Valid reports whether r is a supported sample rate
+func (r SampleRate) Valid() bool {
+	for _, rate := range SampleRates {
+		if r == rate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the voice and text are set, the text is within the API
+// limit and the audio format and sample rate, if set, are supported. The
+// error is a *ValidationError, which matches ErrInvalidAudioFormat or
+// ErrInvalidSampleRate where those are invalid.
+func (i *SpeakExtendedInput) Validate() error {
+	return validate(&speakExtendedRequest{
+		Voice:       i.Voice,
+		Text:        i.Text,
+		AudioFormat: i.AudioFormat,
+		SampleRate:  i.SampleRate,
+		Mode:        i.SpeakMode,
+	}, 0)
+}
+
+// WithFormatCheck makes speakExtended requests check the audio format
+// against the formats returned by listAudioFormats, which is called once and
+// the result cached
+func WithFormatCheck() ClientOption {
+	return func(c *Client) {
+		c.CheckFormats = true
+	}
+}
+
+// checkFormat validates input, and if CheckFormats is set, checks the audio
+// format is offered by the API
+func (c *Client) checkFormat(ctx context.Context, input *SpeakExtendedInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	if !c.CheckFormats || input.AudioFormat == "" {
+		return nil
+	}
+
+	formats, err := c.availableFormats(ctx)
+	if err != nil {
+		return err
+	}
+	for _, format := range formats {
+		if strings.EqualFold(format, string(input.AudioFormat)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w %q: not offered by the API", ErrInvalidAudioFormat, input.AudioFormat)
+}
+
+// availableFormats returns the cached listAudioFormats result, fetching it
+// if needed
+func (c *Client) availableFormats(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	formats := c.formats
+	c.mu.Unlock()
+
+	if formats != nil {
+		return formats, nil
+	}
+
+	r, err := c.ListAudioFormatsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.formats = r.AudioFormats
+	c.mu.Unlock()
+
+	return r.AudioFormats, nil
+}
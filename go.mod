@@ -0,0 +1,3 @@
+module github.com/bganderson/cerevoicego
+
+go 1.21
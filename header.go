@@ -0,0 +1,63 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import "net/http"
+
+// DefaultUserAgent is the User-Agent header sent when Client.UserAgent is
+// empty
+const DefaultUserAgent = "cerevoicego/" + Version + " (+https://github.com/bganderson/cerevoicego)"
+
+// WithHeader adds a header sent with every API request, such as one required
+// by a proxy in front of the API. It is not sent with downloads, which go to
+// CereVoice's file host.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		// Copy so Clones do not share the map
+		h := c.Header.Clone()
+		if h == nil {
+			h = make(http.Header)
+		}
+		h.Add(key, value)
+		c.Header = h
+	}
+}
+
+// setHeaders adds the User-Agent and extra headers to an API request
+func (c *Client) setHeaders(req *http.Request) {
+	for key, values := range c.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	c.setUserAgent(req)
+}
+
+// setUserAgent sets the User-Agent header of req
+func (c *Client) setUserAgent(req *http.Request) {
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+}
+
+// fileClient returns the HTTP client used for downloads, which sends the
+// User-Agent, but not the extra headers meant for the API, and applies the
+// download timeout
+func (c *Client) fileClient() HTTPClient {
+	return &userAgentClient{client: c, next: &timeoutClient{client: c, next: c.httpClient()}}
+}
+
+// userAgentClient sets a Client's User-Agent on requests
+type userAgentClient struct {
+	client *Client
+	next   HTTPClient
+}
+
+func (u *userAgentClient) Do(req *http.Request) (*http.Response, error) {
+	u.client.setUserAgent(req)
+	return u.next.Do(req)
+}
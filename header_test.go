@@ -0,0 +1,53 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+// headerRecorder records the headers of each request by path
+type headerRecorder struct {
+	next    *http.Client
+	mu      sync.Mutex
+	headers map[string]http.Header
+}
+
+func (h *headerRecorder) Do(req *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	h.headers[req.URL.Path] = req.Header.Clone()
+	h.mu.Unlock()
+	return h.next.Do(req)
+}
+
+func TestHeaderOnlyOnAPIRequests(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	rec := &headerRecorder{next: srv.Server.Client(), headers: make(map[string]http.Header)}
+	c := srv.Client()
+	c.Apply(cerevoicego.WithHTTPClient(rec), cerevoicego.WithHeader("X-Proxy-Token", "secret"))
+
+	if _, err := c.SpeakAudio(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, h := range rec.headers {
+		api := path == "/rest"
+		if got := h.Get("X-Proxy-Token"); (got == "secret") != api {
+			t.Errorf("%s: X-Proxy-Token = %q", path, got)
+		}
+		if !strings.HasPrefix(h.Get("User-Agent"), "cerevoicego/") {
+			t.Errorf("%s: User-Agent = %q", path, h.Get("User-Agent"))
+		}
+	}
+	if len(rec.headers) != 2 {
+		t.Errorf("requests to %d paths, want the API and the audio", len(rec.headers))
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Health is the result of Ping
+type Health struct {
+	Reachable      bool          // The API responded
+	AuthOK         bool          // The credentials were accepted
+	Credit         bool          // The account has characters available
+	CharsAvailable int           // Characters available, if AuthOK
+	Latency        time.Duration // Time taken by the call
+	Checked        time.Time     // When the call was made
+	Err            error         // Why the API is not ready, if it is not
+}
+
+// Ready reports whether speak requests can be expected to succeed
+func (h *Health) Ready() bool {
+	return h.Reachable && h.AuthOK && h.Credit
+}
+
+// Ping checks the API can be reached with the Client's credentials and the
+// account has credit, with a single getCredit call, which is not billed. The
+// returned Health is never nil, and the error is its Err. Give ctx a
+// deadline shorter than the probe's timeout, as retries are made within it.
+func (c *Client) Ping(ctx context.Context) (*Health, error) {
+	h := &Health{Checked: time.Now()}
+
+	res, err := c.GetCreditWithContext(ctx)
+	h.Latency = time.Since(h.Checked)
+
+	var apiErr *APIError
+	var httpErr *HTTPError
+	switch {
+	case err == nil:
+		h.Reachable, h.AuthOK = true, true
+		h.CharsAvailable = res.Credit.Available
+		h.Credit = h.CharsAvailable > 0
+		if !h.Credit {
+			err = ErrInsufficientCredit
+		}
+	case errors.As(err, &apiErr):
+		h.Reachable = true
+		h.AuthOK = !errors.Is(err, ErrAuth)
+	case errors.As(err, &httpErr):
+		h.Reachable = true
+	}
+	h.Err = err
+
+	return h, err
+}
+
+// HealthHandler returns a handler for readiness probes which Pings the API,
+// responding 200 OK if it is ready and 503 Service Unavailable if not, with
+// the Health as JSON. Every request calls the API, so serve it only to
+// trusted callers, or use CachedHealthHandler.
+func (c *Client) HealthHandler() http.Handler {
+	return healthHandler(func(ctx context.Context) *Health {
+		h, _ := c.Ping(ctx)
+		return h
+	})
+}
+
+// CachedHealthHandler is a HealthHandler which Pings the API at most once
+// per maxAge, serving the last Health in between, so it can be exposed
+// without authentication. Probes arriving during a Ping wait for its result.
+func (c *Client) CachedHealthHandler(maxAge time.Duration) http.Handler {
+	var mu sync.Mutex
+	var last *Health
+
+	return healthHandler(func(ctx context.Context) *Health {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if last != nil && time.Since(last.Checked) < maxAge {
+			return last
+		}
+		h, _ := c.Ping(ctx)
+		// A probe which gave up says nothing about the API
+		if ctx.Err() == nil {
+			last = h
+		}
+		return h
+	})
+}
+
+// healthHandler serves the Health returned by check
+func healthHandler(check func(ctx context.Context) *Health) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := check(r.Context())
+
+		status := http.StatusOK
+		if !h.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(h)
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestHealthHandlers(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(c *cerevoicego.Client) http.Handler
+		setup   func(srv *cerevoicetest.Server)
+		status  int
+		pings   int // getCredit calls made for three probes
+	}{
+		{"uncached", (*cerevoicego.Client).HealthHandler, nil, 200, 3},
+		{"cached", func(c *cerevoicego.Client) http.Handler { return c.CachedHealthHandler(time.Hour) }, nil, 200, 1},
+		{"expired", func(c *cerevoicego.Client) http.Handler { return c.CachedHealthHandler(0) }, nil, 200, 3},
+		{
+			"not ready",
+			func(c *cerevoicego.Client) http.Handler { return c.CachedHealthHandler(time.Hour) },
+			func(srv *cerevoicetest.Server) { srv.SetError("getCredit", cerevoicego.ResultInvalidCredentials, "Invalid account") },
+			503,
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		srv := cerevoicetest.NewServer()
+		if tt.setup != nil {
+			tt.setup(srv)
+		}
+		h := tt.handler(srv.Client())
+
+		for i := 0; i < 3; i++ {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+			if rec.Code != tt.status {
+				t.Errorf("%s: probe %d status %d, want %d", tt.name, i, rec.Code, tt.status)
+			}
+		}
+		if n := len(srv.Requests()); n != tt.pings {
+			t.Errorf("%s: %d getCredit calls, want %d", tt.name, n, tt.pings)
+		}
+		srv.Close()
+	}
+}
+
+func TestCachedHealthHandlerIgnoresCanceledProbes(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	h := srv.Client().CachedHealthHandler(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil).WithContext(ctx))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("canceled probe status %d, want 503", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status %d after a canceled probe, want 200", rec.Code)
+	}
+}
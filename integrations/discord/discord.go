@@ -0,0 +1,93 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package discord prepares CereVoice audio for Discord voice connections.
+// Audio is resampled to 48 kHz stereo and encoded as 20ms Opus frames, the
+// format expected on a discordgo VoiceConnection's OpusSend channel.
+//
+//	frames, err := discord.Speak(ctx, cerevoice, &cerevoicego.SpeakExtendedInput{
+//		Voice: "Jess",
+//		Text:  "Hello Discord!",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//
+//	vc.Speaking(true)
+//	defer vc.Speaking(false)
+//	err = discord.Send(ctx, vc.OpusSend, frames)
+//
+// Encoding uses the transcode package and so requires building with the
+// opus tag.
+package discord
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/transcode"
+)
+
+// Options are the transcode options used for Discord: 48 kHz stereo in 20ms
+// frames
+var Options = transcode.Options{
+	Bitrate:   64000,
+	FrameSize: transcode.DefaultFrameSize,
+	Channels:  2,
+}
+
+// Encode converts a WAV file to Discord Opus frames
+func Encode(wav []byte) ([][]byte, error) {
+	opts := Options
+	return transcode.Packets(wav, &opts)
+}
+
+// Frames downloads the audio of a SpeakExtended result, which must have been
+// requested as wav, and converts it to Discord Opus frames
+func Frames(ctx context.Context, r *cerevoicego.SpeakExtendedResponse) ([][]byte, error) {
+	body, err := r.Download(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	wav, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encode(wav)
+}
+
+// Speak synthesises input as 48 kHz WAV, so no resampling is needed, and
+// converts it to Discord Opus frames. The Client's Cache and AudioEffects
+// are used as by SpeakAudio.
+func Speak(ctx context.Context, c *cerevoicego.Client, input *cerevoicego.SpeakExtendedInput) ([][]byte, error) {
+	in := *input
+	in.AudioFormat = cerevoicego.FormatWAV
+	if in.SampleRate == "" {
+		in.SampleRate = cerevoicego.SampleRate48k
+	}
+
+	wav, err := c.SpeakAudioWithContext(ctx, &in)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encode(wav)
+}
+
+// Send writes frames to a voice connection's Opus channel, such as
+// discordgo's VoiceConnection.OpusSend, stopping early if ctx is done
+func Send(ctx context.Context, opus chan<- []byte, frames [][]byte) error {
+	for _, frame := range frames {
+		select {
+		case opus <- frame:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package apikey checks the API keys clients of the cerevoiced and
+// cerevoice-proxy servers authenticate with, and issues the temporary tokens
+// they may use instead.
+package apikey
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Set is a set of API keys accepted by a server
+type Set []string
+
+// Load reads comma separated API keys from the environment variable env and
+// keys from the file at path, if set, one per line. Blank lines and lines
+// starting with # are ignored.
+func Load(env, path string) (Set, error) {
+	var keys Set
+	for _, k := range strings.Split(os.Getenv(env), ",") {
+		keys = keys.add(k)
+	}
+
+	if path == "" {
+		return keys, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); !strings.HasPrefix(line, "#") {
+			keys = keys.add(line)
+		}
+	}
+
+	return keys, s.Err()
+}
+
+func (k Set) add(key string) Set {
+	if key = strings.TrimSpace(key); key != "" {
+		k = append(k, key)
+	}
+	return k
+}
+
+// Valid reports whether key is in the set, comparing in constant time
+func (k Set) Valid(key string) bool {
+	ok := 0
+	for _, want := range k {
+		ok |= subtle.ConstantTimeCompare([]byte(key), []byte(want))
+	}
+	return key != "" && ok == 1
+}
+
+// FromRequest returns the key given as "Authorization: Bearer <key>" or
+// "X-API-Key: <key>"
+func FromRequest(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimPrefix(h, "Bearer ")
+	}
+
+	return r.Header.Get("X-API-Key")
+}
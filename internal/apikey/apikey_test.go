@@ -0,0 +1,91 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package apikey
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := ioutil.WriteFile(path, []byte("# keys\nfile-key\n\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_API_KEYS", "env-key, other ,")
+
+	keys, err := Load("TEST_API_KEYS", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"env-key", "other", "file-key"} {
+		if !keys.Valid(key) {
+			t.Errorf("key %q not valid", key)
+		}
+	}
+	for _, key := range []string{"", "# keys", "env"} {
+		if keys.Valid(key) {
+			t.Errorf("key %q valid", key)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-API-Key", "header")
+	if key := FromRequest(r); key != "header" {
+		t.Errorf("FromRequest = %q, want header", key)
+	}
+
+	r.Header.Set("Authorization", "Bearer bearer")
+	if key := FromRequest(r); key != "bearer" {
+		t.Errorf("FromRequest = %q, want bearer", key)
+	}
+}
+
+func TestIssuer(t *testing.T) {
+	issuer, err := NewIssuer(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, issued, expiry, err := issuer.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiry.Sub(issued) != time.Minute {
+		t.Errorf("token valid for %v, want 1m", expiry.Sub(issued))
+	}
+	if !issuer.Valid(token) {
+		t.Error("issued token not valid")
+	}
+
+	other, err := NewIssuer(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := []byte(token)
+	tampered[0] ^= 'A' ^ 'B'
+	var none *Issuer
+	for name, valid := range map[string]bool{
+		"tampered":     issuer.Valid(string(tampered)),
+		"other issuer": other.Valid(token),
+		"no issuer":    none.Valid(token),
+		"empty":        issuer.Valid(""),
+		"not a token":  issuer.Valid("key"),
+		"no signature": issuer.Valid(token[:strings.IndexByte(token, '.')]),
+	} {
+		if valid {
+			t.Errorf("%s token valid", name)
+		}
+	}
+
+	issuer.now = func() time.Time { return expiry }
+	if issuer.Valid(token) {
+		t.Error("expired token valid")
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package apikey
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// TokenHandler issues tokens of issuer for accountID, as a JSON
+// cerevoicego.Token, to POST requests authenticated with one of keys. A
+// token can not be exchanged for another.
+func TokenHandler(keys Set, issuer *Issuer, accountID string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodPost:
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		case !keys.Valid(FromRequest(r)):
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+
+		value, issued, expiry, err := issuer.Issue()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(cerevoicego.Token{
+			AccountID: accountID,
+			Value:     value,
+			Expiry:    expiry,
+			IssuedAt:  issued,
+		})
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
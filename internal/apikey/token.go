@@ -0,0 +1,79 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package apikey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+// Issuer issues temporary tokens accepted in place of an API key, and checks
+// them. Tokens are signed with a secret made when the Issuer is, so they are
+// not accepted by other processes or after a restart.
+type Issuer struct {
+	TTL time.Duration // How long a token is valid
+
+	secret []byte
+	now    func() time.Time
+}
+
+// NewIssuer returns an Issuer of tokens valid for ttl
+func NewIssuer(ttl time.Duration) (*Issuer, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return &Issuer{TTL: ttl, secret: secret, now: time.Now}, nil
+}
+
+// Issue returns a new token, when it was issued and when it expires
+func (i *Issuer) Issue() (token string, issued, expiry time.Time, err error) {
+	issued = i.now()
+	expiry = issued.Add(i.TTL)
+
+	payload := make([]byte, 24)
+	binary.BigEndian.PutUint64(payload, uint64(expiry.Unix()))
+	if _, err := rand.Read(payload[8:]); err != nil {
+		return "", time.Time{}, time.Time{}, err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(i.sign(payload))
+	return token, issued, expiry, nil
+}
+
+// Valid reports whether token was issued by i and has not expired. A nil
+// Issuer accepts no tokens.
+func (i *Issuer) Valid(token string) bool {
+	if i == nil {
+		return false
+	}
+
+	p := strings.IndexByte(token, '.')
+	if p < 0 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(token[:p])
+	if err != nil || len(payload) != 24 {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(token[p+1:])
+	if err != nil || !hmac.Equal(sig, i.sign(payload)) {
+		return false
+	}
+
+	return i.now().Unix() < int64(binary.BigEndian.Uint64(payload))
+}
+
+func (i *Issuer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
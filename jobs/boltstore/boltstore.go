@@ -0,0 +1,147 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package boltstore is a jobs.Store keeping the queue in a BoltDB file. It
+// is a separate module so the jobs package does not depend on BoltDB.
+//
+//	store, err := boltstore.Open("/var/lib/myapp/tts.db")
+//	...
+//	defer store.Close()
+//	q := jobs.New(store, client, cerevoicego.StoreIn(bucket))
+//
+// Each Put is a transaction synced to disk, so unlike a DirStore a queue of
+// many jobs is kept in one file and listed without reading a file per job.
+package boltstore
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/bganderson/cerevoicego/jobs"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket holds each job as JSON keyed by its ID
+var bucket = []byte("jobs")
+
+// openTimeout is how long Open waits for another process to release the
+// file, as a queue must only be run by one process at a time
+const openTimeout = time.Second
+
+// ErrLocked is returned by Open when another process has the file open
+var ErrLocked = errors.New("boltstore: database is in use by another process")
+
+// Store is a jobs.Store backed by a BoltDB database
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database at path
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err == bolt.ErrTimeout {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// New returns a Store keeping jobs in a bucket of an open database, which
+// may hold other buckets of the application
+func New(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put creates or replaces job
+func (s *Store) Put(job *jobs.Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(job.ID), b)
+	})
+}
+
+// Get returns the job with id
+func (s *Store) Get(id string) (*jobs.Job, error) {
+	job := &jobs.Job{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket).Get([]byte(id))
+		if b == nil {
+			return jobs.ErrNotFound
+		}
+		return json.Unmarshal(b, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// List returns the jobs with status, or every job if status is empty,
+// oldest first
+func (s *Store) List(status jobs.Status) ([]*jobs.Job, error) {
+	var list []*jobs.Job
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			job := &jobs.Job{}
+			if err := json.Unmarshal(v, job); err != nil {
+				return err
+			}
+			if status == "" || job.Status == status {
+				list = append(list, job)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Created.Equal(list[j].Created) {
+			return list[i].Created.Before(list[j].Created)
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	return list, nil
+}
+
+// Delete removes the job with id
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b.Get([]byte(id)) == nil {
+			return jobs.ErrNotFound
+		}
+		return b.Delete([]byte(id))
+	})
+}
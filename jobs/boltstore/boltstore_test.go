@@ -0,0 +1,174 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package boltstore
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/jobs"
+)
+
+func open(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func ids(list []*jobs.Job) string {
+	var ids []string
+	for _, job := range list {
+		ids = append(ids, job.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	s := open(t, path)
+
+	now := time.Now().UTC()
+	put := []*jobs.Job{
+		{ID: "c", Status: jobs.Pending, Created: now},
+		{ID: "a", Status: jobs.Done, Created: now.Add(time.Second), Location: "mem://a"},
+		{ID: "b", Status: jobs.Pending, Created: now},
+		{ID: "d", Status: jobs.Failed, Created: now.Add(-time.Second), Error: "invalid voice",
+			Input: cerevoicego.SpeakExtendedInput{Voice: "Nobody", Text: "Hello"}},
+	}
+	for _, job := range put {
+		if err := s.Put(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Jobs survive reopening the database
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s = open(t, path)
+	defer s.Close()
+
+	lists := []struct {
+		status jobs.Status
+		want   string
+	}{
+		{"", "d,b,c,a"},
+		{jobs.Pending, "b,c"},
+		{jobs.Done, "a"},
+		{jobs.Running, ""},
+	}
+	for _, tt := range lists {
+		list, err := s.List(tt.status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(list); got != tt.want {
+			t.Errorf("List(%q) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+
+	job, err := s.Get("d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Error != "invalid voice" || job.Input.Text != "Hello" || !job.Created.Equal(put[3].Created) {
+		t.Errorf("Get(d) = %+v", job)
+	}
+
+	// Put replaces the job
+	job.Status = jobs.Pending
+	if err := s.Put(job); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := s.List(jobs.Pending); ids(list) != "d,b,c" {
+		t.Errorf("pending after retry = %s, want d,b,c", ids(list))
+	}
+
+	if err := s.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	missing := []struct {
+		name string
+		err  error
+	}{
+		{"get", func() error { _, err := s.Get("a"); return err }()},
+		{"delete", s.Delete("a")},
+		{"get unknown", func() error { _, err := s.Get("z"); return err }()},
+	}
+	for _, tt := range missing {
+		if tt.err != jobs.ErrNotFound {
+			t.Errorf("%s: error %v, want ErrNotFound", tt.name, tt.err)
+		}
+	}
+}
+
+func TestOpenLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	s := open(t, path)
+	defer s.Close()
+
+	if _, err := Open(path); err != ErrLocked {
+		t.Errorf("second Open: error %v, want ErrLocked", err)
+	}
+}
+
+// synth returns the text of each job as its audio
+type synth struct{}
+
+func (synth) Synthesize(ctx context.Context, input *cerevoicego.SpeakExtendedInput) ([]byte, error) {
+	return []byte(input.Text), nil
+}
+
+func (synth) Voices(ctx context.Context) (cerevoicego.VoiceCatalog, error) {
+	return nil, nil
+}
+
+func TestQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	s := open(t, path)
+	defer s.Close()
+
+	q := jobs.New(s, synth{}, func(ctx context.Context, key string, audio []byte, contentType string) (string, error) {
+		return "mem://" + string(audio), nil
+	})
+	q.PollInterval = 10 * time.Millisecond
+	done := make(chan jobs.Job, 10)
+	q.OnUpdate = func(job *jobs.Job) {
+		if job.Status == jobs.Done {
+			done <- *job
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	job, err := q.Enqueue(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-done:
+		if got.ID != job.ID || got.Location != "mem://Hello" {
+			t.Errorf("done job = %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job not done")
+	}
+
+	stored, err := s.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != jobs.Done {
+		t.Errorf("stored status %s, want done", stored.Status)
+	}
+}
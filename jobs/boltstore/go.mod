@@ -0,0 +1,12 @@
+module github.com/bganderson/cerevoicego/jobs/boltstore
+
+go 1.21
+
+require (
+	github.com/bganderson/cerevoicego v0.0.0
+	go.etcd.io/bbolt v1.3.10
+)
+
+require golang.org/x/sys v0.21.0 // indirect
+
+replace github.com/bganderson/cerevoicego => ../../
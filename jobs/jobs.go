@@ -0,0 +1,486 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package jobs is a durable queue of synthesis tasks. Jobs are persisted in
+// a Store, so they survive restarts, and drained by workers which retry
+// failures with exponential backoff and save the audio with a StoreFunc.
+//
+//	q := jobs.New(jobs.NewDirStore("queue"), client, cerevoicego.StoreIn(bucket))
+//	go q.Run(ctx)
+//
+//	job, err := q.Enqueue(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"})
+//	...
+//	job, err = q.Job(job.ID)
+//	fmt.Println(job.Status, job.Location)
+//
+// A queue must be run by a single process at a time. Pending jobs are
+// indexed in memory, so jobs put in the Store other than by Enqueue are
+// only picked up when the queue is next run. Done jobs are deleted once
+// they are older than the queue's Retention.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of attempts made per job
+	DefaultMaxAttempts = 3
+	// DefaultBackoff is the default delay before the first retry
+	DefaultBackoff = 10 * time.Second
+	// DefaultPollInterval is the default interval between checks for jobs
+	// which have become due
+	DefaultPollInterval = time.Second
+	// DefaultRetention is the default time done jobs are kept
+	DefaultRetention = 7 * 24 * time.Hour
+)
+
+// pruneInterval is the longest interval between deletions of old done jobs
+const pruneInterval = time.Hour
+
+// Status is the state of a job
+type Status string
+
+// Job states
+const (
+	Pending Status = "pending" // Waiting to run, or to be retried
+	Running Status = "running"
+	Done    Status = "done"
+	Failed  Status = "failed" // Every attempt failed, or the failure is permanent
+)
+
+// Job is a queued synthesis task
+type Job struct {
+	ID        string                         `json:"id"`
+	Input     cerevoicego.SpeakExtendedInput `json:"input"`
+	Status    Status                         `json:"status"`
+	Attempts  int                            `json:"attempts"`
+	Location  string                         `json:"location,omitempty"` // URL of the audio once Done
+	Error     string                         `json:"error,omitempty"`    // Error of the last attempt
+	Created   time.Time                      `json:"created"`
+	Updated   time.Time                      `json:"updated"`
+	NotBefore time.Time                      `json:"notBefore,omitempty"` // When a retry is due
+}
+
+// Queue runs jobs from a Store
+type Queue struct {
+	Store       Store
+	Synthesizer cerevoicego.Synthesizer // Synthesises each job, such as a *cerevoicego.Client
+	Output      cerevoicego.StoreFunc   // Saves the audio of each job
+
+	Workers      int           // Concurrent jobs, 1 if 0
+	MaxAttempts  int           // Attempts per job, DefaultMaxAttempts if 0
+	Backoff      time.Duration // Delay before the first retry, doubling after each, DefaultBackoff if 0
+	PollInterval time.Duration // DefaultPollInterval if 0
+	Retention    time.Duration // How long done jobs are kept, DefaultRetention if 0, forever if negative
+
+	// OnUpdate, if set, is called after a job changes state. It must not
+	// call the Queue.
+	OnUpdate func(job *Job)
+	// OnError, if set, receives errors of the Store while the queue runs,
+	// which are otherwise ignored as there is no caller to return them to
+	OnError func(err error)
+
+	mu      sync.Mutex
+	wake    chan struct{}
+	pending map[string]pendingJob // Index of the pending jobs, by ID
+}
+
+// pendingJob is the entry of a pending job in the index, enough to choose
+// which job to claim next
+type pendingJob struct {
+	created   time.Time
+	notBefore time.Time
+}
+
+// New returns a Queue of jobs kept in store, synthesised with synth and
+// saved with output
+func New(store Store, synth cerevoicego.Synthesizer, output cerevoicego.StoreFunc) *Queue {
+	return &Queue{Store: store, Synthesizer: synth, Output: output}
+}
+
+// Enqueue adds a job for input, returning it with its ID. The input is
+// checked first, so invalid requests are refused rather than queued.
+func (q *Queue) Enqueue(input *cerevoicego.SpeakExtendedInput) (*Job, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &Job{ID: id, Input: *input, Status: Pending, Created: now, Updated: now}
+	if err := q.Store.Put(job); err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.index(job)
+	q.notifyLocked()
+
+	return job, nil
+}
+
+// Job returns the job with id, or ErrNotFound
+func (q *Queue) Job(id string) (*Job, error) {
+	return q.Store.Get(id)
+}
+
+// Jobs returns the jobs with status, or every job if status is empty
+func (q *Queue) Jobs(status Status) ([]*Job, error) {
+	return q.Store.List(status)
+}
+
+// Retry makes a failed job pending again with its attempts reset
+func (q *Queue) Retry(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, err := q.Store.Get(id)
+	if err != nil {
+		return err
+	}
+	if job.Status != Failed {
+		return errors.New("jobs: only failed jobs can be retried")
+	}
+
+	job.Status, job.Attempts, job.NotBefore = Pending, 0, time.Time{}
+	if err := q.update(job); err != nil {
+		return err
+	}
+	q.index(job)
+	q.notifyLocked()
+
+	return nil
+}
+
+// Run works through the queue until ctx ends, returning its error. Jobs left
+// running by a previous process are made pending again first.
+func (q *Queue) Run(ctx context.Context) error {
+	if err := q.recover(); err != nil {
+		return err
+	}
+
+	if retention := q.retention(); retention >= 0 {
+		interval := retention
+		if interval > pruneInterval {
+			interval = pruneInterval
+		}
+		go q.prune(ctx, retention, interval)
+	}
+
+	workers := q.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// recover returns jobs interrupted while running to the queue and indexes
+// the pending jobs
+func (q *Queue) recover() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs, err := q.Store.List(Running)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		job.Status = Pending
+		if err := q.update(job); err != nil {
+			return err
+		}
+	}
+
+	jobs, err = q.Store.List(Pending)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		q.index(job)
+	}
+
+	return nil
+}
+
+// Prune deletes done jobs last updated more than olderThan ago, returning
+// how many were deleted. Failed jobs are kept, so they can be retried.
+func (q *Queue) Prune(olderThan time.Duration) (int, error) {
+	jobs, err := q.Store.List(Done)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	cutoff := time.Now().Add(-olderThan)
+	for _, job := range jobs {
+		if !job.Updated.Before(cutoff) {
+			continue
+		}
+		if err := q.Store.Delete(job.ID); err != nil && err != ErrNotFound {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// prune deletes done jobs older than retention every interval until ctx
+// ends
+func (q *Queue) prune(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := q.Prune(retention); err != nil {
+			q.reportError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// work runs jobs as they become due until ctx ends
+func (q *Queue) work(ctx context.Context) {
+	interval := q.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	wake := q.wakeChan()
+	for ctx.Err() == nil {
+		job, err := q.claim()
+		if err != nil {
+			q.reportError(err)
+		}
+		if job != nil {
+			q.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim marks the oldest due pending job running and returns it, or nil if
+// none is due. The job is chosen from the index, so only it is read from the
+// Store.
+func (q *Queue) claim() (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		id, ok := q.due(time.Now())
+		if !ok {
+			return nil, nil
+		}
+
+		job, err := q.Store.Get(id)
+		if err == ErrNotFound {
+			delete(q.pending, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if job.Status != Pending {
+			delete(q.pending, id)
+			continue
+		}
+
+		job.Status = Running
+		job.Attempts++
+		if err := q.update(job); err != nil {
+			return nil, err
+		}
+		delete(q.pending, id)
+
+		return job, nil
+	}
+}
+
+// due returns the ID of the oldest indexed job due at now, q.mu must be
+// held
+func (q *Queue) due(now time.Time) (string, bool) {
+	var id string
+	var oldest pendingJob
+	for i, p := range q.pending {
+		if p.notBefore.After(now) {
+			continue
+		}
+		if id == "" || p.created.Before(oldest.created) || p.created.Equal(oldest.created) && i < id {
+			id, oldest = i, p
+		}
+	}
+
+	return id, id != ""
+}
+
+// index adds a pending job to the index, q.mu must be held
+func (q *Queue) index(job *Job) {
+	if q.pending == nil {
+		q.pending = make(map[string]pendingJob)
+	}
+	q.pending[job.ID] = pendingJob{created: job.Created, notBefore: job.NotBefore}
+}
+
+// run synthesises and saves a claimed job, then records the outcome
+func (q *Queue) run(ctx context.Context, job *Job) {
+	location, err := q.process(ctx, job)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch {
+	case err == nil:
+		job.Status, job.Location, job.Error = Done, location, ""
+	case ctx.Err() != nil:
+		// Interrupted by shutdown rather than failed, so run it again
+		// without counting the attempt
+		job.Status = Pending
+		job.Attempts--
+	case job.Attempts >= q.maxAttempts() || errors.Is(err, cerevoicego.ErrValidation):
+		job.Status, job.Error = Failed, err.Error()
+	default:
+		job.Status, job.Error = Pending, err.Error()
+		job.NotBefore = time.Now().Add(q.backoff(job.Attempts))
+	}
+
+	// If the outcome cannot be saved the job stays running in the Store,
+	// and is run again when the queue is next started
+	if err := q.update(job); err != nil {
+		q.reportError(err)
+	}
+	if job.Status == Pending {
+		q.index(job)
+	}
+}
+
+// process synthesises the job and saves its audio
+func (q *Queue) process(ctx context.Context, job *Job) (string, error) {
+	audio, err := q.Synthesizer.Synthesize(ctx, &job.Input)
+	if err != nil {
+		return "", err
+	}
+
+	return q.Output(ctx, cerevoicego.StorageKey(&job.Input), audio, job.Input.AudioFormat.ContentType())
+}
+
+// update saves job and reports the change, q.mu must be held
+func (q *Queue) update(job *Job) error {
+	job.Updated = time.Now()
+	if err := q.Store.Put(job); err != nil {
+		return err
+	}
+	if q.OnUpdate != nil {
+		j := *job
+		q.OnUpdate(&j)
+	}
+
+	return nil
+}
+
+func (q *Queue) reportError(err error) {
+	if q.OnError != nil {
+		q.OnError(err)
+	}
+}
+
+func (q *Queue) retention() time.Duration {
+	if q.Retention != 0 {
+		return q.Retention
+	}
+
+	return DefaultRetention
+}
+
+func (q *Queue) maxAttempts() int {
+	if q.MaxAttempts > 0 {
+		return q.MaxAttempts
+	}
+
+	return DefaultMaxAttempts
+}
+
+// backoff returns the delay before retrying after attempt n
+func (q *Queue) backoff(n int) time.Duration {
+	d := q.Backoff
+	if d <= 0 {
+		d = DefaultBackoff
+	}
+	for i := 1; i < n; i++ {
+		d *= 2
+	}
+
+	return d
+}
+
+// wakeChan returns the channel signalled when a job is enqueued
+func (q *Queue) wakeChan() chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.wake == nil {
+		q.wake = make(chan struct{}, 1)
+	}
+
+	return q.wake
+}
+
+// notifyLocked wakes a waiting worker, q.mu must be held
+func (q *Queue) notifyLocked() {
+	if q.wake == nil {
+		q.wake = make(chan struct{}, 1)
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// newID returns a random job ID
+func newID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
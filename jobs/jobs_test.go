@@ -0,0 +1,229 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/jobs"
+)
+
+// synth is a Synthesizer which blocks each call until released, if block is
+// set, and counts the calls
+type synth struct {
+	mu    sync.Mutex
+	calls int
+	block chan struct{}
+	err   error
+}
+
+func (s *synth) Synthesize(ctx context.Context, input *cerevoicego.SpeakExtendedInput) ([]byte, error) {
+	s.mu.Lock()
+	s.calls++
+	block := s.block
+	s.mu.Unlock()
+
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []byte(input.Text), nil
+}
+
+func (s *synth) Voices(ctx context.Context) (cerevoicego.VoiceCatalog, error) {
+	return nil, nil
+}
+
+func output(ctx context.Context, key string, audio []byte, contentType string) (string, error) {
+	return "mem://" + string(audio), nil
+}
+
+// newQueue returns a queue over store reporting each update on the returned
+// channel
+func newQueue(store jobs.Store, s *synth) (*jobs.Queue, chan jobs.Job) {
+	q := jobs.New(store, s, output)
+	q.PollInterval = 10 * time.Millisecond
+	updates := make(chan jobs.Job, 100)
+	q.OnUpdate = func(job *jobs.Job) { updates <- *job }
+	return q, updates
+}
+
+// waitFor returns the first update of a job to status
+func waitFor(t *testing.T, updates chan jobs.Job, status jobs.Status) jobs.Job {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case job := <-updates:
+			if job.Status == status {
+				return job
+			}
+		case <-timeout:
+			t.Fatalf("no job became %s", status)
+		}
+	}
+}
+
+func TestQueueResumesAfterCrash(t *testing.T) {
+	store := jobs.NewDirStore(t.TempDir())
+
+	// A process crashed with one job running and one pending
+	now := time.Now()
+	running := &jobs.Job{ID: "a", Status: jobs.Running, Attempts: 1, Created: now,
+		Input: cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "one"}}
+	pending := &jobs.Job{ID: "b", Status: jobs.Pending, Created: now.Add(time.Millisecond),
+		Input: cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "two"}}
+	for _, job := range []*jobs.Job{running, pending} {
+		if err := store.Put(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q, updates := newQueue(store, &synth{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	first := waitFor(t, updates, jobs.Done)
+	second := waitFor(t, updates, jobs.Done)
+	if first.ID != "a" || second.ID != "b" {
+		t.Errorf("done %s then %s, want the oldest first", first.ID, second.ID)
+	}
+	if first.Attempts != 2 || first.Location != "mem://one" {
+		t.Errorf("resumed job = %+v", first)
+	}
+}
+
+func TestQueueShutdownKeepsAttempt(t *testing.T) {
+	store := jobs.NewDirStore(t.TempDir())
+	s := &synth{block: make(chan struct{})}
+	q, updates := newQueue(store, s)
+
+	job, err := q.Enqueue(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(stopped)
+	}()
+	waitFor(t, updates, jobs.Running)
+	cancel()
+	<-stopped
+
+	got, err := store.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != jobs.Pending || got.Attempts != 0 {
+		t.Fatalf("interrupted job = %s after %d attempts, want pending after 0", got.Status, got.Attempts)
+	}
+
+	// A new process over the same store completes it
+	q, updates = newQueue(store, &synth{})
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+	if done := waitFor(t, updates, jobs.Done); done.ID != job.ID || done.Attempts != 1 {
+		t.Errorf("done = %+v", done)
+	}
+}
+
+func TestQueueRetriesThenFails(t *testing.T) {
+	s := &synth{err: errors.New("unavailable")}
+	q, updates := newQueue(jobs.NewMemoryStore(), s)
+	q.MaxAttempts = 2
+	q.Backoff = time.Millisecond
+
+	if _, err := q.Enqueue(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	failed := waitFor(t, updates, jobs.Failed)
+	if failed.Attempts != 2 || failed.Error != "unavailable" {
+		t.Errorf("failed job = %+v", failed)
+	}
+}
+
+func TestQueuePrune(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := jobs.New(store, &synth{}, output)
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, job := range []*jobs.Job{
+		{ID: "old", Status: jobs.Done, Updated: old},
+		{ID: "new", Status: jobs.Done, Updated: time.Now()},
+		{ID: "failed", Status: jobs.Failed, Updated: old},
+	} {
+		store.Put(job)
+	}
+
+	n, err := q.Prune(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("pruned %d jobs, want 1", n)
+	}
+	if _, err := store.Get("old"); err != jobs.ErrNotFound {
+		t.Errorf("old done job: %v", err)
+	}
+	for _, id := range []string{"new", "failed"} {
+		if _, err := store.Get(id); err != nil {
+			t.Errorf("%s job: %v", id, err)
+		}
+	}
+}
+
+// failingStore fails to save jobs once they are running
+type failingStore struct {
+	*jobs.MemoryStore
+}
+
+func (f failingStore) Put(job *jobs.Job) error {
+	if job.Status != jobs.Pending && job.Status != jobs.Running {
+		return errors.New("disk full")
+	}
+	return f.MemoryStore.Put(job)
+}
+
+func TestQueueReportsStoreErrors(t *testing.T) {
+	q := jobs.New(failingStore{jobs.NewMemoryStore()}, &synth{}, output)
+	q.PollInterval = 10 * time.Millisecond
+	errs := make(chan error, 10)
+	q.OnError = func(err error) { errs <- err }
+
+	if _, err := q.Enqueue(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	select {
+	case err := <-errs:
+		if err.Error() != "disk full" {
+			t.Errorf("OnError(%v)", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("store error not reported")
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bganderson/cerevoicego/internal/atomicfile"
+)
+
+// ErrNotFound is returned for a job which does not exist
+var ErrNotFound = errors.New("jobs: job not found")
+
+// Store persists jobs. Jobs keep their input as enqueued, before TextSteps
+// and any TextAuditor, so a Store holds the raw text and should be protected
+// accordingly. Implementations must be safe for concurrent use. The
+// boltstore module keeps jobs in a BoltDB file where a directory of files is
+// not suitable.
+type Store interface {
+	// Put creates or replaces job
+	Put(job *Job) error
+	// Get returns the job with id, or ErrNotFound
+	Get(id string) (*Job, error)
+	// List returns the jobs with status, or every job if status is empty,
+	// oldest first
+	List(status Status) ([]*Job, error)
+	// Delete removes the job with id
+	Delete(id string) error
+}
+
+// DirStore is a Store keeping each job as a JSON file in a directory, so the
+// queue survives restarts
+type DirStore struct {
+	Dir string // Created if needed
+
+	mu sync.Mutex
+}
+
+// NewDirStore returns a DirStore keeping jobs in dir
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{Dir: dir}
+}
+
+// Put writes job atomically
+func (d *DirStore) Put(job *Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(d.path(job.ID), b, 0600)
+}
+
+// Get reads the job with id
+func (d *DirStore) Get(id string) (*Job, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.read(d.path(id))
+}
+
+// List reads the jobs with status, oldest first
+func (d *DirStore) List(status Status) ([]*Job, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	infos, err := ioutil.ReadDir(d.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*Job
+	for _, info := range infos {
+		if !strings.HasSuffix(info.Name(), ".json") {
+			continue
+		}
+		job, err := d.read(filepath.Join(d.Dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if status == "" || job.Status == status {
+			jobs = append(jobs, job)
+		}
+	}
+	sortJobs(jobs)
+
+	return jobs, nil
+}
+
+// Delete removes the file of the job with id
+func (d *DirStore) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := os.Remove(d.path(id))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func (d *DirStore) read(path string) (*Job, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{}
+	if err := json.Unmarshal(b, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (d *DirStore) path(id string) string {
+	return filepath.Join(d.Dir, filepath.Base(id)+".json")
+}
+
+// MemoryStore is a Store keeping jobs in memory, for tests and queues which
+// need not survive restarts
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]Job)}
+}
+
+// Put stores a copy of job
+func (m *MemoryStore) Put(job *Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.jobs == nil {
+		m.jobs = make(map[string]Job)
+	}
+	m.jobs[job.ID] = *job
+
+	return nil
+}
+
+// Get returns a copy of the job with id
+func (m *MemoryStore) Get(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &job, nil
+}
+
+// List returns copies of the jobs with status, oldest first
+func (m *MemoryStore) List(status Status) ([]*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var jobs []*Job
+	for _, job := range m.jobs {
+		if status == "" || job.Status == status {
+			job := job
+			jobs = append(jobs, &job)
+		}
+	}
+	sortJobs(jobs)
+
+	return jobs, nil
+}
+
+// Delete removes the job with id
+func (m *MemoryStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.jobs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.jobs, id)
+
+	return nil
+}
+
+// sortJobs orders jobs by creation time, then ID
+func sortJobs(jobs []*Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].Created.Equal(jobs[j].Created) {
+			return jobs[i].Created.Before(jobs[j].Created)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// metadataEventJSON is the JSON form of a MetadataEvent, with times in
+// seconds
+type metadataEventJSON struct {
+	Type  string  `json:"type"`
+	Token string  `json:"token"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// MarshalJSON encodes the event with its start and end in seconds
+func (e MetadataEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metadataEventJSON{
+		Type:  e.Type,
+		Token: e.Token,
+		Start: e.Start.Seconds(),
+		End:   e.End.Seconds(),
+	})
+}
+
+// UnmarshalJSON decodes an event encoded by MarshalJSON
+func (e *MetadataEvent) UnmarshalJSON(data []byte) error {
+	var v metadataEventJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*e = MetadataEvent{
+		Type:  v.Type,
+		Token: v.Token,
+		Start: seconds(v.Start),
+		End:   seconds(v.End),
+	}
+	return nil
+}
+
+// MarshalJSON encodes the entry with the duration in seconds and the error
+// as a string
+func (l *RequestLog) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Operation   string     `json:"operation"`
+		Voice       string     `json:"voice,omitempty"`
+		Text        string     `json:"text,omitempty"`
+		TextLength  int        `json:"textLength"`
+		Duration    float64    `json:"duration"`
+		Attempts    int        `json:"attempts"`
+		ResultCode  ResultCode `json:"resultCode"`
+		Description string     `json:"description,omitempty"`
+		CharCount   int        `json:"charCount"`
+		Endpoint    string     `json:"endpoint,omitempty"`
+		Request     string     `json:"request,omitempty"`
+		Conn        *ConnInfo  `json:"conn,omitempty"`
+		Error       string     `json:"error,omitempty"`
+	}{
+		Operation:   l.Operation,
+		Voice:       l.Voice,
+		Text:        l.Text,
+		TextLength:  l.TextLength,
+		Duration:    l.Duration.Seconds(),
+		Attempts:    l.Attempts,
+		ResultCode:  l.ResultCode,
+		Description: l.Description,
+		CharCount:   l.CharCount,
+		Endpoint:    l.Endpoint,
+		Request:     l.Request,
+		Conn:        l.Conn,
+	}
+	if l.Err != nil {
+		v.Error = l.Err.Error()
+	}
+
+	return json.Marshal(v)
+}
+
+// MarshalJSON encodes the connection with its times in seconds
+func (c ConnInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Reused    bool    `json:"reused"`
+		WasIdle   bool    `json:"wasIdle"`
+		IdleTime  float64 `json:"idleTime"`
+		Protocol  string  `json:"protocol,omitempty"`
+		DNS       float64 `json:"dns"`
+		Connect   float64 `json:"connect"`
+		TLS       float64 `json:"tls"`
+		FirstByte float64 `json:"firstByte"`
+	}{
+		Reused:    c.Reused,
+		WasIdle:   c.WasIdle,
+		IdleTime:  c.IdleTime.Seconds(),
+		Protocol:  c.Protocol,
+		DNS:       c.DNS.Seconds(),
+		Connect:   c.Connect.Seconds(),
+		TLS:       c.TLS.Seconds(),
+		FirstByte: c.FirstByte.Seconds(),
+	})
+}
+
+// MarshalJSON encodes the health with its readiness, the latency in seconds
+// and the error as a string
+func (h *Health) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Ready          bool      `json:"ready"`
+		Reachable      bool      `json:"reachable"`
+		AuthOK         bool      `json:"authOk"`
+		Credit         bool      `json:"credit"`
+		CharsAvailable int       `json:"charsAvailable"`
+		Latency        float64   `json:"latency"`
+		Checked        time.Time `json:"checked"`
+		Error          string    `json:"error,omitempty"`
+	}{
+		Ready:          h.Ready(),
+		Reachable:      h.Reachable,
+		AuthOK:         h.AuthOK,
+		Credit:         h.Credit,
+		CharsAvailable: h.CharsAvailable,
+		Latency:        h.Latency.Seconds(),
+		Checked:        h.Checked,
+	}
+	if h.Err != nil {
+		v.Error = h.Err.Error()
+	}
+
+	return json.Marshal(v)
+}
+
+// seconds converts fractional seconds to a Duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
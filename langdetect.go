@@ -0,0 +1,236 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// LanguageDetector guesses the language of text, returning an ISO 639 code
+// or BCP 47 tag and a confidence from 0 to 1. An empty tag means unknown.
+type LanguageDetector interface {
+	DetectLanguage(text string) (tag string, confidence float64)
+}
+
+// LanguageDetectorFunc adapts a function to a LanguageDetector
+type LanguageDetectorFunc func(text string) (string, float64)
+
+// DetectLanguage calls f(text)
+func (f LanguageDetectorFunc) DetectLanguage(text string) (string, float64) {
+	return f(text)
+}
+
+// DefaultLanguageDetector is a small built-in detector. It identifies
+// languages by their script, and Latin script languages by common words, so
+// it suits sentences rather than single words. Plug in a statistical
+// detector for better accuracy.
+var DefaultLanguageDetector LanguageDetector = LanguageDetectorFunc(detectLanguage)
+
+// LanguageDetection configures detecting the language of the text of speak
+// requests. A voice speaking it is chosen for requests without a voice, and
+// OnMismatch is called for requests whose voice speaks another language.
+type LanguageDetection struct {
+	Detector      LanguageDetector // DefaultLanguageDetector if nil
+	Voices        *VoiceCache      // Catalogue to choose from, listVoices is called per request if nil
+	Sex           Sex              // Preferred sex of chosen voices, empty for either
+	MinConfidence float64          // Detections less confident than this are ignored
+
+	// OnMismatch, if set, is called when the voice of a request does not
+	// speak the language detected in its text. The request is still made.
+	OnMismatch func(voice Voice, detected string)
+}
+
+// WithLanguageDetection enables choosing voices by the language of the text
+func WithLanguageDetection(d *LanguageDetection) ClientOption {
+	return func(c *Client) {
+		c.LanguageDetection = d
+	}
+}
+
+// DetectLanguage returns the language of text, ignoring markup, using the
+// Client LanguageDetection detector or DefaultLanguageDetector
+func (c *Client) DetectLanguage(text string) (tag string, confidence float64) {
+	detector := DefaultLanguageDetector
+	if c.LanguageDetection != nil && c.LanguageDetection.Detector != nil {
+		detector = c.LanguageDetection.Detector
+	}
+
+	return detector.DetectLanguage(html.UnescapeString(markup.ReplaceAllString(text, " ")))
+}
+
+// SelectVoice returns the voice best suited to the language of text,
+// preferring sex if it is not empty
+func (c *Client) SelectVoice(ctx context.Context, text string, sex Sex) (Voice, error) {
+	tag, _ := c.DetectLanguage(text)
+	if tag == "" {
+		return Voice{}, fmt.Errorf("%w: language of text not recognised", ErrInvalidVoice)
+	}
+
+	voices, err := c.detectionVoices(ctx)
+	if err != nil {
+		return Voice{}, err
+	}
+
+	voice, ok := voices.Find(tag, sex)
+	if !ok {
+		return Voice{}, fmt.Errorf("%w: no voice speaks %s", ErrInvalidVoice, tag)
+	}
+
+	return voice, nil
+}
+
+// detectVoice applies the LanguageDetection to a speak request, returning
+// the voice to use
+func (c *Client) detectVoice(ctx context.Context, voice, text string) (string, error) {
+	d := c.LanguageDetection
+	if d == nil || (voice != "" && d.OnMismatch == nil) || strings.TrimSpace(text) == "" {
+		return voice, nil
+	}
+
+	tag, confidence := c.DetectLanguage(text)
+	if tag == "" || confidence < d.MinConfidence {
+		return voice, nil
+	}
+
+	voices, err := c.detectionVoices(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if voice == "" {
+		v, ok := voices.Find(tag, d.Sex)
+		if !ok {
+			return "", fmt.Errorf("%w: no voice speaks %s", ErrInvalidVoice, tag)
+		}
+		return v.VoiceName, nil
+	}
+
+	language, _ := parseLanguageTag(NormalizeLanguageTag(tag))
+	if v, ok := voices.Lookup(voice); ok && !strings.EqualFold(v.LanguageCodeISO, language) {
+		d.OnMismatch(v, tag)
+	}
+
+	return voice, nil
+}
+
+// detectionVoices returns the catalogue voices are chosen from
+func (c *Client) detectionVoices(ctx context.Context) (VoiceCatalog, error) {
+	if d := c.LanguageDetection; d != nil && d.Voices != nil {
+		return d.Voices.Voices(ctx)
+	}
+
+	return c.Voices(ctx)
+}
+
+// scriptLanguages maps scripts used by a single common language to it
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Cyrillic, "ru"},
+}
+
+// stopWords are frequent short words of Latin script languages
+var stopWords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "to", "of", "in", "it", "that", "with", "for", "this", "have", "your"},
+	"fr": {"le", "la", "les", "et", "est", "vous", "de", "des", "un", "une", "que", "pour", "dans", "avec", "nous"},
+	"de": {"der", "die", "das", "und", "ist", "sie", "nicht", "ein", "eine", "zu", "mit", "ich", "wir", "auf", "für"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "un", "una", "para", "con", "por", "usted", "está"},
+	"it": {"il", "lo", "la", "gli", "e", "è", "che", "di", "un", "una", "per", "con", "non", "sono", "questo"},
+	"pt": {"o", "os", "as", "e", "é", "que", "de", "um", "uma", "para", "com", "não", "você", "está", "são"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "van", "dat", "met", "voor", "zijn", "wij", "u", "op", "ik"},
+	"ca": {"el", "la", "els", "les", "i", "és", "que", "de", "un", "una", "per", "amb", "no", "això", "està"},
+	"sv": {"och", "är", "att", "det", "en", "ett", "som", "på", "för", "med", "inte", "jag", "vi", "av", "till"},
+	"cy": {"y", "yr", "a", "ac", "mae", "yn", "i", "ar", "o", "ei", "am", "gyda", "chi", "bod", "hwn"},
+	"ga": {"an", "na", "agus", "is", "tá", "ar", "le", "ag", "go", "sé", "sí", "mé", "bhí", "ní", "seo"},
+}
+
+// detectLanguage is the DefaultLanguageDetector
+func detectLanguage(text string) (string, float64) {
+	scripts := make(map[string]int)
+	latin, letters := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.script, r) {
+				scripts[s.language]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return "", 0
+	}
+
+	// Japanese mixes kana with Han characters
+	if scripts["ja"] > 0 {
+		scripts["ja"] += scripts["zh"]
+		delete(scripts, "zh")
+	}
+
+	best, bestCount := "", 0
+	for language, n := range scripts {
+		if n > bestCount || (n == bestCount && language < best) {
+			best, bestCount = language, n
+		}
+	}
+	if bestCount > latin {
+		return best, float64(bestCount) / float64(letters)
+	}
+
+	return detectLatin(text)
+}
+
+// detectLatin scores Latin script text by the stop words of each language
+func detectLatin(text string) (string, float64) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	scores := make(map[string]int)
+	total := 0
+	for _, w := range words {
+		for language, stops := range stopWords {
+			for _, s := range stops {
+				if w == s {
+					scores[language]++
+					total++
+					break
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return "", 0
+	}
+
+	best, bestScore := "", 0
+	for language, n := range scores {
+		if n > bestScore || (n == bestScore && language < best) {
+			best, bestScore = language, n
+		}
+	}
+
+	return best, float64(bestScore) / float64(total)
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"sort"
+	"strings"
+)
+
+// Language groups the voices of a catalogue speaking one language
+type Language struct {
+	Code    string       // ISO 639 language code, lower case, e.g. "en"
+	Accents []Accent     // Accents of the language, sorted by Tag and Code
+	Voices  VoiceCatalog // Voices of every accent
+}
+
+// Accent groups the voices of a catalogue speaking one accent of a language
+type Accent struct {
+	Tag      string       // BCP 47 language tag, e.g. "en-GB"
+	Language string       // ISO 639 language code, e.g. "en"
+	Region   string       // ISO 3166 country code, e.g. "GB", may be empty
+	Code     string       // CereVoice accent code
+	Name     string       // Accent name, e.g. "Scottish"
+	Country  string       // Country name
+	Voices   VoiceCatalog // Voices with the accent
+}
+
+// deprecatedLanguages maps withdrawn ISO 639 codes to their replacements
+var deprecatedLanguages = map[string]string{
+	"in": "id",
+	"iw": "he",
+	"ji": "yi",
+	"jw": "jv",
+	"mo": "ro",
+}
+
+// LanguageTag returns the normalised BCP 47 tag for a language and region,
+// e.g. LanguageTag("EN", "gb") is "en-GB". Withdrawn language codes are
+// replaced and region may be empty.
+func LanguageTag(language, region string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := deprecatedLanguages[language]; ok {
+		language = code
+	}
+
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if language == "" || region == "" {
+		return language
+	}
+
+	return language + "-" + region
+}
+
+// NormalizeLanguageTag returns tag in the normalised form used by Accent,
+// e.g. "en_gb" becomes "en-GB". Subtags other than the language and region
+// are dropped.
+func NormalizeLanguageTag(tag string) string {
+	return LanguageTag(parseLanguageTag(tag))
+}
+
+// LanguageTag returns the normalised BCP 47 tag of the voice
+func (v Voice) LanguageTag() string {
+	return LanguageTag(v.LanguageCodeISO, v.CountryCodeISO)
+}
+
+// Languages groups the voices by language, sorted by code. Voices without a
+// language code are omitted.
+func (vc VoiceCatalog) Languages() []Language {
+	var languages []Language
+	index := make(map[string]int)
+
+	for _, a := range vc.Accents() {
+		i, ok := index[a.Language]
+		if !ok {
+			i = len(languages)
+			index[a.Language] = i
+			languages = append(languages, Language{Code: a.Language})
+		}
+		languages[i].Accents = append(languages[i].Accents, a)
+		languages[i].Voices = append(languages[i].Voices, a.Voices...)
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].Code < languages[j].Code
+	})
+
+	return languages
+}
+
+// Accents groups the voices by language, region and accent code, sorted by
+// Tag and then Code. Voices without a language code are omitted.
+func (vc VoiceCatalog) Accents() []Accent {
+	var accents []Accent
+	index := make(map[string]int)
+
+	for _, v := range vc {
+		tag := v.LanguageTag()
+		if tag == "" {
+			continue
+		}
+
+		key := tag + "\x00" + strings.ToLower(v.AccentCode)
+		i, ok := index[key]
+		if !ok {
+			i = len(accents)
+			index[key] = i
+			language, region := parseLanguageTag(tag)
+			accents = append(accents, Accent{
+				Tag:      tag,
+				Language: language,
+				Region:   region,
+				Code:     v.AccentCode,
+				Name:     v.Accent,
+				Country:  v.Country,
+			})
+		}
+		accents[i].Voices = append(accents[i].Voices, v)
+	}
+
+	sort.SliceStable(accents, func(i, j int) bool {
+		if accents[i].Tag != accents[j].Tag {
+			return accents[i].Tag < accents[j].Tag
+		}
+		return accents[i].Code < accents[j].Code
+	})
+
+	return accents
+}
+
+// Language returns the group for an ISO 639 code or BCP 47 tag, of which
+// only the language is used
+func (vc VoiceCatalog) Language(tag string) (Language, bool) {
+	language, _ := parseLanguageTag(NormalizeLanguageTag(tag))
+	for _, l := range vc.Languages() {
+		if l.Code == language {
+			return l, true
+		}
+	}
+
+	return Language{}, false
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package lexicon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Change is an entry whose transcription differs between two lexicons
+type Change struct {
+	Old Entry
+	New Entry
+}
+
+// Diff lists the differences between two lexicons. Entries are matched by
+// headword and part of speech; their order and line numbers are ignored.
+type Diff struct {
+	Added   []Entry  // Entries only in the new lexicon
+	Removed []Entry  // Entries only in the old lexicon
+	Changed []Change // Entries with a different transcription
+}
+
+// Compare returns the differences from old to new, either of which may be
+// nil. Entries are listed in the order of the lexicon they come from.
+func Compare(old, new *Lexicon) *Diff {
+	d := &Diff{}
+
+	before := make(map[string]Entry)
+	if old != nil {
+		for _, e := range old.Entries {
+			before[key(e)] = e
+		}
+	}
+
+	after := make(map[string]bool)
+	if new != nil {
+		for _, e := range new.Entries {
+			after[key(e)] = true
+			prev, ok := before[key(e)]
+			switch {
+			case !ok:
+				d.Added = append(d.Added, e)
+			case !sameTranscription(prev, e):
+				d.Changed = append(d.Changed, Change{Old: prev, New: e})
+			}
+		}
+	}
+
+	if old != nil {
+		for _, e := range old.Entries {
+			if !after[key(e)] {
+				d.Removed = append(d.Removed, e)
+			}
+		}
+	}
+
+	return d
+}
+
+// Empty reports whether there are no differences
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String lists the differences one per line, prefixed + for added entries,
+// - for removed entries and ~ for changed entries
+func (d *Diff) String() string {
+	var b strings.Builder
+	for _, e := range d.Added {
+		fmt.Fprintf(&b, "+ %s\n", e)
+	}
+	for _, e := range d.Removed {
+		fmt.Fprintf(&b, "- %s\n", e)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %s\t%s\t%s -> %s\n", c.New.Headword, c.New.POS,
+			strings.Join(c.Old.Transcription, " "), strings.Join(c.New.Transcription, " "))
+	}
+
+	return b.String()
+}
+
+// key identifies an entry within a lexicon
+func key(e Entry) string {
+	return e.Headword + "\x00" + e.POS
+}
+
+func sameTranscription(a, b Entry) bool {
+	if len(a.Transcription) != len(b.Transcription) {
+		return false
+	}
+	for i := range a.Transcription {
+		if a.Transcription[i] != b.Transcription[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,296 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package lexicon parses and validates CereProc lexicon files so mistakes
+// can be found before they are uploaded with UploadLexicon.
+//
+// A lexicon file contains one entry per line made up of whitespace separated
+// fields: the headword, a part of speech tag and the phonetic transcription
+// as one or more phones. Phones are lower case letters or "@" with an
+// optional stress digit. Blank lines and lines starting with "#" are ignored.
+//
+//	# headword  pos  transcription
+//	cereproc    n    s e1 r @0 p r o0 k
+//	tomato      n    t @0 m aa1 t ou0
+package lexicon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Parts of speech accepted in a lexicon entry
+var Parts = map[string]string{
+	"n":    "noun",
+	"v":    "verb",
+	"adj":  "adjective",
+	"adv":  "adverb",
+	"pron": "pronoun",
+	"prep": "preposition",
+	"conj": "conjunction",
+	"det":  "determiner",
+	"int":  "interjection",
+	"x":    "any",
+}
+
+// Entry is a single lexicon entry
+type Entry struct {
+	Headword      string
+	POS           string
+	Transcription []string
+	Line          int // Line number in the parsed file, 0 if not parsed
+}
+
+// String formats the entry as a lexicon file line
+func (e Entry) String() string {
+	return e.Headword + "\t" + e.POS + "\t" + strings.Join(e.Transcription, " ")
+}
+
+// Validate checks the headword, part of speech and transcription
+func (e Entry) Validate() error {
+	if e.Headword == "" || strings.ContainsAny(e.Headword, " \t") {
+		return fmt.Errorf("invalid headword %q", e.Headword)
+	}
+	if _, ok := Parts[e.POS]; !ok {
+		return fmt.Errorf("unknown part of speech %q", e.POS)
+	}
+	if len(e.Transcription) == 0 {
+		return fmt.Errorf("missing transcription for %q", e.Headword)
+	}
+	for _, phone := range e.Transcription {
+		if !validPhone(phone) {
+			return fmt.Errorf("invalid phone %q", phone)
+		}
+	}
+
+	return nil
+}
+
+// Lexicon is an ordered list of entries
+type Lexicon struct {
+	Entries []Entry
+}
+
+// Lookup returns the entries for headword, one per part of speech
+func (l *Lexicon) Lookup(headword string) []Entry {
+	var entries []Entry
+	for _, e := range l.Entries {
+		if e.Headword == headword {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// Upsert adds e, replacing any entry with the same headword and part of
+// speech. It reports whether an existing entry was replaced.
+func (l *Lexicon) Upsert(e Entry) bool {
+	for i := range l.Entries {
+		if l.Entries[i].Headword == e.Headword && l.Entries[i].POS == e.POS {
+			l.Entries[i] = e
+			return true
+		}
+	}
+
+	l.Entries = append(l.Entries, e)
+	return false
+}
+
+// Remove deletes the entry for headword and part of speech, or every entry
+// for headword if pos is empty. It reports whether any entry was removed.
+func (l *Lexicon) Remove(headword, pos string) bool {
+	entries := l.Entries[:0]
+	for _, e := range l.Entries {
+		if e.Headword != headword || (pos != "" && e.POS != pos) {
+			entries = append(entries, e)
+		}
+	}
+
+	removed := len(entries) < len(l.Entries)
+	l.Entries = entries
+	return removed
+}
+
+// Merge upserts every entry of other, so entries in other take precedence
+func (l *Lexicon) Merge(other *Lexicon) {
+	for _, e := range other.Entries {
+		l.Upsert(e)
+	}
+}
+
+// Validate checks every entry, returning Errors listing every problem. Line
+// numbers are positions in the list for entries which were not parsed.
+func (l *Lexicon) Validate() error {
+	seen := make(map[string]int)
+	var errs Errors
+
+	for i, e := range l.Entries {
+		n := e.Line
+		if n == 0 {
+			n = i + 1
+		}
+
+		if err := e.Validate(); err != nil {
+			errs = append(errs, &LineError{n, err})
+			continue
+		}
+
+		key := e.Headword + "\x00" + e.POS
+		if prev, ok := seen[key]; ok {
+			errs = append(errs, &LineError{n, fmt.Errorf("duplicate entry for %q, first defined on line %d", e.Headword, prev)})
+			continue
+		}
+		seen[key] = n
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// LineError describes a problem with a single line of a lexicon file
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a list of line errors found while parsing
+type Errors []*LineError
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return "lexicon: " + e[0].Error()
+	}
+
+	return fmt.Sprintf("lexicon: %v (and %d more errors)", e[0], len(e)-1)
+}
+
+// Parse reads a lexicon file. If any lines are malformed the entries that
+// could be parsed are returned along with an Errors listing every problem.
+func Parse(r io.Reader) (*Lexicon, error) {
+	lex := &Lexicon{}
+	seen := make(map[string]int)
+	var errs Errors
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			errs = append(errs, &LineError{n, fmt.Errorf("expected headword, part of speech and transcription")})
+			continue
+		}
+
+		e := Entry{
+			Headword:      fields[0],
+			POS:           fields[1],
+			Transcription: fields[2:],
+			Line:          n,
+		}
+		if err := e.Validate(); err != nil {
+			errs = append(errs, &LineError{n, err})
+			continue
+		}
+
+		key := e.Headword + "\x00" + e.POS
+		if prev, ok := seen[key]; ok {
+			errs = append(errs, &LineError{n, fmt.Errorf("duplicate entry for %q, first defined on line %d", e.Headword, prev)})
+			continue
+		}
+		seen[key] = n
+
+		lex.Entries = append(lex.Entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(errs) > 0 {
+		return lex, errs
+	}
+
+	return lex, nil
+}
+
+// Validate reads a lexicon file and reports any malformed lines
+func Validate(r io.Reader) error {
+	_, err := Parse(r)
+	return err
+}
+
+// WriteTo writes the lexicon in file format
+func (l *Lexicon) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, e := range l.Entries {
+		n, err := io.WriteString(w, e.String()+"\n")
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// String returns the lexicon in file format
+func (l *Lexicon) String() string {
+	var b strings.Builder
+	l.WriteTo(&b)
+	return b.String()
+}
+
+// ParseTranscription splits a transcription such as "t @0 m aa1 t ou0" into
+// phones, checking each is valid
+func ParseTranscription(s string) ([]string, error) {
+	phones := strings.Fields(s)
+	if len(phones) == 0 {
+		return nil, fmt.Errorf("empty transcription")
+	}
+	for _, phone := range phones {
+		if !validPhone(phone) {
+			return nil, fmt.Errorf("invalid phone %q", phone)
+		}
+	}
+
+	return phones, nil
+}
+
+// validPhone reports whether p is letters or "@" with an optional trailing
+// stress digit 0-2
+func validPhone(p string) bool {
+	if p == "" {
+		return false
+	}
+
+	if last := p[len(p)-1]; last >= '0' && last <= '2' {
+		p = p[:len(p)-1]
+	}
+	if p == "" {
+		return false
+	}
+
+	for _, r := range p {
+		if (r < 'a' || r > 'z') && r != '@' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package lexicon
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []Entry
+		errs    []int // Lines reported as malformed
+		message string
+	}{
+		{
+			name: "entries",
+			file: "# comment\n\ncereproc n s e1 r @0 p r o0 k\n  tomato\tn\tt @0 m aa1 t ou0  \n",
+			want: []Entry{
+				{"cereproc", "n", []string{"s", "e1", "r", "@0", "p", "r", "o0", "k"}, 3},
+				{"tomato", "n", []string{"t", "@0", "m", "aa1", "t", "ou0"}, 4},
+			},
+		},
+		{
+			name: "same headword",
+			file: "record n r e1 k o0 d\nrecord v r i0 k o1 d\n",
+			want: []Entry{
+				{"record", "n", []string{"r", "e1", "k", "o0", "d"}, 1},
+				{"record", "v", []string{"r", "i0", "k", "o1", "d"}, 2},
+			},
+		},
+		{
+			name:    "missing transcription",
+			file:    "cereproc n\n",
+			errs:    []int{1},
+			message: "lexicon: line 1: expected headword, part of speech and transcription",
+		},
+		{
+			name:    "unknown part of speech",
+			file:    "tomato noun t @0 m aa1 t ou0\n",
+			errs:    []int{1},
+			message: `lexicon: line 1: unknown part of speech "noun"`,
+		},
+		{
+			name:    "invalid phone",
+			file:    "tomato n t @0 M aa3\n",
+			errs:    []int{1},
+			message: `lexicon: line 1: invalid phone "M"`,
+		},
+		{
+			name: "duplicate",
+			file: "tomato n t @0 m aa1 t ou0\n\ntomato n t @0 m ei1 t ou0\nbad\n",
+			want: []Entry{
+				{"tomato", "n", []string{"t", "@0", "m", "aa1", "t", "ou0"}, 1},
+			},
+			errs:    []int{3, 4},
+			message: `lexicon: line 3: duplicate entry for "tomato", first defined on line 1 (and 1 more errors)`,
+		},
+		{name: "empty", file: ""},
+	}
+
+	for _, tt := range tests {
+		lex, err := Parse(strings.NewReader(tt.file))
+		if !reflect.DeepEqual(lex.Entries, tt.want) {
+			t.Errorf("%s: entries = %v, want %v", tt.name, lex.Entries, tt.want)
+		}
+
+		var errs Errors
+		errors.As(err, &errs)
+		var lines []int
+		for _, e := range errs {
+			lines = append(lines, e.Line)
+		}
+		if !reflect.DeepEqual(lines, tt.errs) {
+			t.Errorf("%s: error lines = %v, want %v (%v)", tt.name, lines, tt.errs, err)
+		}
+		if err != nil && err.Error() != tt.message {
+			t.Errorf("%s: error = %q, want %q", tt.name, err, tt.message)
+		}
+	}
+}
+
+func TestValidPhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  bool
+	}{
+		{"a", true},
+		{"aa1", true},
+		{"@0", true},
+		{"ou2", true},
+		{"e3", false},
+		{"0", false},
+		{"", false},
+		{"A", false},
+		{"a-b", false},
+		{"a1b", false},
+	}
+
+	for _, tt := range tests {
+		if got := validPhone(tt.phone); got != tt.want {
+			t.Errorf("validPhone(%q) = %v, want %v", tt.phone, got, tt.want)
+		}
+	}
+}
+
+func TestLexiconEdits(t *testing.T) {
+	e := func(headword, pos, phones string) Entry {
+		return Entry{Headword: headword, POS: pos, Transcription: strings.Fields(phones)}
+	}
+	base := func() *Lexicon {
+		return &Lexicon{Entries: []Entry{e("record", "n", "r e1 k o0 d"), e("record", "v", "r i0 k o1 d"), e("tomato", "n", "t @0 m aa1 t ou0")}}
+	}
+
+	tests := []struct {
+		name string
+		edit func(l *Lexicon) bool
+		ok   bool
+		want string
+	}{
+		{"upsert new", func(l *Lexicon) bool { return l.Upsert(e("cat", "n", "k a1 t")) }, false,
+			"record\tn\tr e1 k o0 d\nrecord\tv\tr i0 k o1 d\ntomato\tn\tt @0 m aa1 t ou0\ncat\tn\tk a1 t\n"},
+		{"upsert existing", func(l *Lexicon) bool { return l.Upsert(e("tomato", "n", "t @0 m ei1 t ou0")) }, true,
+			"record\tn\tr e1 k o0 d\nrecord\tv\tr i0 k o1 d\ntomato\tn\tt @0 m ei1 t ou0\n"},
+		{"remove part of speech", func(l *Lexicon) bool { return l.Remove("record", "v") }, true,
+			"record\tn\tr e1 k o0 d\ntomato\tn\tt @0 m aa1 t ou0\n"},
+		{"remove headword", func(l *Lexicon) bool { return l.Remove("record", "") }, true,
+			"tomato\tn\tt @0 m aa1 t ou0\n"},
+		{"remove missing", func(l *Lexicon) bool { return l.Remove("cat", "") }, false,
+			"record\tn\tr e1 k o0 d\nrecord\tv\tr i0 k o1 d\ntomato\tn\tt @0 m aa1 t ou0\n"},
+	}
+
+	for _, tt := range tests {
+		l := base()
+		if ok := tt.edit(l); ok != tt.ok {
+			t.Errorf("%s: reported %v, want %v", tt.name, ok, tt.ok)
+		}
+		if got := l.String(); got != tt.want {
+			t.Errorf("%s: lexicon = %q, want %q", tt.name, got, tt.want)
+		}
+		if err := l.Validate(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old, _ := Parse(strings.NewReader("record n r e1 k o0 d\ntomato n t @0 m aa1 t ou0\ncat n k a1 t\n"))
+	new, _ := Parse(strings.NewReader("tomato n t @0 m ei1 t ou0\ncat n k a1 t\ndog n d o1 g\n"))
+
+	tests := []struct {
+		name     string
+		old, new *Lexicon
+		want     string
+	}{
+		{"changes", old, new, "+ dog\tn\td o1 g\n- record\tn\tr e1 k o0 d\n~ tomato\tn\tt @0 m aa1 t ou0 -> t @0 m ei1 t ou0\n"},
+		{"same", old, old, ""},
+		{"from nothing", nil, &Lexicon{Entries: new.Entries[2:]}, "+ dog\tn\td o1 g\n"},
+		{"to nothing", &Lexicon{Entries: new.Entries[2:]}, nil, "- dog\tn\td o1 g\n"},
+	}
+
+	for _, tt := range tests {
+		d := Compare(tt.old, tt.new)
+		if got := d.String(); got != tt.want {
+			t.Errorf("%s: diff = %q, want %q", tt.name, got, tt.want)
+		}
+		if d.Empty() != (tt.want == "") {
+			t.Errorf("%s: Empty = %v", tt.name, d.Empty())
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/lexicon"
+)
+
+// GetLexicon downloads the lexicon file at url, as listed by ListLexicons,
+// and parses it. If some lines are malformed the entries which could be
+// parsed are returned along with a lexicon.Errors.
+func (c *Client) GetLexicon(url string) (*lexicon.Lexicon, error) {
+	return c.GetLexiconWithContext(context.Background(), url)
+}
+
+// GetLexiconWithContext is the same as GetLexicon with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) GetLexiconWithContext(ctx context.Context, url string) (*lexicon.Lexicon, error) {
+	body, err := c.DownloadLexiconWithContext(ctx, &Lexicon{URL: url})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return lexicon.Parse(body)
+}
+
+// UploadLexiconEntries validates lex and uploads it for the given language
+// and accent, replacing any existing lexicon
+func (c *Client) UploadLexiconEntries(lex *lexicon.Lexicon, language, accent string) (*UploadLexiconResponse, error) {
+	return c.UploadLexiconEntriesWithContext(context.Background(), lex, language, accent)
+}
+
+// UploadLexiconEntriesWithContext is the same as UploadLexiconEntries with
+// the addition of the ability to pass a context for cancellation and timeouts
+func (c *Client) UploadLexiconEntriesWithContext(ctx context.Context, lex *lexicon.Lexicon, language, accent string) (*UploadLexiconResponse, error) {
+	if err := lex.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c.UploadLexiconFromReaderWithContext(ctx, strings.NewReader(lex.String()), language, accent)
+}
+
+// EditLexicon downloads the lexicon for the given language and accent, or
+// starts an empty one if there is none, passes it to edit and uploads the
+// result. Nothing is uploaded if edit returns an error.
+func (c *Client) EditLexicon(language, accent string, edit func(lex *lexicon.Lexicon) error) (*UploadLexiconResponse, error) {
+	return c.EditLexiconWithContext(context.Background(), language, accent, edit)
+}
+
+// EditLexiconWithContext is the same as EditLexicon with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) EditLexiconWithContext(ctx context.Context, language, accent string, edit func(lex *lexicon.Lexicon) error) (*UploadLexiconResponse, error) {
+	list, err := c.ListLexiconsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lex := &lexicon.Lexicon{}
+	for _, l := range list.LexiconList {
+		if strings.EqualFold(l.Language, language) && strings.EqualFold(l.Accent, accent) {
+			if lex, err = c.GetLexiconWithContext(ctx, l.URL); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	if err := edit(lex); err != nil {
+		return nil, err
+	}
+
+	return c.UploadLexiconEntriesWithContext(ctx, lex, language, accent)
+}
+
+// DiffLexicon compares lex with the lexicon uploaded for the given language
+// and accent, without changing it, e.g. to review a SyncLexicon beforehand
+func (c *Client) DiffLexicon(lex *lexicon.Lexicon, language, accent string) (*lexicon.Diff, error) {
+	return c.DiffLexiconWithContext(context.Background(), lex, language, accent)
+}
+
+// DiffLexiconWithContext is the same as DiffLexicon with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) DiffLexiconWithContext(ctx context.Context, lex *lexicon.Lexicon, language, accent string) (*lexicon.Diff, error) {
+	current, err := c.currentLexicon(ctx, language, accent)
+	if err != nil {
+		return nil, err
+	}
+
+	return lexicon.Compare(current, lex), nil
+}
+
+// SyncLexicon makes the lexicon for the given language and accent match lex,
+// such as one kept under version control. The uploaded lexicon is downloaded
+// and compared with lex, which is validated and uploaded only if they differ.
+// The differences are returned either way; an empty Diff means nothing was
+// changed. A lex with no entries fails with ErrValidation rather than
+// deleting the uploaded lexicon, as it is more likely a mistake, such as an
+// empty file, than intended; use DeleteLexicon to remove it.
+func (c *Client) SyncLexicon(lex *lexicon.Lexicon, language, accent string) (*lexicon.Diff, error) {
+	return c.SyncLexiconWithContext(context.Background(), lex, language, accent)
+}
+
+// SyncLexiconWithContext is the same as SyncLexicon with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) SyncLexiconWithContext(ctx context.Context, lex *lexicon.Lexicon, language, accent string) (*lexicon.Diff, error) {
+	if err := lex.Validate(); err != nil {
+		return nil, err
+	}
+
+	diff, err := c.DiffLexiconWithContext(ctx, lex, language, accent)
+	if err != nil || diff.Empty() {
+		return diff, err
+	}
+
+	if len(lex.Entries) == 0 {
+		return diff, fmt.Errorf("%w: lexicon has no entries, use DeleteLexicon to delete the %s-%s lexicon", ErrValidation, language, accent)
+	}
+	if _, err := c.UploadLexiconEntriesWithContext(ctx, lex, language, accent); err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
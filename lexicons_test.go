@@ -0,0 +1,42 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/lexicon"
+)
+
+func TestSyncLexiconEmpty(t *testing.T) {
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "tomato\tn\tt @0 m aa1 t ou0")
+	}))
+	defer files.Close()
+
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	srv.SetResponse("listLexicons", `<?xml version="1.0" encoding="UTF-8"?>
+<listLexiconsResponse><lexiconList><lexiconFile><url>`+files.URL+`</url><language>en</language><accent>gb</accent></lexiconFile></lexiconList></listLexiconsResponse>`)
+	c := srv.Client()
+
+	diff, err := c.SyncLexicon(&lexicon.Lexicon{}, "en", "gb")
+	if !errors.Is(err, cerevoicego.ErrValidation) {
+		t.Fatalf("SyncLexicon of an empty lexicon = %v, want ErrValidation", err)
+	}
+	if diff == nil || diff.Empty() {
+		t.Errorf("diff = %v, want the removal", diff)
+	}
+	for _, req := range srv.Requests() {
+		if req.XMLName.Local != "listLexicons" {
+			t.Errorf("%s sent", req.XMLName.Local)
+		}
+	}
+}
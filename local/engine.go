@@ -0,0 +1,185 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build cerevoice
+
+package local
+
+/*
+#cgo LDFLAGS: -lcerevoice_eng -lcerevoice_pmod -lcerehts -lcerevoice -lstdc++ -lm
+#include <stdlib.h>
+#include <cerevoice_eng.h>
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"unsafe"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// Engine synthesises speech with the CereVoice SDK. It is safe for
+// concurrent use, though synthesis is serialised. Close must be called to
+// free it.
+type Engine struct {
+	mu     sync.Mutex
+	eng    *C.CPRCEN_engine
+	voices cerevoicego.VoiceCatalog
+}
+
+// New returns an Engine with the given voices loaded
+func New(voices ...VoiceFile) (*Engine, error) {
+	e := &Engine{eng: C.CPRCEN_engine_new()}
+	if e.eng == nil {
+		return nil, fmt.Errorf("local: creating engine failed")
+	}
+
+	for _, v := range voices {
+		voice, license, config := C.CString(v.Voice), C.CString(v.License), C.CString(v.Config)
+		ok := C.CPRCEN_engine_load_voice(e.eng, license, config, voice, C.CPRC_VOICE_LOAD)
+		C.free(unsafe.Pointer(voice))
+		C.free(unsafe.Pointer(license))
+		C.free(unsafe.Pointer(config))
+
+		if ok == 0 {
+			e.Close()
+			return nil, fmt.Errorf("local: loading voice %s failed", v.Voice)
+		}
+	}
+
+	for i := 0; i < int(C.CPRCEN_engine_get_voice_count(e.eng)); i++ {
+		v := cerevoicego.Voice{
+			SampleRate:            e.info(i, "SAMPLE_RATE"),
+			VoiceName:             e.info(i, "VOICE_NAME"),
+			LanguageCodeISO:       e.info(i, "LANGUAGE_CODE_ISO"),
+			CountryCodeISO:        e.info(i, "COUNTRY_CODE_ISO"),
+			AccentCode:            e.info(i, "ACCENT_CODE"),
+			Sex:                   e.info(i, "SEX"),
+			LanguageCodeMicrosoft: e.info(i, "LANGUAGE_CODE_MICROSOFT"),
+			Country:               e.info(i, "COUNTRY"),
+			Region:                e.info(i, "REGION"),
+			Accent:                e.info(i, "ACCENT"),
+		}
+		v.Hz, _ = strconv.Atoi(v.SampleRate)
+		e.voices = append(e.voices, v)
+	}
+
+	return e, nil
+}
+
+// info returns a property of a loaded voice
+func (e *Engine) info(voice int, key string) string {
+	k := C.CString(key)
+	defer C.free(unsafe.Pointer(k))
+
+	v := C.CPRCEN_engine_get_voice_info(e.eng, C.int(voice), k)
+	if v == nil {
+		return ""
+	}
+
+	return C.GoString(v)
+}
+
+// Synthesize returns the audio for input, which must be WAV or raw. The
+// context is checked before synthesis starts; a request in progress can not
+// be cancelled.
+func (e *Engine) Synthesize(ctx context.Context, input *cerevoicego.SpeakExtendedInput) ([]byte, error) {
+	if err := checkInput(input); err != nil {
+		return nil, err
+	}
+
+	v, ok := e.voices.Lookup(input.Voice)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", cerevoicego.ErrInvalidVoice, input.Voice)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.eng == nil {
+		return nil, ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	samples, rate, err := e.speak(v, input.SpeakMode.Markup(input.Text))
+	if err != nil {
+		return nil, err
+	}
+
+	return encode(samples, rate, input)
+}
+
+// speak synthesises text with voice v, e.mu must be held
+func (e *Engine) speak(v cerevoicego.Voice, text string) ([]int16, int, error) {
+	language, country := C.CString(v.LanguageCodeISO), C.CString(v.CountryCodeISO)
+	name, rate := C.CString(v.VoiceName), C.CString(v.SampleRate)
+	defer func() {
+		C.free(unsafe.Pointer(language))
+		C.free(unsafe.Pointer(country))
+		C.free(unsafe.Pointer(name))
+		C.free(unsafe.Pointer(rate))
+	}()
+
+	channel := C.CPRCEN_engine_open_channel(e.eng, language, country, name, rate)
+	if channel == 0 {
+		return nil, 0, fmt.Errorf("local: opening a channel for voice %s failed", v.VoiceName)
+	}
+	defer C.CPRCEN_engine_channel_close(e.eng, channel)
+
+	ctext := C.CString(text)
+	defer C.free(unsafe.Pointer(ctext))
+
+	abuf := C.CPRCEN_engine_channel_speak(e.eng, channel, ctext, C.int(len(text)), 1)
+	if abuf == nil {
+		return nil, 0, fmt.Errorf("local: synthesis with voice %s failed", v.VoiceName)
+	}
+
+	n := int(C.CPRC_abuf_wav_sz(abuf))
+	samples := make([]int16, n)
+	if n > 0 {
+		data := (*[1 << 30]C.short)(unsafe.Pointer(C.CPRC_abuf_wav_data(abuf)))[:n:n]
+		for i, s := range data {
+			samples[i] = int16(s)
+		}
+	}
+
+	key := C.CString("SAMPLE_RATE")
+	defer C.free(unsafe.Pointer(key))
+	sampleRate, err := strconv.Atoi(C.GoString(C.CPRCEN_channel_get_voice_info(e.eng, channel, key)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("local: voice %s has no sample rate", v.VoiceName)
+	}
+
+	return samples, sampleRate, nil
+}
+
+// Voices returns the loaded voices
+func (e *Engine) Voices(ctx context.Context) (cerevoicego.VoiceCatalog, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.eng == nil {
+		return nil, ErrClosed
+	}
+
+	return append(cerevoicego.VoiceCatalog(nil), e.voices...), nil
+}
+
+// Close frees the engine and its voices
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.eng != nil {
+		C.CPRCEN_engine_delete(e.eng)
+		e.eng = nil
+	}
+
+	return nil
+}
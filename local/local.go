@@ -0,0 +1,95 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package local synthesises speech on the device with the CereVoice SDK.
+// Engine implements cerevoicego.Synthesizer, as *cerevoicego.Client does
+// with the Cloud API, so applications can switch between cloud and local
+// synthesis without changing call sites.
+//
+//	var synth cerevoicego.Synthesizer = client
+//	if engine, err := local.New(local.VoiceFile{Voice: "heather.voice", License: "heather.lic"}); err == nil {
+//		synth = engine
+//	}
+//	wav, err := synth.Synthesize(ctx, input)
+//
+// The engine uses the CereVoice SDK through cgo and is only available when
+// built with the cerevoice tag, e.g. go build -tags cerevoice, with the SDK
+// headers and libraries on the compiler and linker paths:
+//
+//	CGO_CFLAGS=-I$SDK/cerevoice_eng/include CGO_LDFLAGS="-L$SDK/cerevoice_eng/lib -L$SDK/cerevoice/lib ..." go build -tags cerevoice
+//
+// Without it New returns ErrUnavailable.
+package local
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+var (
+	// ErrUnavailable is returned by New when the package is built without
+	// the cerevoice tag
+	ErrUnavailable = errors.New("local: built without CereVoice SDK support, rebuild with -tags cerevoice")
+	// ErrClosed is returned when an Engine is used after Close
+	ErrClosed = errors.New("local: engine closed")
+)
+
+// VoiceFile locates a CereVoice voice to load into an Engine
+type VoiceFile struct {
+	Voice   string // Path of the .voice file
+	License string // Path of the voice licence file
+	Config  string // Path of a configuration file, may be empty
+}
+
+var _ cerevoicego.Synthesizer = (*Engine)(nil)
+
+// checkInput validates input for local synthesis, which produces WAV or raw
+// audio only
+func checkInput(input *cerevoicego.SpeakExtendedInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	switch cerevoicego.AudioFormat(strings.ToLower(string(input.AudioFormat))) {
+	case "", cerevoicego.FormatWAV, cerevoicego.FormatRaw:
+		return nil
+	}
+
+	return fmt.Errorf("%w %q: the local engine produces wav and raw", cerevoicego.ErrInvalidAudioFormat, input.AudioFormat)
+}
+
+// encode returns 16 bit mono samples at sampleRate in the format and sample
+// rate requested by input, resampling if needed
+func encode(samples []int16, sampleRate int, input *cerevoicego.SpeakExtendedInput) ([]byte, error) {
+	w := &audio.WAV{
+		Format: audio.PCM16(sampleRate, 1),
+		Data:   make([]byte, 2*len(samples)),
+	}
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(w.Data[2*i:], uint16(s))
+	}
+
+	if input.SampleRate != "" {
+		rate, err := strconv.Atoi(string(input.SampleRate))
+		if err != nil {
+			return nil, fmt.Errorf("%w %q", cerevoicego.ErrInvalidSampleRate, input.SampleRate)
+		}
+		if rate != sampleRate {
+			if err := w.Apply(audio.Resample(rate)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if strings.EqualFold(string(input.AudioFormat), string(cerevoicego.FormatRaw)) {
+		return w.Data, nil
+	}
+
+	return w.Bytes(), nil
+}
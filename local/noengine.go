@@ -0,0 +1,34 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build !cerevoice
+
+package local
+
+import (
+	"context"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// Engine synthesises speech with the CereVoice SDK. It is only functional
+// when built with the cerevoice tag.
+type Engine struct{}
+
+// New returns ErrUnavailable when built without the cerevoice tag
+func New(voices ...VoiceFile) (*Engine, error) {
+	return nil, ErrUnavailable
+}
+
+// Synthesize returns ErrUnavailable when built without the cerevoice tag
+func (e *Engine) Synthesize(ctx context.Context, input *cerevoicego.SpeakExtendedInput) ([]byte, error) {
+	return nil, ErrUnavailable
+}
+
+// Voices returns ErrUnavailable when built without the cerevoice tag
+func (e *Engine) Voices(ctx context.Context) (cerevoicego.VoiceCatalog, error) {
+	return nil, ErrUnavailable
+}
+
+// Close frees the engine
+func (e *Engine) Close() error { return nil }
@@ -0,0 +1,105 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RedactedPassword replaces the password and any token in logged requests
+const RedactedPassword = "REDACTED"
+
+// RequestLog describes a completed CereVoice Cloud API request. CereVoice
+// bills per character, so CharCount is the credit consumed by the request.
+type RequestLog struct {
+	Operation   string        // API function, e.g. speakExtended
+	Voice       string        // Voice requested, if any
+	Text        string        // Text sent after preprocessing, or as redacted by the TextAuditor, if any
+	TextLength  int           // Characters of text sent, if any
+	Duration    time.Duration // Total time including retries
+	Attempts    int           // Number of attempts made
+	ResultCode  ResultCode    // Result code returned, 0 if none
+	Description string        // Result description returned, if any
+	CharCount   int           // Characters billed, 0 if none
+	Endpoint    string        // API URL which served the final attempt, if any
+	Conn        *ConnInfo     // Connection used by the final attempt, if any
+	Request     string        // Request XML with the password redacted
+	Err         error         // Error returned to the caller, if any
+}
+
+// record copies the status of an API response into the entry
+func (l *RequestLog) record(res *result) {
+	if res == nil {
+		return
+	}
+
+	l.ResultCode, _, _ = res.code()
+	l.Description = res.ResultDescription
+	l.CharCount = res.chars()
+}
+
+// Logger receives a RequestLog for every API request. Implementations must
+// be safe for concurrent use.
+type Logger interface {
+	LogRequest(entry *RequestLog)
+}
+
+// LoggerFunc adapts a function to the Logger interface
+type LoggerFunc func(entry *RequestLog)
+
+// LogRequest calls f(entry)
+func (f LoggerFunc) LogRequest(entry *RequestLog) {
+	f(entry)
+}
+
+// SlogLogger returns a Logger writing to a structured slog.Logger. Failed
+// requests are logged at error level and others at info level.
+func SlogLogger(logger *slog.Logger) Logger {
+	return LoggerFunc(func(entry *RequestLog) {
+		level := slog.LevelInfo
+		attrs := []slog.Attr{
+			slog.String("operation", entry.Operation),
+			slog.Duration("duration", entry.Duration),
+			slog.Int("attempts", entry.Attempts),
+			slog.Int("result_code", int(entry.ResultCode)),
+			slog.Int("char_count", entry.CharCount),
+		}
+		if entry.Voice != "" {
+			attrs = append(attrs, slog.String("voice", entry.Voice))
+		}
+		if entry.Endpoint != "" {
+			attrs = append(attrs, slog.String("endpoint", entry.Endpoint))
+		}
+		if entry.Err != nil {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", entry.Err.Error()))
+		}
+
+		logger.LogAttrs(context.Background(), level, "cerevoice request", attrs...)
+	})
+}
+
+// redact returns the request XML with the password and token replaced
+func redact(req *Request) string {
+	return string(encodeRequest(redacted(req), false))
+}
+
+// redacted returns a copy of req with the password and token replaced, and
+// the text replaced by any given by the TextAuditor
+func redacted(req *Request) *Request {
+	r := *req
+	if r.Password != "" {
+		r.Password = RedactedPassword
+	}
+	if r.Token != "" {
+		r.Token = RedactedPassword
+	}
+	if r.loggedText != "" {
+		r.Text = r.loggedText
+	}
+
+	return &r
+}
@@ -0,0 +1,467 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bganderson/cerevoicego/audio"
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+const (
+	// DefaultChunkLength is the default maximum characters per request made
+	// by SpeakLong
+	DefaultChunkLength = 2000
+	// DefaultConcurrency is the default number of concurrent requests made
+	// by SpeakLong
+	DefaultConcurrency = 4
+)
+
+// SpeakLongInput contains SpeakLong parameters. AudioFormat must be wav or
+// raw so the chunks can be joined; raw audio is assumed to be 16 bit mono.
+type SpeakLongInput struct {
+	SpeakExtendedInput
+
+	// ChunkLength is the most characters sent per request, once TextSteps
+	// and SpeakMode markup are applied, DefaultChunkLength if 0. It is no
+	// more than the Client MaxTextLength.
+	ChunkLength int
+	Concurrency int // Maximum concurrent requests, DefaultConcurrency if 0
+
+	// OnProgress, if set, receives an event as each chunk is synthesised
+	// and downloaded
+	OnProgress ProgressFunc `json:"-"`
+
+	// Progress, if set, is called after each chunk is synthesised and
+	// downloaded. Calls are not concurrent.
+	//
+	// Deprecated: use OnProgress, which reports every long operation alike
+	Progress func(p Progress) `json:"-"`
+}
+
+// Progress reports how far a SpeakLong request has got.
+//
+// Deprecated: use ProgressEvent
+type Progress struct {
+	Chunk      int           // Index of the chunk just completed
+	ChunksDone int           // Chunks completed so far
+	Chunks     int           // Total chunks
+	Chars      int           // Characters of text completed so far
+	TotalChars int           // Total characters of text
+	Bytes      int64         // Audio bytes downloaded so far
+	Elapsed    time.Duration // Time since the request started
+	ETA        time.Duration // Estimated time remaining
+}
+
+// progressFunc returns the ProgressFunc reporting to OnProgress and
+// Progress, nil if neither is set
+func (in *SpeakLongInput) progressFunc() ProgressFunc {
+	if in.Progress == nil {
+		return in.OnProgress
+	}
+
+	return func(e ProgressEvent) {
+		if in.OnProgress != nil {
+			in.OnProgress(e)
+		}
+		in.Progress(Progress{
+			Chunk:      e.Item,
+			ChunksDone: e.ItemsDone,
+			Chunks:     e.Items,
+			Chars:      e.Chars,
+			TotalChars: e.TotalChars,
+			Bytes:      e.TotalBytes,
+			Elapsed:    e.Elapsed,
+			ETA:        e.ETA,
+		})
+	}
+}
+
+// SpeakLongResponse contains the joined result of SpeakLong
+type SpeakLongResponse struct {
+	Audio     []byte                   `json:"-"`                  // The complete audio, not included in JSON
+	Metadata  *Metadata                `json:"metadata,omitempty"` // Merged timings, if Metadata was requested
+	CharCount int                      `json:"charCount"`          // Total characters billed
+	Chunks    []*SpeakExtendedResponse `json:"chunks"`             // Responses for each chunk, in order
+}
+
+// SpeakLong synthesises text longer than a single request allows. The text
+// is split on sentence boundaries, the chunks synthesised concurrently and
+// the audio joined into one continuous file with timings merged. Markup is
+// only split between its top level elements.
+func (c *Client) SpeakLong(input *SpeakLongInput) (*SpeakLongResponse, error) {
+	return c.SpeakLongWithContext(context.Background(), input)
+}
+
+// SpeakLongWithContext is the same as SpeakLong with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) SpeakLongWithContext(ctx context.Context, input *SpeakLongInput) (*SpeakLongResponse, error) {
+	if in := c.profile(&input.SpeakExtendedInput); in != &input.SpeakExtendedInput {
+		profiled := *input
+		profiled.SpeakExtendedInput = *in
+		input = &profiled
+	}
+	format := input.AudioFormat
+	if format == "" {
+		format = FormatWAV
+	}
+	if format != FormatWAV && format != FormatRaw {
+		return nil, fmt.Errorf("%w %q: SpeakLong supports wav and raw", ErrInvalidAudioFormat, format)
+	}
+
+	chunkLength := input.ChunkLength
+	if chunkLength <= 0 {
+		chunkLength = DefaultChunkLength
+	}
+	concurrency := input.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	if max := c.maxTextLength(); chunkLength > max {
+		chunkLength = max
+	}
+
+	chunks, err := c.fitChunks(ctx, &input.SpeakExtendedInput, chunkLength)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("cerevoicego: no text to speak")
+	}
+
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	ctx, cancel := c.budget(ctx)
+	defer cancel()
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+
+	chars := 0
+	for _, text := range chunks {
+		chars += len([]rune(text))
+	}
+	prog := newProgressTracker(input.progressFunc(), ProgressSpeakLong, len(chunks), chars)
+	results := make([]*longChunk, len(chunks))
+	errs := make(chan error, len(chunks))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, text := range chunks {
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+
+			chunkInput := input.SpeakExtendedInput
+			chunkInput.Text = text
+			chunkInput.AudioFormat = format
+
+			chunk, err := c.speakChunk(ctx, &chunkInput)
+			if err != nil {
+				errs <- err
+				cancel()
+				return
+			}
+			results[i] = chunk
+			prog.finish(i, "", len([]rune(text)), int64(len(chunk.audio)), nil)
+		}(i, text)
+	}
+
+	wg.Wait()
+	close(errs)
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+
+	return joinChunks(results, &input.SpeakExtendedInput, format)
+}
+
+// longChunk is the result of synthesising one chunk
+type longChunk struct {
+	response *SpeakExtendedResponse
+	audio    []byte
+	metadata *Metadata
+}
+
+// speakChunk synthesises and downloads a single chunk
+func (c *Client) speakChunk(ctx context.Context, input *SpeakExtendedInput) (*longChunk, error) {
+	r, err := c.SpeakExtendedWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	audio, err := c.readAudio(ctx, r, input)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := &longChunk{response: r, audio: audio}
+	if input.Metadata && r.Metadata != "" {
+		if chunk.metadata, err = c.GetMetadataWithContext(ctx, r.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	return chunk, nil
+}
+
+// joinChunks concatenates the audio and metadata of the chunks in order
+func joinChunks(chunks []*longChunk, input *SpeakExtendedInput, format AudioFormat) (*SpeakLongResponse, error) {
+	resp := &SpeakLongResponse{}
+	files := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		files[i] = chunk.audio
+		resp.Chunks = append(resp.Chunks, chunk.response)
+		resp.CharCount += chunk.response.Chars
+	}
+
+	var durations []time.Duration
+	if format == FormatWAV {
+		audio, d, err := joinWAV(files)
+		if err != nil {
+			return nil, err
+		}
+		resp.Audio, durations = audio, d
+	} else {
+		d, err := rawDurations(files, input.SampleRate)
+		if err != nil {
+			return nil, err
+		}
+		resp.Audio, durations = bytes.Join(files, nil), d
+	}
+
+	if input.Metadata {
+		resp.Metadata = &Metadata{}
+		var offset time.Duration
+		for i, chunk := range chunks {
+			d := durations[i]
+			if chunk.metadata != nil {
+				for _, e := range chunk.metadata.Events {
+					e.Start += offset
+					e.End += offset
+					resp.Metadata.Events = append(resp.Metadata.Events, e)
+				}
+				if d == 0 && len(chunk.metadata.Events) > 0 {
+					d = chunk.metadata.Events[len(chunk.metadata.Events)-1].End
+				}
+			}
+			offset += d
+		}
+	}
+
+	return resp, nil
+}
+
+// joinWAV joins WAV files with identical formats into one, returning the
+// length of each file
+func joinWAV(files [][]byte) ([]byte, []time.Duration, error) {
+	wavs := make([]*audio.WAV, len(files))
+	durations := make([]time.Duration, len(files))
+	for i, f := range files {
+		w, err := audio.DecodeWAV(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		wavs[i], durations[i] = w, w.Duration()
+	}
+
+	joined, err := audio.Concat(wavs...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return joined.Bytes(), durations, nil
+}
+
+// rawDurations returns the length of raw audio files, or zero if the sample
+// rate is unknown. The API's raw audio is 16 bit mono, without a header to
+// say so, so files which are not whole 16 bit samples are an *AudioError.
+func rawDurations(files [][]byte, rate SampleRate) ([]time.Duration, error) {
+	durations := make([]time.Duration, len(files))
+
+	format := audio.PCM16(rate.Hz(), 1)
+	for i, f := range files {
+		if err := VerifyAudio(f, FormatRaw, rate); err != nil {
+			return nil, err
+		}
+		durations[i] = format.Duration(len(f))
+	}
+
+	return durations, nil
+}
+
+// chunkPart is text which splitChunks keeps whole, and whether space
+// separated it from the part before
+type chunkPart struct {
+	text  string
+	space bool
+}
+
+// splitChunks splits text on sentence boundaries into chunks of at most max
+// characters. Sentences longer than max are split between words. Markup is
+// only split between its top level elements, each of which must be within
+// max, and every chunk is wrapped in the speak element wrapping the text, if
+// there is one.
+func splitChunks(text string, max int) ([]string, error) {
+	if !ssml.IsMarkup(text) {
+		return packChunks(textParts(text, max, true), max), nil
+	}
+
+	open, content, close := "", text, ""
+	if m := speakElement.FindStringSubmatch(text); m != nil {
+		open, content, close = m[1], m[2], "</speak>"
+	}
+	if max -= utf8.RuneCountInString(open + close); max <= 0 {
+		return nil, fmt.Errorf("cerevoicego: speak element exceeds the chunk length")
+	}
+
+	nodes, err := ssml.Nodes(content)
+	if err != nil {
+		return nil, fmt.Errorf("cerevoicego: %w", err)
+	}
+
+	var parts []chunkPart
+	space := false
+	for _, node := range nodes {
+		if !strings.HasPrefix(node, "<") {
+			parts = append(parts, textParts(node, max, space)...)
+			space = strings.TrimRightFunc(node, unicode.IsSpace) != node
+			continue
+		}
+		if n := utf8.RuneCountInString(node); n > max {
+			return nil, fmt.Errorf("cerevoicego: markup element of %d characters exceeds the chunk length of %d", n, max)
+		}
+		parts = append(parts, chunkPart{text: node, space: space})
+		space = false
+	}
+
+	chunks := packChunks(parts, max)
+	for i := range chunks {
+		chunks[i] = open + chunks[i] + close
+	}
+
+	return chunks, nil
+}
+
+// textParts splits text into sentences, and sentences longer than max
+// between words. The first part follows the part before it with space if
+// space is set or text starts with space.
+func textParts(text string, max int, space bool) []chunkPart {
+	space = space || strings.TrimLeftFunc(text, unicode.IsSpace) != text
+
+	sentences, rest := splitSentences(text)
+	if rest = strings.TrimSpace(rest); rest != "" {
+		sentences = append(sentences, rest)
+	}
+
+	var parts []chunkPart
+	for _, sentence := range sentences {
+		for _, part := range splitLong(sentence, max) {
+			parts = append(parts, chunkPart{text: part, space: space})
+			space = true
+		}
+	}
+
+	return parts
+}
+
+// packChunks joins parts in order into chunks of at most max characters
+func packChunks(parts []chunkPart, max int) []string {
+	var chunks []string
+	var b strings.Builder
+	n := 0
+
+	for _, part := range parts {
+		length := utf8.RuneCountInString(part.text)
+		sep := 0
+		if n > 0 && part.space {
+			sep = 1
+		}
+		if n > 0 && n+sep+length > max {
+			chunks = append(chunks, b.String())
+			b.Reset()
+			n, sep = 0, 0
+		}
+		if sep > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(part.text)
+		n += sep + length
+	}
+	if n > 0 {
+		chunks = append(chunks, b.String())
+	}
+
+	return chunks
+}
+
+// splitLong splits s between words into parts of at most max characters,
+// splitting words only if a single word is longer than max
+func splitLong(s string, max int) []string {
+	if len([]rune(s)) <= max {
+		return []string{s}
+	}
+
+	var parts []string
+	var cur []rune
+	for _, word := range strings.FieldsFunc(s, unicode.IsSpace) {
+		w := []rune(word)
+		for len(w) > max {
+			if len(cur) > 0 {
+				parts = append(parts, string(cur))
+				cur = nil
+			}
+			parts = append(parts, string(w[:max]))
+			w = w[max:]
+		}
+		if len(cur) > 0 && len(cur)+1+len(w) > max {
+			parts = append(parts, string(cur))
+			cur = nil
+		}
+		if len(cur) > 0 {
+			cur = append(cur, ' ')
+		}
+		cur = append(cur, w...)
+	}
+	if len(cur) > 0 {
+		parts = append(parts, string(cur))
+	}
+
+	return parts
+}
+
+// firstError returns the first error which is not a cancellation caused by
+// another error, or the first error if all are cancellations
+func firstError(errs <-chan error) error {
+	var first error
+	for err := range errs {
+		if first == nil {
+			first = err
+		}
+		if err != context.Canceled {
+			return err
+		}
+	}
+
+	return first
+}
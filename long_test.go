@@ -0,0 +1,83 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want []string
+		err  bool
+	}{
+		{"fits", "One. Two.", 20, []string{"One. Two."}, false},
+		{"sentences", "One two. Three four. Five.", 12, []string{"One two.", "Three four.", "Five."}, false},
+		{"long sentence", "one two three four five", 9, []string{"one two", "three", "four five"}, false},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}, false},
+		{"empty", "  ", 10, nil, false},
+		{
+			"markup between elements",
+			`Hello <break time="1s"/> world. <emphasis>Big</emphasis>, bold.`,
+			32,
+			[]string{`Hello <break time="1s"/> world.`, `<emphasis>Big</emphasis>, bold.`},
+			false,
+		},
+		{
+			"speak element",
+			`<speak>One. <break/> Two.</speak>`,
+			30,
+			[]string{`<speak>One. <break/></speak>`, `<speak>Two.</speak>`},
+			false,
+		},
+		{"element too long", `<emphasis>a long emphasis</emphasis>`, 10, nil, true},
+		{"malformed", `<emphasis>open`, 100, nil, true},
+		{"speak too long", `<speak version="1.1">Hi</speak>`, 20, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := splitChunks(tt.text, tt.max)
+		if tt.err != (err != nil) {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splitChunks = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRawDurations(t *testing.T) {
+	tests := []struct {
+		name  string
+		files [][]byte
+		rate  SampleRate
+		want  []time.Duration
+		err   bool
+	}{
+		{"16 kHz", [][]byte{make([]byte, 32000), make([]byte, 16000)}, SampleRate16k, []time.Duration{time.Second, 500 * time.Millisecond}, false},
+		{"8 kHz", [][]byte{make([]byte, 1600)}, SampleRate8k, []time.Duration{100 * time.Millisecond}, false},
+		{"unknown rate", [][]byte{make([]byte, 100)}, "", []time.Duration{0}, false},
+		{"odd length", [][]byte{make([]byte, 100), make([]byte, 101)}, SampleRate16k, nil, true},
+		{"empty", [][]byte{nil}, SampleRate16k, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := rawDurations(tt.files, tt.rate)
+		var audioErr *AudioError
+		if tt.err != errors.As(err, &audioErr) {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: rawDurations = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
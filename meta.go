@@ -0,0 +1,27 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import "time"
+
+// ResponseMeta describes the API request which produced a response. It is
+// embedded in every response, so each call can be logged the same way
+// without a Logger. It is not part of the XML or JSON encoding.
+type ResponseMeta struct {
+	Duration   time.Duration // Time taken by the call, including retries
+	StatusCode int           // HTTP status of the final attempt, 0 for a DryRun
+	Retries    int           // Attempts made after the first
+	Endpoint   string        // API URL which served the response
+	Chars      int           // Characters billed, charCount parsed, 0 if none
+	Voice      string        // Voice which spoke the text, for speak requests
+}
+
+// metaSetter is implemented by responses embedding ResponseMeta
+type metaSetter interface {
+	setMeta(meta ResponseMeta)
+}
+
+func (m *ResponseMeta) setMeta(meta ResponseMeta) {
+	*m = meta
+}
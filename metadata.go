@@ -0,0 +1,195 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata event types
+const (
+	EventWord  = "word"
+	EventPhone = "phone"
+	EventMark  = "mark"
+)
+
+// MetadataEvent is a single timed item from a metadata file
+type MetadataEvent struct {
+	Type  string        // Event type, e.g. EventWord or EventPhone
+	Token string        // Word, phone or mark name
+	Start time.Duration // Offset from the start of the audio
+	End   time.Duration // Offset from the start of the audio
+}
+
+// Metadata contains the timing information returned by speakExtended when
+// metadata is requested
+type Metadata struct {
+	Events []MetadataEvent `json:"events"`
+}
+
+// Words returns the word events
+func (m *Metadata) Words() []MetadataEvent {
+	return m.filter(EventWord)
+}
+
+// Phones returns the phone events
+func (m *Metadata) Phones() []MetadataEvent {
+	return m.filter(EventPhone)
+}
+
+// Marks returns the mark events
+func (m *Metadata) Marks() []MetadataEvent {
+	return m.filter(EventMark)
+}
+
+func (m *Metadata) filter(typ string) []MetadataEvent {
+	var events []MetadataEvent
+	for _, e := range m.Events {
+		if e.Type == typ {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// GetMetadata downloads and parses the metadata file at url, as returned in
+// SpeakExtendedResponse.Metadata
+func (c *Client) GetMetadata(url string) (*Metadata, error) {
+	return c.GetMetadataWithContext(context.Background(), url)
+}
+
+// GetMetadataWithContext is the same as GetMetadata with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) GetMetadataWithContext(ctx context.Context, url string) (*Metadata, error) {
+	body, err := fetch(ctx, c.fileClient(), url, isXMLContentType)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	cr := c.CharsetReader
+	if cr == nil {
+		cr = charsetReader
+	}
+
+	return parseMetadata(body, cr)
+}
+
+// ParseMetadata parses a CereVoice metadata file. Every element carrying
+// start and end attributes, in seconds, becomes an event. The event type is
+// taken from a type attribute if present and the element name otherwise, and
+// the token from a name attribute if present and the element text otherwise.
+func ParseMetadata(r io.Reader) (*Metadata, error) {
+	return parseMetadata(r, charsetReader)
+}
+
+// parseMetadata parses a metadata file, converting other charsets with cr
+func parseMetadata(r io.Reader, cr CharsetReaderFunc) (*Metadata, error) {
+	m := &Metadata{}
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = cr
+
+	open := -1 // index of the event whose text is being collected
+	var text strings.Builder
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return m, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e, ok, err := parseMetadataEvent(t)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				m.Events = append(m.Events, e)
+				open = len(m.Events) - 1
+				text.Reset()
+			}
+		case xml.CharData:
+			if open >= 0 {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if open >= 0 {
+				if m.Events[open].Token == "" {
+					m.Events[open].Token = strings.TrimSpace(text.String())
+				}
+				open = -1
+			}
+		}
+	}
+}
+
+// parseMetadataEvent builds an event from the attributes of el, ok is false
+// if el is not a timed element
+func parseMetadataEvent(el xml.StartElement) (e MetadataEvent, ok bool, err error) {
+	var start, end string
+	e.Type = el.Name.Local
+
+	for _, attr := range el.Attr {
+		switch attr.Name.Local {
+		case "start":
+			start = attr.Value
+		case "end":
+			end = attr.Value
+		case "type":
+			e.Type = attr.Value
+		case "name":
+			e.Token = attr.Value
+		}
+	}
+	if start == "" || end == "" {
+		return e, false, nil
+	}
+
+	if e.Start, err = parseSeconds(start); err != nil {
+		return e, false, err
+	}
+	if e.End, err = parseSeconds(end); err != nil {
+		return e, false, err
+	}
+
+	return e, true, nil
+}
+
+// parseSeconds parses a decimal number of seconds
+func parseSeconds(s string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(f * float64(time.Second)), nil
+}
+
+// isXMLContentType reports whether ct is acceptable for an XML file
+func isXMLContentType(ct string) bool {
+	if ct == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasSuffix(mediaType, "/xml") ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		mediaType == "text/plain" ||
+		mediaType == "application/octet-stream"
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import "net/http"
+
+// RoundTripFunc sends an HTTP request to the CereVoice Cloud API
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the sending of API requests. It can modify the request,
+// inspect or replace the response, or return without calling next to short
+// circuit the request.
+//
+//	func addHeader(next cerevoicego.RoundTripFunc) cerevoicego.RoundTripFunc {
+//		return func(req *http.Request) (*http.Response, error) {
+//			req.Header.Set("X-Request-Source", "batch")
+//			return next(req)
+//		}
+//	}
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware appends middleware to the Client. The first middleware
+// given is the outermost, seeing each request first.
+func WithMiddleware(mw ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.Middleware = append(c.Middleware, mw...)
+	}
+}
+
+// roundTrip returns the function sending API requests through the
+// middleware chain
+func (c *Client) roundTrip() RoundTripFunc {
+	rt := RoundTripFunc(c.httpClient().Do)
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		rt = c.Middleware[i](rt)
+	}
+
+	return rt
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package normalize contains text preprocessing steps which tidy text before
+// it is synthesised, for use with cerevoicego.WithTextSteps. Each step is a
+// func(string) string and steps are applied in order.
+//
+// Number and date expansion produce English words.
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Chain returns a step applying steps in order
+func Chain(steps ...func(string) string) func(string) string {
+	return func(text string) string {
+		for _, step := range steps {
+			text = step(text)
+		}
+		return text
+	}
+}
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// CollapseWhitespace replaces runs of whitespace with a single space and
+// trims the ends
+func CollapseWhitespace(text string) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(text, " "))
+}
+
+// StripEmoji removes emoji, pictographs and their modifiers
+func StripEmoji(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// isEmoji reports whether r is an emoji, pictograph or emoji modifier
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, flags
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols and dingbats
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // arrows and stars
+		return true
+	case r == 0x200D || r == 0x20E3 || (r >= 0xFE00 && r <= 0xFE0F): // joiners and selectors
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // tag sequences
+		return true
+	}
+
+	return false
+}
+
+var (
+	mdCodeBlock = regexp.MustCompile("(?s)```.*?```")
+	mdImage     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink      = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdHeading   = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+`)
+	mdQuote     = regexp.MustCompile(`(?m)^\s{0,3}>\s?`)
+	mdList      = regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+\.)\s+`)
+	mdRule      = regexp.MustCompile(`(?m)^\s{0,3}(?:[-*_]\s*){3,}$`)
+	mdEmphasis  = []*regexp.Regexp{
+		regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`),
+		regexp.MustCompile(`\b__(\S(?:.*?\S)?)__\b`),
+		regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`),
+		regexp.MustCompile(`\b_(\S(?:.*?\S)?)_\b`),
+		regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`),
+		regexp.MustCompile("`([^`]+)`"),
+	}
+)
+
+// StripMarkdown removes Markdown formatting, keeping the text of links,
+// images and emphasis. Code blocks are removed entirely.
+func StripMarkdown(text string) string {
+	text = mdCodeBlock.ReplaceAllString(text, "")
+	text = mdImage.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = mdRule.ReplaceAllString(text, "")
+	text = mdHeading.ReplaceAllString(text, "")
+	text = mdQuote.ReplaceAllString(text, "")
+	text = mdList.ReplaceAllString(text, "")
+	for _, re := range mdEmphasis {
+		text = re.ReplaceAllString(text, "$1")
+	}
+
+	return text
+}
+
+// MaskProfanity returns a step replacing each of words, matched as whole
+// words ignoring case, with replacement
+func MaskProfanity(words []string, replacement string) func(string) string {
+	if len(words) == 0 {
+		return func(text string) string { return text }
+	}
+
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	re := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+
+	return func(text string) string {
+		return re.ReplaceAllString(text, replacement)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ones = []string{"zero", "one", "two", "three", "four", "five", "six",
+		"seven", "eight", "nine", "ten", "eleven", "twelve", "thirteen",
+		"fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	tens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty",
+		"seventy", "eighty", "ninety"}
+	scales = []string{"", "thousand", "million", "billion", "trillion"}
+
+	// ordinals maps the last word of a cardinal to its ordinal form
+	ordinals = map[string]string{
+		"one": "first", "two": "second", "three": "third", "five": "fifth",
+		"eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+	}
+)
+
+// Cardinal returns n in English words, e.g. "one hundred and twenty three"
+func Cardinal(n int64) string {
+	if n < 0 {
+		return "minus " + Cardinal(-n)
+	}
+	if n < 20 {
+		return ones[n]
+	}
+
+	var groups []string
+	for scale := 0; n > 0; scale++ {
+		group := n % 1000
+		n /= 1000
+		if group == 0 {
+			continue
+		}
+
+		words := hundreds(group)
+		if scale > 0 {
+			words += " " + scales[scale]
+		} else if group < 100 && n > 0 {
+			words = "and " + words
+		}
+		groups = append([]string{words}, groups...)
+	}
+
+	return strings.Join(groups, " ")
+}
+
+// hundreds returns 1 <= n < 1000 in words
+func hundreds(n int64) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, ones[n/100], "hundred")
+		if n%100 != 0 {
+			words = append(words, "and")
+		}
+		n %= 100
+	}
+	if n >= 20 {
+		w := tens[n/10]
+		if n%10 != 0 {
+			w += " " + ones[n%10]
+		}
+		words = append(words, w)
+	} else if n > 0 {
+		words = append(words, ones[n])
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Ordinal returns n as an English ordinal, e.g. "twenty first"
+func Ordinal(n int64) string {
+	words := Cardinal(n)
+	i := strings.LastIndex(words, " ") + 1
+	last := words[i:]
+
+	switch {
+	case ordinals[last] != "":
+		last = ordinals[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+
+	return words[:i] + last
+}
+
+// Year returns a year as it is usually read, e.g. "nineteen eighty four"
+// or "two thousand and five"
+func Year(y int) string {
+	switch {
+	case y >= 2000 && y < 2010, y < 1100 || y >= 10000:
+		return Cardinal(int64(y))
+	case y%100 == 0:
+		return Cardinal(int64(y/100)) + " hundred"
+	case y%100 < 10:
+		return Cardinal(int64(y/100)) + " oh " + ones[y%10]
+	}
+
+	return Cardinal(int64(y/100)) + " " + Cardinal(int64(y%100))
+}
+
+var (
+	numberPattern  = regexp.MustCompile(`\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)*`)
+	isoDatePattern = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	tagPattern     = regexp.MustCompile(`<[/?!]?[A-Za-z][^<>]*>`)
+)
+
+// currency is how amounts of a currency are read
+type currency struct {
+	unit, units       string
+	subunit, subunits string
+}
+
+// currencies are the currency symbols understood before a number
+var currencies = map[rune]*currency{
+	'$': {"dollar", "dollars", "cent", "cents"},
+	'£': {"pound", "pounds", "penny", "pence"},
+	'€': {"euro", "euros", "cent", "cents"},
+}
+
+// ExpandNumbers replaces numbers with words. Thousands separators, decimals,
+// negative numbers, ordinal suffixes such as "21st", amounts such as "£3.50"
+// and ranges such as "10-20" are understood, and dotted numbers such as
+// "1.2.3" are read part by part. Zero-padded numbers such as "007", numbers
+// within words, ISO dates and markup tags are left as they are.
+func ExpandNumbers(text string) string {
+	return outsideTags(text, expandNumbers)
+}
+
+func expandNumbers(text string) string {
+	dates := isoDatePattern.FindAllStringIndex(text, -1)
+
+	var b strings.Builder
+	last, prevEnd := 0, -1
+	var prevCur *currency
+	for _, loc := range numberPattern.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if inSpans(dates, start) {
+			continue
+		}
+		if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && (isWordRune(r) || r == '.') {
+			continue
+		}
+
+		suffix := ordinalSuffix(text[end:])
+		if r, _ := utf8.DecodeRuneInString(text[end+len(suffix):]); end+len(suffix) < len(text) && isWordRune(r) {
+			continue
+		}
+
+		// Look back from the digits for a currency symbol, then a hyphen
+		// joining the number to the one before or making it negative
+		pre := start
+		r, size := utf8.DecodeLastRuneInString(text[last:pre])
+		cur := currencies[r]
+		if cur != nil {
+			pre -= size
+		}
+		isRange, negative := false, false
+		if r, size := utf8.DecodeLastRuneInString(text[last:pre]); r == '-' || r == '–' {
+			switch before, _ := utf8.DecodeLastRuneInString(text[:pre-size]); {
+			case pre-size == prevEnd:
+				isRange = true
+				if cur == nil {
+					cur = prevCur
+				}
+			case pre-size == 0 || !isWordRune(before):
+				negative = r == '-'
+			}
+			if isRange || negative {
+				pre -= size
+			}
+		}
+
+		words, ok := spellNumber(text[start:end], suffix, cur)
+		if !ok {
+			continue
+		}
+
+		b.WriteString(text[last:pre])
+		if isRange {
+			b.WriteString(" to ")
+		}
+		if negative {
+			b.WriteString("minus ")
+		}
+		b.WriteString(words)
+		last = end + len(suffix)
+		prevEnd, prevCur = last, cur
+	}
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+// spellNumber returns a number with any ordinal suffix, in cur if it is an
+// amount, in words. ok is false if it should be left as it is.
+func spellNumber(number, suffix string, cur *currency) (words string, ok bool) {
+	parts := strings.Split(number, ".")
+	if len(parts) > 2 {
+		if suffix != "" || cur != nil {
+			return "", false
+		}
+		words := make([]string, len(parts))
+		for i, part := range parts {
+			n, ok := parseWhole(part)
+			if !ok {
+				return "", false
+			}
+			words[i] = Cardinal(n)
+		}
+		return strings.Join(words, " point "), true
+	}
+
+	n, ok := parseWhole(strings.Replace(parts[0], ",", "", -1))
+	if !ok {
+		return "", false
+	}
+	fraction := ""
+	if len(parts) == 2 {
+		fraction = parts[1]
+	}
+
+	switch {
+	case suffix != "":
+		if fraction != "" || cur != nil {
+			return "", false
+		}
+		return Ordinal(n), true
+	case cur != nil:
+		return cur.amount(n, fraction), true
+	case fraction != "":
+		return Cardinal(n) + " point " + digits(fraction), true
+	}
+
+	return Cardinal(n), true
+}
+
+// amount returns n and the fraction of cur in words, e.g. "three pounds and
+// fifty pence"
+func (cur *currency) amount(n int64, fraction string) string {
+	units := cur.units
+	if n == 1 {
+		units = cur.unit
+	}
+
+	switch {
+	case fraction == "":
+		return Cardinal(n) + " " + units
+	case len(fraction) != 2:
+		return Cardinal(n) + " point " + digits(fraction) + " " + cur.units
+	}
+
+	sub, _ := strconv.ParseInt(fraction, 10, 64)
+	subunits := cur.subunits
+	if sub == 1 {
+		subunits = cur.subunit
+	}
+	switch {
+	case sub == 0:
+		return Cardinal(n) + " " + units
+	case n == 0:
+		return Cardinal(sub) + " " + subunits
+	}
+
+	return Cardinal(n) + " " + units + " and " + Cardinal(sub) + " " + subunits
+}
+
+// parseWhole parses a whole number, ok is false if it is zero-padded, as
+// codes such as 007 are, or too large to read
+func parseWhole(s string) (n int64, ok bool) {
+	if len(s) > 1 && s[0] == '0' {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil && n < 1e15
+}
+
+// digits returns each digit of s in words
+func digits(s string) string {
+	words := make([]string, len(s))
+	for i, d := range s {
+		words[i] = ones[d-'0']
+	}
+
+	return strings.Join(words, " ")
+}
+
+// ordinalSuffix returns the ordinal suffix at the start of s, if any
+func ordinalSuffix(s string) string {
+	if len(s) < 2 {
+		return ""
+	}
+
+	switch s[:2] {
+	case "st", "nd", "rd", "th":
+		return s[:2]
+	}
+
+	return ""
+}
+
+// outsideTags applies fn to the text between markup tags, leaving the tags
+// and their attributes unchanged
+func outsideTags(text string, fn func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range tagPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(fn(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(fn(text[last:]))
+
+	return b.String()
+}
+
+// inSpans reports whether i is within any of spans
+func inSpans(spans [][]int, i int) bool {
+	for _, span := range spans {
+		if i >= span[0] && i < span[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// ExpandDates replaces ISO 8601 dates such as 2024-03-05 with words, e.g.
+// "the fifth of March twenty twenty four". Markup tags are left as they are.
+func ExpandDates(text string) string {
+	return outsideTags(text, func(text string) string {
+		return isoDatePattern.ReplaceAllStringFunc(text, func(match string) string {
+			t, err := time.Parse("2006-01-02", match)
+			if err != nil {
+				return match
+			}
+
+			return "the " + Ordinal(int64(t.Day())) + " of " + t.Month().String() + " " + Year(t.Year())
+		})
+	})
+}
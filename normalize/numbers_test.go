@@ -0,0 +1,88 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package normalize
+
+import "testing"
+
+func TestExpandNumbers(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"I have 3 cats", "I have three cats"},
+		{"1,250 people", "one thousand two hundred and fifty people"},
+		{"pi is 3.14", "pi is three point one four"},
+		{"the 21st century", "the twenty first century"},
+		{"it is -5 outside", "it is minus five outside"},
+		{"-0.5", "minus zero point five"},
+		{"pages 10-20", "pages ten to twenty"},
+		{"1990–2000", "one thousand nine hundred and ninety to two thousand"},
+		{"5 -3", "five minus three"},
+		{"agent 007", "agent 007"},
+		{"call 0800 123", "call 0800 one hundred and twenty three"},
+		{"0.5", "zero point five"},
+		{"version 1.2.3", "version one point two point three"},
+		{"costs $5", "costs five dollars"},
+		{"costs $1", "costs one dollar"},
+		{"£3.50", "three pounds and fifty pence"},
+		{"€0.01", "one cent"},
+		{"$2.00", "two dollars"},
+		{"£5-10", "five pounds to ten pounds"},
+		{"-$5", "minus five dollars"},
+		{"$1.5", "one point five dollars"},
+		{"mp3 and 3D", "mp3 and 3D"},
+		{"COVID-19", "COVID-nineteen"},
+		{"on 2024-03-05", "on 2024-03-05"},
+		{`Wait <break time="3s"/> 2 seconds`, `Wait <break time="3s"/> two seconds`},
+		{`<prosody rate="1.5">1</prosody>`, `<prosody rate="1.5">one</prosody>`},
+		{"1 < 2", "one < two"},
+		{"999999999999999999", "999999999999999999"},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandNumbers(tt.text); got != tt.want {
+			t.Errorf("ExpandNumbers(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExpandDates(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"on 2024-03-05", "on the fifth of March twenty twenty four"},
+		{"2005-12-01", "the first of December two thousand and five"},
+		{"2024-13-01", "2024-13-01"},
+		{`<mark name="2024-03-05"/>`, `<mark name="2024-03-05"/>`},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandDates(tt.text); got != tt.want {
+			t.Errorf("ExpandDates(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCardinalOrdinalYear(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{Cardinal(0), "zero"},
+		{Cardinal(105), "one hundred and five"},
+		{Cardinal(1001), "one thousand and one"},
+		{Cardinal(-42), "minus forty two"},
+		{Ordinal(12), "twelfth"},
+		{Ordinal(20), "twentieth"},
+		{Ordinal(103), "one hundred and third"},
+		{Year(1984), "nineteen eighty four"},
+		{Year(1900), "nineteen hundred"},
+		{Year(1905), "nineteen oh five"},
+		{Year(2005), "two thousand and five"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
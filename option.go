@@ -0,0 +1,148 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// NewClient returns a Client for the given credentials, configured by any
+// options. APIURL is left empty so requests use DefaultAPIURL, or the
+// deprecated CereVoiceAPIURL if that is set later.
+func NewClient(accountID, password string, opts ...ClientOption) *Client {
+	c := &Client{
+		AccountID: accountID,
+		Password:  password,
+	}
+	c.Apply(opts...)
+
+	return c
+}
+
+// Apply applies options to the Client
+func (c *Client) Apply(opts ...ClientOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithAPIURL sets the CereVoice Cloud API URL
+func WithAPIURL(url string) ClientOption {
+	return func(c *Client) {
+		c.APIURL = url
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for all requests
+func WithHTTPClient(client HTTPClient) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient = client
+	}
+}
+
+// WithRetry enables retrying transient failures with the given policy
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.RetryPolicy = &policy
+	}
+}
+
+// WithLogger sets the Logger receiving every API request
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) {
+		c.Logger = logger
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with requests, replacing
+// DefaultUserAgent
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithCache sets the Cache used by SpeakAudio
+func WithCache(cache Cache) ClientOption {
+	return func(c *Client) {
+		c.Cache = cache
+	}
+}
+
+// WithTracer enables tracing and metrics of every API request
+func WithTracer(t Tracer) ClientOption {
+	return func(c *Client) {
+		c.Tracer = t
+	}
+}
+
+// Clone returns a copy of c with opts applied. The copy shares the HTTP
+// client and its connection pool, rate limiter, cache, logger, tracer and
+// middleware with c, so it is cheap to create one per tenant.
+func (c *Client) Clone(opts ...ClientOption) *Client {
+	clone := &Client{
+		AccountID:         c.AccountID,
+		Password:          c.Password,
+		Credentials:       c.Credentials,
+		APIURL:            c.APIURL,
+		HTTPClient:        c.HTTPClient,
+		RetryPolicy:       c.RetryPolicy,
+		Cache:             c.Cache,
+		Logger:            c.Logger,
+		Tracer:            c.Tracer,
+		UserAgent:         c.UserAgent,
+		Header:            c.Header.Clone(),
+		RateLimiter:       c.RateLimiter,
+		Breaker:           c.Breaker,
+		Dedup:             c.Dedup,
+		CreditGuard:       c.CreditGuard,
+		CheckFormats:      c.CheckFormats,
+		VerifyAudio:       c.VerifyAudio,
+		Middleware:        c.Middleware,
+		DryRun:            c.DryRun,
+		TextSteps:         c.TextSteps,
+		TextAuditor:       c.TextAuditor,
+		CustomVoices:      c.CustomVoices,
+		AudioEffects:      c.AudioEffects,
+		Codec:             c.Codec,
+		DecodeMode:        c.DecodeMode,
+		CharsetReader:     c.CharsetReader,
+		ElementAliases:    c.ElementAliases,
+		FallbackURLs:      c.FallbackURLs,
+		EndpointCooldown:  c.EndpointCooldown,
+		Timeouts:          c.Timeouts,
+		LanguageDetection: c.LanguageDetection,
+		CheckEmotions:     c.CheckEmotions,
+		EmotionalVoices:   c.EmotionalVoices,
+		VoiceProfiles:     c.VoiceProfiles,
+		Ledger:            c.Ledger,
+		Quota:             c.Quota,
+		Debug:             c.Debug,
+		ConnTracker:       c.ConnTracker,
+		MaxTextLength:     c.MaxTextLength,
+		LengthPolicy:      c.LengthPolicy,
+		TLSConfig:         c.TLSConfig,
+		Proxy:             c.Proxy,
+		Dialer:            c.Dialer,
+		CereVoiceAPIURL:   c.CereVoiceAPIURL,
+	}
+	clone.Apply(opts...)
+
+	// Share the connection pool built for the same transport settings
+	if c.customTransport() && c.sameTransport(clone) {
+		clone.transport = c.transportClient()
+	}
+
+	return clone
+}
+
+// ForAccount returns a Clone of c using the given credentials. The clone
+// has no CreditGuard, as the guard tracks the balance of a single account.
+func (c *Client) ForAccount(accountID, password string) *Client {
+	return c.Clone(func(clone *Client) {
+		clone.AccountID = accountID
+		clone.Password = password
+		clone.Credentials = nil
+		clone.CreditGuard = nil
+	})
+}
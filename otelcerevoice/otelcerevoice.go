@@ -0,0 +1,154 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package otelcerevoice instruments a cerevoicego.Client with OpenTelemetry.
+// It is a separate module so cerevoicego itself does not depend on
+// OpenTelemetry.
+//
+// Each API request is traced by a client span named after its operation,
+// e.g. cerevoice.speakExtended, and measured by the metrics:
+//
+//	cerevoice.client.request.duration  histogram of request latency in seconds, including retries
+//	cerevoice.client.characters        counter of characters billed
+//
+// Both metrics are recorded with the cerevoice.operation and cerevoice.voice
+// attributes, and error.type if the request failed.
+package otelcerevoice
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/bganderson/cerevoicego"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter
+const ScopeName = "github.com/bganderson/cerevoicego/otelcerevoice"
+
+// Span and metric attributes
+const (
+	OperationKey  = attribute.Key("cerevoice.operation")
+	VoiceKey      = attribute.Key("cerevoice.voice")
+	TextLengthKey = attribute.Key("cerevoice.text_length")
+	CharCountKey  = attribute.Key("cerevoice.char_count")
+	ResultCodeKey = attribute.Key("cerevoice.result_code")
+	AttemptsKey   = attribute.Key("cerevoice.attempts")
+	ErrorTypeKey  = attribute.Key("error.type")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option configures a Tracer
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider, the global one by default
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the MeterProvider, the global one by default
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// Tracer is a cerevoicego.Tracer recording spans and metrics with
+// OpenTelemetry
+type Tracer struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	chars    metric.Int64Counter
+}
+
+// NewTracer returns a Tracer. Errors creating its instruments are passed to
+// otel.Handle, leaving the instrument disabled.
+func NewTracer(opts ...Option) *Tracer {
+	c := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	t := &Tracer{tracer: c.tracerProvider.Tracer(ScopeName)}
+	meter := c.meterProvider.Meter(ScopeName)
+
+	var err error
+	if t.duration, err = meter.Float64Histogram("cerevoice.client.request.duration",
+		metric.WithDescription("Duration of CereVoice API requests, including retries"),
+		metric.WithUnit("s")); err != nil {
+		otel.Handle(err)
+	}
+	if t.chars, err = meter.Int64Counter("cerevoice.client.characters",
+		metric.WithDescription("Characters billed by CereVoice"),
+		metric.WithUnit("{char}")); err != nil {
+		otel.Handle(err)
+	}
+
+	return t
+}
+
+// WithTelemetry returns a ClientOption tracing and measuring every API
+// request of the client
+func WithTelemetry(opts ...Option) cerevoicego.ClientOption {
+	return cerevoicego.WithTracer(NewTracer(opts...))
+}
+
+// StartRequest starts the span of an API request, ending it and recording
+// the metrics when the request finishes
+func (t *Tracer) StartRequest(ctx context.Context, operation string) (context.Context, func(*cerevoicego.RequestLog)) {
+	ctx, span := t.tracer.Start(ctx, "cerevoice."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(OperationKey.String(operation)))
+
+	return ctx, func(e *cerevoicego.RequestLog) {
+		attrs := []attribute.KeyValue{OperationKey.String(e.Operation)}
+		if e.Voice != "" {
+			attrs = append(attrs, VoiceKey.String(e.Voice))
+		}
+		if e.Err != nil {
+			attrs = append(attrs, ErrorTypeKey.String(errorType(e)))
+		}
+
+		span.SetAttributes(append(attrs[1:],
+			TextLengthKey.Int(e.TextLength),
+			CharCountKey.Int(e.CharCount),
+			ResultCodeKey.Int(int(e.ResultCode)),
+			AttemptsKey.Int(e.Attempts),
+		)...)
+		if e.Err != nil {
+			span.RecordError(e.Err)
+			span.SetStatus(codes.Error, e.Err.Error())
+		}
+		span.End()
+
+		set := metric.WithAttributes(attrs...)
+		if t.duration != nil {
+			t.duration.Record(ctx, e.Duration.Seconds(), set)
+		}
+		if t.chars != nil && e.CharCount > 0 {
+			t.chars.Add(ctx, int64(e.CharCount), set)
+		}
+	}
+}
+
+// errorType classifies a failed request by its result code, or as _OTHER,
+// following the OpenTelemetry conventions, if CereVoice returned none
+func errorType(e *cerevoicego.RequestLog) string {
+	if e.ResultCode != 0 {
+		return strconv.Itoa(int(e.ResultCode))
+	}
+	return "_OTHER"
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package otelcerevoice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		call   func(c *cerevoicego.Client) error
+		fail   cerevoicego.ResultCode // Of speakExtended, if set
+		span   string
+		attrs  map[attribute.Key]attribute.Value
+		status codes.Code
+		chars  int64
+	}{
+		{
+			name: "speak",
+			call: func(c *cerevoicego.Client) error {
+				_, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"})
+				return err
+			},
+			span: "cerevoice.speakExtended",
+			attrs: map[attribute.Key]attribute.Value{
+				OperationKey:  attribute.StringValue("speakExtended"),
+				VoiceKey:      attribute.StringValue("Heather"),
+				TextLengthKey: attribute.IntValue(5),
+				CharCountKey:  attribute.IntValue(5),
+				ResultCodeKey: attribute.IntValue(1),
+				AttemptsKey:   attribute.IntValue(1),
+			},
+			status: codes.Unset,
+			chars:  5,
+		},
+		{
+			name: "credit",
+			call: func(c *cerevoicego.Client) error {
+				_, err := c.GetCredit()
+				return err
+			},
+			span: "cerevoice.getCredit",
+			attrs: map[attribute.Key]attribute.Value{
+				OperationKey: attribute.StringValue("getCredit"),
+				CharCountKey: attribute.IntValue(0),
+			},
+			status: codes.Unset,
+		},
+		{
+			name: "invalid voice",
+			call: func(c *cerevoicego.Client) error {
+				_, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{Voice: "Nobody", Text: "Hello"})
+				return err
+			},
+			fail: cerevoicego.ResultInvalidVoice,
+			span: "cerevoice.speakExtended",
+			attrs: map[attribute.Key]attribute.Value{
+				VoiceKey:      attribute.StringValue("Nobody"),
+				ResultCodeKey: attribute.IntValue(-3),
+				ErrorTypeKey:  attribute.StringValue("-3"),
+			},
+			status: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		srv.Reset()
+		if tt.fail != 0 {
+			srv.SetError("speakExtended", tt.fail, "invalid voice")
+		}
+		spans := tracetest.NewSpanRecorder()
+		reader := sdkmetric.NewManualReader()
+		c := srv.Client()
+		c.Apply(WithTelemetry(
+			WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+			WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		))
+
+		err := tt.call(c)
+		if (err != nil) != (tt.status == codes.Error) {
+			t.Errorf("%s: error %v", tt.name, err)
+		}
+
+		ended := spans.Ended()
+		if len(ended) != 1 {
+			t.Errorf("%s: %d spans, want 1", tt.name, len(ended))
+			continue
+		}
+		span := ended[0]
+		if span.Name() != tt.span || span.SpanKind() != trace.SpanKindClient {
+			t.Errorf("%s: span %s of kind %s, want client span %s", tt.name, span.Name(), span.SpanKind(), tt.span)
+		}
+		if span.Status().Code != tt.status {
+			t.Errorf("%s: status %s, want %s", tt.name, span.Status().Code, tt.status)
+		}
+		got := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			got[kv.Key] = kv.Value
+		}
+		for k, v := range tt.attrs {
+			if got[k] != v {
+				t.Errorf("%s: attribute %s = %v, want %v", tt.name, k, got[k].Emit(), v.Emit())
+			}
+		}
+
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatal(err)
+		}
+		var requests uint64
+		var chars int64
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				switch data := m.Data.(type) {
+				case metricdata.Histogram[float64]:
+					for _, dp := range data.DataPoints {
+						requests += dp.Count
+						if op, _ := dp.Attributes.Value(OperationKey); op != tt.attrs[OperationKey] && tt.attrs[OperationKey].Type() != attribute.INVALID {
+							t.Errorf("%s: duration recorded for operation %v", tt.name, op.Emit())
+						}
+					}
+				case metricdata.Sum[int64]:
+					for _, dp := range data.DataPoints {
+						chars += dp.Value
+					}
+				}
+			}
+		}
+		if requests != 1 {
+			t.Errorf("%s: %d request durations recorded, want 1", tt.name, requests)
+		}
+		if chars != tt.chars {
+			t.Errorf("%s: %d characters counted, want %d", tt.name, chars, tt.chars)
+		}
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		entry cerevoicego.RequestLog
+		want  string
+	}{
+		{cerevoicego.RequestLog{ResultCode: cerevoicego.ResultInvalidVoice}, "-3"},
+		{cerevoicego.RequestLog{}, "_OTHER"},
+	}
+
+	for _, tt := range tests {
+		if got := errorType(&tt.entry); got != tt.want {
+			t.Errorf("errorType(%v) = %q, want %q", tt.entry.ResultCode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// Defaults of the Pipeline effects
+const (
+	// DefaultLoudness is the target of Pipeline.Normalize in LUFS, suited to
+	// speech
+	DefaultLoudness = -16
+	// DefaultSilenceThreshold is the level below which Pipeline.TrimSilence
+	// treats audio as silent, in decibels relative to full scale
+	DefaultSilenceThreshold = -50
+	// DefaultSilencePadding is the silence Pipeline.TrimSilence keeps either
+	// side of the speech
+	DefaultSilencePadding = 100 * time.Millisecond
+)
+
+// Pipeline chains the synthesis, download and post-processing of some text,
+// reporting any failure once from the final step:
+//
+//	err := client.Speak("Hello world!").
+//		WithVoice("Heather").
+//		Normalize().
+//		TrimSilence().
+//		SaveTo("hello.wav")
+//
+// Each step modifies and returns the Pipeline, which is not safe for
+// concurrent use. Audio is synthesised with SpeakAudio, so the Client's
+// Cache, AudioEffects and other settings apply, then the Pipeline's effects.
+// Effects need WAV audio.
+type Pipeline struct {
+	client  *Client
+	ctx     context.Context
+	input   SpeakExtendedInput
+	effects []audio.Effect
+}
+
+// Speak starts a Pipeline synthesising text
+func (c *Client) Speak(text string) *Pipeline {
+	return &Pipeline{
+		client: c,
+		ctx:    context.Background(),
+		input:  SpeakExtendedInput{Text: text},
+	}
+}
+
+// WithContext sets the context for cancellation and timeouts
+func (p *Pipeline) WithContext(ctx context.Context) *Pipeline {
+	p.ctx = ctx
+	return p
+}
+
+// WithVoice sets the voice
+func (p *Pipeline) WithVoice(voice string) *Pipeline {
+	p.input.Voice = voice
+	return p
+}
+
+// WithMode sets the speak mode
+func (p *Pipeline) WithMode(mode SpeakMode) *Pipeline {
+	p.input.SpeakMode = mode
+	return p
+}
+
+// As sets the audio format. SaveTo uses that of the file extension if none
+// is set.
+func (p *Pipeline) As(format AudioFormat) *Pipeline {
+	p.input.AudioFormat = format
+	return p
+}
+
+// At sets the sample rate
+func (p *Pipeline) At(rate SampleRate) *Pipeline {
+	p.input.SampleRate = rate
+	return p
+}
+
+// Effect adds effects, applied in order after those added before
+func (p *Pipeline) Effect(effects ...audio.Effect) *Pipeline {
+	p.effects = append(p.effects, effects...)
+	return p
+}
+
+// Normalize adds audio.Normalize to DefaultLoudness
+func (p *Pipeline) Normalize() *Pipeline {
+	return p.Effect(audio.Normalize(DefaultLoudness))
+}
+
+// TrimSilence adds audio.TrimSilence with DefaultSilenceThreshold and
+// DefaultSilencePadding
+func (p *Pipeline) TrimSilence() *Pipeline {
+	return p.Effect(audio.TrimSilence(DefaultSilenceThreshold, DefaultSilencePadding))
+}
+
+// Gain adds audio.Gain, changing the volume by db decibels
+func (p *Pipeline) Gain(db float64) *Pipeline {
+	return p.Effect(audio.Gain(db))
+}
+
+// FadeIn adds audio.FadeIn over d
+func (p *Pipeline) FadeIn(d time.Duration) *Pipeline {
+	return p.Effect(audio.FadeIn(d))
+}
+
+// FadeOut adds audio.FadeOut over d
+func (p *Pipeline) FadeOut(d time.Duration) *Pipeline {
+	return p.Effect(audio.FadeOut(d))
+}
+
+// Bytes runs the pipeline and returns the audio
+func (p *Pipeline) Bytes() ([]byte, error) {
+	input := *p.client.profile(&p.input)
+	if len(p.effects) > 0 {
+		if err := effectsFormat(input.AudioFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := p.client.SpeakAudioWithContext(p.ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return audio.Process(b, p.effects...)
+}
+
+// WriteTo runs the pipeline and writes the audio to w
+func (p *Pipeline) WriteTo(w io.Writer) (int64, error) {
+	b, err := p.Bytes()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// SaveTo runs the pipeline and writes the audio to the file at path
+func (p *Pipeline) SaveTo(path string) error {
+	if p.input.AudioFormat == "" {
+		if ext := AudioFormat(strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))); ext.Valid() {
+			p.input.AudioFormat = ext
+		}
+	}
+
+	b, err := p.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
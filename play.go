@@ -0,0 +1,35 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build playback
+
+package cerevoicego
+
+import (
+	"context"
+
+	"github.com/bganderson/cerevoicego/playback"
+)
+
+// SpeakAndPlay synthesises input and plays it through the default output
+// device. It is only available when built with the playback tag and is
+// intended for auditioning voices and lexicons during development.
+func (c *Client) SpeakAndPlay(input *SpeakExtendedInput) error {
+	return c.SpeakAndPlayWithContext(context.Background(), input)
+}
+
+// SpeakAndPlayWithContext is the same as SpeakAndPlay with the addition of
+// the ability to pass a context for cancellation and timeouts
+func (c *Client) SpeakAndPlayWithContext(ctx context.Context, input *SpeakExtendedInput) error {
+	audio, err := c.SpeakAudioWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	format := input.AudioFormat
+	if format == "" {
+		format = FormatWAV
+	}
+
+	return playback.Play(ctx, audio, string(format))
+}
@@ -0,0 +1,18 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build !portaudio
+
+package playback
+
+import (
+	"context"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// playDevice returns errUnavailable when built without the portaudio tag, so
+// a command line player is used
+func playDevice(ctx context.Context, samples []float32, f audio.Format) error {
+	return errUnavailable
+}
@@ -0,0 +1,200 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package playback plays synthesised audio through the default output device
+// for auditioning voices and lexicon changes during development.
+//
+// When built with the portaudio tag, WAV audio is played in process through
+// PortAudio, as is MP3 if also built with the lame tag. Other formats, and
+// all audio when built without the tag, are played with a command line
+// player installed on the system, such as afplay on macOS or paplay, aplay,
+// ffplay or mpv on Linux.
+package playback
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/audio"
+	"github.com/bganderson/cerevoicego/transcode"
+)
+
+// ErrNoPlayer is returned when no supported audio player is installed
+var ErrNoPlayer = errors.New("playback: no audio player found")
+
+// errUnavailable is returned by playDevice when built without the portaudio
+// tag
+var errUnavailable = errors.New("playback: built without portaudio support, rebuild with -tags portaudio")
+
+// errUndecodable is returned by decode for formats only a player can play
+var errUndecodable = errors.New("playback: format can not be decoded")
+
+// chunkFrames is the frames written to the device at a time, so
+// cancellation stops playback within about 20 ms at 48 kHz
+const chunkFrames = 1024
+
+// player is a command line audio player
+type player struct {
+	name    string
+	args    func(path string) []string
+	formats []string // formats supported, nil for any
+}
+
+func (p player) supports(format string) bool {
+	if p.formats == nil {
+		return true
+	}
+	for _, f := range p.formats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// common players available on most platforms
+var common = []player{
+	{
+		name: "ffplay",
+		args: func(path string) []string {
+			return []string{"-nodisp", "-autoexit", "-loglevel", "quiet", path}
+		},
+	},
+	{
+		name: "mpv",
+		args: func(path string) []string {
+			return []string{"--really-quiet", "--no-video", path}
+		},
+	},
+}
+
+// Play plays audio in the given format, e.g. "wav" or "mp3", blocking until
+// playback finishes or ctx is done
+func Play(ctx context.Context, audio []byte, format string) error {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	if format == "" {
+		format = "wav"
+	}
+
+	if samples, f, err := decode(audio, format); err == nil {
+		if err := playDevice(ctx, samples, f); err != errUnavailable {
+			return err
+		}
+	}
+
+	return playCommand(ctx, audio, format)
+}
+
+// decode returns the interleaved samples of WAV, or MP3 when built with the
+// lame tag, to play in process
+func decode(b []byte, format string) ([]float32, audio.Format, error) {
+	switch format {
+	case "wav":
+	case "mp3":
+		var err error
+		if b, err = transcode.DecodeMP3(b); err != nil {
+			return nil, audio.Format{}, err
+		}
+	default:
+		return nil, audio.Format{}, errUndecodable
+	}
+
+	w, err := audio.DecodeWAV(b)
+	if err != nil {
+		return nil, audio.Format{}, err
+	}
+	samples, err := w.Samples()
+	if err != nil {
+		return nil, audio.Format{}, err
+	}
+
+	out := make([]float32, len(samples))
+	for i, s := range samples {
+		out[i] = float32(s)
+	}
+
+	return out, w.Format, nil
+}
+
+// stream is an output stream of the default device, started and accepting
+// interleaved samples
+type stream interface {
+	// write blocks until the samples are buffered for playback
+	write(samples []float32) error
+	// stop waits for the buffered samples to play and stops the stream
+	stop() error
+	// abort stops the stream, discarding the buffered samples
+	abort() error
+	close() error
+}
+
+// play writes samples to s in chunks, so it can be stopped when ctx is done,
+// then waits for them to play and closes s
+func play(ctx context.Context, s stream, samples []float32, channels int) (err error) {
+	defer func() {
+		if cerr := s.close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	for n := chunkFrames * channels; len(samples) > 0; {
+		if err := ctx.Err(); err != nil {
+			s.abort()
+			return err
+		}
+		chunk := samples
+		if len(chunk) > n {
+			chunk = chunk[:n]
+		}
+		if err := s.write(chunk); err != nil {
+			s.abort()
+			return err
+		}
+		samples = samples[len(chunk):]
+	}
+
+	return s.stop()
+}
+
+// playCommand plays audio with the first installed player supporting format
+func playCommand(ctx context.Context, audio []byte, format string) error {
+	p, path, err := find(format)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile("", "cerevoice-*."+format)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(audio); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return exec.CommandContext(ctx, path, p.args(f.Name())...).Run()
+}
+
+// find returns the first installed player supporting format
+func find(format string) (player, string, error) {
+	for _, p := range append(players, common...) {
+		if !p.supports(format) {
+			continue
+		}
+		if path, err := exec.LookPath(p.name); err == nil {
+			return p, path, nil
+		}
+	}
+
+	return player{}, "", ErrNoPlayer
+}
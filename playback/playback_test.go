@@ -0,0 +1,242 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package playback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// fakeStream records the calls made by play
+type fakeStream struct {
+	writes  []int // Samples per write
+	calls   []string
+	failAt  int // Write failing, from 1, or 0
+	onWrite func()
+}
+
+func (s *fakeStream) write(samples []float32) error {
+	s.writes = append(s.writes, len(samples))
+	if s.onWrite != nil {
+		s.onWrite()
+	}
+	if len(s.writes) == s.failAt {
+		return errors.New("device lost")
+	}
+	return nil
+}
+
+func (s *fakeStream) stop() error  { s.calls = append(s.calls, "stop"); return nil }
+func (s *fakeStream) abort() error { s.calls = append(s.calls, "abort"); return nil }
+func (s *fakeStream) close() error { s.calls = append(s.calls, "close"); return nil }
+
+func TestPlay(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		samples  int
+		channels int
+		failAt   int
+		cancelAt int // Write after which ctx is canceled, or 0
+		writes   []int
+		calls    string
+		err      bool
+	}{
+		{name: "mono", samples: 2500, channels: 1, writes: []int{1024, 1024, 452}, calls: "stop,close"},
+		{name: "stereo", samples: 4096, channels: 2, writes: []int{2048, 2048}, calls: "stop,close"},
+		{name: "empty", samples: 0, channels: 1, calls: "stop,close"},
+		{name: "canceled", ctx: canceled, samples: 2048, channels: 1, calls: "abort,close", err: true},
+		{name: "canceled while playing", samples: 4096, channels: 1, cancelAt: 2, writes: []int{1024, 1024}, calls: "abort,close", err: true},
+		{name: "write error", samples: 4096, channels: 1, failAt: 2, writes: []int{1024, 1024}, calls: "abort,close", err: true},
+	}
+
+	for _, tt := range tests {
+		ctx := tt.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		s := &fakeStream{failAt: tt.failAt}
+		s.onWrite = func() {
+			if len(s.writes) == tt.cancelAt {
+				cancel()
+			}
+		}
+
+		err := play(ctx, s, make([]float32, tt.samples), tt.channels)
+		cancel()
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error %v, want error %t", tt.name, err, tt.err)
+		}
+		if !equalInts(s.writes, tt.writes) {
+			t.Errorf("%s: writes %v, want %v", tt.name, s.writes, tt.writes)
+		}
+		if calls := strings.Join(s.calls, ","); calls != tt.calls {
+			t.Errorf("%s: calls %s, want %s", tt.name, calls, tt.calls)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	w := &audio.WAV{Format: audio.PCM16(8000, 2)}
+	if err := w.SetSamples([]float64{0.5, -0.5, 0.25, -0.25}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		audio  []byte
+		format string
+		want   []float32
+		err    bool
+	}{
+		{name: "wav", audio: w.Bytes(), format: "wav", want: []float32{0.5, -0.5, 0.25, -0.25}},
+		{name: "corrupt wav", audio: []byte("RIFF"), format: "wav", err: true},
+		{name: "ogg", audio: []byte("OggS"), format: "ogg", err: true},
+		{name: "raw", audio: []byte{0, 0}, format: "raw", err: true},
+	}
+
+	for _, tt := range tests {
+		got, f, err := decode(tt.audio, tt.format)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error %v, want error %t", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err {
+			continue
+		}
+		if f.Channels != 2 || f.SampleRate != 8000 {
+			t.Errorf("%s: %d channels at %d Hz, want 2 at 8000 Hz", tt.name, f.Channels, f.SampleRate)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: %d samples, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if d := got[i] - tt.want[i]; d > 1e-4 || d < -1e-4 {
+				t.Errorf("%s: sample %d = %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		p      player
+		format string
+		want   bool
+	}{
+		{player{name: "any"}, "ogg", true},
+		{player{name: "aplay", formats: []string{"wav"}}, "wav", true},
+		{player{name: "aplay", formats: []string{"wav"}}, "mp3", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.supports(tt.format); got != tt.want {
+			t.Errorf("%s.supports(%q) = %t, want %t", tt.p.name, tt.format, got, tt.want)
+		}
+	}
+}
+
+// installPlayers puts scripts standing in for the named players in an empty
+// PATH. Each copies the file it is given to played in the directory.
+func installPlayers(t *testing.T, names ...string) string {
+	cp, err := exec.LookPath("cp")
+	if err != nil {
+		t.Skip("cp not found")
+	}
+	dir := t.TempDir()
+	for _, name := range names {
+		script := "#!/bin/sh\nfor f; do :; done\n" + cp + " \"$f\" \"" + filepath.Join(dir, "played") + "\"\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	return dir
+}
+
+func TestFind(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("players are shell scripts")
+	}
+
+	tests := []struct {
+		name      string
+		installed []string
+		format    string
+		want      string
+		err       error
+	}{
+		{name: "none", format: "wav", err: ErrNoPlayer},
+		{name: "common", installed: []string{"mpv"}, format: "ogg", want: "mpv"},
+		{name: "common order", installed: []string{"mpv", "ffplay"}, format: "mp3", want: "ffplay"},
+	}
+
+	for _, tt := range tests {
+		installPlayers(t, tt.installed...)
+		p, _, err := find(tt.format)
+		if err != tt.err {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+		}
+		if p.name != tt.want {
+			t.Errorf("%s: player %q, want %q", tt.name, p.name, tt.want)
+		}
+	}
+}
+
+func TestPlayCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("players are shell scripts")
+	}
+	dir := installPlayers(t, "ffplay")
+
+	// Ogg is never decoded in process, so is always given to a player
+	ogg := []byte("OggS audio")
+	if err := Play(context.Background(), ogg, ".OGG"); err != nil {
+		t.Fatal(err)
+	}
+	played, err := ioutil.ReadFile(filepath.Join(dir, "played"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(played, ogg) {
+		t.Errorf("player given %q, want %q", played, ogg)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(os.TempDir(), "cerevoice-*.ogg"))
+	for _, m := range matches {
+		t.Errorf("temporary file %s not removed", m)
+	}
+
+	os.Remove(filepath.Join(dir, "ffplay"))
+	if err := Play(context.Background(), ogg, "ogg"); err != ErrNoPlayer {
+		t.Errorf("without a player: error %v, want ErrNoPlayer", err)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
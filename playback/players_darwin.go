@@ -0,0 +1,12 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package playback
+
+// players preferred on macOS
+var players = []player{
+	{
+		name: "afplay",
+		args: func(path string) []string { return []string{path} },
+	},
+}
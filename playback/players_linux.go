@@ -0,0 +1,18 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package playback
+
+// players preferred on Linux
+var players = []player{
+	{
+		name:    "paplay",
+		args:    func(path string) []string { return []string{path} },
+		formats: []string{"wav", "ogg", "flac", "aiff"},
+	},
+	{
+		name:    "aplay",
+		args:    func(path string) []string { return []string{"-q", path} },
+		formats: []string{"wav"},
+	},
+}
@@ -0,0 +1,9 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build !darwin && !linux && !windows
+
+package playback
+
+// players preferred on other systems, only the common players are used
+var players []player
@@ -0,0 +1,16 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package playback
+
+// players preferred on Windows
+var players = []player{
+	{
+		name: "powershell",
+		args: func(path string) []string {
+			return []string{"-NoProfile", "-Command",
+				"(New-Object Media.SoundPlayer '" + path + "').PlaySync()"}
+		},
+		formats: []string{"wav"},
+	},
+}
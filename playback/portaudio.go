@@ -0,0 +1,72 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build portaudio
+
+package playback
+
+/*
+#cgo LDFLAGS: -lportaudio
+#include <portaudio.h>
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"unsafe"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// playDevice plays interleaved samples through the default output device.
+// PortAudio is initialised for each call, so no devices are held between
+// plays.
+func playDevice(ctx context.Context, samples []float32, f audio.Format) error {
+	if code := C.Pa_Initialize(); code != C.paNoError {
+		return paError(code)
+	}
+	defer C.Pa_Terminate()
+
+	var s unsafe.Pointer
+	if code := C.Pa_OpenDefaultStream(&s, 0, C.int(f.Channels), C.paFloat32,
+		C.double(f.SampleRate), C.paFramesPerBufferUnspecified, nil, nil); code != C.paNoError {
+		return paError(code)
+	}
+	if code := C.Pa_StartStream(s); code != C.paNoError {
+		C.Pa_CloseStream(s)
+		return paError(code)
+	}
+
+	return play(ctx, &paStream{s: s, channels: f.Channels}, samples, f.Channels)
+}
+
+// paStream is a started PortAudio blocking stream
+type paStream struct {
+	s        unsafe.Pointer
+	channels int
+}
+
+func (p *paStream) write(samples []float32) error {
+	code := C.Pa_WriteStream(p.s, unsafe.Pointer(&samples[0]), C.ulong(len(samples)/p.channels))
+	// An underflow is only a gap, e.g. while the process was descheduled
+	if code != C.paNoError && code != C.paOutputUnderflowed {
+		return paError(code)
+	}
+	return nil
+}
+
+func (p *paStream) stop() error  { return paCheck(C.Pa_StopStream(p.s)) }
+func (p *paStream) abort() error { return paCheck(C.Pa_AbortStream(p.s)) }
+func (p *paStream) close() error { return paCheck(C.Pa_CloseStream(p.s)) }
+
+func paCheck(code C.PaError) error {
+	if code != C.paNoError {
+		return paError(code)
+	}
+	return nil
+}
+
+func paError(code C.PaError) error {
+	return errors.New("playback: portaudio: " + C.GoString(C.Pa_GetErrorText(code)))
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// WithAudioEffects sets effects applied, in order, to downloaded audio, such
+// as audio.Normalize, audio.TrimSilence and audio.FadeOut. The audio must be
+// WAV: SpeakAudio, SpeakTo and stored batch items asking for another format
+// fail validation before anything is synthesised.
+func WithAudioEffects(effects ...audio.Effect) ClientOption {
+	return func(c *Client) {
+		c.AudioEffects = effects
+	}
+}
+
+// checkEffects returns an ErrValidation error if the Client has
+// AudioEffects and input asks for audio they can not be applied to
+func (c *Client) checkEffects(input *SpeakExtendedInput) error {
+	if len(c.AudioEffects) == 0 {
+		return nil
+	}
+
+	return effectsFormat(input.AudioFormat)
+}
+
+// effectsFormat returns an ErrValidation error if audio effects can not be
+// applied to format, which must be WAV, or empty for the default of WAV
+func effectsFormat(format AudioFormat) error {
+	if f := AudioFormat(strings.ToLower(string(format))); f != "" && f != FormatWAV {
+		return fmt.Errorf("%w: audio effects need wav audio, not %s", ErrValidation, format)
+	}
+
+	return nil
+}
+
+// postProcess applies the AudioEffects to a WAV file
+func (c *Client) postProcess(b []byte) ([]byte, error) {
+	return audio.Process(b, c.AudioEffects...)
+}
+
+// copyProcessed reads a WAV file from r, applies the AudioEffects and writes
+// the result to w
+func (c *Client) copyProcessed(w io.Writer, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if b, err = c.postProcess(b); err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
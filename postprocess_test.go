@@ -0,0 +1,59 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/audio"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestAudioEffectsWAV(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	c.Apply(cerevoicego.WithAudioEffects(audio.Resample(16000)))
+
+	for _, format := range []cerevoicego.AudioFormat{"", cerevoicego.FormatWAV} {
+		b, err := c.SpeakAudio(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello", AudioFormat: format})
+		if err != nil {
+			t.Fatalf("SpeakAudio %q: %v", format, err)
+		}
+		w, err := audio.DecodeWAV(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Format.SampleRate != 16000 {
+			t.Errorf("SpeakAudio %q: sample rate %d, want 16000", format, w.Format.SampleRate)
+		}
+	}
+}
+
+func TestAudioEffectsRejectFormat(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	c.Apply(cerevoicego.WithAudioEffects(audio.Resample(16000)))
+	input := &cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello", AudioFormat: cerevoicego.FormatOGG}
+
+	if _, err := c.SpeakAudio(input); !errors.Is(err, cerevoicego.ErrValidation) {
+		t.Errorf("SpeakAudio error = %v, want ErrValidation", err)
+	}
+	if _, err := c.SpeakTo(&bytes.Buffer{}, input); !errors.Is(err, cerevoicego.ErrValidation) {
+		t.Errorf("SpeakTo error = %v, want ErrValidation", err)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d requests made, want none", n)
+	}
+
+	// Without effects, other formats are returned as downloaded
+	c.Apply(cerevoicego.WithAudioEffects())
+	if _, err := c.SpeakAudio(input); err != nil {
+		t.Errorf("SpeakAudio without effects: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// Preset is a set of output settings suited to a destination
+type Preset struct {
+	Name        string         // Short name, used in storage keys
+	AudioFormat AudioFormat    // Requested audio format
+	SampleRate  SampleRate     // Requested sample rate
+	ContentType string         // MIME type of the final audio
+	Effects     []audio.Effect // Applied after the Client's AudioEffects
+	Speaker     *SpeakerSpec   // Smart speaker the MP3 must comply with, if any
+}
+
+var (
+	// PresetTelephony is 8 kHz mono 16 bit WAV, playable by Twilio <Play>
+	// and by Asterisk as a .wav prompt
+	PresetTelephony = Preset{
+		Name:        "telephony",
+		AudioFormat: FormatWAV,
+		SampleRate:  SampleRate8k,
+		ContentType: "audio/wav",
+		Effects:     []audio.Effect{audio.Remix(1)},
+	}
+
+	// PresetMuLaw is 8 kHz mono G.711 μ-law WAV, the native encoding of
+	// telephone calls, at half the size of PresetTelephony
+	PresetMuLaw = Preset{
+		Name:        "mulaw",
+		AudioFormat: FormatWAV,
+		SampleRate:  SampleRate8k,
+		ContentType: "audio/wav",
+		Effects:     []audio.Effect{audio.Remix(1), audio.Convert(audio.FormatMuLaw, 8)},
+	}
+)
+
+// StoreFunc saves audio under key and returns a public URL for it, for
+// example after uploading it to a bucket served over HTTPS
+type StoreFunc func(ctx context.Context, key string, audio []byte, contentType string) (string, error)
+
+// SpeakPreset synthesises input with the audio format and sample rate of
+// preset, returning the audio after the Client's AudioEffects and those of
+// the preset. For presets with a Speaker spec, such as PresetAlexa, audio
+// which does not comply is re-encoded, which requires the transcode package
+// built with the lame tag; without it such audio fails with an error
+// matching transcode.ErrMP3Unavailable.
+func (c *Client) SpeakPreset(input *SpeakExtendedInput, preset Preset) ([]byte, error) {
+	return c.SpeakPresetWithContext(context.Background(), input, preset)
+}
+
+// SpeakPresetWithContext is the same as SpeakPreset with the addition of the
+// ability to pass a context for cancellation and timeouts
+func (c *Client) SpeakPresetWithContext(ctx context.Context, input *SpeakExtendedInput, preset Preset) ([]byte, error) {
+	if preset.Speaker != nil {
+		return c.speakSpeaker(ctx, input, preset)
+	}
+	in := preset.apply(input)
+	if len(preset.Effects) > 0 {
+		if err := effectsFormat(in.AudioFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := c.SpeakAudioWithContext(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return audio.Process(b, preset.Effects...)
+}
+
+// SpeakAndPublish synthesises input with preset and passes the audio to
+// store, returning the URL it reports. Keys are derived from the preset and
+// input, so identical requests are stored under the same key. The URL can be
+// used directly in TwiML:
+//
+//	<Response><Play>https://example.com/prompts/telephony/3f2a....wav</Play></Response>
+func (c *Client) SpeakAndPublish(input *SpeakExtendedInput, preset Preset, store StoreFunc) (string, error) {
+	return c.SpeakAndPublishWithContext(context.Background(), input, preset, store)
+}
+
+// SpeakAndPublishWithContext is the same as SpeakAndPublish with the
+// addition of the ability to pass a context for cancellation and timeouts
+func (c *Client) SpeakAndPublishWithContext(ctx context.Context, input *SpeakExtendedInput, preset Preset, store StoreFunc) (string, error) {
+	b, err := c.SpeakPresetWithContext(ctx, input, preset)
+	if err != nil {
+		return "", err
+	}
+
+	key := CacheKey(preset.apply(input)) + "." + string(preset.AudioFormat)
+	if preset.Name != "" {
+		key = preset.Name + "/" + key
+	}
+
+	return store(ctx, key, b, preset.ContentType)
+}
+
+// apply returns a copy of input with the preset's format and sample rate
+func (p Preset) apply(input *SpeakExtendedInput) *SpeakExtendedInput {
+	in := *input
+	if p.AudioFormat != "" {
+		in.AudioFormat = p.AudioFormat
+	}
+	if p.SampleRate != "" {
+		in.SampleRate = p.SampleRate
+	}
+
+	return &in
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PreviewPhrases are the sample phrases spoken by PreviewVoice, by ISO 639
+// language code, with %s replaced by the voice name. Voices of other
+// languages speak the English phrase.
+var PreviewPhrases = map[string]string{
+	"en": "Hello, my name is %s. This is how I sound.",
+	"fr": "Bonjour, je m'appelle %s. Voici ma voix.",
+	"de": "Hallo, ich heiße %s. So klingt meine Stimme.",
+	"es": "Hola, me llamo %s. Así suena mi voz.",
+	"ca": "Hola, em dic %s. Aquesta és la meva veu.",
+	"it": "Ciao, mi chiamo %s. Questa è la mia voce.",
+	"pt": "Olá, o meu nome é %s. Esta é a minha voz.",
+	"nl": "Hallo, mijn naam is %s. Zo klinkt mijn stem.",
+	"sv": "Hej, jag heter %s. Så här låter min röst.",
+	"nb": "Hei, jeg heter %s. Slik høres stemmen min ut.",
+	"ro": "Bună, mă numesc %s. Aceasta este vocea mea.",
+	"pl": "Cześć, nazywam się %s. Tak brzmi mój głos.",
+	"ja": "こんにちは、%sです。これが私の声です。",
+	"cy": "Helo, %s ydw i. Dyma sut dw i'n swnio.",
+	"ga": "Dia duit, %s is ainm dom.",
+	"gd": "Halò, is mise %s.",
+}
+
+// PreviewVoice returns WAV audio of voice speaking a short sample phrase in
+// its language, for voice picker interfaces. Previews are kept for the life
+// of the Client, and in its Cache if it has one, so each voice is only
+// synthesised once.
+func (c *Client) PreviewVoice(voice string) ([]byte, error) {
+	return c.PreviewVoiceWithContext(context.Background(), voice)
+}
+
+// PreviewVoiceWithContext is the same as PreviewVoice with the addition of
+// the ability to pass a context for cancellation and timeouts
+func (c *Client) PreviewVoiceWithContext(ctx context.Context, voice string) ([]byte, error) {
+	key := strings.ToLower(voice)
+
+	c.mu.Lock()
+	audio, ok := c.previews[key]
+	c.mu.Unlock()
+	if ok {
+		return audio, nil
+	}
+
+	voices, err := c.ListVoicesWithContext(ctx, &ListVoicesInput{})
+	if err != nil {
+		return nil, err
+	}
+	v, ok := voices.Catalog().Lookup(voice)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrInvalidVoice, voice)
+	}
+
+	audio, err = c.SpeakAudioWithContext(ctx, &SpeakExtendedInput{
+		Voice:       v.VoiceName,
+		Text:        previewPhrase(v),
+		AudioFormat: FormatWAV,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.previews == nil {
+		c.previews = make(map[string][]byte)
+	}
+	c.previews[key] = audio
+	c.mu.Unlock()
+
+	return audio, nil
+}
+
+// previewPhrase returns the sample phrase for v
+func previewPhrase(v Voice) string {
+	phrase, ok := PreviewPhrases[strings.ToLower(v.LanguageCodeISO)]
+	if !ok {
+		phrase = PreviewPhrases["en"]
+	}
+
+	return fmt.Sprintf(phrase, v.VoiceName)
+}
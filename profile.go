@@ -0,0 +1,149 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+// VoiceProfile is the default settings of speak requests for a voice. Each
+// is used when a request leaves it unset, so requests can give only the
+// voice and text.
+//
+// Variant and Emotion are taken from the profile of each voice tried,
+// including FallbackVoices and a voice chosen by LanguageDetection. The
+// audio settings are taken from the profile of the requested Voice, so the
+// audio is in the format the caller expects whichever voice speaks.
+type VoiceProfile struct {
+	AudioFormat AudioFormat `json:"audioFormat,omitempty"`
+	SampleRate  SampleRate  `json:"sampleRate,omitempty"`
+
+	// Audio3D and Metadata are requested if either the profile or the
+	// request sets them, as a request can not tell false from unset. A
+	// request can not turn off one its voice's profile sets.
+	Audio3D  bool `json:"audio3D,omitempty"`
+	Metadata bool `json:"metadata,omitempty"`
+
+	Variant int          `json:"variant,omitempty"`
+	Emotion ssml.Emotion `json:"emotion,omitempty"` // Used unless the request sets an Emotion or Genre
+}
+
+// VoiceProfiles maps voice names to their default settings. Names are
+// matched regardless of case.
+type VoiceProfiles map[string]VoiceProfile
+
+// WithVoiceProfiles fills in the settings speak requests leave unset from
+// the profile of the requested voice
+func WithVoiceProfiles(profiles VoiceProfiles) ClientOption {
+	return func(c *Client) {
+		c.VoiceProfiles = profiles
+	}
+}
+
+// Lookup returns the profile of voice
+func (vp VoiceProfiles) Lookup(voice string) (VoiceProfile, bool) {
+	if p, ok := vp[voice]; ok {
+		return p, true
+	}
+	for name, p := range vp {
+		if strings.EqualFold(name, voice) {
+			return p, true
+		}
+	}
+
+	return VoiceProfile{}, false
+}
+
+// applyMode fills in the unset speak mode settings of m
+func (p VoiceProfile) applyMode(m *SpeakMode) {
+	if m.Variant == 0 {
+		m.Variant = p.Variant
+	}
+	if m.Emotion == "" && m.Genre == "" {
+		m.Emotion = p.Emotion
+	}
+}
+
+// profile returns a copy of input with the settings it leaves unset filled
+// in from the profile of its voice, or input if the voice has none or input
+// was already filled in
+func (c *Client) profile(input *SpeakExtendedInput) *SpeakExtendedInput {
+	p, ok := c.VoiceProfiles.Lookup(input.Voice)
+	if !ok || input.requested != nil {
+		return input
+	}
+
+	in := *input
+	requested := input.SpeakMode
+	in.requested = &requested
+	if in.AudioFormat == "" {
+		in.AudioFormat = p.AudioFormat
+	}
+	if in.SampleRate == "" {
+		in.SampleRate = p.SampleRate
+	}
+	in.Audio3D = in.Audio3D || p.Audio3D
+	in.Metadata = in.Metadata || p.Metadata
+	p.applyMode(&in.SpeakMode)
+
+	return &in
+}
+
+// voiceMode returns the SpeakMode of input as requested, with the settings
+// it leaves unset filled in from the profile of voice, which may be one of
+// its FallbackVoices
+func (c *Client) voiceMode(input *SpeakExtendedInput, voice string) SpeakMode {
+	mode := input.SpeakMode
+	if input.requested != nil {
+		mode = *input.requested
+	}
+	if p, ok := c.VoiceProfiles.Lookup(voice); ok {
+		p.applyMode(&mode)
+	}
+
+	return mode
+}
+
+// simpleProfile is profile for speakSimple, which only has speak modes
+func (c *Client) simpleProfile(input *SpeakSimpleInput) *SpeakSimpleInput {
+	p, ok := c.VoiceProfiles.Lookup(input.Voice)
+	if !ok {
+		return input
+	}
+
+	in := *input
+	p.applyMode(&in.SpeakMode)
+
+	return &in
+}
+
+// set sets the setting named by the config file key
+func (p *VoiceProfile) set(key, value string) error {
+	var err error
+	switch key {
+	case "audio_format":
+		p.AudioFormat = AudioFormat(value)
+	case "sample_rate":
+		p.SampleRate = SampleRate(value)
+	case "audio_3d":
+		p.Audio3D, err = strconv.ParseBool(value)
+	case "metadata":
+		p.Metadata, err = strconv.ParseBool(value)
+	case "variant":
+		p.Variant, err = strconv.Atoi(value)
+	case "emotion":
+		p.Emotion = ssml.Emotion(value)
+		if !p.Emotion.Valid() {
+			err = fmt.Errorf("unknown emotion %q", value)
+		}
+	default:
+		return fmt.Errorf("unknown voice key %q", key)
+	}
+
+	return err
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+// rejectVoice answers API requests for voice as CereVoice does for a voice
+// it does not have
+type rejectVoice struct {
+	next  cerevoicego.HTTPClient
+	voice string
+}
+
+func (r *rejectVoice) Do(req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(body, []byte("<voice>"+r.voice+"</voice>")) {
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return r.next.Do(req)
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": {"text/xml"}},
+		Body: ioutil.NopCloser(strings.NewReader(fmt.Sprintf(
+			`<?xml version="1.0" encoding="UTF-8"?><speakExtendedResponse><resultCode>%d</resultCode>`+
+				`<resultDescription>Invalid voice</resultDescription></speakExtendedResponse>`,
+			int(cerevoicego.ResultInvalidVoice)))),
+	}, nil
+}
+
+func TestVoiceProfileOfFallbackVoice(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	c.Apply(
+		cerevoicego.WithHTTPClient(&rejectVoice{next: srv.Server.Client(), voice: "Retired"}),
+		cerevoicego.WithVoiceProfiles(cerevoicego.VoiceProfiles{
+			"Retired": {AudioFormat: cerevoicego.FormatMP3, Variant: 3, Emotion: ssml.Happy},
+			"William": {Variant: 2},
+		}))
+
+	res, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{
+		Voice:          "Retired",
+		FallbackVoices: []string{"William"},
+		Text:           "Hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Voice != "William" {
+		t.Errorf("spoken by %s, want William", res.Voice)
+	}
+
+	reqs := srv.Requests()
+	req := reqs[len(reqs)-1]
+	if req.AudioFormat != string(cerevoicego.FormatMP3) {
+		t.Errorf("audioFormat = %q, want that of the requested voice", req.AudioFormat)
+	}
+	if !strings.Contains(req.Text, `variant="2"`) || strings.Contains(req.Text, "happy") {
+		t.Errorf("text = %s, want William's variant and no emotion", req.Text)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"sync"
+	"time"
+)
+
+// Operations reporting progress
+const (
+	ProgressSpeakLong  = "speakLong"  // SpeakLong
+	ProgressSpeakBatch = "speakBatch" // SpeakBatch
+	ProgressDownload   = "download"   // DownloadFiles
+)
+
+// ProgressEvent reports how far a long operation has got. Every operation
+// reports the same event: one per item, chunk or file, with Done set once it
+// has finished, and for downloads more as bytes arrive. The totals are
+// cumulative, so the latest event alone gives the whole picture.
+type ProgressEvent struct {
+	Operation string // Operation reporting, e.g. ProgressSpeakBatch
+	Item      int    // Index of the item, chunk or file which progressed
+	ID        string // Its batch ID or download path, empty for chunks
+	Done      bool   // The item has finished
+	Err       error  // Why the item failed, if Done
+	Bytes     int64  // Its bytes of audio so far
+	Size      int64  // Its total bytes, -1 if not yet known
+
+	ItemsDone  int           // Items finished so far
+	Items      int           // Total items
+	TotalBytes int64         // Bytes of audio so far across all items
+	Chars      int           // Characters of text finished so far, 0 for downloads
+	TotalChars int           // Total characters of text, 0 for downloads
+	Elapsed    time.Duration // Time since the operation started
+	ETA        time.Duration // Estimated time remaining, 0 until known
+}
+
+// ProgressFunc receives the progress of an operation. Calls for one
+// operation are not concurrent and are made in order, so it need not lock,
+// but it should return quickly as the operation waits for it.
+type ProgressFunc func(e ProgressEvent)
+
+// ProgressChan returns a ProgressFunc delivering events on ch, for
+// consumers in another goroutine such as a progress bar. Events for items in
+// progress are dropped while ch is full, as later events supersede them;
+// those with Done set wait to be received, so ch must be read until the
+// operation returns. ch is not closed.
+func ProgressChan(ch chan<- ProgressEvent) ProgressFunc {
+	return func(e ProgressEvent) {
+		if e.Done {
+			ch <- e
+			return
+		}
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// progressTracker keeps the totals of an operation and reports each change
+// to fn
+type progressTracker struct {
+	mu    sync.Mutex
+	fn    ProgressFunc
+	start time.Time
+	state ProgressEvent
+	bytes map[int]int64 // bytes of each item counted in TotalBytes
+}
+
+// newProgressTracker returns a tracker for an operation on items, or nil if
+// there is no fn to report to
+func newProgressTracker(fn ProgressFunc, operation string, items, chars int) *progressTracker {
+	if fn == nil {
+		return nil
+	}
+
+	p := &progressTracker{fn: fn, start: time.Now(), bytes: make(map[int]int64)}
+	p.state.Operation = operation
+	p.state.Items = items
+	p.state.TotalChars = chars
+
+	return p
+}
+
+// update records that item i has n of size bytes
+func (p *progressTracker) update(i int, id string, n, size int64) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.set(i, id, n, size)
+	p.state.Done, p.state.Err = false, nil
+	p.report()
+}
+
+// finish records that item i has finished with chars characters of text and
+// n bytes of audio, or failed with err keeping the bytes it had
+func (p *progressTracker) finish(i int, id string, chars int, n int64, err error) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil && n == 0 {
+		n = p.bytes[i]
+	}
+	p.set(i, id, n, n)
+	p.state.ItemsDone++
+	p.state.Chars += chars
+	p.state.Done, p.state.Err = true, err
+	p.report()
+}
+
+// set makes item i the item reported, p.mu must be held
+func (p *progressTracker) set(i int, id string, n, size int64) {
+	p.state.TotalBytes += n - p.bytes[i]
+	p.bytes[i] = n
+	p.state.Item, p.state.ID, p.state.Bytes, p.state.Size = i, id, n, size
+}
+
+// report estimates the time remaining and passes the state to fn, p.mu must
+// be held
+func (p *progressTracker) report() {
+	p.state.Elapsed = time.Since(p.start)
+	p.state.ETA = 0
+
+	done, total := p.state.Chars, p.state.TotalChars
+	if total == 0 {
+		done, total = p.state.ItemsDone, p.state.Items
+	}
+	if done > 0 {
+		p.state.ETA = time.Duration(int64(p.state.Elapsed) / int64(done) * int64(total-done))
+	}
+
+	p.fn(p.state)
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrPromptNotFound is returned by Prompts for text without a recording
+var ErrPromptNotFound = errors.New("cerevoicego: no recorded prompt")
+
+// Prompts is a Synthesizer serving pre-recorded audio for known texts, such
+// as the fixed messages of an IVR, for use as the last resort of a Fallback.
+// Text is matched ignoring case and spacing, and the voice and audio
+// settings of the input are ignored. It is safe for concurrent use.
+type Prompts struct {
+	mu      sync.RWMutex
+	prompts map[string][]byte
+}
+
+var _ Synthesizer = (*Prompts)(nil)
+
+// NewPrompts returns an empty Prompts
+func NewPrompts() *Prompts {
+	return &Prompts{prompts: make(map[string][]byte)}
+}
+
+// LoadPrompts reads the recordings in dir. Each recording, such as
+// welcome.wav, is paired with a file of the same name ending .txt holding
+// the text it speaks. Recordings without text are ignored.
+func LoadPrompts(dir string) (*Prompts, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewPrompts()
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || strings.HasSuffix(name, ".txt") {
+			continue
+		}
+
+		text, err := ioutil.ReadFile(filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".txt"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		audio, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		p.Add(string(text), audio)
+	}
+
+	return p, nil
+}
+
+// Add records audio as the prompt for text
+func (p *Prompts) Add(text string, audio []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.prompts == nil {
+		p.prompts = make(map[string][]byte)
+	}
+	p.prompts[promptKey(text)] = audio
+}
+
+// Synthesize returns the recording for the input text, or an error matching
+// ErrPromptNotFound if there is none
+func (p *Prompts) Synthesize(ctx context.Context, input *SpeakExtendedInput) ([]byte, error) {
+	p.mu.RLock()
+	audio, ok := p.prompts[promptKey(input.Text)]
+	p.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w for %q", ErrPromptNotFound, input.Text)
+	}
+
+	return audio, nil
+}
+
+// Voices returns no voices, as recordings are served for any voice
+func (p *Prompts) Voices(ctx context.Context) (VoiceCatalog, error) {
+	return nil, nil
+}
+
+// promptKey normalises text for matching
+func promptKey(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
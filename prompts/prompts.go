@@ -0,0 +1,204 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package prompts synthesises named text templates, such as the messages of
+// an IVR. Each unique rendering is synthesised once and cached under the
+// template name and parameters.
+//
+//	lib := prompts.New(client, cerevoicego.NewMemoryCache(1000, 0, 0))
+//	lib.Input.Voice = "Heather"
+//	lib.MustDefine("code", "Your code is {{spell .Code}}.")
+//
+//	wav, err := lib.Speak(ctx, "code", map[string]string{"Code": "4711"})
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+// ErrUnknownTemplate is returned for a template name which is not defined
+var ErrUnknownTemplate = errors.New("prompts: unknown template")
+
+// Funcs are the functions available to every template
+var Funcs = template.FuncMap{
+	// spell reads a value character by character, e.g. codes and numbers
+	"spell": func(v interface{}) string {
+		return ssml.New().Spell(fmt.Sprint(v)).Fragment()
+	},
+}
+
+// Library is a set of named templates synthesised with a Synthesizer. It is
+// safe for concurrent use once its fields are set.
+type Library struct {
+	Synthesizer cerevoicego.Synthesizer        // Synthesises renderings, such as a *cerevoicego.Client
+	Cache       cerevoicego.Cache              // Audio of renderings, nothing is cached if nil
+	Input       cerevoicego.SpeakExtendedInput // Voice and audio settings of every prompt; Text is ignored
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+	sources   map[string]string
+}
+
+// New returns an empty Library synthesising with synth and caching in cache
+func New(synth cerevoicego.Synthesizer, cache cerevoicego.Cache) *Library {
+	return &Library{Synthesizer: synth, Cache: cache}
+}
+
+// Define parses text as the template name, replacing any template of the
+// same name. Templates use the text/template syntax with Funcs. Parameters
+// are inserted as markup, so untrusted values should be piped to html.
+func (l *Library) Define(name, text string) error {
+	t, err := template.New(name).Funcs(Funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("prompts: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.templates == nil {
+		l.templates = make(map[string]*template.Template)
+		l.sources = make(map[string]string)
+	}
+	l.templates[name] = t
+	l.sources[name] = text
+
+	return nil
+}
+
+// MustDefine is like Define but panics if text cannot be parsed
+func (l *Library) MustDefine(name, text string) {
+	if err := l.Define(name, text); err != nil {
+		panic(err)
+	}
+}
+
+// Load defines a template for each file in dir ending .tmpl, named after the
+// file without the extension
+func (l *Library) Load(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		if err := l.Define(name, strings.TrimSpace(string(text))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Names returns the names of the defined templates, sorted
+func (l *Library) Names() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	names := make([]string, 0, len(l.templates))
+	for name := range l.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Render returns the text of the template name with params
+func (l *Library) Render(name string, params interface{}) (string, error) {
+	l.mu.RLock()
+	t, ok := l.templates[name]
+	l.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, params); err != nil {
+		return "", fmt.Errorf("prompts: %v", err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Speak returns the audio of the template name rendered with params. The
+// audio is cached under Key, so each rendering is only synthesised once.
+func (l *Library) Speak(ctx context.Context, name string, params interface{}) ([]byte, error) {
+	key, err := l.Key(name, params)
+	if err != nil {
+		return nil, err
+	}
+	if l.Cache != nil {
+		if audio, ok := l.Cache.Get(key); ok {
+			return audio, nil
+		}
+	}
+
+	text, err := l.Render(name, params)
+	if err != nil {
+		return nil, err
+	}
+
+	input := l.Input
+	input.Text = text
+	audio, err := l.Synthesizer.Synthesize(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.Cache != nil {
+		l.Cache.Set(key, audio)
+	}
+
+	return audio, nil
+}
+
+// Key returns the cache key of the template name rendered with params. It
+// covers the template name and source, the params as JSON, and the voice and
+// audio settings of Input, so changing any of them synthesises again.
+func (l *Library) Key(name string, params interface{}) (string, error) {
+	l.mu.RLock()
+	source, ok := l.sources[name]
+	l.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("prompts: params of %q: %v", name, err)
+	}
+
+	input := l.Input
+	input.Text = ""
+
+	h := sha256.New()
+	for _, field := range []string{name, source, string(encoded), cerevoicego.CacheKey(&input)} {
+		h.Write([]byte(strconv.Itoa(len(field))))
+		h.Write([]byte{':'})
+		h.Write([]byte(field))
+	}
+
+	return "prompt-" + hex.EncodeToString(h.Sum(nil)), nil
+}
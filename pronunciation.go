@@ -0,0 +1,250 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bganderson/cerevoicego/lexicon"
+)
+
+// PronunciationTestInput contains TestPronunciations parameters
+type PronunciationTestInput struct {
+	Words       []string         // Words or phrases to synthesise
+	Voice       string           // Voice to synthesise with
+	Lexicon     *lexicon.Lexicon // Candidate lexicon
+	Language    string           // Language of the lexicon, that of Voice if empty
+	Accent      string           // Accent of the lexicon, that of Voice if Language is empty
+	AudioFormat AudioFormat      // FormatWAV if empty
+	Phones      bool             // Request metadata to compare the phones spoken
+
+	// Replace uploads the candidate as the whole lexicon. By default its
+	// entries are merged into the current lexicon, as a change would be.
+	Replace bool
+}
+
+// PronunciationResult is the audio of one word before and after the
+// candidate lexicon
+type PronunciationResult struct {
+	Word         string   `json:"word"`
+	Before       []byte   `json:"-"`
+	After        []byte   `json:"-"`
+	BeforePhones []string `json:"beforePhones,omitempty"`
+	AfterPhones  []string `json:"afterPhones,omitempty"`
+	Changed      bool     `json:"changed"` // Phones differ, only set with Phones
+	BeforeFile   string   `json:"beforeFile,omitempty"`
+	AfterFile    string   `json:"afterFile,omitempty"`
+}
+
+// PronunciationReport contains the results of TestPronunciations, in the
+// order of the words
+type PronunciationReport struct {
+	Voice       string                `json:"voice"`
+	Language    string                `json:"language"`
+	Accent      string                `json:"accent"`
+	AudioFormat AudioFormat           `json:"audioFormat"`
+	Results     []PronunciationResult `json:"results"`
+}
+
+// TestPronunciations synthesises each word with the current lexicon and with
+// the candidate lexicon, so lexicon changes can be evaluated before they are
+// made. Lexicons apply to the whole account, so the candidate is uploaded
+// while the words are synthesised again and the original is then restored,
+// or deleted if there was none. Other requests made by the account meanwhile
+// are affected, so use a separate account if it is in production.
+func (c *Client) TestPronunciations(ctx context.Context, input *PronunciationTestInput) (report *PronunciationReport, err error) {
+	if input.Lexicon == nil || len(input.Words) == 0 {
+		return nil, fmt.Errorf("%w: a lexicon and words are required", ErrValidation)
+	}
+	if err = input.Lexicon.Validate(); err != nil {
+		return nil, err
+	}
+
+	report = &PronunciationReport{
+		Voice:       input.Voice,
+		Language:    input.Language,
+		Accent:      input.Accent,
+		AudioFormat: input.AudioFormat,
+	}
+	if report.AudioFormat == "" {
+		report.AudioFormat = FormatWAV
+	}
+	if report.Language == "" {
+		voices, err := c.Voices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v, ok := voices.Lookup(input.Voice)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVoice, input.Voice)
+		}
+		report.Language, report.Accent = v.LanguageCodeISO, v.AccentCode
+	}
+
+	original, err := c.currentLexicon(ctx, report.Language, report.Accent)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Results = make([]PronunciationResult, len(input.Words))
+	for i, word := range input.Words {
+		r := &report.Results[i]
+		r.Word = word
+		if r.Before, r.BeforePhones, err = c.speakWord(ctx, input, report.AudioFormat, word); err != nil {
+			return nil, err
+		}
+	}
+
+	candidate := input.Lexicon
+	if !input.Replace && original != nil {
+		candidate = &lexicon.Lexicon{Entries: append([]lexicon.Entry(nil), original.Entries...)}
+		candidate.Merge(input.Lexicon)
+	}
+	if _, err := c.UploadLexiconEntriesWithContext(ctx, candidate, report.Language, report.Accent); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr := c.restoreLexicon(original, report.Language, report.Accent); rerr != nil && err == nil {
+			report, err = nil, fmt.Errorf("cerevoicego: restoring lexicon: %w", rerr)
+		}
+	}()
+
+	for i, word := range input.Words {
+		r := &report.Results[i]
+		if r.After, r.AfterPhones, err = c.speakWord(ctx, input, report.AudioFormat, word); err != nil {
+			return nil, err
+		}
+		r.Changed = input.Phones && strings.Join(r.BeforePhones, " ") != strings.Join(r.AfterPhones, " ")
+	}
+
+	return report, nil
+}
+
+// currentLexicon returns the lexicon uploaded for language and accent, nil if
+// there is none
+func (c *Client) currentLexicon(ctx context.Context, language, accent string) (*lexicon.Lexicon, error) {
+	list, err := c.ListLexiconsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range list.LexiconList {
+		if strings.EqualFold(l.Language, language) && strings.EqualFold(l.Accent, accent) {
+			return c.GetLexiconWithContext(ctx, l.URL)
+		}
+	}
+
+	return nil, nil
+}
+
+// restoreLexicon uploads original, or deletes the lexicon if it is nil. It is
+// not bound to the test context, so the lexicon is restored after a
+// cancellation.
+func (c *Client) restoreLexicon(original *lexicon.Lexicon, language, accent string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	if original == nil {
+		_, err := c.DeleteLexiconWithContext(ctx, &DeleteLexiconInput{Language: language, Accent: accent})
+		return err
+	}
+
+	_, err := c.UploadLexiconEntriesWithContext(ctx, original, language, accent)
+	return err
+}
+
+// speakWord synthesises word without the Cache, returning the audio and, if
+// requested, its phones
+func (c *Client) speakWord(ctx context.Context, input *PronunciationTestInput, format AudioFormat, word string) ([]byte, []string, error) {
+	r, err := c.SpeakExtendedWithContext(ctx, &SpeakExtendedInput{
+		Voice:       input.Voice,
+		Text:        word,
+		AudioFormat: format,
+		Metadata:    input.Phones,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := r.Download(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+
+	audio, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var phones []string
+	if input.Phones && r.Metadata != "" {
+		meta, err := c.GetMetadataWithContext(ctx, r.Metadata)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, e := range meta.Phones() {
+			phones = append(phones, e.Token)
+		}
+	}
+
+	return audio, phones, nil
+}
+
+// WriteFiles writes the audio of every result to dir as pairs of files named
+// after the word, such as 001-tomato.before.wav and 001-tomato.after.wav,
+// and the report as report.json
+func (r *PronunciationReport) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for i := range r.Results {
+		res := &r.Results[i]
+		base := fmt.Sprintf("%03d-%s", i+1, fileSafe(res.Word))
+		res.BeforeFile = base + ".before." + string(r.AudioFormat)
+		res.AfterFile = base + ".after." + string(r.AudioFormat)
+
+		if err := ioutil.WriteFile(filepath.Join(dir, res.BeforeFile), res.Before, 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, res.AfterFile), res.After, 0644); err != nil {
+			return err
+		}
+	}
+
+	report, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, "report.json"), append(report, '\n'), 0644)
+}
+
+// fileSafe returns word with characters other than letters and digits
+// replaced, for use in a file name
+func fileSafe(word string) string {
+	s := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, word)
+	if runes := []rune(s); len(runes) > 40 {
+		s = string(runes[:40])
+	}
+	if s == "" {
+		s = strconv.Itoa(len(word))
+	}
+
+	return s
+}
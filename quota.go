@@ -0,0 +1,178 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a speak request would take a tenant
+// over its Quota
+var ErrQuotaExceeded = errors.New("cerevoicego: quota exceeded")
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose speak requests count towards the Quota
+// of tenant. Requests without a tenant are not limited.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// requestTenant returns the tenant set by WithTenant
+func requestTenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// QuotaStore keeps the characters used by each tenant in each window.
+// Implementations must be safe for concurrent use, and may be shared by
+// several processes to enforce a quota across them.
+type QuotaStore interface {
+	// Add adds n characters, which may be negative, to the usage of
+	// tenant in the window starting at start, unless the total would
+	// exceed limit. A negative limit is not checked. It returns the usage
+	// after adding and whether n was added.
+	Add(ctx context.Context, tenant string, start time.Time, n, limit int) (used int, ok bool, err error)
+}
+
+// Quota limits the characters each tenant, set with WithTenant, may use in
+// a Window. Speak requests are counted at their estimated cost before being
+// sent, refused with ErrQuotaExceeded if that would exceed the limit, and
+// corrected to the characters billed once complete.
+type Quota struct {
+	Limit  int            // Characters per tenant per window
+	Limits map[string]int // Per tenant limits, overriding Limit
+	Window time.Duration  // Length of each window, aligned to the Unix epoch; 0 for one unending window
+	Store  QuotaStore     // Counters, kept in memory if nil
+
+	once sync.Once
+	mem  QuotaStore
+}
+
+// WithQuota enables the Quota q
+func WithQuota(q *Quota) ClientOption {
+	return func(c *Client) {
+		c.Quota = q
+	}
+}
+
+// Usage returns the characters tenant has used in the current window and
+// its limit
+func (q *Quota) Usage(ctx context.Context, tenant string) (used, limit int, err error) {
+	used, _, err = q.store().Add(ctx, tenant, q.windowStart(time.Now()), 0, -1)
+	return used, q.limit(tenant), err
+}
+
+// reserve counts text towards the quota of tenant, returning a function to
+// call with the characters billed once the request completes, 0 if it failed
+func (q *Quota) reserve(ctx context.Context, tenant, text string) (func(chars int), error) {
+	n := BilledChars(text)
+	start := q.windowStart(time.Now())
+	limit := q.limit(tenant)
+
+	used, ok, err := q.store().Add(ctx, tenant, start, n, limit)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: tenant %q has used %d of %d characters, request needs %d",
+			ErrQuotaExceeded, tenant, used, limit, n)
+	}
+
+	return func(chars int) {
+		if chars != n {
+			q.store().Add(context.Background(), tenant, start, chars-n, -1)
+		}
+	}, nil
+}
+
+func (q *Quota) limit(tenant string) int {
+	if limit, ok := q.Limits[tenant]; ok {
+		return limit
+	}
+
+	return q.Limit
+}
+
+func (q *Quota) windowStart(t time.Time) time.Time {
+	if q.Window <= 0 {
+		return time.Time{}
+	}
+
+	return t.Truncate(q.Window)
+}
+
+func (q *Quota) store() QuotaStore {
+	if q.Store != nil {
+		return q.Store
+	}
+
+	q.once.Do(func() {
+		q.mem = NewMemoryQuotaStore()
+	})
+	return q.mem
+}
+
+// reserveQuota counts a speak request towards the Quota of its tenant. The
+// returned function settles the reservation and is never nil.
+func (c *Client) reserveQuota(ctx context.Context, req *Request) (func(chars int), error) {
+	tenant := requestTenant(ctx)
+	if c.Quota == nil || tenant == "" || c.DryRun || !isSpeak(req.XMLName.Local) {
+		return func(int) {}, nil
+	}
+
+	return c.Quota.reserve(ctx, tenant, req.Text)
+}
+
+// MemoryQuotaStore is a QuotaStore keeping counters in memory. Only the
+// latest window of each tenant is kept.
+type MemoryQuotaStore struct {
+	mu       sync.Mutex
+	counters map[string]*quotaCounter
+}
+
+type quotaCounter struct {
+	start time.Time
+	used  int
+}
+
+// NewMemoryQuotaStore returns an empty MemoryQuotaStore
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counters: make(map[string]*quotaCounter)}
+}
+
+// Add adds n characters to the usage of tenant in the window starting at
+// start, unless the total would exceed a non-negative limit
+func (m *MemoryQuotaStore) Add(ctx context.Context, tenant string, start time.Time, n, limit int) (int, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.counters == nil {
+		m.counters = make(map[string]*quotaCounter)
+	}
+
+	counter, ok := m.counters[tenant]
+	if !ok || counter.start.Before(start) {
+		counter = &quotaCounter{start: start}
+		m.counters[tenant] = counter
+	} else if start.Before(counter.start) {
+		// A request which started in the previous window, which is no
+		// longer counted
+		return 0, true, nil
+	}
+
+	if limit >= 0 && n > 0 && counter.used+n > limit {
+		return counter.used, false, nil
+	}
+	counter.used += n
+	if counter.used < 0 {
+		counter.used = 0
+	}
+
+	return counter.used, true, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestMemoryQuotaStore(t *testing.T) {
+	hour := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	type add struct {
+		tenant string
+		start  time.Time
+		n      int
+		limit  int
+		used   int
+		ok     bool
+	}
+
+	tests := []struct {
+		name string
+		adds []add
+	}{
+		{"within limit", []add{{"a", hour, 40, 100, 40, true}, {"a", hour, 60, 100, 100, true}}},
+		{"over limit", []add{{"a", hour, 90, 100, 90, true}, {"a", hour, 20, 100, 90, false}}},
+		{"tenants apart", []add{{"a", hour, 90, 100, 90, true}, {"b", hour, 90, 100, 90, true}}},
+		{"unchecked", []add{{"a", hour, 500, -1, 500, true}}},
+		{"refund", []add{{"a", hour, 50, 100, 50, true}, {"a", hour, -20, 100, 30, true}, {"a", hour, -100, 100, 0, true}}},
+		{"refund over limit", []add{{"a", hour, 100, 100, 100, true}, {"a", hour, -10, 50, 90, true}}},
+		{"new window", []add{{"a", hour, 100, 100, 100, true}, {"a", hour.Add(time.Hour), 30, 100, 30, true}}},
+		{"old window", []add{{"a", hour.Add(time.Hour), 10, 100, 10, true}, {"a", hour, -5, -1, 0, true}, {"a", hour.Add(time.Hour), 0, -1, 10, true}}},
+	}
+
+	for _, tt := range tests {
+		s := cerevoicego.NewMemoryQuotaStore()
+		for i, a := range tt.adds {
+			used, ok, err := s.Add(context.Background(), a.tenant, a.start, a.n, a.limit)
+			if err != nil || used != a.used || ok != a.ok {
+				t.Errorf("%s: add %d = %d, %v, %v, want %d, %v", tt.name, i, used, ok, err, a.used, a.ok)
+			}
+		}
+	}
+}
+
+func TestQuota(t *testing.T) {
+	tests := []struct {
+		name   string
+		quota  *cerevoicego.Quota
+		tenant string
+		texts  []string
+		errs   []bool
+		used   int
+	}{
+		{"within", &cerevoicego.Quota{Limit: 10}, "a", []string{"Hello", "World"}, []bool{false, false}, 10},
+		{"refused", &cerevoicego.Quota{Limit: 8}, "a", []string{"Hello", "World", "Hi"}, []bool{false, true, false}, 7},
+		{"per tenant limit", &cerevoicego.Quota{Limit: 100, Limits: map[string]int{"a": 4}}, "a", []string{"Hello"}, []bool{true}, 0},
+		{"no tenant", &cerevoicego.Quota{Limit: 1}, "", []string{"Hello", "World"}, []bool{false, false}, 0},
+	}
+
+	for _, tt := range tests {
+		srv := cerevoicetest.NewServer()
+		c := srv.Client()
+		q := tt.quota
+		c.Apply(cerevoicego.WithQuota(q))
+
+		ctx := context.Background()
+		if tt.tenant != "" {
+			ctx = cerevoicego.WithTenant(ctx, tt.tenant)
+		}
+		for i, text := range tt.texts {
+			_, err := c.SpeakSimpleWithContext(ctx, &cerevoicego.SpeakSimpleInput{Voice: "Heather", Text: text})
+			if tt.errs[i] != errors.Is(err, cerevoicego.ErrQuotaExceeded) {
+				t.Errorf("%s: request %d error = %v", tt.name, i, err)
+			}
+		}
+		srv.Close()
+
+		if used, _, err := q.Usage(context.Background(), tt.tenant); err != nil || used != tt.used {
+			t.Errorf("%s: used %d, %v, want %d", tt.name, used, err, tt.used)
+		}
+	}
+}
+
+func TestQuotaRefundsFailures(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	srv.SetError("speakSimple", cerevoicego.ResultInvalidVoice, "Invalid voice")
+	c := srv.Client()
+	q := &cerevoicego.Quota{Limit: 10, Window: time.Hour}
+	c.Apply(cerevoicego.WithQuota(q))
+
+	ctx := cerevoicego.WithTenant(context.Background(), "a")
+	if _, err := c.SpeakSimpleWithContext(ctx, &cerevoicego.SpeakSimpleInput{Voice: "Nobody", Text: "Hello"}); err == nil {
+		t.Fatal("no error")
+	}
+	if used, limit, _ := q.Usage(ctx, "a"); used != 0 || limit != 10 {
+		t.Errorf("Usage = %d of %d, want 0 of 10", used, limit)
+	}
+}
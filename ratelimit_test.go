@@ -0,0 +1,81 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name  string
+		rps   float64
+		burst int
+		at    []time.Duration // Time of each request
+		want  []time.Duration // Wait before each request
+	}{
+		{"burst", 10, 3, []time.Duration{0, 0, 0}, []time.Duration{0, 0, 0}},
+		{"beyond burst", 10, 2, []time.Duration{0, 0, 0, 0}, []time.Duration{0, 0, 100 * ms, 200 * ms}},
+		{"refills", 10, 1, []time.Duration{0, 100 * ms, 150 * ms}, []time.Duration{0, 0, 50 * ms}},
+		{"refill capped at burst", 10, 2, []time.Duration{0, 0, 10 * time.Second, 10 * time.Second, 10 * time.Second}, []time.Duration{0, 0, 0, 0, 100 * ms}},
+		{"burst at least one", 2, 0, []time.Duration{0, 0}, []time.Duration{0, 500 * ms}},
+		{"no refill", 0, 1, []time.Duration{0, time.Hour}, []time.Duration{0, time.Duration(1<<63 - 1)}},
+	}
+
+	for _, tt := range tests {
+		l := NewRateLimiter(tt.rps, tt.burst)
+		start := time.Now()
+		for i, at := range tt.at {
+			got := l.reserve(start.Add(at))
+			if d := got - tt.want[i]; d < -time.Microsecond || d > time.Microsecond {
+				t.Errorf("%s: request %d waits %v, want %v", tt.name, i, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+		want    error
+	}{
+		{"waits", time.Second, false, nil},
+		{"deadline too soon", 10 * time.Millisecond, false, ErrRateLimited},
+		{"canceled", time.Second, true, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		// 20 per second, so the second request waits 50ms
+		l := NewRateLimiter(20, 1)
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+		if tt.cancel {
+			cancel()
+		}
+		err := l.Wait(ctx)
+		cancel()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: Wait = %v, want %v", tt.name, err, tt.want)
+		}
+
+		// A failed Wait returns its token
+		l.mu.Lock()
+		tokens := l.tokens
+		l.mu.Unlock()
+		if tt.want == nil && tokens > -0.9 {
+			t.Errorf("%s: %.2f tokens after Wait, want its token spent", tt.name, tokens)
+		} else if tt.want != nil && tokens < -0.1 {
+			t.Errorf("%s: %.2f tokens after failed Wait, want its token returned", tt.name, tokens)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxTextLength is the most characters of text the API accepts in one
+// speak request
+const DefaultMaxTextLength = 5000
+
+// ErrValidation is matched by a ValidationError, returned when a request is
+// rejected before being sent
+var ErrValidation = errors.New("cerevoicego: invalid request")
+
+// FieldError describes a single invalid request field
+type FieldError struct {
+	Field  string // API field name, e.g. voice
+	Reason string // Why the value is invalid
+	Err    error  // More specific error matched by errors.Is, may be nil
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// Unwrap returns Err
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned when a request has invalid fields. It matches
+// ErrValidation, and the Err of any field, with errors.Is.
+type ValidationError struct {
+	Operation string // API function, e.g. speakExtended
+	Fields    []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		reasons[i] = f.Error()
+	}
+
+	return fmt.Sprintf("cerevoicego: invalid %s request: %s", e.Operation, strings.Join(reasons, "; "))
+}
+
+// Is reports whether target is ErrValidation or matches a field error
+func (e *ValidationError) Is(target error) bool {
+	if target == ErrValidation {
+		return true
+	}
+	for _, f := range e.Fields {
+		if errors.Is(f, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// operation is a request for a single API function. It is validated, then
+// built into the Request sent on the wire.
+type operation interface {
+	name() string
+	validate(v *validator)
+	request() *Request
+}
+
+// speakOperation is a speak request, whose text is wrapped in the markup for
+// its mode after preprocessing
+type speakOperation interface {
+	operation
+	speakMode() SpeakMode
+}
+
+// unpreparedText is the maxText of a validator checking text which is yet
+// to be preprocessed, so only its presence is checked
+const unpreparedText = -1
+
+// validator collects the field errors of a request
+type validator struct {
+	fields  []*FieldError
+	maxText int // Text length limit, DefaultMaxTextLength if 0
+}
+
+func (v *validator) fail(field, reason string, err error) {
+	v.fields = append(v.fields, &FieldError{Field: field, Reason: reason, Err: err})
+}
+
+// required checks value is not blank
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.fail(field, "required", nil)
+		return false
+	}
+
+	return true
+}
+
+// maxLength checks value has at most n characters
+func (v *validator) maxLength(field, value string, n int) {
+	if length := utf8.RuneCountInString(value); length > n {
+		v.fail(field, fmt.Sprintf("%d characters exceeds the limit of %d", length, n), nil)
+	}
+}
+
+// oneOf checks value, if set, is one of allowed, ignoring case
+func (v *validator) oneOf(field, value string, allowed []string, err error) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return
+		}
+	}
+
+	v.fail(field, fmt.Sprintf("%q is not one of %s", value, strings.Join(allowed, ", ")), err)
+}
+
+// text checks the text of a speak request
+func (v *validator) text(value string) {
+	if v.required("text", value) && v.maxText != unpreparedText {
+		max := v.maxText
+		if max <= 0 {
+			max = DefaultMaxTextLength
+		}
+		v.maxLength("text", value, max)
+		if err := checkMarkup(value); err != nil {
+			v.fail("text", "malformed markup", err)
+		}
+	}
+}
+
+// validate checks op, returning a *ValidationError if any field is invalid.
+// Text is limited to maxText characters, DefaultMaxTextLength if 0, or only
+// required if maxText is unpreparedText.
+func validate(op operation, maxText int) error {
+	v := validator{maxText: maxText}
+	op.validate(&v)
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Operation: op.name(), Fields: v.fields}
+}
+
+type speakSimpleRequest struct {
+	Voice string
+	Text  string
+	Mode  SpeakMode
+}
+
+func (r *speakSimpleRequest) name() string { return "speakSimple" }
+
+func (r *speakSimpleRequest) validate(v *validator) {
+	v.required("voice", r.Voice)
+	v.text(r.Text)
+	r.Mode.validate(v)
+}
+
+func (r *speakSimpleRequest) speakMode() SpeakMode { return r.Mode }
+
+func (r *speakSimpleRequest) request() *Request {
+	return &Request{
+		XMLName: xml.Name{Local: r.name()},
+		Voice:   r.Voice,
+		Text:    r.Text,
+	}
+}
+
+type speakExtendedRequest struct {
+	Voice       string
+	Text        string
+	AudioFormat AudioFormat
+	SampleRate  SampleRate
+	Audio3D     bool
+	Metadata    bool
+	Mode        SpeakMode
+}
+
+func (r *speakExtendedRequest) name() string { return "speakExtended" }
+
+func (r *speakExtendedRequest) validate(v *validator) {
+	v.required("voice", r.Voice)
+	v.text(r.Text)
+	r.Mode.validate(v)
+
+	formats := make([]string, len(AudioFormats))
+	for i, f := range AudioFormats {
+		formats[i] = string(f)
+	}
+	v.oneOf("audioFormat", string(r.AudioFormat), formats, ErrInvalidAudioFormat)
+
+	if r.SampleRate != "" && !r.SampleRate.Valid() {
+		rates := make([]string, len(SampleRates))
+		for i, s := range SampleRates {
+			rates[i] = string(s)
+		}
+		v.fail("sampleRate", fmt.Sprintf("%q is not one of %s", r.SampleRate, strings.Join(rates, ", ")), ErrInvalidSampleRate)
+	}
+}
+
+func (r *speakExtendedRequest) speakMode() SpeakMode { return r.Mode }
+
+func (r *speakExtendedRequest) request() *Request {
+	return &Request{
+		XMLName:     xml.Name{Local: r.name()},
+		Voice:       r.Voice,
+		Text:        r.Text,
+		AudioFormat: string(r.AudioFormat),
+		SampleRate:  string(r.SampleRate),
+		Audio3D:     r.Audio3D,
+		Metadata:    r.Metadata,
+	}
+}
+
+type listVoicesRequest struct {
+	Language string
+	Accent   string
+	Sex      Sex
+}
+
+func (r *listVoicesRequest) name() string { return "listVoices" }
+
+func (r *listVoicesRequest) validate(v *validator) {
+	v.oneOf("gender", string(r.Sex), []string{string(Female), string(Male)}, nil)
+	if r.Accent != "" && r.Language == "" {
+		v.fail("language", "required with accent", nil)
+	}
+}
+
+func (r *listVoicesRequest) request() *Request {
+	return &Request{
+		XMLName:  xml.Name{Local: r.name()},
+		Language: r.Language,
+		Accent:   r.Accent,
+		Gender:   string(r.Sex),
+	}
+}
+
+type uploadLexiconRequest struct {
+	LexiconFile string
+	Language    string
+	Accent      string
+}
+
+func (r *uploadLexiconRequest) name() string { return "uploadLexicon" }
+
+func (r *uploadLexiconRequest) validate(v *validator) {
+	v.required("lexiconFile", r.LexiconFile)
+	v.required("language", r.Language)
+}
+
+func (r *uploadLexiconRequest) request() *Request {
+	return &Request{
+		XMLName:     xml.Name{Local: r.name()},
+		LexiconFile: r.LexiconFile,
+		Language:    r.Language,
+		Accent:      r.Accent,
+	}
+}
+
+type deleteLexiconRequest struct {
+	Language string
+	Accent   string
+}
+
+func (r *deleteLexiconRequest) name() string { return "deleteLexicon" }
+
+func (r *deleteLexiconRequest) validate(v *validator) {
+	v.required("language", r.Language)
+}
+
+func (r *deleteLexiconRequest) request() *Request {
+	return &Request{
+		XMLName:  xml.Name{Local: r.name()},
+		Language: r.Language,
+		Accent:   r.Accent,
+	}
+}
+
+type uploadAbbreviationsRequest struct {
+	AbbreviationFile string
+	Language         string
+}
+
+func (r *uploadAbbreviationsRequest) name() string { return "uploadAbbreviations" }
+
+func (r *uploadAbbreviationsRequest) validate(v *validator) {
+	v.required("abbreviationFile", r.AbbreviationFile)
+	v.required("language", r.Language)
+}
+
+func (r *uploadAbbreviationsRequest) request() *Request {
+	return &Request{
+		XMLName:          xml.Name{Local: r.name()},
+		AbbreviationFile: r.AbbreviationFile,
+		Language:         r.Language,
+	}
+}
+
+type deleteAbbreviationsRequest struct {
+	Language string
+}
+
+func (r *deleteAbbreviationsRequest) name() string { return "deleteAbbreviations" }
+
+func (r *deleteAbbreviationsRequest) validate(v *validator) {
+	v.required("language", r.Language)
+}
+
+func (r *deleteAbbreviationsRequest) request() *Request {
+	return &Request{
+		XMLName:  xml.Name{Local: r.name()},
+		Language: r.Language,
+	}
+}
+
+// noInputRequest is a request for a function without parameters, such as
+// listLexicons or getCredit
+type noInputRequest string
+
+func (r noInputRequest) name() string { return string(r) }
+
+func (r noInputRequest) validate(v *validator) {}
+
+func (r noInputRequest) request() *Request {
+	return &Request{XMLName: xml.Name{Local: string(r)}}
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ResultCode is the resultCode returned by the CereVoice Cloud API
+type ResultCode int
+
+// Result codes. The CereVoice Cloud API documents only ResultSuccess and
+// ResultFailure, describing the failure in the resultDescription. The
+// negative codes identify failures more precisely, as sent by servers such as
+// cerevoicetest, cerevoiced and cerevoice-proxy, and as found by
+// APIError.Code from the description of a ResultFailure.
+const (
+	ResultFailure             ResultCode = 0
+	ResultSuccess             ResultCode = 1
+	ResultInvalidCredentials  ResultCode = -1
+	ResultInsufficientCredit  ResultCode = -2
+	ResultInvalidVoice        ResultCode = -3
+	ResultInvalidParameter    ResultCode = -4
+	ResultTextTooLong         ResultCode = -5
+	ResultInvalidAudioFormat  ResultCode = -6
+	ResultInvalidSampleRate   ResultCode = -7
+	ResultInvalidLexicon      ResultCode = -8
+	ResultInvalidAbbreviation ResultCode = -9
+	ResultServerBusy          ResultCode = -10
+	ResultInternalError       ResultCode = -11
+)
+
+var resultCodeNames = map[ResultCode]string{
+	ResultFailure:             "failure",
+	ResultSuccess:             "success",
+	ResultInvalidCredentials:  "invalid credentials",
+	ResultInsufficientCredit:  "insufficient credit",
+	ResultInvalidVoice:        "invalid voice",
+	ResultInvalidParameter:    "invalid parameter",
+	ResultTextTooLong:         "text too long",
+	ResultInvalidAudioFormat:  "invalid audio format",
+	ResultInvalidSampleRate:   "invalid sample rate",
+	ResultInvalidLexicon:      "invalid lexicon",
+	ResultInvalidAbbreviation: "invalid abbreviation",
+	ResultServerBusy:          "server busy",
+	ResultInternalError:       "internal error",
+}
+
+// String returns a description of the result code
+func (rc ResultCode) String() string {
+	if name, ok := resultCodeNames[rc]; ok {
+		return name
+	}
+
+	return "result code " + strconv.Itoa(int(rc))
+}
+
+// IsSuccess reports whether the result code indicates success
+func (rc ResultCode) IsSuccess() bool {
+	return rc == ResultSuccess
+}
+
+// IsRetryable reports whether the result code indicates a transient failure
+// which may succeed if retried
+func (rc ResultCode) IsRetryable() bool {
+	return rc == ResultServerBusy || rc == ResultInternalError
+}
+
+// failurePhrases are phrases of the resultDescription of a ResultFailure
+// which identify it, matched in order
+var failurePhrases = []struct {
+	code    ResultCode
+	phrases []string
+}{
+	{ResultInvalidCredentials, []string{"invalid account", "invalid password", "incorrect password", "invalid token", "authentication failed", "not authorised", "not authorized"}},
+	{ResultInsufficientCredit, []string{"insufficient credit", "not enough credit", "out of credit", "no credit"}},
+	{ResultInvalidVoice, []string{"invalid voice", "unknown voice", "voice not found", "voice not available", "no such voice"}},
+	{ResultTextTooLong, []string{"text too long"}},
+	{ResultServerBusy, []string{"server busy", "try again later"}},
+}
+
+// describedCode returns the code description identifies, or ResultFailure
+func describedCode(description string) ResultCode {
+	desc := strings.ToLower(description)
+	for _, f := range failurePhrases {
+		for _, phrase := range f.phrases {
+			if strings.Contains(desc, phrase) {
+				return f.code
+			}
+		}
+	}
+
+	return ResultFailure
+}
+
+// err returns the package error corresponding to the result code, or nil
+func (rc ResultCode) err() error {
+	switch rc {
+	case ResultInvalidCredentials:
+		return ErrAuth
+	case ResultInsufficientCredit:
+		return ErrInsufficientCredit
+	case ResultInvalidVoice:
+		return ErrInvalidVoice
+	}
+
+	return nil
+}
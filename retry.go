@@ -0,0 +1,109 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// DefaultRetryPolicy is a reasonable policy for transient failures
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// RetryPolicy controls how requests which fail with a transient error are
+// retried. Delays grow exponentially from BaseDelay up to MaxDelay and full
+// jitter is applied, so each delay is a random duration up to that bound.
+//
+// Retrying a speak request after a network error may synthesise, and bill,
+// the same text twice if the original request reached the API.
+type RetryPolicy struct {
+	MaxAttempts      int           // Total attempts including the first, 1 or less disables retries
+	BaseDelay        time.Duration // Upper bound of the delay before the first retry
+	MaxDelay         time.Duration // Upper bound of any delay, 0 for no limit
+	RetryResultCodes []ResultCode  // Additional API result codes treated as transient
+}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a context which overrides the Client RetryPolicy
+// for requests made with it
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// retryPolicy returns the policy for a request made with ctx
+func (c *Client) retryPolicy(ctx context.Context) RetryPolicy {
+	if policy, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return policy
+	}
+	if c.RetryPolicy != nil {
+		return *c.RetryPolicy
+	}
+
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// delay returns the randomised delay before retry attempt n, starting at 1
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// retryable reports whether err is a transient failure worth retrying
+func (p RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code().IsRetryable() {
+			return true
+		}
+		for _, code := range p.RetryResultCodes {
+			if apiErr.ResultCode == code || apiErr.Code() == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
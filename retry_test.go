@@ -0,0 +1,132 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		n      int
+		max    time.Duration
+	}{
+		{"first", RetryPolicy{BaseDelay: 100 * time.Millisecond}, 1, 100 * time.Millisecond},
+		{"doubles", RetryPolicy{BaseDelay: 100 * time.Millisecond}, 3, 400 * time.Millisecond},
+		{"capped", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 250 * time.Millisecond}, 3, 250 * time.Millisecond},
+		{"cap below base", RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Millisecond}, 1, 10 * time.Millisecond},
+		{"no overflow", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 1000, time.Minute},
+		{"no delay", RetryPolicy{}, 2, 0},
+	}
+
+	for _, tt := range tests {
+		// Full jitter, so the delay is anywhere up to the bound
+		var longest time.Duration
+		for i := 0; i < 1000; i++ {
+			d := tt.policy.delay(tt.n)
+			if d < 0 || d > tt.max {
+				t.Errorf("%s: delay(%d) = %v, want at most %v", tt.name, tt.n, d, tt.max)
+				break
+			}
+			if d > longest {
+				longest = d
+			}
+		}
+		if longest < tt.max/2 {
+			t.Errorf("%s: longest delay(%d) = %v, want near %v", tt.name, tt.n, longest, tt.max)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	busy := &APIError{Operation: "speakSimple", ResultCode: ResultServerBusy}
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		err    error
+		want   bool
+	}{
+		{"server busy", RetryPolicy{}, busy, true},
+		{"wrapped", RetryPolicy{}, fmt.Errorf("speaking: %w", busy), true},
+		{"internal error", RetryPolicy{}, &APIError{ResultCode: ResultInternalError}, true},
+		{"busy by description", RetryPolicy{}, &APIError{ResultCode: ResultFailure, Description: "Server busy, try again later"}, true},
+		{"invalid voice", RetryPolicy{}, &APIError{ResultCode: ResultInvalidVoice}, false},
+		{"extra code", RetryPolicy{RetryResultCodes: []ResultCode{ResultInvalidVoice}}, &APIError{ResultCode: ResultInvalidVoice}, true},
+		{"extra described code", RetryPolicy{RetryResultCodes: []ResultCode{ResultInsufficientCredit}}, &APIError{ResultCode: ResultFailure, Description: "Out of credit"}, true},
+		{"5xx", RetryPolicy{}, &HTTPError{StatusCode: 502}, true},
+		{"4xx", RetryPolicy{}, &HTTPError{StatusCode: 404}, false},
+		{"network", RetryPolicy{}, &net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{"canceled", RetryPolicy{}, context.Canceled, false},
+		{"deadline", RetryPolicy{}, context.DeadlineExceeded, false},
+		{"other", RetryPolicy{}, errors.New("bad input"), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.retryable(tt.err); got != tt.want {
+			t.Errorf("%s: retryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+// countingClient answers every request with an HTTP status, counting them
+type countingClient struct {
+	status int
+	n      int32
+}
+
+func (c *countingClient) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.n, 1)
+	return &http.Response{
+		StatusCode: c.status,
+		Status:     http.StatusText(c.status),
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("error")),
+		Request:    req,
+	}, nil
+}
+
+func TestRetryAttempts(t *testing.T) {
+	fast := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	tests := []struct {
+		name   string
+		client *RetryPolicy
+		ctx    *RetryPolicy
+		status int
+		want   int32
+	}{
+		{"no policy", nil, nil, 503, 1},
+		{"client policy", &fast, nil, 503, 3},
+		{"not transient", &fast, nil, 400, 1},
+		{"context overrides", &fast, &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}, 503, 2},
+		{"context disables", &fast, &RetryPolicy{MaxAttempts: 1}, 503, 1},
+	}
+
+	for _, tt := range tests {
+		doer := &countingClient{status: tt.status}
+		c := NewClient("account", "password", WithHTTPClient(doer))
+		c.RetryPolicy = tt.client
+		ctx := context.Background()
+		if tt.ctx != nil {
+			ctx = WithRetryPolicy(ctx, *tt.ctx)
+		}
+
+		if _, err := c.GetCreditWithContext(ctx); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+		if doer.n != tt.want {
+			t.Errorf("%s: %d attempts, want %d", tt.name, doer.n, tt.want)
+		}
+	}
+}
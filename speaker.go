@@ -0,0 +1,246 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bganderson/cerevoicego/audio"
+	"github.com/bganderson/cerevoicego/transcode"
+)
+
+// ErrNonCompliant is matched by a ComplianceError, returned when audio does
+// not meet a smart speaker's requirements
+var ErrNonCompliant = errors.New("cerevoicego: audio not smart speaker compliant")
+
+// SpeakerSpec is the MP3 a smart speaker plays from an SSML <audio> tag.
+// Zero fields are not checked.
+type SpeakerSpec struct {
+	Bitrate     int           // Constant bits per second
+	SampleRates []int         // Accepted sample rates in Hz, the first used when re-encoding
+	MaxDuration time.Duration // Longest audio accepted
+}
+
+var (
+	// AlexaSpec is the audio accepted by Alexa skills
+	AlexaSpec = SpeakerSpec{
+		Bitrate:     48000,
+		SampleRates: []int{24000, 22050, 16000},
+		MaxDuration: 240 * time.Second,
+	}
+
+	// GoogleAssistantSpec is the audio accepted by Google Assistant actions
+	GoogleAssistantSpec = SpeakerSpec{
+		Bitrate:     48000,
+		SampleRates: []int{24000},
+		MaxDuration: 240 * time.Second,
+	}
+)
+
+var (
+	// PresetAlexa is 24 kHz 48 kbps MP3 for an Alexa <audio src>
+	PresetAlexa = Preset{
+		Name:        "alexa",
+		AudioFormat: FormatMP3,
+		SampleRate:  SampleRate24k,
+		ContentType: "audio/mpeg",
+		Speaker:     &AlexaSpec,
+	}
+
+	// PresetGoogleAssistant is 24 kHz 48 kbps MP3 for a Google Assistant
+	// <audio src>
+	PresetGoogleAssistant = Preset{
+		Name:        "google",
+		AudioFormat: FormatMP3,
+		SampleRate:  SampleRate24k,
+		ContentType: "audio/mpeg",
+		Speaker:     &GoogleAssistantSpec,
+	}
+)
+
+// ComplianceError describes audio which a smart speaker would reject
+type ComplianceError struct {
+	Reason     string        // What is wrong
+	Bitrate    int           // Bits per second found, 0 if variable or unknown
+	SampleRate int           // Sample rate found in Hz, 0 if unknown
+	Duration   time.Duration // Length of the audio, 0 if not all frames were read
+}
+
+func (e *ComplianceError) Error() string {
+	var b strings.Builder
+	b.WriteString("cerevoicego: audio not smart speaker compliant: " + e.Reason)
+	if e.Duration > 0 {
+		fmt.Fprintf(&b, " (%d kbps, %d Hz, %s)", e.Bitrate/1000, e.SampleRate, e.Duration.Round(time.Millisecond))
+	}
+
+	return b.String()
+}
+
+// Is reports whether target is ErrNonCompliant
+func (e *ComplianceError) Is(target error) bool {
+	return target == ErrNonCompliant
+}
+
+// mp3Bitrates are the layer III bitrates in kbps by MPEG-1 and by index
+var mp3Bitrates = map[bool][15]int{
+	true:  {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	false: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// CheckSpeakerAudio checks every frame of an MP3 file against spec,
+// returning a *ComplianceError if the audio is not layer III, is truncated,
+// changes bitrate or sample rate, or does not meet the spec
+func CheckSpeakerAudio(b []byte, spec SpeakerSpec) error {
+	e := &ComplianceError{}
+
+	var samples int64
+	for p := skipID3(b); p < len(b) && !bytes.HasPrefix(b[p:], []byte("TAG")); {
+		if p+4 > len(b) {
+			e.Reason = "truncated frame"
+			return e
+		}
+		if b[p] != 0xFF || b[p+1]&0xE0 != 0xE0 {
+			e.Reason = "invalid frame header"
+			return e
+		}
+		if (b[p+1]>>1)&3 != 1 {
+			e.Reason = "not MPEG layer III"
+			return e
+		}
+
+		version := (b[p+1] >> 3) & 3
+		rates, ok := mp3Rates[version]
+		bitIndex, rateIndex := b[p+2]>>4, (b[p+2]>>2)&3
+		if !ok || bitIndex == 0 || bitIndex == 15 || rateIndex == 3 {
+			e.Reason = "invalid frame header"
+			return e
+		}
+		mpeg1 := version == 3
+		bitrate, rate := mp3Bitrates[mpeg1][bitIndex]*1000, rates[rateIndex]
+
+		switch {
+		case e.SampleRate == 0:
+			e.Bitrate, e.SampleRate = bitrate, rate
+		case rate != e.SampleRate:
+			e.Reason = "sample rate changes"
+			return e
+		case bitrate != e.Bitrate:
+			e.Bitrate = 0
+		}
+
+		padding := int(b[p+2]>>1) & 1
+		size, frameSamples := 144*bitrate/rate+padding, int64(1152)
+		if !mpeg1 {
+			size, frameSamples = 72*bitrate/rate+padding, 576
+		}
+		if p+size > len(b) {
+			e.Reason = "truncated frame"
+			return e
+		}
+		p += size
+		samples += frameSamples
+	}
+	if e.SampleRate == 0 {
+		e.Reason = "no audio frames"
+		return e
+	}
+	e.Duration = time.Duration(samples * int64(time.Second) / int64(e.SampleRate))
+
+	switch {
+	case e.Bitrate == 0:
+		e.Reason = "variable bitrate"
+	case spec.Bitrate > 0 && e.Bitrate != spec.Bitrate:
+		e.Reason = fmt.Sprintf("expected %d kbps", spec.Bitrate/1000)
+	case len(spec.SampleRates) > 0 && !containsRate(spec.SampleRates, e.SampleRate):
+		e.Reason = fmt.Sprintf("expected %s Hz", joinRates(spec.SampleRates))
+	case spec.MaxDuration > 0 && e.Duration > spec.MaxDuration:
+		e.Reason = "longer than " + spec.MaxDuration.String()
+	default:
+		return nil
+	}
+
+	return e
+}
+
+// speakSpeaker synthesises input for a smart speaker preset, re-encoding the
+// audio if CereVoice's does not comply. Effects need WAV, so if there are
+// any that is synthesised and encoded instead. The encoder is only needed
+// for re-encoding, so compliant audio is returned without it.
+func (c *Client) speakSpeaker(ctx context.Context, input *SpeakExtendedInput, preset Preset) ([]byte, error) {
+	in := preset.apply(input)
+	if len(c.AudioEffects) > 0 || len(preset.Effects) > 0 {
+		in.AudioFormat = FormatWAV
+		b, err := c.SpeakAudioWithContext(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		if b, err = audio.Process(b, preset.Effects...); err != nil {
+			return nil, err
+		}
+		return preset.encodeSpeaker(b)
+	}
+
+	b, err := c.SpeakAudioWithContext(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	err = CheckSpeakerAudio(b, *preset.Speaker)
+	var ce *ComplianceError
+	switch {
+	case err == nil:
+		return b, nil
+	case !errors.As(err, &ce), preset.Speaker.MaxDuration > 0 && ce.Duration > preset.Speaker.MaxDuration:
+		// Re-encoding will not shorten the audio
+		return nil, err
+	}
+
+	wav, err := transcode.DecodeMP3(b)
+	if err != nil {
+		return nil, fmt.Errorf("cerevoicego: re-encoding for %s: %w", preset.Name, err)
+	}
+
+	return preset.encodeSpeaker(wav)
+}
+
+// encodeSpeaker encodes a WAV file as mono MP3 to the preset's Speaker spec
+// and checks the result
+func (p Preset) encodeSpeaker(wav []byte) ([]byte, error) {
+	opts := &transcode.MP3Options{Bitrate: p.Speaker.Bitrate, Channels: 1}
+	if len(p.Speaker.SampleRates) > 0 {
+		opts.SampleRate = p.Speaker.SampleRates[0]
+	}
+
+	b, err := transcode.MP3(wav, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cerevoicego: re-encoding for %s: %w", p.Name, err)
+	}
+	if err := CheckSpeakerAudio(b, *p.Speaker); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func containsRate(rates []int, rate int) bool {
+	for _, r := range rates {
+		if r == rate {
+			return true
+		}
+	}
+	return false
+}
+
+func joinRates(rates []int) string {
+	s := make([]string, len(rates))
+	for i, r := range rates {
+		s[i] = fmt.Sprint(r)
+	}
+	return strings.Join(s, ", ")
+}
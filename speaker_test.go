@@ -0,0 +1,108 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/transcode"
+)
+
+// Frame headers of mono layer III frames and the size of each frame
+var (
+	frame48k24k = []byte{0xFF, 0xF3, 0x64, 0xC0} // MPEG 2, 48 kbps, 24 kHz, 144 bytes
+	frame64k24k = []byte{0xFF, 0xF3, 0x84, 0xC0} // MPEG 2, 64 kbps, 24 kHz, 192 bytes
+	frame48k44k = []byte{0xFF, 0xFB, 0x30, 0xC0} // MPEG 1, 48 kbps, 44.1 kHz, 156 bytes
+	frameLayer2 = []byte{0xFF, 0xF5, 0x64, 0xC0} // MPEG 2 layer II
+)
+
+// mp3 returns n frames with header, each size bytes
+func mp3(header []byte, size, n int) []byte {
+	frame := make([]byte, size)
+	copy(frame, header)
+	return bytes.Repeat(frame, n)
+}
+
+func TestCheckSpeakerAudio(t *testing.T) {
+	compliant := mp3(frame48k24k, 144, 10)
+	id3 := append([]byte("ID3\x03\x00\x00\x00\x00\x00\x04"), 0, 0, 0, 0)
+	tagged := append(append(append([]byte(nil), id3...), compliant...), append([]byte("TAG"), make([]byte, 125)...)...)
+
+	tests := []struct {
+		name   string
+		audio  []byte
+		spec   cerevoicego.SpeakerSpec
+		reason string
+	}{
+		{"compliant", compliant, cerevoicego.AlexaSpec, ""},
+		{"tagged", tagged, cerevoicego.AlexaSpec, ""},
+		{"truncated frame", compliant[:len(compliant)-10], cerevoicego.AlexaSpec, "truncated frame"},
+		{"truncated header", append(compliant, 0xFF, 0xF3), cerevoicego.AlexaSpec, "truncated frame"},
+		{"variable bitrate", append(mp3(frame64k24k, 192, 1), compliant...), cerevoicego.AlexaSpec, "variable bitrate"},
+		{"bitrate", mp3(frame64k24k, 192, 10), cerevoicego.AlexaSpec, "expected 48 kbps"},
+		{"sample rate", mp3(frame48k44k, 156, 10), cerevoicego.AlexaSpec, "expected 24000, 22050, 16000 Hz"},
+		{"sample rate changes", append(mp3(frame48k44k, 156, 1), compliant...), cerevoicego.AlexaSpec, "sample rate changes"},
+		{"layer II", mp3(frameLayer2, 144, 10), cerevoicego.AlexaSpec, "not MPEG layer III"},
+		{"not MP3", []byte("RIFF....WAVEfmt "), cerevoicego.AlexaSpec, "invalid frame header"},
+		{"empty", nil, cerevoicego.AlexaSpec, "no audio frames"},
+		{"too long", compliant, cerevoicego.SpeakerSpec{MaxDuration: 200 * time.Millisecond}, "longer than 200ms"},
+	}
+	for _, tt := range tests {
+		err := cerevoicego.CheckSpeakerAudio(tt.audio, tt.spec)
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+
+		var ce *cerevoicego.ComplianceError
+		if !errors.Is(err, cerevoicego.ErrNonCompliant) || !errors.As(err, &ce) || ce.Reason != tt.reason {
+			t.Errorf("%s: %v, want %q", tt.name, err, tt.reason)
+		}
+	}
+
+	var ce *cerevoicego.ComplianceError
+	err := cerevoicego.CheckSpeakerAudio(compliant, cerevoicego.SpeakerSpec{MaxDuration: time.Millisecond})
+	if !errors.As(err, &ce) || ce.Duration != 240*time.Millisecond || ce.Bitrate != 48000 || ce.SampleRate != 24000 {
+		t.Errorf("ComplianceError = %+v, want 240ms of 48 kbps at 24 kHz", ce)
+	}
+}
+
+func TestSpeakerPresetEncoder(t *testing.T) {
+	_, err := transcode.DecodeMP3(nil)
+	lame := !errors.Is(err, transcode.ErrMP3Unavailable)
+
+	tests := []struct {
+		name  string
+		audio []byte
+		err   error
+	}{
+		{"compliant", mp3(frame48k24k, 144, 10), nil},
+		{"needs re-encoding", mp3(frame64k24k, 192, 10), transcode.ErrMP3Unavailable},
+	}
+
+	for _, tt := range tests {
+		if tt.err != nil && lame {
+			continue
+		}
+		srv := cerevoicetest.NewServer()
+		srv.SetAudio(tt.audio)
+		c := srv.Client()
+
+		b, err := c.SpeakPreset(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: "Hello"}, cerevoicego.PresetAlexa)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: SpeakPreset = %v, want %v", tt.name, err, tt.err)
+		}
+		if tt.err == nil && !bytes.Equal(b, tt.audio) {
+			t.Errorf("%s: compliant audio was changed", tt.name)
+		}
+		srv.Close()
+	}
+}
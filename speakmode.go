@@ -0,0 +1,132 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/bganderson/cerevoicego/lexicon"
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+// SpeakMode selects the synthesis modes of a speak request. When any is set
+// the Text is wrapped in the CereProc markup for the mode, after any
+// TextSteps. Text may itself be markup, such as SSML with breaks, which is
+// kept, except with Spell, which spells out plain text.
+type SpeakMode struct {
+	Spell   bool   `json:"spell,omitempty"`   // Spell the text out character by character
+	Variant int    `json:"variant,omitempty"` // Unit selection variant of the voice, 0 for the default
+	Genre   string `json:"genre,omitempty"`   // Unit selection genre, such as an emotional style
+
+	// Emotion selects an emotional style of voices which have one, in place
+	// of a Genre. See EmotionalVoices and WithEmotionalVoices.
+	Emotion ssml.Emotion `json:"emotion,omitempty"`
+
+	// Phonemes maps words of the text to CereProc phones they are spoken
+	// with, e.g. "tomato": "t @0 m aa1 t ou0", for names and jargon without
+	// uploading a lexicon. Words are matched regardless of case.
+	Phonemes map[string]string `json:"phonemes,omitempty"`
+}
+
+// isZero reports whether no mode is set
+func (m SpeakMode) isZero() bool {
+	return !m.Spell && m.Variant == 0 && m.genre() == "" && len(m.Phonemes) == 0
+}
+
+// genre returns the unit selection genre of the Genre or Emotion
+func (m SpeakMode) genre() string {
+	if m.Emotion != "" {
+		return string(m.Emotion)
+	}
+
+	return m.Genre
+}
+
+// validate checks the mode fields
+func (m SpeakMode) validate(v *validator) {
+	if m.Variant < 0 {
+		v.fail("variant", "must not be negative", nil)
+	}
+	switch {
+	case m.Emotion != "" && !m.Emotion.Valid():
+		v.fail("emotion", "unknown emotion", nil)
+	case m.Emotion != "" && m.Genre != "":
+		v.fail("emotion", "can not be combined with a genre", nil)
+	}
+	for _, word := range m.phonemeWords() {
+		if _, err := lexicon.ParseTranscription(m.Phonemes[word]); err != nil {
+			v.fail("phonemes", fmt.Sprintf("%v for %q", err, word), nil)
+		}
+	}
+}
+
+// Markup returns text wrapped in the markup for the mode, or unchanged when
+// no mode is set
+func (m SpeakMode) Markup(text string) string {
+	if m.isZero() {
+		return text
+	}
+
+	content := func(b *ssml.Builder) {
+		switch {
+		case m.Spell:
+			b.Spell(text)
+		case ssml.IsMarkup(text):
+			b.MarkupWithPhonemes(speakContent(text), m.Phonemes)
+		default:
+			b.TextWithPhonemes(text, m.Phonemes)
+		}
+	}
+
+	b := ssml.New()
+	if m.Variant == 0 && m.genre() == "" {
+		content(b)
+	} else {
+		b.UselMarkup(m.Variant, m.genre(), content)
+	}
+
+	return b.String()
+}
+
+// speakElement matches markup wrapped in a speak element
+var speakElement = regexp.MustCompile(`(?s)^\s*(<speak(?:\s[^>]*)?>)(.*)</speak>\s*$`)
+
+// speakContent returns markup without any speak element wrapping it, as the
+// mode wraps it in its own
+func speakContent(markup string) string {
+	if m := speakElement.FindStringSubmatch(markup); m != nil {
+		return m[2]
+	}
+
+	return markup
+}
+
+// cacheFields returns the mode as strings for CacheKey, none when no mode is
+// set so existing keys are unchanged
+func (m SpeakMode) cacheFields() []string {
+	if m.isZero() {
+		return nil
+	}
+
+	fields := []string{strconv.FormatBool(m.Spell), strconv.Itoa(m.Variant), m.genre()}
+	for _, word := range m.phonemeWords() {
+		fields = append(fields, word+"="+m.Phonemes[word])
+	}
+
+	return fields
+}
+
+// phonemeWords returns the words of Phonemes in order
+func (m SpeakMode) phonemeWords() []string {
+	words := make([]string, 0, len(m.Phonemes))
+	for word := range m.Phonemes {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	return words
+}
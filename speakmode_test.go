@@ -0,0 +1,39 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/ssml"
+)
+
+func TestSpeakModeMarkup(t *testing.T) {
+	phonemes := map[string]string{"CereProc": "s e1 r @0 p r o0 k"}
+	tests := []struct {
+		name string
+		mode cerevoicego.SpeakMode
+		text string
+		want string
+	}{
+		{"plain text", cerevoicego.SpeakMode{Phonemes: phonemes}, "CereProc & co",
+			`<speak><phoneme ph="s e1 r @0 p r o0 k">CereProc</phoneme> &amp; co</speak>`},
+		{"markup", cerevoicego.SpeakMode{Phonemes: phonemes}, `Hello <break time="1s"/> CereProc`,
+			`<speak>Hello <break time="1s"/> <phoneme ph="s e1 r @0 p r o0 k">CereProc</phoneme></speak>`},
+		{"speak element", cerevoicego.SpeakMode{Phonemes: phonemes}, `<speak>Hello <break time="1s"/> CereProc</speak>`,
+			`<speak>Hello <break time="1s"/> <phoneme ph="s e1 r @0 p r o0 k">CereProc</phoneme></speak>`},
+		{"emotion", cerevoicego.SpeakMode{Emotion: ssml.Happy}, `Hello <break time="1s"/> CereProc`,
+			`<speak><usel genre="happy">Hello <break time="1s"/> CereProc</usel></speak>`},
+		{"emotion plain text", cerevoicego.SpeakMode{Emotion: ssml.Sad}, "Tom & Jerry",
+			`<speak><usel genre="sad">Tom &amp; Jerry</usel></speak>`},
+		{"spell", cerevoicego.SpeakMode{Spell: true}, "A&B",
+			`<speak><say-as interpret-as="characters">A&amp;B</say-as></speak>`},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.Markup(tt.text); got != tt.want {
+			t.Errorf("%s: Markup(%q) = %s, want %s", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
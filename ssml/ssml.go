@@ -0,0 +1,456 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package ssml builds SSML and CereProc markup suitable for the Text field of
+// cerevoicego.SpeakSimpleInput and cerevoicego.SpeakExtendedInput.
+//
+//	text := ssml.New().
+//		Text("Your code is").
+//		Break(300 * time.Millisecond).
+//		Spell("A1B2").
+//		String()
+package ssml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bganderson/cerevoicego/lexicon"
+)
+
+// Strength is the strength of a pause
+type Strength string
+
+// Pause strengths
+const (
+	StrengthNone    Strength = "none"
+	StrengthXWeak   Strength = "x-weak"
+	StrengthWeak    Strength = "weak"
+	StrengthMedium  Strength = "medium"
+	StrengthStrong  Strength = "strong"
+	StrengthXStrong Strength = "x-strong"
+)
+
+// Level is the level of emphasis
+type Level string
+
+// Emphasis levels
+const (
+	LevelStrong   Level = "strong"
+	LevelModerate Level = "moderate"
+	LevelReduced  Level = "reduced"
+	LevelNone     Level = "none"
+)
+
+// Emotion is a CereProc voice style selected with the usel genre attribute
+type Emotion string
+
+// Emotions supported by CereProc voices with emotional variants
+const (
+	Happy Emotion = "happy"
+	Sad   Emotion = "sad"
+	Cross Emotion = "cross"
+	Calm  Emotion = "calm"
+)
+
+// Emotions lists the emotional styles
+var Emotions = []Emotion{Happy, Sad, Cross, Calm}
+
+// Valid reports whether e is one of Emotions
+func (e Emotion) Valid() bool {
+	for _, emotion := range Emotions {
+		if e == emotion {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Prosody contains the attributes of a prosody element. Values use the SSML
+// syntax, e.g. Rate "slow" or "+10%", Pitch "high", Volume "loud" or "-6dB".
+type Prosody struct {
+	Rate   string
+	Pitch  string
+	Volume string
+}
+
+// Builder constructs markup. The zero value is ready to use.
+type Builder struct {
+	buf strings.Builder
+	err error
+}
+
+// New returns an empty Builder
+func New() *Builder {
+	return &Builder{}
+}
+
+// Text appends escaped plain text
+func (b *Builder) Text(text string) *Builder {
+	b.escape(text)
+	return b
+}
+
+// Break appends a pause of duration d
+func (b *Builder) Break(d time.Duration) *Builder {
+	b.buf.WriteString(`<break time="`)
+	b.buf.WriteString(strconv.FormatInt(d.Milliseconds(), 10))
+	b.buf.WriteString(`ms"/>`)
+	return b
+}
+
+// BreakStrength appends a pause of the given strength
+func (b *Builder) BreakStrength(s Strength) *Builder {
+	return b.empty("break", "strength", string(s))
+}
+
+// Emphasis appends text spoken with the given level of emphasis
+func (b *Builder) Emphasis(level Level, text string) *Builder {
+	return b.element("emphasis", text, "level", string(level))
+}
+
+// Prosody appends text spoken with the given rate, pitch and volume
+func (b *Builder) Prosody(p Prosody, text string) *Builder {
+	return b.element("prosody", text,
+		"rate", p.Rate,
+		"pitch", p.Pitch,
+		"volume", p.Volume)
+}
+
+// Spell appends text spelled out character by character
+func (b *Builder) Spell(text string) *Builder {
+	return b.element("say-as", text, "interpret-as", "characters")
+}
+
+// SayAs appends text with an explicit interpretation, e.g. "date" or "digits"
+func (b *Builder) SayAs(interpretAs, format, text string) *Builder {
+	return b.element("say-as", text,
+		"interpret-as", interpretAs,
+		"format", format)
+}
+
+// Voice appends markup built by fn spoken with a different voice
+func (b *Builder) Voice(name string, fn func(*Builder)) *Builder {
+	return b.nested("voice", fn, "name", name)
+}
+
+// Usel appends text synthesised using the CereProc unit selection variant
+// and genre given. Either may be empty.
+func (b *Builder) Usel(variant int, genre string, text string) *Builder {
+	return b.element("usel", text, uselAttrs(variant, genre)...)
+}
+
+// UselMarkup appends markup built by fn synthesised using the CereProc unit
+// selection variant and genre given. Either may be empty.
+func (b *Builder) UselMarkup(variant int, genre string, fn func(*Builder)) *Builder {
+	return b.nested("usel", fn, uselAttrs(variant, genre)...)
+}
+
+func uselAttrs(variant int, genre string) []string {
+	v := ""
+	if variant > 0 {
+		v = strconv.Itoa(variant)
+	}
+
+	return []string{"variant", v, "genre", genre}
+}
+
+// Emotion appends text spoken in the given emotional style. Only voices with
+// emotional variants support this.
+func (b *Builder) Emotion(e Emotion, text string) *Builder {
+	return b.Usel(0, string(e), text)
+}
+
+// Spurt appends a CereProc vocal gesture such as a laugh or cough, e.g.
+// Spurt("g0001_004", "cough")
+func (b *Builder) Spurt(audio, text string) *Builder {
+	return b.element("spurt", text, "audio", audio)
+}
+
+// Phoneme appends text spoken with the CereProc phones of transcription,
+// e.g. Phoneme("cereproc", "s e1 r @0 p r o0 k"), overriding the lexicon for
+// this request only. Phones use the lexicon file notation for the voice's
+// language. An invalid transcription is reported by Err and the text
+// appended without it.
+func (b *Builder) Phoneme(text, transcription string) *Builder {
+	phones, err := lexicon.ParseTranscription(transcription)
+	if err != nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("ssml: phoneme for %q: %v", text, err)
+		}
+		return b.Text(text)
+	}
+
+	return b.element("phoneme", text, "ph", strings.Join(phones, " "))
+}
+
+// TextWithPhonemes appends escaped plain text, speaking the words which are
+// keys of phonemes, matched regardless of case, with their transcriptions as
+// Phoneme does
+func (b *Builder) TextWithPhonemes(text string, phonemes map[string]string) *Builder {
+	return b.withPhonemes(text, phonemes, (*Builder).Text)
+}
+
+// Markup appends markup as it is, such as SSML written by hand. Markup which
+// is not well formed is reported by Err and appended escaped as text.
+func (b *Builder) Markup(markup string) *Builder {
+	return b.MarkupWithPhonemes(markup, nil)
+}
+
+// MarkupWithPhonemes appends markup as Markup does, speaking the words of
+// its text with phonemes as TextWithPhonemes does. Its tags and entities are
+// kept, and words within phoneme, say-as and spurt elements are left alone.
+func (b *Builder) MarkupWithPhonemes(markup string, phonemes map[string]string) *Builder {
+	if err := checkMarkup(markup); err != nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("ssml: malformed markup: %v", err)
+		}
+		return b.TextWithPhonemes(markup, phonemes)
+	}
+
+	raw := func(b *Builder, s string) *Builder {
+		b.buf.WriteString(s)
+		return b
+	}
+
+	skip := 0 // Depth within elements whose words are left alone
+	start := 0
+	for _, tag := range tagPattern.FindAllStringSubmatchIndex(markup, -1) {
+		if skip == 0 {
+			b.withPhonemes(markup[start:tag[0]], phonemes, raw)
+		} else {
+			raw(b, markup[start:tag[0]])
+		}
+		raw(b, markup[tag[0]:tag[1]])
+		start = tag[1]
+
+		closing, name, selfClosing := markup[tag[2]:tag[3]] == "/", markup[tag[4]:tag[5]], markup[tag[1]-2] == '/'
+		if keepWords[strings.ToLower(name)] && !selfClosing {
+			if closing {
+				skip--
+			} else {
+				skip++
+			}
+		}
+	}
+	if skip == 0 {
+		return b.withPhonemes(markup[start:], phonemes, raw)
+	}
+
+	return raw(b, markup[start:])
+}
+
+// Nodes splits markup into its top level nodes: each element with its
+// content, each comment and the text between them, so it can be divided
+// without cutting an element. Markup which is not well formed is an error.
+func Nodes(markup string) ([]string, error) {
+	if err := checkMarkup(markup); err != nil {
+		return nil, fmt.Errorf("ssml: malformed markup: %v", err)
+	}
+
+	var nodes []string
+	depth, start := 0, 0
+	for _, tag := range tagPattern.FindAllStringSubmatchIndex(markup, -1) {
+		if depth == 0 && tag[0] > start {
+			nodes = append(nodes, markup[start:tag[0]])
+			start = tag[0]
+		}
+
+		closing, name := markup[tag[2]:tag[3]] == "/", markup[tag[4]:tag[5]]
+		switch {
+		case closing:
+			depth--
+		case markup[tag[1]-2] != '/' && name[0] != '!' && name[0] != '?':
+			depth++
+		}
+		if depth == 0 {
+			nodes = append(nodes, markup[start:tag[1]])
+			start = tag[1]
+		}
+	}
+	if start < len(markup) {
+		nodes = append(nodes, markup[start:])
+	}
+
+	return nodes, nil
+}
+
+// IsMarkup reports whether text contains tags, and so is parsed as markup
+// by the engine rather than spoken as plain text
+func IsMarkup(text string) bool {
+	return markupStart.MatchString(text)
+}
+
+var (
+	// markupStart matches the start of a tag, comment or processing
+	// instruction
+	markupStart = regexp.MustCompile(`<[A-Za-z/!?]`)
+	// tagPattern matches a tag, comment or processing instruction, with
+	// any / closing it and its name
+	tagPattern = regexp.MustCompile(`<(/?)([A-Za-z?!][^\s/>]*)[^>]*>`)
+	// entityPattern matches a character or entity reference
+	entityPattern = regexp.MustCompile(`&#?[A-Za-z0-9]+;`)
+)
+
+// keepWords are the elements whose words MarkupWithPhonemes leaves alone
+var keepWords = map[string]bool{"phoneme": true, "say-as": true, "spurt": true}
+
+// checkMarkup checks markup is well formed, allowing HTML entities
+func checkMarkup(markup string) error {
+	dec := xml.NewDecoder(strings.NewReader("<speak>" + markup + "</speak>"))
+	dec.Entity = xml.HTMLEntity
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// withPhonemes appends text with write, speaking the words which are keys of
+// phonemes with their transcriptions. Entities in text are not words.
+func (b *Builder) withPhonemes(text string, phonemes map[string]string, write func(*Builder, string) *Builder) *Builder {
+	if len(phonemes) == 0 {
+		return write(b, text)
+	}
+
+	lower := make(map[string]string, len(phonemes))
+	for word, transcription := range phonemes {
+		lower[strings.ToLower(word)] = transcription
+	}
+
+	// Entities are blanked out, keeping the offsets, so their names are not
+	// taken for words
+	plain := entityPattern.ReplaceAllStringFunc(text, func(e string) string {
+		return strings.Repeat(" ", len(e))
+	})
+
+	start := 0
+	for _, w := range words(plain) {
+		word := strings.ToLower(text[w[0]:w[1]])
+		transcription, ok := lower[word]
+		if !ok && strings.HasSuffix(word, "'s") {
+			// A possessive is spoken as the word followed by s
+			if transcription, ok = lower[strings.TrimSuffix(word, "'s")]; ok {
+				w[1] -= 2
+			}
+		}
+		if !ok {
+			continue
+		}
+		write(b, text[start:w[0]])
+		b.Phoneme(text[w[0]:w[1]], transcription)
+		start = w[1]
+	}
+
+	return write(b, text[start:])
+}
+
+// words returns the start and end of each word in text. Words are letters
+// and digits, joined by apostrophes.
+func words(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || (start >= 0 && r == '\'')
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+
+	// Apostrophes end quotes rather than words
+	for i, s := range spans {
+		for s[1] > s[0] && text[s[1]-1] == '\'' {
+			s[1]--
+		}
+		spans[i] = s
+	}
+
+	return spans
+}
+
+// Err returns the first problem found while building, such as an invalid
+// Phoneme transcription
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// String returns the markup wrapped in a speak element
+func (b *Builder) String() string {
+	return "<speak>" + b.buf.String() + "</speak>"
+}
+
+// Fragment returns the markup without a surrounding speak element
+func (b *Builder) Fragment() string {
+	return b.buf.String()
+}
+
+// element appends <name attrs...>text</name>, attributes with an empty value
+// are omitted
+func (b *Builder) element(name, text string, attrs ...string) *Builder {
+	b.open(name, attrs)
+	b.buf.WriteString(">")
+	b.escape(text)
+	b.close(name)
+	return b
+}
+
+// nested appends <name attrs...>, the markup built by fn, and </name>
+func (b *Builder) nested(name string, fn func(*Builder), attrs ...string) *Builder {
+	b.open(name, attrs)
+	b.buf.WriteString(">")
+	if fn != nil {
+		fn(b)
+	}
+	b.close(name)
+	return b
+}
+
+// empty appends <name attrs.../>
+func (b *Builder) empty(name string, attrs ...string) *Builder {
+	b.open(name, attrs)
+	b.buf.WriteString("/>")
+	return b
+}
+
+func (b *Builder) open(name string, attrs []string) {
+	b.buf.WriteString("<")
+	b.buf.WriteString(name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i+1] == "" {
+			continue
+		}
+		b.buf.WriteString(" ")
+		b.buf.WriteString(attrs[i])
+		b.buf.WriteString(`="`)
+		b.escape(attrs[i+1])
+		b.buf.WriteString(`"`)
+	}
+}
+
+func (b *Builder) close(name string) {
+	b.buf.WriteString("</")
+	b.buf.WriteString(name)
+	b.buf.WriteString(">")
+}
+
+func (b *Builder) escape(s string) {
+	xml.EscapeText(&b.buf, []byte(s))
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package ssml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, world", []string{"Hello", "world"}},
+		{"don't stop", []string{"don't", "stop"}},
+		{"CereProc's voices", []string{"CereProc's", "voices"}},
+		{"'quoted' words'", []string{"quoted", "words"}},
+		{"route 66 ok", []string{"route", "66", "ok"}},
+		{"naïve café", []string{"naïve", "café"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, w := range words(tt.text) {
+			got = append(got, tt.text[w[0]:w[1]])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("words(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTextWithPhonemes(t *testing.T) {
+	phonemes := map[string]string{"tomato": "t @0 m aa1 t ou0", "CereProc": "s e1 r @0 p r o0 k"}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"A tomato", `A <phoneme ph="t @0 m aa1 t ou0">tomato</phoneme>`},
+		{"TOMATO!", `<phoneme ph="t @0 m aa1 t ou0">TOMATO</phoneme>!`},
+		{"cereproc's voices", `<phoneme ph="s e1 r @0 p r o0 k">cereproc</phoneme>&#39;s voices`},
+		{"tomatoes", "tomatoes"},
+		{"don't <tomato> & more", `don&#39;t &lt;<phoneme ph="t @0 m aa1 t ou0">tomato</phoneme>&gt; &amp; more`},
+	}
+	for _, tt := range tests {
+		b := New().TextWithPhonemes(tt.text, phonemes)
+		if got := b.Fragment(); got != tt.want {
+			t.Errorf("TextWithPhonemes(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+		if err := b.Err(); err != nil {
+			t.Errorf("TextWithPhonemes(%q): %v", tt.text, err)
+		}
+	}
+}
+
+func TestMarkupWithPhonemes(t *testing.T) {
+	phonemes := map[string]string{"CereProc": "s e1 r @0 p r o0 k", "amp": "a1 m p"}
+	tests := []struct {
+		markup string
+		want   string
+	}{
+		{`Hello <break time="1s"/> CereProc`, `Hello <break time="1s"/> <phoneme ph="s e1 r @0 p r o0 k">CereProc</phoneme>`},
+		{`<emphasis>CereProc's</emphasis> &amp; co`, `<emphasis><phoneme ph="s e1 r @0 p r o0 k">CereProc</phoneme>'s</emphasis> &amp; co`},
+		{`<say-as interpret-as="characters">CereProc</say-as> CereProc`, `<say-as interpret-as="characters">CereProc</say-as> <phoneme ph="s e1 r @0 p r o0 k">CereProc</phoneme>`},
+		{`<phoneme ph="k">CereProc</phoneme>`, `<phoneme ph="k">CereProc</phoneme>`},
+	}
+	for _, tt := range tests {
+		b := New().MarkupWithPhonemes(tt.markup, phonemes)
+		if got := b.Fragment(); got != tt.want {
+			t.Errorf("MarkupWithPhonemes(%q) = %s, want %s", tt.markup, got, tt.want)
+		}
+		if err := b.Err(); err != nil {
+			t.Errorf("MarkupWithPhonemes(%q): %v", tt.markup, err)
+		}
+	}
+
+	b := New().Markup("a < b")
+	if b.Err() == nil || b.Fragment() != "a &lt; b" {
+		t.Errorf("Markup of malformed markup = %s, %v", b.Fragment(), b.Err())
+	}
+}
+
+func TestNodes(t *testing.T) {
+	tests := []struct {
+		markup string
+		want   []string
+		err    bool
+	}{
+		{"plain text", []string{"plain text"}, false},
+		{`Hello <break time="1s"/> world`, []string{"Hello ", `<break time="1s"/>`, " world"}, false},
+		{`A <emphasis>big <say-as interpret-as="digits">12</say-as></emphasis> dog.`, []string{"A ", `<emphasis>big <say-as interpret-as="digits">12</say-as></emphasis>`, " dog."}, false},
+		{`<p>One.</p><p>Two.</p>`, []string{"<p>One.</p>", "<p>Two.</p>"}, false},
+		{`<!-- note -->Hi &amp; bye`, []string{"<!-- note -->", "Hi &amp; bye"}, false},
+		{`<p>unclosed`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := Nodes(tt.markup)
+		if tt.err != (err != nil) {
+			t.Errorf("Nodes(%q) error = %v", tt.markup, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Nodes(%q) = %q, want %q", tt.markup, got, tt.want)
+		}
+	}
+}
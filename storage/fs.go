@@ -0,0 +1,65 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/internal/atomicfile"
+)
+
+// Dir stores objects as files under a directory, for example one served by
+// a web server
+type Dir struct {
+	Root    string // Directory objects are stored in, created if needed
+	BaseURL string // URL Root is served from, file URLs are used if empty
+}
+
+// NewDir returns a Dir storing objects under root and served from baseURL
+func NewDir(root, baseURL string) *Dir {
+	return &Dir{Root: root, BaseURL: baseURL}
+}
+
+// Put writes data to the file for key, replacing it atomically
+func (d *Dir) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(path, data, 0644)
+}
+
+// Get reads the file for key
+func (d *Dir) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(d.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+// URL returns BaseURL joined with key, or a file URL if BaseURL is empty
+func (d *Dir) URL(key string) string {
+	if d.BaseURL == "" {
+		abs, err := filepath.Abs(d.path(key))
+		if err != nil {
+			abs = d.path(key)
+		}
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	}
+
+	return strings.TrimSuffix(d.BaseURL, "/") + "/" + escapePath(key)
+}
+
+// path returns the file for key, which can not escape Root
+func (d *Dir) path(key string) string {
+	return filepath.Join(d.Root, filepath.FromSlash(filepath.Clean("/"+key)))
+}
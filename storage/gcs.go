@@ -0,0 +1,102 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// gcsAPI is the Google Cloud Storage JSON API URL
+const gcsAPI = "https://storage.googleapis.com"
+
+// GCS stores objects in a Google Cloud Storage bucket using the JSON API
+type GCS struct {
+	Bucket string
+
+	// Token returns an OAuth 2.0 access token with a storage scope, for
+	// example from an oauth2.TokenSource or the metadata server
+	Token func(ctx context.Context) (string, error)
+
+	// PublicURL is the URL objects are served from, e.g. a CDN.
+	// https://storage.googleapis.com/<bucket> is used if empty.
+	PublicURL string
+	// Endpoint overrides the API URL, e.g. for an emulator
+	Endpoint string
+
+	HTTPClient *http.Client // HTTP client, http.DefaultClient if nil
+}
+
+// NewGCS returns a GCS storing objects in bucket, authenticating with token
+func NewGCS(bucket string, token func(ctx context.Context) (string, error)) *GCS {
+	return &GCS{Bucket: bucket, Token: token}
+}
+
+// Put uploads data to the object for key
+func (g *GCS) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	u := g.endpoint() + "/upload/storage/v1/b/" + escape(g.Bucket) + "/o?uploadType=media&name=" + url.QueryEscape(key)
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	if err := g.authorize(ctx, req); err != nil {
+		return err
+	}
+
+	_, err = doRequest(g.HTTPClient, req.WithContext(ctx), "Put", key)
+	return err
+}
+
+// Get downloads the object for key
+func (g *GCS) Get(ctx context.Context, key string) ([]byte, error) {
+	u := g.endpoint() + "/storage/v1/b/" + escape(g.Bucket) + "/o/" + escape(key) + "?alt=media"
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return doRequest(g.HTTPClient, req.WithContext(ctx), "Get", key)
+}
+
+// URL returns the public URL of the object for key. The bucket or PublicURL
+// must allow anonymous reads for it to be fetchable.
+func (g *GCS) URL(key string) string {
+	if g.PublicURL != "" {
+		return strings.TrimSuffix(g.PublicURL, "/") + "/" + escapePath(key)
+	}
+
+	return gcsAPI + "/" + g.Bucket + "/" + escapePath(key)
+}
+
+func (g *GCS) endpoint() string {
+	if g.Endpoint != "" {
+		return strings.TrimSuffix(g.Endpoint, "/")
+	}
+
+	return gcsAPI
+}
+
+// authorize adds a bearer token to req, if Token is set
+func (g *GCS) authorize(ctx context.Context, req *http.Request) error {
+	if g.Token == nil {
+		return nil
+	}
+
+	token, err := g.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package storage persists synthesised audio so it can be served from a
+// durable URL rather than the temporary one returned by CereVoice. Backends
+// are provided for the local filesystem, Amazon S3 and Google Cloud Storage,
+// using only the standard library.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrNotFound is returned by Get when no object exists for the key
+var ErrNotFound = errors.New("storage: not found")
+
+// Storage stores objects by key. Keys are slash separated paths.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put stores data under key
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns the data stored under key, or ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// URL returns the URL the object stored under key is served from
+	URL(key string) string
+}
+
+// StatusError is returned when a storage service responds with an
+// unexpected HTTP status
+type StatusError struct {
+	Op         string // Put or Get
+	Key        string
+	StatusCode int
+	Body       string // Start of the response body
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("storage: %s %s: HTTP %d: %s", e.Op, e.Key, e.StatusCode, e.Body)
+}
+
+// doRequest sends req and returns the response body, mapping 404 to
+// ErrNotFound and other failures to a StatusError
+func doRequest(client *http.Client, req *http.Request, op, key string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		if len(body) > 256 {
+			body = body[:256]
+		}
+		return nil, &StatusError{Op: op, Key: key, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	return body, nil
+}
+
+// escapePath percent-encodes each segment of a slash separated key
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// escape percent-encodes everything except RFC 3986 unreserved characters
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
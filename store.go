@@ -0,0 +1,120 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bganderson/cerevoicego/storage"
+)
+
+// SpeakAndStore synthesises input and saves the audio, after any
+// AudioEffects, to s, returning its durable URL instead of CereVoice's
+// temporary one. Objects are keyed by the hash of the input, so text which
+// has already been stored is not synthesised again.
+func (c *Client) SpeakAndStore(input *SpeakExtendedInput, s storage.Storage) (string, error) {
+	return c.SpeakAndStoreWithContext(context.Background(), input, s)
+}
+
+// SpeakAndStoreWithContext is the same as SpeakAndStore with the addition of
+// the ability to pass a context for cancellation and timeouts
+func (c *Client) SpeakAndStoreWithContext(ctx context.Context, input *SpeakExtendedInput, s storage.Storage) (string, error) {
+	key := StorageKey(input)
+
+	_, err := s.Get(ctx, key)
+	if err == nil {
+		return s.URL(key), nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return "", err
+	}
+
+	audio, err := c.SpeakAudioWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Put(ctx, key, audio, input.AudioFormat.ContentType()); err != nil {
+		return "", err
+	}
+
+	return s.URL(key), nil
+}
+
+// StorageKey returns the content addressed key SpeakAndStore saves the audio
+// for input under: the CacheKey with the audio format as extension
+func StorageKey(input *SpeakExtendedInput) string {
+	format := input.AudioFormat
+	if format == "" {
+		format = FormatWAV
+	}
+
+	return CacheKey(input) + "." + string(format)
+}
+
+// StorageCache is a Cache keeping audio in a storage backend such as
+// storage.S3, so that servers can share a cache which outlives them. Entries
+// do not expire; use the bucket's lifecycle rules to remove old audio.
+// Failures to read or write the storage are cache misses.
+type StorageCache struct {
+	Storage storage.Storage
+	Prefix  string        // Prepended to keys, e.g. "cache/"
+	Timeout time.Duration // Limit on each storage request, DefaultTimeout if 0
+
+	stats cacheStats
+}
+
+// NewStorageCache returns a StorageCache keeping audio in s under prefix
+func NewStorageCache(s storage.Storage, prefix string) *StorageCache {
+	return &StorageCache{Storage: s, Prefix: prefix}
+}
+
+// Get returns the audio stored for key
+func (s *StorageCache) Get(key string) ([]byte, bool) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	audio, err := s.Storage.Get(ctx, s.Prefix+key)
+	if err != nil {
+		s.stats.miss()
+		return nil, false
+	}
+
+	s.stats.hit()
+	return audio, true
+}
+
+// Set stores audio for key
+func (s *StorageCache) Set(key string, audio []byte) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	s.Storage.Put(ctx, s.Prefix+key, audio, "")
+}
+
+// Stats returns the cache hit metrics
+func (s *StorageCache) Stats() CacheStats {
+	return s.stats.snapshot()
+}
+
+func (s *StorageCache) context() (context.Context, context.CancelFunc) {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// StoreIn returns a StoreFunc saving audio to s, for use with
+// SpeakAndPublish
+func StoreIn(s storage.Storage) StoreFunc {
+	return func(ctx context.Context, key string, audio []byte, contentType string) (string, error) {
+		if err := s.Put(ctx, key, audio, contentType); err != nil {
+			return "", err
+		}
+		return s.URL(key), nil
+	}
+}
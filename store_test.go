@@ -0,0 +1,28 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/storage"
+)
+
+func TestStorageCache(t *testing.T) {
+	cache := cerevoicego.NewStorageCache(storage.NewDir(t.TempDir(), ""), "cache/")
+
+	if _, ok := cache.Get("key"); ok {
+		t.Fatal("Get of an empty cache hit")
+	}
+	cache.Set("key", []byte("audio"))
+	if audio, ok := cache.Get("key"); !ok || !bytes.Equal(audio, []byte("audio")) {
+		t.Fatalf("Get = %q, %v", audio, ok)
+	}
+
+	if st := cache.Stats(); st.Hits != 1 || st.Misses != 1 {
+		t.Errorf("Stats = %+v, want 1 hit and 1 miss", st)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrStreamClosed is returned when writing to a Stream after Close
+var ErrStreamClosed = errors.New("cerevoicego: write to closed stream")
+
+// Stream incrementally synthesises text as it is written and makes the audio
+// available for reading as soon as each sentence has been synthesised.
+//
+// The CereVoice Cloud REST API has no streaming endpoint, so text is buffered
+// until a sentence boundary is seen and each sentence is synthesised and
+// downloaded in order. Every sentence produces a complete audio file; use a
+// headerless format such as "raw" or a frame based format such as "mp3" or
+// "ogg" when the output is to be played back as one continuous stream.
+type Stream struct {
+	client *Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   func() // ends the Client's tracking of the stream
+	input  SpeakExtendedInput
+
+	mu      sync.Mutex
+	pending strings.Builder
+	closed  bool
+	err     error // Why the stream could not be opened
+
+	text chan string
+	pr   *io.PipeReader
+	pw   *io.PipeWriter
+}
+
+// SpeakStream opens a Stream which synthesises text written to it using the
+// voice and audio settings from input. input.Text, if set, is written first.
+// After the Client is closed every method of the Stream fails with
+// ErrClientClosed.
+func (c *Client) SpeakStream(ctx context.Context, input *SpeakExtendedInput) *Stream {
+	input = c.profile(input)
+	pr, pw := io.Pipe()
+
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		pw.CloseWithError(err)
+		return &Stream{client: c, ctx: ctx, cancel: func() {}, done: done, closed: true, err: err, pr: pr, pw: pw}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	s := &Stream{
+		client: c,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   done,
+		input:  *input,
+		text:   make(chan string, 16),
+		pr:     pr,
+		pw:     pw,
+	}
+	s.input.Text = ""
+
+	go s.run()
+
+	if input.Text != "" {
+		s.WriteString(input.Text)
+	}
+
+	return s
+}
+
+// Write queues p for synthesis. Complete sentences are sent immediately, any
+// trailing partial sentence is held until more text arrives or Flush or Close
+// is called.
+func (s *Stream) Write(p []byte) (int, error) {
+	return s.WriteString(string(p))
+}
+
+// WriteString is like Write but accepts a string. If the stream fails part
+// way, the bytes of text in the sentences already queued are returned.
+func (s *Stream) WriteString(text string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.err != nil:
+		return 0, s.err
+	case s.closed:
+		return 0, ErrStreamClosed
+	}
+
+	held := s.pending.Len()
+	s.pending.WriteString(text)
+	all := s.pending.String()
+	sentences, rest := splitSentences(all)
+	s.pending.Reset()
+	s.pending.WriteString(rest)
+
+	// end is the offset in all of the end of the last sentence queued
+	end := 0
+	for _, sentence := range sentences {
+		if err := s.send(sentence); err != nil {
+			if end -= held; end < 0 {
+				end = 0
+			}
+			return end, err
+		}
+		end += strings.Index(all[end:], sentence) + len(sentence)
+	}
+
+	return len(text), nil
+}
+
+// Flush sends any buffered partial sentence for synthesis
+func (s *Stream) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.err != nil:
+		return s.err
+	case s.closed:
+		return ErrStreamClosed
+	}
+
+	return s.flush()
+}
+
+// Close flushes any buffered text and signals that no more text will be
+// written. Audio already queued remains readable until io.EOF.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return s.err
+	}
+
+	err := s.flush()
+	s.closed = true
+	close(s.text)
+
+	return err
+}
+
+// Read reads synthesised audio. It returns io.EOF once the stream has been
+// closed and all queued text has been synthesised, or the first error
+// encountered while synthesising.
+func (s *Stream) Read(p []byte) (int, error) {
+	return s.pr.Read(p)
+}
+
+// Cancel aborts the stream, discarding any queued text
+func (s *Stream) Cancel() {
+	s.cancel()
+}
+
+// flush sends the pending partial sentence, s.mu must be held
+func (s *Stream) flush() error {
+	text := strings.TrimSpace(s.pending.String())
+	s.pending.Reset()
+	if text == "" {
+		return nil
+	}
+
+	return s.send(text)
+}
+
+// send queues text for synthesis, s.mu must be held
+func (s *Stream) send(text string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case s.text <- text:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// run synthesises queued text in order and writes the audio to the pipe
+func (s *Stream) run() {
+	defer s.done()
+	defer s.cancel()
+
+	for {
+		select {
+		case text, ok := <-s.text:
+			if !ok {
+				s.pw.Close()
+				return
+			}
+			if err := s.speak(text); err != nil {
+				s.pw.CloseWithError(err)
+				return
+			}
+		case <-s.ctx.Done():
+			s.pw.CloseWithError(s.ctx.Err())
+			return
+		}
+	}
+}
+
+// speak synthesises a single piece of text and copies the audio to the pipe
+func (s *Stream) speak(text string) error {
+	input := s.input
+	input.Text = text
+
+	r, err := s.client.SpeakExtendedWithContext(s.ctx, &input)
+	if err != nil {
+		return err
+	}
+
+	body, err := r.Download(s.ctx)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	_, err = io.Copy(s.pw, body)
+	return err
+}
+
+// splitSentences splits text into complete sentences and the trailing
+// remainder which has not yet been terminated
+func splitSentences(text string) (sentences []string, rest string) {
+	start := 0
+	runes := []rune(text)
+
+	for i, r := range runes {
+		boundary := r == '\n'
+		if (r == '.' || r == '!' || r == '?') && i+1 < len(runes) && unicode.IsSpace(runes[i+1]) {
+			boundary = true
+		}
+		if !boundary {
+			continue
+		}
+
+		if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = i + 1
+	}
+
+	return sentences, string(runes[start:])
+}
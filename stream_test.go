@@ -0,0 +1,91 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestSpeakStreamAfterClose(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s := c.SpeakStream(context.Background(), &cerevoicego.SpeakExtendedInput{Voice: "Heather", AudioFormat: cerevoicego.FormatRaw})
+	if _, err := s.WriteString("Hello. "); !errors.Is(err, cerevoicego.ErrClientClosed) {
+		t.Errorf("WriteString = %v, want ErrClientClosed", err)
+	}
+	if err := s.Flush(); !errors.Is(err, cerevoicego.ErrClientClosed) {
+		t.Errorf("Flush = %v, want ErrClientClosed", err)
+	}
+	if _, err := ioutil.ReadAll(s); !errors.Is(err, cerevoicego.ErrClientClosed) {
+		t.Errorf("Read = %v, want ErrClientClosed", err)
+	}
+	if err := s.Close(); !errors.Is(err, cerevoicego.ErrClientClosed) {
+		t.Errorf("Close = %v, want ErrClientClosed", err)
+	}
+}
+
+// blockClient holds every request until its context ends, signalling the
+// first
+type blockClient struct {
+	arrived chan struct{}
+	once    bool
+}
+
+func (b *blockClient) Do(req *http.Request) (*http.Response, error) {
+	if !b.once {
+		b.once = true
+		close(b.arrived)
+	}
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestStreamPartialWrite(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	block := &blockClient{arrived: make(chan struct{})}
+	c := srv.Client()
+	c.Apply(cerevoicego.WithHTTPClient(block))
+
+	var sentences []string
+	for i := 0; i < 40; i++ {
+		sentences = append(sentences, fmt.Sprintf("Sentence %d.", i))
+	}
+	text := strings.Join(sentences, " ") + " "
+
+	s := c.SpeakStream(context.Background(), &cerevoicego.SpeakExtendedInput{Voice: "Heather", AudioFormat: cerevoicego.FormatRaw})
+	type result struct {
+		n   int
+		err error
+	}
+	written := make(chan result)
+	go func() {
+		n, err := s.WriteString(text)
+		written <- result{n, err}
+	}()
+	<-block.arrived
+	s.Cancel()
+
+	r := <-written
+	if !errors.Is(r.err, context.Canceled) {
+		t.Fatalf("WriteString error = %v, want context.Canceled", r.err)
+	}
+	if r.n <= 0 || r.n >= len(text) || !strings.HasSuffix(text[:r.n], ".") {
+		t.Errorf("WriteString = %d of %d bytes, want the whole sentences queued", r.n, len(text))
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import "context"
+
+// Synthesizer turns text into audio. *Client implements it with the Cloud
+// API and local.Engine with the CereVoice SDK on the device, so code written
+// against it can switch between them without changing call sites.
+type Synthesizer interface {
+	// Synthesize returns the audio for input
+	Synthesize(ctx context.Context, input *SpeakExtendedInput) ([]byte, error)
+	// Voices returns the voices available
+	Voices(ctx context.Context) (VoiceCatalog, error)
+}
+
+var _ Synthesizer = (*Client)(nil)
+
+// Synthesize is the same as SpeakAudioWithContext
+func (c *Client) Synthesize(ctx context.Context, input *SpeakExtendedInput) ([]byte, error) {
+	return c.SpeakAudioWithContext(ctx, input)
+}
+
+// Voices returns every voice offered by the API
+func (c *Client) Voices(ctx context.Context) (VoiceCatalog, error) {
+	r, err := c.ListVoicesWithContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Catalog(), nil
+}
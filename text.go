@@ -0,0 +1,41 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import "context"
+
+// TextStep transforms text before it is synthesised, for example to expand
+// numbers or strip markup. The normalize package contains common steps.
+type TextStep func(text string) string
+
+// WithTextSteps sets the steps applied, in order, to the text of every
+// speak request
+func WithTextSteps(steps ...TextStep) ClientOption {
+	return func(c *Client) {
+		c.TextSteps = steps
+	}
+}
+
+type textStepsKey struct{}
+
+// WithRequestTextSteps returns a context which replaces the Client
+// TextSteps for requests made with it. No steps disables preprocessing.
+func WithRequestTextSteps(ctx context.Context, steps ...TextStep) context.Context {
+	return context.WithValue(ctx, textStepsKey{}, steps)
+}
+
+// PreprocessText returns text as it would be sent by a speak request made
+// with ctx, after the TextSteps have been applied
+func (c *Client) PreprocessText(ctx context.Context, text string) string {
+	steps := c.TextSteps
+	if s, ok := ctx.Value(textStepsKey{}).([]TextStep); ok {
+		steps = s
+	}
+
+	for _, step := range steps {
+		text = step(text)
+	}
+
+	return text
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LengthPolicy decides what happens to speak requests whose text exceeds the
+// Client MaxTextLength
+type LengthPolicy int
+
+// Length policies
+const (
+	// LengthReject fails the request with a *ValidationError
+	LengthReject LengthPolicy = iota
+	// LengthTruncate cuts the text at the last word which fits, once
+	// TextSteps and SpeakMode markup have been applied. It suits plain text,
+	// as markup in the text cut part way is rejected.
+	LengthTruncate
+	// LengthSplit synthesises the text in several requests and joins the
+	// audio, as SpeakLong does, for SpeakAudio, SpeakTo and SpeakToFile in
+	// wav or raw. Other requests are rejected, as their audio is a single
+	// URL.
+	LengthSplit
+)
+
+// WithMaxTextLength limits the characters of text sent in one speak request
+// to n, DefaultMaxTextLength if 0, applying policy to longer text
+func WithMaxTextLength(n int, policy LengthPolicy) ClientOption {
+	return func(c *Client) {
+		c.MaxTextLength = n
+		c.LengthPolicy = policy
+	}
+}
+
+// maxTextLength returns the text length limit
+func (c *Client) maxTextLength() int {
+	if c.MaxTextLength > 0 {
+		return c.MaxTextLength
+	}
+
+	return DefaultMaxTextLength
+}
+
+// prepareText applies the TextSteps, SpeakMode markup and TextAuditor to
+// the text of req, a speak request for op. The text limit is applied to the
+// text as it will be sent: under LengthTruncate the prepared text is cut
+// until its markup fits, and text which is still too long, or whose markup
+// is malformed, is a *ValidationError.
+func (c *Client) prepareText(ctx context.Context, op operation, req *Request) error {
+	var mode SpeakMode
+	if s, ok := op.(speakOperation); ok {
+		mode = s.speakMode()
+	}
+
+	text := c.PreprocessText(ctx, req.Text)
+	req.Text = mode.Markup(text)
+
+	max := c.maxTextLength()
+	if c.LengthPolicy == LengthTruncate {
+		n := utf8.RuneCountInString(text)
+		for over := utf8.RuneCountInString(req.Text) - max; over > 0 && n > 0; over = utf8.RuneCountInString(req.Text) - max {
+			if n -= over; n < 0 {
+				n = 0
+			}
+			req.Text = mode.Markup(truncateText(text, n))
+		}
+	}
+
+	if c.TextAuditor != nil {
+		if err := c.auditText(ctx, req); err != nil {
+			return err
+		}
+	}
+
+	v := validator{maxText: max}
+	v.text(req.Text)
+	if len(v.fields) > 0 {
+		return &ValidationError{Operation: op.name(), Fields: v.fields}
+	}
+
+	return nil
+}
+
+// splitLong reports whether input should be synthesised in several requests
+// under LengthSplit
+func (c *Client) splitLong(input *SpeakExtendedInput) bool {
+	if c.LengthPolicy != LengthSplit || utf8.RuneCountInString(input.Text) <= c.maxTextLength() {
+		return false
+	}
+
+	return input.AudioFormat == "" || input.AudioFormat == FormatWAV || input.AudioFormat == FormatRaw
+}
+
+// speakSplit synthesises input in chunks within the limit and returns the
+// joined audio
+func (c *Client) speakSplit(ctx context.Context, input *SpeakExtendedInput) (*SpeakLongResponse, error) {
+	return c.SpeakLongWithContext(ctx, &SpeakLongInput{
+		SpeakExtendedInput: *input,
+		ChunkLength:        c.maxTextLength(),
+	})
+}
+
+// fitChunks splits the text of input into chunks of at most max characters
+// once the TextSteps and SpeakMode markup are applied, as the limit applies
+// to the text sent. Chunks which preparation takes over max are split again
+// into shorter ones.
+func (c *Client) fitChunks(ctx context.Context, input *SpeakExtendedInput, max int) ([]string, error) {
+	mode := c.voiceMode(input, input.Voice)
+
+	var fit func(text string, limit int) ([]string, error)
+	fit = func(text string, limit int) ([]string, error) {
+		split, err := splitChunks(text, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		var chunks []string
+		for _, chunk := range split {
+			n := utf8.RuneCountInString(chunk)
+			over := utf8.RuneCountInString(mode.Markup(c.PreprocessText(ctx, chunk))) - max
+			if over <= 0 || n-over < 1 {
+				// Chunks whose markup alone is over the limit are left
+				// for prepareText to reject
+				chunks = append(chunks, chunk)
+				continue
+			}
+			fitted, err := fit(chunk, n-over)
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, fitted...)
+		}
+		return chunks, nil
+	}
+
+	return fit(input.Text, max)
+}
+
+// truncateText returns text cut to at most n characters, at the end of the
+// last whole word if there is one
+func truncateText(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := n
+	if !unicode.IsSpace(runes[n]) {
+		for i := n - 1; i > 0; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+	}
+
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace)
+}
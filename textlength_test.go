@@ -0,0 +1,124 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/normalize"
+)
+
+func TestTextLengthAfterPreparation(t *testing.T) {
+	double := func(text string) string { return text + " " + text }
+	transform := cerevoicego.TextAuditorFunc(func(ctx context.Context, audit *cerevoicego.TextAudit) cerevoicego.TextDecision {
+		return cerevoicego.TextDecision{Action: cerevoicego.TextTransform, Text: strings.Repeat(audit.Text, 3)}
+	})
+
+	tests := []struct {
+		name  string
+		text  string
+		opts  []cerevoicego.ClientOption
+		mode  cerevoicego.SpeakMode
+		valid bool
+	}{
+		{"steps lengthen", strings.Repeat("a", 30), []cerevoicego.ClientOption{cerevoicego.WithTextSteps(double)}, cerevoicego.SpeakMode{}, false},
+		{"steps shorten", "a" + strings.Repeat(" ", 60) + "b", []cerevoicego.ClientOption{cerevoicego.WithTextSteps(normalize.CollapseWhitespace)}, cerevoicego.SpeakMode{}, true},
+		{"markup lengthens", strings.Repeat("a", 40), nil, cerevoicego.SpeakMode{Spell: true}, false},
+		{"auditor lengthens", strings.Repeat("a", 30), []cerevoicego.ClientOption{cerevoicego.WithTextAuditor(transform)}, cerevoicego.SpeakMode{}, false},
+		{"steps emptied", "🙂", []cerevoicego.ClientOption{cerevoicego.WithTextSteps(normalize.StripEmoji)}, cerevoicego.SpeakMode{}, false},
+	}
+
+	for _, tt := range tests {
+		srv := cerevoicetest.NewServer()
+		c := srv.Client()
+		c.Apply(cerevoicego.WithMaxTextLength(50, cerevoicego.LengthReject))
+		c.Apply(tt.opts...)
+
+		_, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{Voice: "Heather", Text: tt.text, SpeakMode: tt.mode})
+		if tt.valid && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, cerevoicego.ErrValidation) {
+			t.Errorf("%s: error = %v, want ErrValidation", tt.name, err)
+		}
+		if n := len(srv.Requests()); tt.valid != (n == 1) {
+			t.Errorf("%s: %d requests sent", tt.name, n)
+		}
+		srv.Close()
+	}
+}
+
+func TestLengthTruncateMarkup(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	c.Apply(
+		cerevoicego.WithMaxTextLength(60, cerevoicego.LengthTruncate),
+		cerevoicego.WithTextSteps(normalize.ExpandNumbers),
+	)
+
+	_, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{
+		Voice:     "Heather",
+		Text:      "Call 1 2 3 4 5 6 7 8 9 now",
+		SpeakMode: cerevoicego.SpeakMode{Variant: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := srv.Requests()[0].Text
+	if n := utf8.RuneCountInString(sent); n > 60 {
+		t.Errorf("sent %d characters, over the limit of 60: %q", n, sent)
+	}
+	if !strings.Contains(sent, "<usel") || !strings.HasSuffix(sent, "</usel></speak>") || !strings.Contains(sent, "Call one") {
+		t.Errorf("sent %q, want the expanded text cut within its markup", sent)
+	}
+}
+
+func TestLengthSplitMarkup(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	c.Apply(cerevoicego.WithMaxTextLength(60, cerevoicego.LengthSplit))
+
+	text := "The quick brown fox jumps over the lazy dog. Then it runs away into the woods and hides."
+	_, err := c.SpeakAudio(&cerevoicego.SpeakExtendedInput{
+		Voice:     "Heather",
+		Text:      text,
+		SpeakMode: cerevoicego.SpeakMode{Variant: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var words []string
+	for _, req := range srv.Requests() {
+		if req.XMLName.Local != "speakExtended" {
+			continue
+		}
+		if n := utf8.RuneCountInString(req.Text); n > 60 {
+			t.Errorf("sent %d characters, over the limit of 60: %q", n, req.Text)
+		}
+		if !strings.Contains(req.Text, "<usel") {
+			t.Errorf("sent %q, want the variant markup", req.Text)
+		}
+		content := strings.TrimSuffix(strings.TrimPrefix(req.Text, `<speak><usel variant="2">`), "</usel></speak>")
+		words = append(words, strings.Fields(content)...)
+	}
+	// Chunks are sent concurrently, so only the words are compared
+	want := strings.Fields(text)
+	sort.Strings(words)
+	sort.Strings(want)
+	if !reflect.DeepEqual(words, want) {
+		t.Errorf("sent words %q, want %q", words, want)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Timeouts sets deadlines for individual operations, each covering every
+// attempt of a request including retries. A zero duration leaves that
+// operation to the deadline of its context.
+//
+// When any timeout is set and the Client has no HTTPClient, the overall
+// DefaultTimeout of the default HTTP client no longer applies, so that
+// downloads may run longer than it. Operations without a timeout of their
+// own then use DefaultTimeout instead.
+type Timeouts struct {
+	Speak    time.Duration // speakSimple and speakExtended requests
+	Query    time.Duration // listVoices, listAudioFormats, getCredit and other list requests
+	Upload   time.Duration // Uploading and deleting lexicons and abbreviations
+	Download time.Duration // Downloading audio, metadata, lexicon and abbreviation files
+	Budget   time.Duration // Overall time for composite operations such as SpeakToFile and SpeakAudio
+
+	Operations map[string]time.Duration // Per operation overrides, keyed by API operation name
+}
+
+// WithTimeouts sets per operation deadlines
+func WithTimeouts(t Timeouts) ClientOption {
+	return func(c *Client) {
+		c.Timeouts = t
+	}
+}
+
+// isZero reports whether no timeout is set
+func (t Timeouts) isZero() bool {
+	return t.Speak == 0 && t.Query == 0 && t.Upload == 0 && t.Download == 0 &&
+		t.Budget == 0 && len(t.Operations) == 0
+}
+
+// operation returns the timeout for an API operation
+func (t Timeouts) operation(op string) time.Duration {
+	if d, ok := t.Operations[op]; ok {
+		return d
+	}
+
+	switch op {
+	case "speakSimple", "speakExtended":
+		return t.Speak
+	case "uploadLexicon", "uploadAbbreviations", "deleteLexicon", "deleteAbbreviations":
+		return t.Upload
+	}
+
+	return t.Query
+}
+
+// untimedHTTPClient is the default HTTP client without its overall timeout,
+// used when a Client sets Timeouts. It shares the default transport.
+var untimedHTTPClient = &http.Client{Transport: defaultHTTPClient.Transport}
+
+// withTimeout returns ctx limited to d, or ctx unchanged when d is 0. When
+// the default HTTP client has been replaced by untimedHTTPClient, 0 means
+// DefaultTimeout.
+func (c *Client) withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d == 0 && c.HTTPClient == nil && !c.Timeouts.isZero() {
+		d = DefaultTimeout
+	}
+	if d <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, d)
+}
+
+// operationContext limits ctx to the timeout for an API operation
+func (c *Client) operationContext(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	return c.withTimeout(ctx, c.Timeouts.operation(op))
+}
+
+// budget limits ctx to the Budget for a composite operation. Only the Budget
+// applies, so without one each phase is limited by its own timeout alone.
+func (c *Client) budget(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeouts.Budget <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, c.Timeouts.Budget)
+}
+
+// timeoutClient limits each request to a download timeout, lasting until the
+// response body is closed
+type timeoutClient struct {
+	client *Client
+	next   HTTPClient
+}
+
+func (t *timeoutClient) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := t.client.withTimeout(req.Context(), t.client.Timeouts.Download)
+
+	resp, err := t.next.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelBody releases the context of a response when its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// WithTLSConfig sets the TLS configuration for connections to the API and
+// audio downloads, such as for an on-premises installation. Like the options
+// below it has no effect on a custom HTTPClient, whose transport should be
+// configured instead.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.TLSConfig = config
+	}
+}
+
+// WithRootCAs trusts the certificate authorities in pool instead of the
+// system ones, e.g. a private corporate CA
+func WithRootCAs(pool *x509.CertPool) ClientOption {
+	return func(c *Client) {
+		c.TLSConfig = c.cloneTLSConfig()
+		c.TLSConfig.RootCAs = pool
+	}
+}
+
+// WithClientCertificate presents cert to servers requiring mutual TLS. Load
+// it with tls.LoadX509KeyPair.
+func WithClientCertificate(cert tls.Certificate) ClientOption {
+	return func(c *Client) {
+		c.TLSConfig = c.cloneTLSConfig()
+		c.TLSConfig.Certificates = append(c.TLSConfig.Certificates, cert)
+	}
+}
+
+// LoadRootCAs returns a pool of the PEM encoded certificates in the files
+// at paths, for WithRootCAs
+func LoadRootCAs(paths ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, path := range paths {
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("cerevoicego: no certificates found in %s", path)
+		}
+	}
+
+	return pool, nil
+}
+
+// cloneTLSConfig returns a copy of the TLSConfig to modify, so one passed to
+// WithTLSConfig or shared with a Clone is left unchanged
+func (c *Client) cloneTLSConfig() *tls.Config {
+	if c.TLSConfig == nil {
+		return &tls.Config{}
+	}
+
+	return c.TLSConfig.Clone()
+}
@@ -0,0 +1,277 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultTokenSkew is how long before it expires a TokenProvider replaces a
+// token, when its Skew is 0
+const DefaultTokenSkew = 30 * time.Second
+
+// ErrInvalidToken is returned when a TokenSource issues a token without a
+// value or account
+var ErrInvalidToken = errors.New("cerevoicego: invalid token")
+
+// Token is a temporary credential issued in exchange for the account
+// password, such as a session token from the API or an authenticating proxy
+type Token struct {
+	AccountID string    `json:"accountID"`
+	Value     string    `json:"token"`              // Sent in place of the password
+	Expiry    time.Time `json:"expiry,omitempty"`   // When the token expires, by the issuer's clock, zero if it does not
+	IssuedAt  time.Time `json:"issuedAt,omitempty"` // When the token was issued, by the issuer's clock, zero if unknown
+}
+
+// TokenSource issues tokens, e.g. from a token endpoint or a secret store.
+// Implementations must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc adapts a function to the TokenSource interface
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token calls f(ctx)
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// HTTPTokenSource is a TokenSource which requests tokens from the token
+// endpoint of a cerevoiced or cerevoice-proxy server, authenticating with
+// one of its API keys
+type HTTPTokenSource struct {
+	URL        string     // Token endpoint, e.g. "https://proxy.example.com/token"
+	APIKey     string     // Sent as "Authorization: Bearer <key>"
+	HTTPClient HTTPClient // HTTP client, the package default if nil
+}
+
+// Token requests a token from the endpoint
+func (s *HTTPTokenSource) Token(ctx context.Context) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	req.Header.Set("User-Agent", DefaultUserAgent)
+
+	client := s.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%w: token request: %s", ErrAuth, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("cerevoicego: token request: %s", resp.Status)
+	}
+
+	var t Token
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&t); err != nil {
+		return nil, fmt.Errorf("cerevoicego: token request: %w", err)
+	}
+
+	return &t, nil
+}
+
+// TokenProvider is a CredentialsProvider which sends tokens from Source in
+// place of the password, so a long-running service need not hold the
+// password. A token is reused until Skew before it expires, then replaced
+// by the first request to need one, which the others wait for without
+// holding up requests which have a token. Expiry is measured from IssuedAt
+// when the token has one, so the clocks of the issuer and the Client need
+// not agree. A token rejected by the API is replaced on the next request. It
+// is safe for concurrent use.
+type TokenProvider struct {
+	Source TokenSource
+	Skew   time.Duration // Margin before expiry, DefaultTokenSkew if 0
+
+	mu      sync.Mutex
+	token   *Token
+	expires time.Time   // expiry of token by the local clock, zero if none
+	fetch   *tokenFetch // fetch of a new token in progress, if any
+}
+
+// tokenFetch is a request for a token shared by the callers needing one
+type tokenFetch struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// WithTokens sets the credentials of every request from tokens issued by
+// source, in place of AccountID and Password
+func WithTokens(source TokenSource) ClientOption {
+	return func(c *Client) {
+		c.Credentials = &TokenProvider{Source: source}
+	}
+}
+
+// Credentials returns the account of the current token, and the token as
+// Credentials.Token, fetching a new one if it has expired or is about to.
+// The Source is called without holding the provider's lock, and a caller
+// waiting on a fetch cancelled by the caller which made it makes its own.
+func (p *TokenProvider) Credentials(ctx context.Context) (Credentials, error) {
+	for {
+		p.mu.Lock()
+		if p.token != nil && !p.expiring() {
+			t := p.token
+			p.mu.Unlock()
+			return tokenCredentials(t), nil
+		}
+
+		f := p.fetch
+		if f == nil {
+			f = &tokenFetch{done: make(chan struct{})}
+			p.fetch = f
+			p.mu.Unlock()
+
+			fetched := time.Now()
+			t, err := p.Source.Token(ctx)
+			if err == nil && (t == nil || t.Value == "" || t.AccountID == "") {
+				err = ErrInvalidToken
+			}
+
+			p.mu.Lock()
+			if err == nil {
+				p.token, p.expires = t, localExpiry(t, fetched)
+			}
+			p.fetch = nil
+			p.mu.Unlock()
+			f.token, f.err = t, err
+			close(f.done)
+
+			if err != nil {
+				return Credentials{}, err
+			}
+			return tokenCredentials(t), nil
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return Credentials{}, ctx.Err()
+		}
+		switch {
+		case f.err == nil:
+			return tokenCredentials(f.token), nil
+		case ctx.Err() == nil &&
+			(errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)):
+			continue
+		}
+
+		return Credentials{}, f.err
+	}
+}
+
+func tokenCredentials(t *Token) Credentials {
+	return Credentials{AccountID: t.AccountID, Token: t.Value}
+}
+
+// Expire discards the current token, so the next request fetches another
+func (p *TokenProvider) Expire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.token = nil
+}
+
+// expireToken discards the current token if it is value, so a token
+// rejected after it was already replaced does not discard its replacement
+func (p *TokenProvider) expireToken(value string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != nil && p.token.Value == value {
+		p.token = nil
+	}
+}
+
+// expiring reports whether the token expires within the skew
+func (p *TokenProvider) expiring() bool {
+	if p.expires.IsZero() {
+		return false
+	}
+
+	skew := p.Skew
+	if skew <= 0 {
+		skew = DefaultTokenSkew
+	}
+
+	return !time.Now().Add(skew).Before(p.expires)
+}
+
+// localExpiry returns when t expires by the local clock, given it was
+// requested at fetched. With IssuedAt the lifetime is added to fetched,
+// which errs early by the time taken to issue the token.
+func localExpiry(t *Token, fetched time.Time) time.Time {
+	switch {
+	case t.Expiry.IsZero():
+		return time.Time{}
+	case t.IssuedAt.IsZero():
+		return t.Expiry
+	}
+
+	return fetched.Add(t.Expiry.Sub(t.IssuedAt))
+}
+
+// expirer is a CredentialsProvider which can discard credentials rejected
+// by the API
+type expirer interface {
+	Expire()
+}
+
+// tokenExpirer is a CredentialsProvider which can discard a token rejected
+// by the API only while it is still the current one
+type tokenExpirer interface {
+	expireToken(value string)
+}
+
+// expireCredentials discards the provider credentials used for req, which
+// failed authentication, unless it used those of the context
+func (c *Client) expireCredentials(ctx context.Context, req *Request) {
+	if _, ok := requestCredentials(ctx); ok {
+		return
+	}
+	switch e := c.Credentials.(type) {
+	case tokenExpirer:
+		e.expireToken(req.Token)
+	case expirer:
+		e.Expire()
+	}
+}
+
+// Relay sends req, an API request from a Client using tokens issued by the
+// caller, with the credentials of c in place of the token, and returns the
+// response undecoded. The caller must have checked the token. It is for
+// servers, such as cerevoice-proxy, which issue tokens so that their
+// clients need not hold the password.
+func (c *Client) Relay(ctx context.Context, req *Request) (*Response, error) {
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	relayed := *req
+	if err := c.authenticate(ctx, &relayed); err != nil {
+		return nil, err
+	}
+
+	return c.queryAPI(ctx, &relayed)
+}
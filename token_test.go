@@ -0,0 +1,232 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+	"github.com/bganderson/cerevoicego/internal/apikey"
+)
+
+// tokenSource issues the tokens t1, t2... holding each until released, if
+// block is set
+type tokenSource struct {
+	block chan struct{}
+
+	mu     sync.Mutex
+	issued int
+}
+
+func (s *tokenSource) Token(ctx context.Context) (*cerevoicego.Token, error) {
+	s.mu.Lock()
+	s.issued++
+	n := s.issued
+	s.mu.Unlock()
+
+	if s.block != nil {
+		select {
+		case <-s.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &cerevoicego.Token{AccountID: "test", Value: "t" + strconv.Itoa(n)}, nil
+}
+
+func (s *tokenSource) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issued
+}
+
+func TestTokenProviderSharesFetch(t *testing.T) {
+	source := &tokenSource{block: make(chan struct{})}
+	p := &cerevoicego.TokenProvider{Source: source}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			creds, err := p.Credentials(context.Background())
+			if err != nil || creds.Token != "t1" {
+				t.Errorf("Credentials = %+v, %v", creds, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// The provider is not locked while the source is called
+	expired := make(chan struct{})
+	go func() {
+		p.Expire()
+		close(expired)
+	}()
+	select {
+	case <-expired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expire blocked by the token fetch")
+	}
+
+	close(source.block)
+	wg.Wait()
+	if n := source.count(); n != 1 {
+		t.Errorf("%d tokens fetched, want 1", n)
+	}
+}
+
+func TestTokenProviderFollowerRetriesCancelledFetch(t *testing.T) {
+	source := &tokenSource{block: make(chan struct{})}
+	p := &cerevoicego.TokenProvider{Source: source}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := p.Credentials(ctx)
+		leader <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	follower := make(chan error, 1)
+	go func() {
+		_, err := p.Credentials(context.Background())
+		follower <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader = %v, want context.Canceled", err)
+	}
+	close(source.block)
+	if err := <-follower; err != nil {
+		t.Errorf("follower = %v, want its own fetch to succeed", err)
+	}
+}
+
+// rejectFirst answers the first API request with invalid credentials once
+// released, and passes the others on
+type rejectFirst struct {
+	next    cerevoicego.HTTPClient
+	arrived chan struct{}
+	release chan struct{}
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (r *rejectFirst) Do(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.calls++
+	first := r.calls == 1
+	r.mu.Unlock()
+	if !first {
+		return r.next.Do(req)
+	}
+
+	close(r.arrived)
+	<-r.release
+	body := `<?xml version="1.0" encoding="UTF-8"?><getCreditResponse><resultCode>-1</resultCode>` +
+		`<resultDescription>Invalid credentials</resultDescription></getCreditResponse>`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": {"text/xml"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestTokenExpiresOnlyRejected(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	source := &tokenSource{}
+	reject := &rejectFirst{next: srv.Server.Client(), arrived: make(chan struct{}), release: make(chan struct{})}
+	p := &cerevoicego.TokenProvider{Source: source}
+	c := srv.Client()
+	c.Apply(cerevoicego.WithHTTPClient(reject), cerevoicego.WithCredentials(p))
+
+	// t1 is rejected after it was replaced by t2
+	rejected := make(chan error, 1)
+	go func() {
+		_, err := c.GetCredit()
+		rejected <- err
+	}()
+	<-reject.arrived
+	p.Expire()
+	if _, err := c.GetCredit(); err != nil {
+		t.Fatal(err)
+	}
+	close(reject.release)
+	if err := <-rejected; !errors.Is(err, cerevoicego.ErrAuth) {
+		t.Fatalf("rejected request = %v, want ErrAuth", err)
+	}
+
+	if _, err := c.GetCredit(); err != nil {
+		t.Fatal(err)
+	}
+	if n := source.count(); n != 2 {
+		t.Errorf("%d tokens fetched, want 2", n)
+	}
+	reqs := srv.Requests()
+	if got := reqs[len(reqs)-1].Token; got != "t2" {
+		t.Errorf("token %q sent, want t2 kept", got)
+	}
+}
+
+func TestRelay(t *testing.T) {
+	srv := cerevoicetest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	resp, err := c.Relay(context.Background(), &cerevoicego.Request{
+		XMLName:   xml.Name{Local: "getCredit"},
+		AccountID: "caller",
+		Token:     "issued",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(resp.Raw), "getCreditResponse") {
+		t.Errorf("Relay = %d %s", resp.StatusCode, resp.Raw)
+	}
+
+	req := srv.Requests()[0]
+	if req.AccountID != "test" || req.Password != "test" || req.Token != "" {
+		t.Errorf("relayed with %q, %q, token %q, want the Client's credentials", req.AccountID, req.Password, req.Token)
+	}
+}
+
+func TestHTTPTokenSource(t *testing.T) {
+	issuer, err := apikey.NewIssuer(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(apikey.TokenHandler(apikey.Set{"key"}, issuer, "account"))
+	defer ts.Close()
+
+	source := &cerevoicego.HTTPTokenSource{URL: ts.URL, APIKey: "key"}
+	token, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccountID != "account" || !issuer.Valid(token.Value) || token.Expiry.Sub(token.IssuedAt) != time.Minute {
+		t.Errorf("Token = %+v", token)
+	}
+
+	source.APIKey = token.Value
+	if _, err := source.Token(context.Background()); !errors.Is(err, cerevoicego.ErrAuth) {
+		t.Errorf("token exchanged for a token: %v, want ErrAuth", err)
+	}
+}
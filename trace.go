@@ -0,0 +1,26 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import "context"
+
+// Tracer instruments API requests, typically by starting a span and
+// recording latency and character metrics. It allows integration with any
+// tracing system without this package depending on it; the otelcerevoice
+// module implements it with OpenTelemetry. Implementations must be safe for
+// concurrent use.
+type Tracer interface {
+	// StartRequest is called before an API request is sent, including any
+	// retries. The returned context is used for the request and finish is
+	// called with the completed RequestLog.
+	StartRequest(ctx context.Context, operation string) (_ context.Context, finish func(*RequestLog))
+}
+
+// TracerFunc adapts a function to the Tracer interface
+type TracerFunc func(ctx context.Context, operation string) (context.Context, func(*RequestLog))
+
+// StartRequest calls f(ctx, operation)
+func (f TracerFunc) StartRequest(ctx context.Context, operation string) (context.Context, func(*RequestLog)) {
+	return f(ctx, operation)
+}
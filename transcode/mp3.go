@@ -0,0 +1,177 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build lame
+
+package transcode
+
+/*
+#cgo LDFLAGS: -lmp3lame
+#include <stdlib.h>
+#include <lame/lame.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// mp3Chunk is the samples per channel passed to the encoder at a time
+const mp3Chunk = 8192
+
+// mp3FrameSamples is the most samples per channel decoded from one frame
+const mp3FrameSamples = 1152
+
+// MP3 encodes a WAV file as constant bitrate MP3, resampling and remixing it
+// as opts requests
+func MP3(wav []byte, opts *MP3Options) ([]byte, error) {
+	var o MP3Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Bitrate <= 0 {
+		o.Bitrate = DefaultMP3Bitrate
+	}
+	if o.Channels > 2 {
+		return nil, errors.New("transcode: MP3 supports 1 or 2 channels")
+	}
+
+	w, pcm, err := decodePCM(wav, o.SampleRate, o.Channels)
+	if err != nil {
+		return nil, err
+	}
+	channels := w.Format.Channels
+	if channels > 2 {
+		return nil, errors.New("transcode: MP3 supports 1 or 2 channels")
+	}
+
+	gfp := C.lame_init()
+	if gfp == nil {
+		return nil, errors.New("transcode: lame: out of memory")
+	}
+	defer C.lame_close(gfp)
+
+	mode := C.JOINT_STEREO
+	if channels == 1 {
+		mode = C.MONO
+	}
+	C.lame_set_num_channels(gfp, C.int(channels))
+	C.lame_set_in_samplerate(gfp, C.int(w.Format.SampleRate))
+	C.lame_set_out_samplerate(gfp, C.int(w.Format.SampleRate))
+	C.lame_set_brate(gfp, C.int(o.Bitrate/1000))
+	C.lame_set_mode(gfp, C.MPEG_mode(mode))
+	C.lame_set_VBR(gfp, C.vbr_off)
+	C.lame_set_bWriteVbrTag(gfp, 0)
+	if C.lame_init_params(gfp) < 0 {
+		return nil, errors.New("transcode: lame: unsupported bitrate or sample rate")
+	}
+
+	var out []byte
+	buf := make([]byte, mp3Chunk*5/4+7200)
+	for start := 0; start < len(pcm); start += mp3Chunk * channels {
+		frame := pcm[start:]
+		if len(frame) > mp3Chunk*channels {
+			frame = frame[:mp3Chunk*channels]
+		}
+		samples := C.int(len(frame) / channels)
+		if samples == 0 {
+			break
+		}
+
+		var n C.int
+		if channels == 1 {
+			n = C.lame_encode_buffer(gfp, (*C.short)(unsafe.Pointer(&frame[0])), nil, samples,
+				(*C.uchar)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+		} else {
+			n = C.lame_encode_buffer_interleaved(gfp, (*C.short)(unsafe.Pointer(&frame[0])), samples,
+				(*C.uchar)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+		}
+		if n < 0 {
+			return nil, lameError(n)
+		}
+		out = append(out, buf[:n]...)
+	}
+
+	n := C.lame_encode_flush(gfp, (*C.uchar)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+	if n < 0 {
+		return nil, lameError(n)
+	}
+
+	return append(out, buf[:n]...), nil
+}
+
+// DecodeMP3 decodes an MP3 file to 16 bit WAV
+func DecodeMP3(mp3 []byte) ([]byte, error) {
+	if len(mp3) == 0 {
+		return nil, errors.New("transcode: no audio to decode")
+	}
+
+	hip := C.hip_decode_init()
+	if hip == nil {
+		return nil, errors.New("transcode: lame: out of memory")
+	}
+	defer C.hip_decode_exit(hip)
+
+	// hip copies its input, so it may be freed once passed
+	in := C.CBytes(mp3)
+	defer C.free(in)
+
+	var (
+		left, right [mp3FrameSamples]int16
+		data        C.mp3data_struct
+		w           *audio.WAV
+		pcm         []int16
+	)
+	for size := C.size_t(len(mp3)); ; size = 0 {
+		n := C.hip_decode1_headers(hip, (*C.uchar)(in), size,
+			(*C.short)(unsafe.Pointer(&left[0])), (*C.short)(unsafe.Pointer(&right[0])), &data)
+		if n < 0 {
+			return nil, errors.New("transcode: lame: invalid MP3 data")
+		}
+		if n == 0 {
+			// After the first call hip has no whole frame left to decode
+			if size == 0 {
+				break
+			}
+			continue
+		}
+
+		if w == nil {
+			w = &audio.WAV{Format: audio.PCM16(int(data.samplerate), int(data.stereo))}
+		}
+		for i := 0; i < int(n); i++ {
+			pcm = append(pcm, left[i])
+			if w.Format.Channels == 2 {
+				pcm = append(pcm, right[i])
+			}
+		}
+	}
+	if w == nil {
+		return nil, errors.New("transcode: no MP3 frames found")
+	}
+
+	w.Data = make([]byte, 2*len(pcm))
+	for i, s := range pcm {
+		w.Data[2*i] = byte(s)
+		w.Data[2*i+1] = byte(uint16(s) >> 8)
+	}
+
+	return w.Bytes(), nil
+}
+
+// lameError converts a lame_encode error code to an error
+func lameError(code C.int) error {
+	switch code {
+	case -1:
+		return errors.New("transcode: lame: output buffer too small")
+	case -2:
+		return errors.New("transcode: lame: out of memory")
+	case -3:
+		return errors.New("transcode: lame: parameters not initialised")
+	}
+
+	return errors.New("transcode: lame: psychoacoustic problem")
+}
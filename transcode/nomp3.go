@@ -0,0 +1,16 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build !lame
+
+package transcode
+
+// MP3 returns ErrMP3Unavailable when built without the lame tag
+func MP3(wav []byte, opts *MP3Options) ([]byte, error) {
+	return nil, ErrMP3Unavailable
+}
+
+// DecodeMP3 returns ErrMP3Unavailable when built without the lame tag
+func DecodeMP3(mp3 []byte) ([]byte, error) {
+	return nil, ErrMP3Unavailable
+}
@@ -0,0 +1,38 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build !opus
+
+package transcode
+
+// Encoder encodes 48 kHz 16 bit PCM frames as Opus packets. It is only
+// functional when built with the opus tag.
+type Encoder struct{}
+
+// NewEncoder returns ErrUnavailable when built without the opus tag
+func NewEncoder(channels int, opts *Options) (*Encoder, error) {
+	return nil, ErrUnavailable
+}
+
+// FrameSamples returns the samples per channel in each frame passed to
+// Encode
+func (e *Encoder) FrameSamples() int { return 0 }
+
+// PreSkip returns the encoder delay in samples per channel
+func (e *Encoder) PreSkip() int { return 0 }
+
+// Encode returns ErrUnavailable when built without the opus tag
+func (e *Encoder) Encode(pcm []int16) ([]byte, error) { return nil, ErrUnavailable }
+
+// Close frees the encoder
+func (e *Encoder) Close() {}
+
+// Packets returns ErrUnavailable when built without the opus tag
+func Packets(wav []byte, opts *Options) ([][]byte, error) {
+	return nil, ErrUnavailable
+}
+
+// OggOpus returns ErrUnavailable when built without the opus tag
+func OggOpus(wav []byte, opts *Options) ([]byte, error) {
+	return nil, ErrUnavailable
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package transcode
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+)
+
+// Ogg page header flags
+const (
+	oggFirst = 2
+	oggLast  = 4
+)
+
+// maxPacket is the largest packet which fits in a single Ogg page
+const maxPacket = 255 * 255
+
+// oggCRC is the lookup table for the Ogg CRC-32, polynomial 0x04c11db7
+// without bit reflection
+var oggCRC = func() (table [256]uint32) {
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// OggWriter writes Opus packets to an Ogg Opus stream, as described in
+// RFC 7845
+type OggWriter struct {
+	w       io.Writer
+	serial  uint32
+	seq     uint32
+	granule uint64
+
+	pending        []byte // last packet, written on the next call or Close
+	pendingGranule uint64
+	closed         bool
+}
+
+// NewOggWriter writes the Ogg Opus headers to w and returns a writer for
+// the packets. preSkip is the encoder delay in samples at 48 kHz and
+// sampleRate the rate of the original audio, which is informational.
+func NewOggWriter(w io.Writer, channels, sampleRate, preSkip int) (*OggWriter, error) {
+	o := &OggWriter{w: w, serial: rand.Uint32(), granule: uint64(preSkip)}
+
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // version
+	head[9] = byte(channels)
+	binary.LittleEndian.PutUint16(head[10:], uint16(preSkip))
+	binary.LittleEndian.PutUint32(head[12:], uint32(sampleRate))
+	// output gain and channel mapping family are zero
+	if err := o.page(head, 0, oggFirst); err != nil {
+		return nil, err
+	}
+
+	vendor := "cerevoicego"
+	tags := make([]byte, 8+4+len(vendor)+4)
+	copy(tags, "OpusTags")
+	binary.LittleEndian.PutUint32(tags[8:], uint32(len(vendor)))
+	copy(tags[12:], vendor)
+	if err := o.page(tags, 0, 0); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// WritePacket writes an Opus packet containing samples per channel at
+// 48 kHz. The last packet may report fewer samples than it contains to trim
+// padding from the end of the stream.
+func (o *OggWriter) WritePacket(packet []byte, samples int) error {
+	if o.closed {
+		return errors.New("transcode: write to closed OggWriter")
+	}
+	if len(packet) >= maxPacket {
+		return errors.New("transcode: Opus packet too large")
+	}
+
+	if o.pending != nil {
+		if err := o.page(o.pending, o.pendingGranule, 0); err != nil {
+			return err
+		}
+	}
+
+	o.granule += uint64(samples)
+	o.pending = append(o.pending[:0], packet...)
+	o.pendingGranule = o.granule
+	return nil
+}
+
+// Close writes the last page, marking the end of the stream. It does not
+// close the underlying writer.
+func (o *OggWriter) Close() error {
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+
+	if o.pending == nil {
+		return o.page(nil, o.granule, oggLast)
+	}
+
+	return o.page(o.pending, o.pendingGranule, oggLast)
+}
+
+// page writes a single packet as an Ogg page
+func (o *OggWriter) page(packet []byte, granule uint64, flags byte) error {
+	segments := len(packet)/255 + 1
+
+	b := make([]byte, 27+segments+len(packet))
+	copy(b, "OggS")
+	b[5] = flags
+	binary.LittleEndian.PutUint64(b[6:], granule)
+	binary.LittleEndian.PutUint32(b[14:], o.serial)
+	binary.LittleEndian.PutUint32(b[18:], o.seq)
+	b[26] = byte(segments)
+	for i := 0; i < segments-1; i++ {
+		b[27+i] = 255
+	}
+	b[27+segments-1] = byte(len(packet) % 255)
+	copy(b[27+segments:], packet)
+
+	var crc uint32
+	for _, c := range b {
+		crc = crc<<8 ^ oggCRC[byte(crc>>24)^c]
+	}
+	binary.LittleEndian.PutUint32(b[22:], crc)
+
+	o.seq++
+	_, err := o.w.Write(b)
+	return err
+}
@@ -0,0 +1,198 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+//go:build opus
+
+package transcode
+
+/*
+#cgo pkg-config: opus
+#include <opus/opus.h>
+
+static int set_bitrate(OpusEncoder *enc, opus_int32 bitrate) {
+	return opus_encoder_ctl(enc, OPUS_SET_BITRATE(bitrate));
+}
+
+static int get_lookahead(OpusEncoder *enc, opus_int32 *lookahead) {
+	return opus_encoder_ctl(enc, OPUS_GET_LOOKAHEAD(lookahead));
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// maxPacketBytes is the recommended output buffer size for opus_encode
+const maxPacketBytes = 4000
+
+// Encoder encodes 48 kHz 16 bit PCM frames as Opus packets
+type Encoder struct {
+	enc      *C.OpusEncoder
+	channels int
+	frame    int
+	buf      []byte
+}
+
+// NewEncoder returns an Encoder for 48 kHz audio with the given channels.
+// Close must be called to free it.
+func NewEncoder(channels int, opts *Options) (*Encoder, error) {
+	frame, err := opts.frameSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	application := C.OPUS_APPLICATION_VOIP
+	if opts != nil {
+		switch opts.Application {
+		case Audio:
+			application = C.OPUS_APPLICATION_AUDIO
+		case LowDelay:
+			application = C.OPUS_APPLICATION_RESTRICTED_LOWDELAY
+		}
+	}
+
+	var cerr C.int
+	enc := C.opus_encoder_create(SampleRate, C.int(channels), C.int(application), &cerr)
+	if cerr != C.OPUS_OK {
+		return nil, opusError(cerr)
+	}
+
+	e := &Encoder{enc: enc, channels: channels, frame: frame, buf: make([]byte, maxPacketBytes)}
+	if opts != nil && opts.Bitrate > 0 {
+		if cerr := C.set_bitrate(enc, C.opus_int32(opts.Bitrate)); cerr != C.OPUS_OK {
+			e.Close()
+			return nil, opusError(cerr)
+		}
+	}
+
+	return e, nil
+}
+
+// FrameSamples returns the samples per channel in each frame passed to
+// Encode
+func (e *Encoder) FrameSamples() int {
+	return e.frame
+}
+
+// PreSkip returns the encoder delay in samples per channel, to be skipped
+// by the decoder
+func (e *Encoder) PreSkip() int {
+	var lookahead C.opus_int32
+	if C.get_lookahead(e.enc, &lookahead) != C.OPUS_OK {
+		return 0
+	}
+	return int(lookahead)
+}
+
+// Encode encodes one frame of interleaved samples, FrameSamples per
+// channel, and returns the packet. The packet is only valid until the next
+// call.
+func (e *Encoder) Encode(pcm []int16) ([]byte, error) {
+	if len(pcm) != e.frame*e.channels {
+		return nil, errors.New("transcode: frame must contain FrameSamples per channel")
+	}
+
+	n := C.opus_encode(e.enc, (*C.opus_int16)(unsafe.Pointer(&pcm[0])), C.int(e.frame),
+		(*C.uchar)(unsafe.Pointer(&e.buf[0])), C.opus_int32(len(e.buf)))
+	if n < 0 {
+		return nil, opusError(n)
+	}
+
+	return e.buf[:n], nil
+}
+
+// Close frees the encoder
+func (e *Encoder) Close() {
+	if e.enc != nil {
+		C.opus_encoder_destroy(e.enc)
+		e.enc = nil
+	}
+}
+
+// opusError converts a libopus error code to an error
+func opusError(code C.int) error {
+	return errors.New("transcode: opus: " + C.GoString(C.opus_strerror(code)))
+}
+
+// Packets encodes a WAV file as Opus packets of opts.FrameSize, resampling
+// it to 48 kHz. The last frame is padded with silence.
+func Packets(wav []byte, opts *Options) ([][]byte, error) {
+	var packets [][]byte
+	err := encode(wav, opts, func(enc *Encoder, packet []byte, samples int) error {
+		packets = append(packets, append([]byte(nil), packet...))
+		return nil
+	})
+
+	return packets, err
+}
+
+// OggOpus encodes a WAV file as an Ogg Opus file
+func OggOpus(wav []byte, opts *Options) ([]byte, error) {
+	var b bytesBuffer
+	var ogg *OggWriter
+
+	err := encode(wav, opts, func(enc *Encoder, packet []byte, samples int) error {
+		if ogg == nil {
+			var err error
+			if ogg, err = NewOggWriter(&b, enc.channels, SampleRate, enc.PreSkip()); err != nil {
+				return err
+			}
+		}
+		return ogg.WritePacket(packet, samples)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ogg == nil {
+		return nil, errors.New("transcode: no audio to encode")
+	}
+	if err := ogg.Close(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// encode encodes a WAV file frame by frame, calling fn with each packet and
+// the number of real, unpadded, samples per channel it contains
+func encode(wav []byte, opts *Options, fn func(enc *Encoder, packet []byte, samples int) error) error {
+	w, pcm, err := prepare(wav, opts)
+	if err != nil {
+		return err
+	}
+
+	enc, err := NewEncoder(w.Format.Channels, opts)
+	if err != nil {
+		return err
+	}
+	defer enc.Close()
+
+	size := enc.FrameSamples() * w.Format.Channels
+	frame := make([]int16, size)
+	for start := 0; start < len(pcm); start += size {
+		n := copy(frame, pcm[start:])
+		for i := n; i < size; i++ {
+			frame[i] = 0
+		}
+
+		packet, err := enc.Encode(frame)
+		if err != nil {
+			return err
+		}
+		if err := fn(enc, packet, n/w.Format.Channels); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bytesBuffer is an append-only io.Writer
+type bytesBuffer []byte
+
+func (b *bytesBuffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package transcode converts CereVoice WAV output to Opus, as raw packets for
+// Discord and WebRTC or as an Ogg Opus file, and re-encodes MP3 at the
+// bitrate and sample rate smart speakers require.
+//
+// Opus encoding uses libopus through cgo and is only available when built
+// with the opus tag, e.g. go build -tags opus. Without it the encoding
+// functions return ErrUnavailable. Likewise MP3 uses libmp3lame and the lame
+// tag, returning ErrMP3Unavailable without it. The Ogg writer is always
+// available.
+package transcode
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// ErrUnavailable is returned by the encoding functions when the package is
+// built without the opus tag
+var ErrUnavailable = errors.New("transcode: built without opus support, rebuild with -tags opus")
+
+// ErrMP3Unavailable is returned by the MP3 functions when the package is
+// built without the lame tag
+var ErrMP3Unavailable = errors.New("transcode: built without MP3 support, rebuild with -tags lame")
+
+// ErrFrameSize is returned for a frame size Opus does not support
+var ErrFrameSize = errors.New("transcode: frame size must be 2.5, 5, 10, 20, 40 or 60ms")
+
+// SampleRate is the sample rate audio is resampled to before encoding, and
+// the rate Opus timestamps are measured in
+const SampleRate = 48000
+
+// DefaultFrameSize is the duration of audio in each Opus packet, as expected
+// by Discord
+const DefaultFrameSize = 20 * time.Millisecond
+
+// Application tunes the encoder for the type of audio
+type Application int
+
+// Encoder applications
+const (
+	Voice    Application = iota // Speech, the default
+	Audio                       // Music or mixed content
+	LowDelay                    // Lowest latency, for interactive use
+)
+
+// Options configures Opus encoding
+type Options struct {
+	Bitrate     int           // Bits per second, 0 for the encoder default
+	FrameSize   time.Duration // Audio per packet, DefaultFrameSize if 0
+	Channels    int           // Output channels, those of the input if 0
+	Application Application   // Encoder tuning, Voice by default
+}
+
+// DefaultMP3Bitrate is the MP3 bitrate in bits per second when none is set,
+// that required by Alexa
+const DefaultMP3Bitrate = 48000
+
+// MP3Options configures MP3 encoding
+type MP3Options struct {
+	Bitrate    int // Constant bits per second, DefaultMP3Bitrate if 0
+	SampleRate int // Output sample rate in Hz, that of the input if 0
+	Channels   int // Output channels, 1 or 2, those of the input if 0
+}
+
+// frameSamples returns the samples per channel in each frame
+func (o *Options) frameSamples() (int, error) {
+	d := DefaultFrameSize
+	if o != nil && o.FrameSize != 0 {
+		d = o.FrameSize
+	}
+
+	switch d {
+	case 2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond,
+		20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond:
+		return int(d * SampleRate / time.Second), nil
+	}
+
+	return 0, ErrFrameSize
+}
+
+// prepare decodes a WAV file and converts it to 48 kHz 16 bit samples with
+// the requested channels
+func prepare(wav []byte, opts *Options) (*audio.WAV, []int16, error) {
+	channels := 0
+	if opts != nil {
+		channels = opts.Channels
+	}
+
+	return decodePCM(wav, SampleRate, channels)
+}
+
+// decodePCM decodes a WAV file and converts it to 16 bit samples at rate,
+// and with channels, either of which may be 0 to keep that of the file
+func decodePCM(wav []byte, rate, channels int) (*audio.WAV, []int16, error) {
+	w, err := audio.DecodeWAV(wav)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var effects []audio.Effect
+	if rate > 0 {
+		effects = append(effects, audio.Resample(rate))
+	}
+	if channels > 0 {
+		effects = append(effects, audio.Remix(channels))
+	}
+	if err := w.Apply(effects...); err != nil {
+		return nil, nil, err
+	}
+
+	samples, err := w.Samples()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pcm := make([]int16, len(samples))
+	for i, s := range samples {
+		if s > 1 {
+			s = 1
+		} else if s < -1 {
+			s = -1
+		}
+		pcm[i] = int16(s * 32767)
+	}
+
+	return w, pcm, nil
+}
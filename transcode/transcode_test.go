@@ -0,0 +1,189 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package transcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+func TestFrameSamples(t *testing.T) {
+	tests := []struct {
+		opts *Options
+		want int
+		err  error
+	}{
+		{nil, 960, nil},
+		{&Options{}, 960, nil},
+		{&Options{FrameSize: 2500 * time.Microsecond}, 120, nil},
+		{&Options{FrameSize: 60 * time.Millisecond}, 2880, nil},
+		{&Options{FrameSize: 30 * time.Millisecond}, 0, ErrFrameSize},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.opts.frameSamples()
+		if got != tt.want || err != tt.err {
+			t.Errorf("frameSamples(%+v) = %d, %v, want %d, %v", tt.opts, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestDecodePCM(t *testing.T) {
+	stereo := (&audio.WAV{Format: audio.PCM16(8000, 2), Data: make([]byte, 8000*4)}).Bytes()
+	tests := []struct {
+		name     string
+		rate     int
+		channels int
+		format   audio.Format
+		samples  int
+	}{
+		{"unchanged", 0, 0, audio.PCM16(8000, 2), 16000},
+		{"resampled", 48000, 0, audio.PCM16(48000, 2), 96000},
+		{"mono", 0, 1, audio.PCM16(8000, 1), 8000},
+		{"both", 48000, 1, audio.PCM16(48000, 1), 48000},
+	}
+
+	for _, tt := range tests {
+		w, pcm, err := decodePCM(stereo, tt.rate, tt.channels)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if w.Format != tt.format || len(pcm) != tt.samples {
+			t.Errorf("%s: %+v with %d samples, want %+v with %d", tt.name, w.Format, len(pcm), tt.format, tt.samples)
+		}
+	}
+
+	if _, _, err := decodePCM([]byte("not a wav"), 0, 0); err != audio.ErrNotWAV {
+		t.Errorf("decodePCM error = %v, want ErrNotWAV", err)
+	}
+}
+
+// oggPage is a parsed Ogg page
+type oggPage struct {
+	flags   byte
+	granule uint64
+	seq     uint32
+	lacing  []byte
+	packet  []byte
+}
+
+// parsePages splits an Ogg stream into pages, checking each CRC
+func parsePages(t *testing.T, b []byte) []oggPage {
+	var pages []oggPage
+	for len(b) > 0 {
+		if len(b) < 27 || string(b[:4]) != "OggS" {
+			t.Fatalf("bad page header %q", b)
+		}
+		segments := int(b[26])
+		size := 27 + segments
+		for _, l := range b[27 : 27+segments] {
+			size += int(l)
+		}
+
+		page := append([]byte(nil), b[:size]...)
+		want := binary.LittleEndian.Uint32(page[22:])
+		binary.LittleEndian.PutUint32(page[22:], 0)
+		var crc uint32
+		for _, c := range page {
+			crc = crc<<8 ^ oggCRC[byte(crc>>24)^c]
+		}
+		if crc != want {
+			t.Errorf("page %d CRC %08x, want %08x", len(pages), crc, want)
+		}
+
+		pages = append(pages, oggPage{
+			flags:   b[5],
+			granule: binary.LittleEndian.Uint64(b[6:]),
+			seq:     binary.LittleEndian.Uint32(b[18:]),
+			lacing:  b[27 : 27+segments],
+			packet:  b[27+segments : size],
+		})
+		b = b[size:]
+	}
+	return pages
+}
+
+func TestOggWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		packets  [][]byte
+		samples  []int
+		granules []uint64 // Of the audio pages
+		lacing   [][]byte
+	}{
+		{"empty", nil, nil, []uint64{312}, [][]byte{{0}}},
+		{"packets", [][]byte{{1, 2, 3}, {4, 5}}, []int{960, 500}, []uint64{1272, 1772}, [][]byte{{3}, {2}}},
+		{"lacing", [][]byte{make([]byte, 255), make([]byte, 600)}, []int{960, 960}, []uint64{1272, 2232}, [][]byte{{255, 0}, {255, 255, 90}}},
+	}
+
+	for _, tt := range tests {
+		var b bytes.Buffer
+		o, err := NewOggWriter(&b, 2, 16000, 312)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range tt.packets {
+			if err := o.WritePacket(p, tt.samples[i]); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+		if err := o.Close(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := o.WritePacket([]byte{1}, 960); err == nil {
+			t.Errorf("%s: write after Close succeeded", tt.name)
+		}
+
+		pages := parsePages(t, b.Bytes())
+		if len(pages) != 2+len(tt.granules) {
+			t.Fatalf("%s: %d pages, want %d", tt.name, len(pages), 2+len(tt.granules))
+		}
+
+		head := pages[0].packet
+		if pages[0].flags != oggFirst || string(head[:8]) != "OpusHead" || head[9] != 2 ||
+			binary.LittleEndian.Uint16(head[10:]) != 312 || binary.LittleEndian.Uint32(head[12:]) != 16000 {
+			t.Errorf("%s: OpusHead page %+v", tt.name, pages[0])
+		}
+		if string(pages[1].packet[:8]) != "OpusTags" || pages[1].flags != 0 {
+			t.Errorf("%s: OpusTags page %+v", tt.name, pages[1])
+		}
+
+		var granules []uint64
+		var lacing [][]byte
+		for i, p := range pages {
+			if p.seq != uint32(i) {
+				t.Errorf("%s: page %d has sequence %d", tt.name, i, p.seq)
+			}
+			if i >= 2 {
+				granules = append(granules, p.granule)
+				lacing = append(lacing, p.lacing)
+				if i < 2+len(tt.packets) && !bytes.Equal(p.packet, tt.packets[i-2]) {
+					t.Errorf("%s: page %d packet differs", tt.name, i)
+				}
+			}
+		}
+		if !reflect.DeepEqual(granules, tt.granules) || !reflect.DeepEqual(lacing, tt.lacing) {
+			t.Errorf("%s: granules %v lacing %v, want %v %v", tt.name, granules, lacing, tt.granules, tt.lacing)
+		}
+		if last := pages[len(pages)-1]; last.flags != oggLast {
+			t.Errorf("%s: last page flags %d, want end of stream", tt.name, last.flags)
+		}
+	}
+}
+
+func TestOggWriterPacketTooLarge(t *testing.T) {
+	o, err := NewOggWriter(&bytes.Buffer{}, 1, 48000, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.WritePacket(make([]byte, maxPacket), 960); err == nil {
+		t.Error("oversized packet accepted")
+	}
+}
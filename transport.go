@@ -0,0 +1,281 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Request to CereVoice Cloud API, as sent on the wire. Requests are built
+// from a validated request for each operation, so every field is optional
+// here.
+type Request struct {
+	XMLName          xml.Name `json:"-"`
+	AccountID        string   `xml:"accountID" json:"accountID"`
+	Password         string   `xml:"password" json:"password"`
+	Token            string   `xml:"token,omitempty" json:"token,omitempty"`
+	Voice            string   `xml:"voice,omitempty" json:"voice,omitempty"`
+	Text             string   `xml:"text,omitempty" json:"text,omitempty"`
+	AudioFormat      string   `xml:"audioFormat,omitempty" json:"audioFormat,omitempty"`
+	SampleRate       string   `xml:"sampleRate,omitempty" json:"sampleRate,omitempty"`
+	Audio3D          bool     `xml:"audio3D,omitempty" json:"audio3D,omitempty"`
+	Metadata         bool     `xml:"metadata,omitempty" json:"metadata,omitempty"`
+	LexiconFile      string   `xml:"lexiconFile,omitempty" json:"lexiconFile,omitempty"`
+	AbbreviationFile string   `xml:"abbreviationFile,omitempty" json:"abbreviationFile,omitempty"`
+	Language         string   `xml:"language,omitempty" json:"language,omitempty"`
+	Accent           string   `xml:"accent,omitempty" json:"accent,omitempty"`
+	Gender           string   `xml:"gender,omitempty" json:"gender,omitempty"`
+
+	loggedText string // recorded in place of Text, if set
+}
+
+// Response from CereVoice Cloud API
+type Response struct {
+	Raw         []byte
+	Endpoint    string    // API URL which served the response
+	StatusCode  int       // HTTP status code
+	ContentType string    // Content-Type header
+	Conn        *ConnInfo // Connection used
+
+	codec Codec // decodes Raw, that of the Client if nil
+}
+
+// call validates op, queries the CereVoice Cloud API and decodes a
+// successful response into v
+func (c *Client) call(ctx context.Context, op operation, v interface{}) (err error) {
+	if err := validate(op, unpreparedText); err != nil {
+		return err
+	}
+	req := op.request()
+	policy := c.retryPolicy(ctx)
+
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	ctx, cancel := c.operationContext(ctx, req.XMLName.Local)
+	defer cancel()
+
+	if isSpeak(req.XMLName.Local) {
+		if err := c.prepareText(ctx, op, req); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	entry := &RequestLog{
+		Operation:  req.XMLName.Local,
+		Voice:      req.Voice,
+		Text:       redacted(req).Text,
+		TextLength: len([]rune(req.Text)),
+	}
+	var finish func(*RequestLog)
+	if c.Tracer != nil {
+		ctx, finish = c.Tracer.StartRequest(ctx, entry.Operation)
+	}
+	if c.Logger != nil || finish != nil {
+		defer func() {
+			entry.Duration = time.Since(start)
+			entry.Err = err
+			if c.Logger != nil {
+				entry.Request = redact(req)
+				c.Logger.LogRequest(entry)
+			}
+			if finish != nil {
+				finish(entry)
+			}
+		}()
+	}
+
+	if err := c.authenticate(ctx, req); err != nil {
+		return err
+	}
+
+	// Dry runs spend no credit, so neither fetch nor reduce the balance
+	guard := c.CreditGuard
+	if _, ok := requestCredentials(ctx); ok || c.DryRun {
+		guard = nil
+	}
+	if guard != nil && isSpeak(req.XMLName.Local) {
+		if err := guard.check(ctx, c, req.Text); err != nil {
+			return err
+		}
+	} else {
+		guard = nil
+	}
+
+	settle, err := c.reserveQuota(ctx, req)
+	if err != nil {
+		return err
+	}
+	billed := 0
+	defer func() { settle(billed) }()
+
+	for attempt := 1; ; attempt++ {
+		entry.Attempts = attempt
+
+		// Dry runs make no request for the breaker to judge
+		var done func(error)
+		if c.Breaker != nil && !(c.DryRun && isSpeak(req.XMLName.Local)) {
+			if done, err = c.Breaker.allow(); err != nil {
+				return err
+			}
+		}
+
+		resp, err := c.queryAPI(ctx, req)
+		if err == nil {
+			entry.Endpoint, entry.Conn = resp.Endpoint, resp.Conn
+			var res *result
+			res, err = c.checkResult(req.XMLName.Local, resp)
+			entry.record(res)
+		}
+		if done != nil {
+			done(err)
+		}
+		if err == nil {
+			if guard != nil {
+				guard.consume(entry.CharCount)
+			}
+			c.recordUsage(ctx, req, entry.CharCount)
+			billed = entry.CharCount
+			if err := resp.decode(c, req.XMLName.Local, v); err != nil {
+				return resp.decodeError(req.XMLName.Local, err)
+			}
+			if m, ok := v.(metaSetter); ok {
+				m.setMeta(ResponseMeta{
+					Duration:   time.Since(start),
+					StatusCode: resp.StatusCode,
+					Retries:    attempt - 1,
+					Endpoint:   resp.Endpoint,
+					Chars:      entry.CharCount,
+					Voice:      req.Voice,
+				})
+			}
+			return nil
+		}
+
+		if errors.Is(err, ErrAuth) {
+			c.expireCredentials(ctx, req)
+		}
+		if attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return err
+		}
+		if err := sleep(ctx, policy.delay(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// Query CereVoice Cloud API, failing over to the next endpoint if one can
+// not be reached
+func (c *Client) queryAPI(ctx context.Context, req *Request) (*Response, error) {
+	if c.DryRun && isSpeak(req.XMLName.Local) {
+		resp := dryRun(req)
+		resp.codec = xmlCodec{c}
+		return resp, nil
+	}
+
+	if c.RateLimiter != nil {
+		if err := c.RateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	codec := c.codec()
+	body, err := codec.Encode(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, endpoint := range c.endpoints() {
+		var resp *Response
+		resp, err = c.post(ctx, req, codec, endpoint, body)
+		if err == nil || ctx.Err() != nil || !failover(err) {
+			return resp, err
+		}
+		c.markDown(endpoint)
+	}
+
+	return nil, err
+}
+
+// post sends the body of req, encoded by codec, to endpoint
+func (c *Client) post(ctx context.Context, req *Request, codec Codec, endpoint string, body []byte) (_ *Response, err error) {
+	var status int
+	var raw []byte
+	if c.Debug != nil {
+		start := time.Now()
+		defer func() {
+			c.Debug.capture(req, endpoint, status, raw, err, start)
+		}()
+	}
+
+	request, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(request)
+	request.Header.Set("Content-Type", codec.ContentType())
+
+	conn := &ConnInfo{}
+	ctx, traced := traceConn(ctx, conn)
+
+	resp, err := c.roundTrip()(request.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	status = resp.StatusCode
+	traced(resp)
+	if c.ConnTracker != nil {
+		c.ConnTracker.add(conn)
+	}
+
+	// Error statuses are often proxy pages of any size, so only as much is
+	// read as an API response could need
+	r := io.Reader(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		r = io.LimitReader(resp.Body, maxErrorBody)
+	}
+	raw, err = ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Server errors, and other failures without an API response to decode,
+	// are reported by status
+	if resp.StatusCode >= 500 || (resp.StatusCode != http.StatusOK && !codec.Match(raw)) {
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: snippet(raw)}
+	}
+
+	return &Response{
+		Raw:         raw,
+		Endpoint:    endpoint,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Conn:        conn,
+		codec:       codec,
+	}, nil
+}
+
+// decode decodes the response to operation into v with the codec of r, or
+// that of c
+func (r *Response) decode(c *Client, operation string, v interface{}) error {
+	codec := r.codec
+	if codec == nil {
+		codec = c.codec()
+	}
+
+	return codec.Decode(operation, r.Raw, v)
+}
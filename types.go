@@ -0,0 +1,214 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+// SpeakSimpleInput contains speakSimple parameters
+type SpeakSimpleInput struct {
+	Voice string `json:"voice,omitempty"`
+	Text  string `json:"text,omitempty"`
+
+	SpeakMode
+}
+
+// SpeakExtendedInput contains speakExtended parameters
+type SpeakExtendedInput struct {
+	Voice       string      `json:"voice,omitempty"`
+	Text        string      `json:"text,omitempty"`
+	AudioFormat AudioFormat `json:"audioFormat,omitempty"`
+	SampleRate  SampleRate  `json:"sampleRate,omitempty"`
+	Audio3D     bool        `json:"audio3D,omitempty"`
+	Metadata    bool        `json:"metadata,omitempty"`
+
+	// FallbackVoices are tried in order if the voice is rejected as invalid,
+	// e.g. after it is retired, or lacks the Emotion under WithEmotionCheck.
+	// ResponseMeta.Voice is the one which spoke.
+	FallbackVoices []string `json:"fallbackVoices,omitempty"`
+
+	SpeakMode
+
+	requested *SpeakMode // SpeakMode before VoiceProfiles filled it in, nil if they have not
+}
+
+// voices returns the voice and fallback voices to try in order, skipping
+// empty names unless there are no others
+func (in *SpeakExtendedInput) voices() []string {
+	var voices []string
+	for _, v := range append([]string{in.Voice}, in.FallbackVoices...) {
+		if v != "" {
+			voices = append(voices, v)
+		}
+	}
+	if len(voices) == 0 {
+		return []string{in.Voice}
+	}
+
+	return voices
+}
+
+// ListVoicesInput contains optional listVoices filters
+type ListVoicesInput struct {
+	Language string `json:"language,omitempty"` // ISO language code, e.g. en
+	Accent   string `json:"accent,omitempty"`   // Accent code
+	Sex      Sex    `json:"sex,omitempty"`      // Female or Male
+}
+
+// UploadLexiconInput contains uploadLexicon paramters
+type UploadLexiconInput struct {
+	LexiconFile string `json:"lexiconFile,omitempty"` // Contents of the lexicon file, not a path or URL
+	Language    string `json:"language,omitempty"`    // ISO language code, e.g. en
+	Accent      string `json:"accent,omitempty"`      // Accent code
+}
+
+// UploadAbbreviationsInput contains uploadAbbreviations parameters
+type UploadAbbreviationsInput struct {
+	AbbreviationFile string `json:"abbreviationFile,omitempty"` // Contents of the abbreviation file, not a path or URL
+	Language         string `json:"language,omitempty"`         // ISO language code, e.g. en
+}
+
+// DeleteLexiconInput contains deleteLexicon parameters
+type DeleteLexiconInput struct {
+	Language string `json:"language,omitempty"`
+	Accent   string `json:"accent,omitempty"`
+}
+
+// DeleteAbbreviationsInput contains deleteAbbreviations parameters
+type DeleteAbbreviationsInput struct {
+	Language string `json:"language,omitempty"`
+}
+
+// SpeakSimpleResponse contains response from speakSimple
+type SpeakSimpleResponse struct {
+	FileURL           string     `xml:"fileUrl" json:"fileUrl"`
+	CharCount         string     `xml:"charCount" json:"charCount"`
+	ResultCode        ResultCode `xml:"resultCode" json:"resultCode"`
+	ResultDescription string     `xml:"resultDescription" json:"resultDescription"`
+
+	ResponseMeta `xml:"-" json:"-"`
+
+	client HTTPClient // used to download the synthesised audio
+}
+
+// SpeakExtendedResponse contains response from speakExtended
+type SpeakExtendedResponse struct {
+	FileURL           string     `xml:"fileUrl" json:"fileUrl"`
+	CharCount         string     `xml:"charCount" json:"charCount"`
+	ResultCode        ResultCode `xml:"resultCode" json:"resultCode"`
+	ResultDescription string     `xml:"resultDescription" json:"resultDescription"`
+	Metadata          string     `xml:"metadataUrl" json:"metadataUrl"`
+
+	ResponseMeta `xml:"-" json:"-"`
+
+	client HTTPClient // used to download the synthesised audio
+}
+
+// ListVoicesResponse contains response from listVoices
+type ListVoicesResponse struct {
+	VoiceList []Voice `xml:"voicesList>voice" json:"voices"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// UploadLexiconResponse contains response from uploadLexicon
+type UploadLexiconResponse struct {
+	ResultCode        ResultCode `xml:"resultCode" json:"resultCode"`
+	ResultDescription string     `xml:"resultDescription" json:"resultDescription"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// ListLexiconsResponse contains response from listLexicons
+type ListLexiconsResponse struct {
+	LexiconList []Lexicon `xml:"lexiconList>lexiconFile" json:"lexicons"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// UploadAbbreviationsResponse contains response from uploadAbbreviations
+type UploadAbbreviationsResponse struct {
+	ResultCode        ResultCode `xml:"resultCode" json:"resultCode"`
+	ResultDescription string     `xml:"resultDescription" json:"resultDescription"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// DeleteLexiconResponse contains response from deleteLexicon
+type DeleteLexiconResponse struct {
+	ResultCode        ResultCode `xml:"resultCode" json:"resultCode"`
+	ResultDescription string     `xml:"resultDescription" json:"resultDescription"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// DeleteAbbreviationsResponse contains response from deleteAbbreviations
+type DeleteAbbreviationsResponse struct {
+	ResultCode        ResultCode `xml:"resultCode" json:"resultCode"`
+	ResultDescription string     `xml:"resultDescription" json:"resultDescription"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// ListAbbreviationsResponse contains response from listAbbreviations
+type ListAbbreviationsResponse struct {
+	AbbreviationList []Abbreviation `xml:"abbreviationList>abbreviationFile" json:"abbreviations"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// ListAudioFormatsResponse contains response from listAudioFormats
+type ListAudioFormatsResponse struct {
+	AudioFormats []string `xml:"formatList>format" json:"audioFormats"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// GetCreditResponse contains response from getCredit
+type GetCreditResponse struct {
+	Credit Credit `xml:"credit" json:"credit"`
+
+	ResponseMeta `xml:"-" json:"-"`
+}
+
+// Voice contains details about a voice
+type Voice struct {
+	SampleRate            string `xml:"sampleRate" json:"sampleRate"`
+	VoiceName             string `xml:"voiceName" json:"voiceName"`
+	LanguageCodeISO       string `xml:"languageCodeISO" json:"languageCodeISO"`
+	CountryCodeISO        string `xml:"countryCodeISO" json:"countryCodeISO"`
+	AccentCode            string `xml:"accentCode" json:"accentCode"`
+	Sex                   string `xml:"sex" json:"sex"`
+	LanguageCodeMicrosoft string `xml:"languageCodeMicrosoft" json:"languageCodeMicrosoft"`
+	Country               string `xml:"country" json:"country"`
+	Region                string `xml:"region" json:"region"`
+	Accent                string `xml:"accent" json:"accent"`
+	Hz                    int    `xml:"-" json:"hz,omitempty"` // SampleRate parsed
+}
+
+// Lexicon contains details about a lexicon
+type Lexicon struct {
+	URL          string `xml:"url" json:"url"`
+	Language     string `xml:"language" json:"language"`
+	Accent       string `xml:"accent" json:"accent"`
+	LastModified string `xml:"lastModified" json:"lastModified"`
+	Size         string `xml:"size" json:"size"`
+	Bytes        int64  `xml:"-" json:"bytes,omitempty"` // Size parsed
+}
+
+// Abbreviation contains details about an abbreviation
+type Abbreviation struct {
+	URL          string `xml:"url" json:"url"`
+	Language     string `xml:"language" json:"language"`
+	LastModified string `xml:"lastModified" json:"lastModified"`
+	Size         string `xml:"size" json:"size"`
+	Bytes        int64  `xml:"-" json:"bytes,omitempty"` // Size parsed
+}
+
+// Credit contains details about CereVoice Cloud credits
+type Credit struct {
+	FreeCredit     string `xml:"freeCredit" json:"freeCredit"`
+	PaidCredit     string `xml:"paidCredit" json:"paidCredit"`
+	CharsAvailable string `xml:"charsAvailable" json:"charsAvailable"`
+	Free           Amount `xml:"-" json:"free"`      // FreeCredit parsed
+	Paid           Amount `xml:"-" json:"paid"`      // PaidCredit parsed
+	Available      int    `xml:"-" json:"available"` // CharsAvailable parsed
+}
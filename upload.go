@@ -0,0 +1,72 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// UploadLexiconFromReader uploads the lexicon read from r for the given
+// language and accent. The contents are embedded in the request, so the
+// file does not need to be hosted anywhere.
+func (c *Client) UploadLexiconFromReader(r io.Reader, language, accent string) (*UploadLexiconResponse, error) {
+	return c.UploadLexiconFromReaderWithContext(context.Background(), r, language, accent)
+}
+
+// UploadLexiconFromReaderWithContext is the same as UploadLexiconFromReader with
+// the addition of the ability to pass a context for cancellation and timeouts
+func (c *Client) UploadLexiconFromReaderWithContext(ctx context.Context, r io.Reader, language, accent string) (*UploadLexiconResponse, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.UploadLexiconWithContext(ctx, &UploadLexiconInput{
+		LexiconFile: string(contents),
+		Language:    language,
+		Accent:      accent,
+	})
+}
+
+// UploadLexiconFromPath uploads the lexicon file at path for the given
+// language and accent
+func (c *Client) UploadLexiconFromPath(path, language, accent string) (*UploadLexiconResponse, error) {
+	return c.UploadLexiconFromPathWithContext(context.Background(), path, language, accent)
+}
+
+// UploadLexiconFromPathWithContext is the same as UploadLexiconFromPath with
+// the addition of the ability to pass a context for cancellation and timeouts
+func (c *Client) UploadLexiconFromPathWithContext(ctx context.Context, path, language, accent string) (*UploadLexiconResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return c.UploadLexiconFromReaderWithContext(ctx, f, language, accent)
+}
+
+// UploadAbbreviationsFromReader uploads the abbreviation file read from r for
+// the given language, such as one written by an abbrev.List
+func (c *Client) UploadAbbreviationsFromReader(r io.Reader, language string) (*UploadAbbreviationsResponse, error) {
+	return c.UploadAbbreviationsFromReaderWithContext(context.Background(), r, language)
+}
+
+// UploadAbbreviationsFromReaderWithContext is the same as
+// UploadAbbreviationsFromReader with the addition of the ability to pass a
+// context for cancellation and timeouts
+func (c *Client) UploadAbbreviationsFromReaderWithContext(ctx context.Context, r io.Reader, language string) (*UploadAbbreviationsResponse, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.UploadAbbreviationsWithContext(ctx, &UploadAbbreviationsInput{
+		AbbreviationFile: string(contents),
+		Language:         language,
+	})
+}
@@ -0,0 +1,290 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UsageRecord is the credit consumed by one speak request
+type UsageRecord struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Voice     string    `json:"voice"`
+	AccountID string    `json:"accountId,omitempty"`
+	Tag       string    `json:"tag,omitempty"` // Set with WithUsageTag
+	Chars     int       `json:"chars"`         // Characters billed
+}
+
+// Ledger stores the UsageRecord of every billed request. Implementations
+// must be safe for concurrent use.
+type Ledger interface {
+	// Record stores r. Errors are the ledger's own concern, the request
+	// has already succeeded.
+	Record(r UsageRecord)
+	// Records returns the records in period, oldest first
+	Records(period Period) ([]UsageRecord, error)
+}
+
+// WithLedger records the credit used by every speak request in l
+func WithLedger(l Ledger) ClientOption {
+	return func(c *Client) {
+		c.Ledger = l
+	}
+}
+
+type usageTagKey struct{}
+
+// WithUsageTag returns a context whose speak requests are recorded in the
+// Ledger with tag, such as the feature making them, so spend can be
+// attributed in a UsageReport
+func WithUsageTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, usageTagKey{}, tag)
+}
+
+// recordUsage records the credit used by a successful request
+func (c *Client) recordUsage(ctx context.Context, req *Request, chars int) {
+	if c.Ledger == nil || !isSpeak(req.XMLName.Local) || c.DryRun {
+		return
+	}
+
+	tag, _ := ctx.Value(usageTagKey{}).(string)
+	c.Ledger.Record(UsageRecord{
+		Time:      time.Now(),
+		Operation: req.XMLName.Local,
+		Voice:     req.Voice,
+		AccountID: req.AccountID,
+		Tag:       tag,
+		Chars:     chars,
+	})
+}
+
+// Period is a span of time, from From up to but not including To. A zero
+// From or To leaves that end open.
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// LastDays returns the period covering today and the n-1 days before it, in
+// the local time zone
+func LastDays(n int) Period {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return Period{From: today.AddDate(0, 0, 1-n), To: today.AddDate(0, 0, 1)}
+}
+
+// Month returns the calendar month containing t
+func Month(t time.Time) Period {
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return Period{From: from, To: from.AddDate(0, 1, 0)}
+}
+
+// Contains reports whether t is in the period
+func (p Period) Contains(t time.Time) bool {
+	return (p.From.IsZero() || !t.Before(p.From)) && (p.To.IsZero() || t.Before(p.To))
+}
+
+// UsageReport aggregates the usage in a period
+type UsageReport struct {
+	Period   Period
+	Requests int            // Billed requests
+	Chars    int            // Characters billed
+	ByVoice  map[string]int // Characters billed per voice
+	ByTag    map[string]int // Characters billed per usage tag, untagged requests under ""
+	ByDay    []DailyUsage   // Usage per day with requests, oldest first
+}
+
+// DailyUsage is the usage on one day
+type DailyUsage struct {
+	Date     string         // Day in the local time zone, as 2006-01-02
+	Requests int            // Billed requests
+	Chars    int            // Characters billed
+	ByVoice  map[string]int // Characters billed per voice
+}
+
+// ErrNoLedger is returned by UsageReport when the Client has no Ledger
+var ErrNoLedger = errors.New("cerevoicego: no usage ledger")
+
+// UsageReport aggregates the usage recorded in the Client Ledger during
+// period by voice, tag and day
+func (c *Client) UsageReport(period Period) (*UsageReport, error) {
+	if c.Ledger == nil {
+		return nil, ErrNoLedger
+	}
+
+	records, err := c.Ledger.Records(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUsageReport(period, records), nil
+}
+
+// NewUsageReport aggregates the records in period
+func NewUsageReport(period Period, records []UsageRecord) *UsageReport {
+	report := &UsageReport{
+		Period:  period,
+		ByVoice: make(map[string]int),
+		ByTag:   make(map[string]int),
+	}
+	days := make(map[string]*DailyUsage)
+
+	for _, r := range records {
+		if !period.Contains(r.Time) {
+			continue
+		}
+
+		report.Requests++
+		report.Chars += r.Chars
+		report.ByVoice[r.Voice] += r.Chars
+		report.ByTag[r.Tag] += r.Chars
+
+		date := r.Time.Local().Format("2006-01-02")
+		day, ok := days[date]
+		if !ok {
+			day = &DailyUsage{Date: date, ByVoice: make(map[string]int)}
+			days[date] = day
+		}
+		day.Requests++
+		day.Chars += r.Chars
+		day.ByVoice[r.Voice] += r.Chars
+	}
+
+	for _, day := range days {
+		report.ByDay = append(report.ByDay, *day)
+	}
+	sort.Slice(report.ByDay, func(i, j int) bool {
+		return report.ByDay[i].Date < report.ByDay[j].Date
+	})
+
+	return report
+}
+
+// MemoryLedger is a Ledger holding records in memory
+type MemoryLedger struct {
+	mu      sync.Mutex
+	records []UsageRecord
+}
+
+// NewMemoryLedger returns an empty MemoryLedger
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{}
+}
+
+// Record stores r
+func (m *MemoryLedger) Record(r UsageRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = append(m.records, r)
+}
+
+// Records returns the records in period, oldest first
+func (m *MemoryLedger) Records(period Period) ([]UsageRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return filterRecords(m.records, period), nil
+}
+
+// FileLedger is a Ledger appending records to a file as JSON lines, so usage
+// survives restarts and can be processed by other tools
+type FileLedger struct {
+	Path string
+	// OnError, if set, receives errors writing records, which are
+	// otherwise dropped
+	OnError func(err error)
+
+	mu sync.Mutex
+}
+
+// NewFileLedger returns a FileLedger appending to path
+func NewFileLedger(path string) *FileLedger {
+	return &FileLedger{Path: path}
+}
+
+// Record appends r to the file
+func (f *FileLedger) Record(r UsageRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.append(r); err != nil && f.OnError != nil {
+		f.OnError(err)
+	}
+}
+
+func (f *FileLedger) append(r UsageRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Records reads the records in period from the file, oldest first. A
+// missing file has no records.
+func (f *FileLedger) Records(period Period) ([]UsageRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.Open(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []UsageRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r UsageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return filterRecords(records, period), nil
+}
+
+// filterRecords returns the records in period sorted oldest first
+func filterRecords(records []UsageRecord, period Period) []UsageRecord {
+	var filtered []UsageRecord
+	for _, r := range records {
+		if period.Contains(r.Time) {
+			filtered = append(filtered, r)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Time.Before(filtered[j].Time)
+	})
+
+	return filtered
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestUsageReport(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, time.Local) }
+	records := []cerevoicego.UsageRecord{
+		{Time: day(1, 9), Voice: "Heather", Tag: "alerts", Chars: 10},
+		{Time: day(1, 17), Voice: "Jess", Chars: 5},
+		{Time: day(2, 12), Voice: "Heather", Tag: "alerts", Chars: 20},
+		{Time: day(4, 0), Voice: "Heather", Chars: 40},
+	}
+
+	tests := []struct {
+		name   string
+		period cerevoicego.Period
+		want   cerevoicego.UsageReport
+	}{
+		{
+			"all",
+			cerevoicego.Period{},
+			cerevoicego.UsageReport{
+				Requests: 4,
+				Chars:    75,
+				ByVoice:  map[string]int{"Heather": 70, "Jess": 5},
+				ByTag:    map[string]int{"alerts": 30, "": 45},
+				ByDay: []cerevoicego.DailyUsage{
+					{Date: "2024-03-01", Requests: 2, Chars: 15, ByVoice: map[string]int{"Heather": 10, "Jess": 5}},
+					{Date: "2024-03-02", Requests: 1, Chars: 20, ByVoice: map[string]int{"Heather": 20}},
+					{Date: "2024-03-04", Requests: 1, Chars: 40, ByVoice: map[string]int{"Heather": 40}},
+				},
+			},
+		},
+		{
+			"end exclusive",
+			cerevoicego.Period{From: day(1, 17), To: day(4, 0)},
+			cerevoicego.UsageReport{
+				Period:   cerevoicego.Period{From: day(1, 17), To: day(4, 0)},
+				Requests: 2,
+				Chars:    25,
+				ByVoice:  map[string]int{"Heather": 20, "Jess": 5},
+				ByTag:    map[string]int{"alerts": 20, "": 5},
+				ByDay: []cerevoicego.DailyUsage{
+					{Date: "2024-03-01", Requests: 1, Chars: 5, ByVoice: map[string]int{"Jess": 5}},
+					{Date: "2024-03-02", Requests: 1, Chars: 20, ByVoice: map[string]int{"Heather": 20}},
+				},
+			},
+		},
+		{
+			"month",
+			cerevoicego.Month(day(9, 0).AddDate(0, 1, 0)),
+			cerevoicego.UsageReport{
+				Period:  cerevoicego.Month(day(9, 0).AddDate(0, 1, 0)),
+				ByVoice: map[string]int{},
+				ByTag:   map[string]int{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := cerevoicego.NewUsageReport(tt.period, records); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: report = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+func TestLedgers(t *testing.T) {
+	tests := []struct {
+		name   string
+		ledger cerevoicego.Ledger
+	}{
+		{"memory", cerevoicego.NewMemoryLedger()},
+		{"file", cerevoicego.NewFileLedger(filepath.Join(t.TempDir(), "usage.jsonl"))},
+	}
+
+	for _, tt := range tests {
+		srv := cerevoicetest.NewServer()
+		c := srv.Client()
+		c.Apply(cerevoicego.WithLedger(tt.ledger))
+
+		ctx := cerevoicego.WithUsageTag(context.Background(), "alerts")
+		if _, err := c.SpeakSimpleWithContext(ctx, &cerevoicego.SpeakSimpleInput{Voice: "Heather", Text: "Hello"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.SpeakExtended(&cerevoicego.SpeakExtendedInput{Voice: "Jess", Text: "Hi there"}); err != nil {
+			t.Fatal(err)
+		}
+		// Neither failed, non-speak nor dry run requests are billed
+		srv.SetError("speakSimple", cerevoicego.ResultInvalidVoice, "Invalid voice")
+		c.SpeakSimple(&cerevoicego.SpeakSimpleInput{Voice: "Nobody", Text: "Hello"})
+		c.ListVoices(&cerevoicego.ListVoicesInput{})
+		dry := c.Clone()
+		dry.Apply(cerevoicego.WithDryRun())
+		dry.SpeakExtended(&cerevoicego.SpeakExtendedInput{Voice: "Jess", Text: "Not billed"})
+		srv.Close()
+
+		records, err := tt.ledger.Records(cerevoicego.LastDays(1))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []cerevoicego.UsageRecord
+		for _, r := range records {
+			got = append(got, cerevoicego.UsageRecord{Operation: r.Operation, Voice: r.Voice, Tag: r.Tag, Chars: r.Chars})
+		}
+		want := []cerevoicego.UsageRecord{
+			{Operation: "speakSimple", Voice: "Heather", Tag: "alerts", Chars: 5},
+			{Operation: "speakExtended", Voice: "Jess", Chars: 8},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: records = %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
+
+func TestUsageReportNoLedger(t *testing.T) {
+	c := cerevoicego.NewClient("account", "password")
+	if _, err := c.UsageReport(cerevoicego.Period{}); !errors.Is(err, cerevoicego.ErrNoLedger) {
+		t.Errorf("UsageReport error = %v, want ErrNoLedger", err)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The API returns numbers as strings. Responses keep the raw strings for
+// compatibility and fill in parsed fields as they are decoded from XML or
+// JSON, including responses only partly decoded in DecodeLenient mode. Values
+// which are missing or malformed parse as zero.
+
+// Amount is a credit value in hundredths, e.g. 1000 for "10.00"
+type Amount int64
+
+// String formats the amount with two decimal places
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign, a = "-", -a
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, a/100, a%100)
+}
+
+// MarshalJSON encodes the amount as a number of whole units, such as 10.50
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON decodes a number or string of whole units
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+
+	v, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+
+	return nil
+}
+
+// Float64 returns the amount in whole units
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// ParseAmount parses a credit value such as "10", "10.5" or "1,234.56".
+// Fractions beyond hundredths are rejected rather than rounded.
+func ParseAmount(s string) (Amount, error) {
+	v := strings.Replace(strings.TrimSpace(s), ",", "", -1)
+	neg := strings.HasPrefix(v, "-")
+	whole, frac := strings.TrimPrefix(v, "-"), ""
+	if i := strings.IndexByte(whole, '.'); i >= 0 {
+		whole, frac = whole[:i], whole[i+1:]
+	}
+	if whole == "" && frac == "" || len(frac) > 2 || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("cerevoicego: invalid credit value %q", s)
+	}
+
+	var a int64
+	if whole != "" {
+		n, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cerevoicego: invalid credit value %q", s)
+		}
+		a = n * 100
+	}
+	if frac != "" {
+		n, _ := strconv.ParseInt((frac + "0")[:2], 10, 64)
+		a += n
+	}
+	if neg {
+		a = -a
+	}
+
+	return Amount(a), nil
+}
+
+// Hz returns the sample rate as a number, 0 if it is empty
+func (r SampleRate) Hz() int {
+	return atoi(string(r))
+}
+
+// UnmarshalXML decodes the response and parses CharCount
+func (r *SpeakSimpleResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type response SpeakSimpleResponse
+	err := d.DecodeElement((*response)(r), &start)
+	r.Chars = atoi(r.CharCount)
+	return err
+}
+
+// UnmarshalJSON decodes the response and parses CharCount
+func (r *SpeakSimpleResponse) UnmarshalJSON(data []byte) error {
+	type response SpeakSimpleResponse
+	err := json.Unmarshal(data, (*response)(r))
+	r.Chars = atoi(r.CharCount)
+	return err
+}
+
+// UnmarshalXML decodes the response and parses CharCount
+func (r *SpeakExtendedResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type response SpeakExtendedResponse
+	err := d.DecodeElement((*response)(r), &start)
+	r.Chars = atoi(r.CharCount)
+	return err
+}
+
+// UnmarshalJSON decodes the response and parses CharCount
+func (r *SpeakExtendedResponse) UnmarshalJSON(data []byte) error {
+	type response SpeakExtendedResponse
+	err := json.Unmarshal(data, (*response)(r))
+	r.Chars = atoi(r.CharCount)
+	return err
+}
+
+// UnmarshalXML decodes the voice and parses SampleRate
+func (v *Voice) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type voice Voice
+	err := d.DecodeElement((*voice)(v), &start)
+	v.Hz = atoi(v.SampleRate)
+	return err
+}
+
+// UnmarshalJSON decodes the voice and parses SampleRate
+func (v *Voice) UnmarshalJSON(data []byte) error {
+	type voice Voice
+	err := json.Unmarshal(data, (*voice)(v))
+	if v.SampleRate != "" {
+		v.Hz = atoi(v.SampleRate)
+	}
+	return err
+}
+
+// UnmarshalXML decodes the lexicon details and parses Size
+func (l *Lexicon) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type lexicon Lexicon
+	err := d.DecodeElement((*lexicon)(l), &start)
+	l.Bytes = atoi64(l.Size)
+	return err
+}
+
+// UnmarshalJSON decodes the lexicon details and parses Size
+func (l *Lexicon) UnmarshalJSON(data []byte) error {
+	type lexicon Lexicon
+	err := json.Unmarshal(data, (*lexicon)(l))
+	if l.Size != "" {
+		l.Bytes = atoi64(l.Size)
+	}
+	return err
+}
+
+// UnmarshalXML decodes the abbreviation details and parses Size
+func (a *Abbreviation) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type abbreviation Abbreviation
+	err := d.DecodeElement((*abbreviation)(a), &start)
+	a.Bytes = atoi64(a.Size)
+	return err
+}
+
+// UnmarshalJSON decodes the abbreviation details and parses Size
+func (a *Abbreviation) UnmarshalJSON(data []byte) error {
+	type abbreviation Abbreviation
+	err := json.Unmarshal(data, (*abbreviation)(a))
+	if a.Size != "" {
+		a.Bytes = atoi64(a.Size)
+	}
+	return err
+}
+
+// UnmarshalXML decodes the credit and parses its values
+func (c *Credit) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type credit Credit
+	err := d.DecodeElement((*credit)(c), &start)
+	c.parse()
+	return err
+}
+
+// UnmarshalJSON decodes the credit and parses its values
+func (c *Credit) UnmarshalJSON(data []byte) error {
+	type credit Credit
+	err := json.Unmarshal(data, (*credit)(c))
+	c.parse()
+	return err
+}
+
+// parse sets the typed credit fields from the raw strings, keeping those
+// decoded from JSON where there is no string
+func (c *Credit) parse() {
+	if c.FreeCredit != "" {
+		c.Free, _ = ParseAmount(c.FreeCredit)
+	}
+	if c.PaidCredit != "" {
+		c.Paid, _ = ParseAmount(c.PaidCredit)
+	}
+	if c.CharsAvailable != "" {
+		c.Available = atoi(c.CharsAvailable)
+	}
+}
+
+// atoi parses a count, 0 if s is not a number
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+// atoi64 parses a size, 0 if s is not a number
+func atoi64(s string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return n
+}
+
+// isDigits reports whether s contains only ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
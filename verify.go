@@ -0,0 +1,323 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/bganderson/cerevoicego/audio"
+)
+
+// ErrCorruptAudio is matched by an AudioError, returned when downloaded
+// audio is empty, truncated or not what was requested
+var ErrCorruptAudio = errors.New("cerevoicego: corrupt audio")
+
+// AudioError describes audio which failed verification
+type AudioError struct {
+	URL          string      // Where the audio was downloaded from, if known
+	Format       AudioFormat // Format requested, empty for the default
+	SampleRate   SampleRate  // Sample rate requested, empty for the default
+	Detected     AudioFormat // Format found, empty if unrecognised
+	DetectedRate int         // Sample rate found in Hz, 0 if unknown
+	Size         int         // Bytes of audio
+	Reason       string      // What is wrong
+}
+
+func (e *AudioError) Error() string {
+	var b strings.Builder
+	b.WriteString("cerevoicego: corrupt audio")
+	if e.URL != "" {
+		b.WriteString(" from " + e.URL)
+	}
+	fmt.Fprintf(&b, ": %s (%d bytes", e.Reason, e.Size)
+	if e.Detected != "" {
+		fmt.Fprintf(&b, ", %s", e.Detected)
+	}
+	if e.DetectedRate > 0 {
+		fmt.Fprintf(&b, " at %d Hz", e.DetectedRate)
+	}
+	b.WriteString(")")
+
+	return b.String()
+}
+
+// Is reports whether target is ErrCorruptAudio
+func (e *AudioError) Is(target error) bool {
+	return target == ErrCorruptAudio
+}
+
+// WithAudioVerification checks the audio downloaded by SpeakAudio, SpeakTo,
+// SpeakLong and Batch with VerifyAudio, so truncated or unexpected files fail
+// with ErrCorruptAudio rather than later in playback. SpeakTo then buffers
+// the whole file before writing it.
+func WithAudioVerification() ClientOption {
+	return func(c *Client) {
+		c.VerifyAudio = true
+	}
+}
+
+// VerifyAudio checks b is non-empty, complete audio in the given format and
+// sample rate, either of which may be empty to accept any. Headers are
+// parsed for every format but raw, which is only checked for whole 16 bit
+// samples. It returns an *AudioError if not.
+func VerifyAudio(b []byte, format AudioFormat, rate SampleRate) error {
+	e := &AudioError{Format: format, SampleRate: rate, Size: len(b)}
+	want := AudioFormat(strings.ToLower(string(format)))
+
+	switch {
+	case len(b) == 0:
+		e.Reason = "empty file"
+		return e
+	case want == FormatRaw:
+		if len(b)%2 != 0 {
+			e.Reason = "odd length for 16 bit samples"
+			return e
+		}
+		return nil
+	}
+
+	e.Detected = sniffAudio(b)
+	switch {
+	case e.Detected == "":
+		e.Reason = "unrecognised audio"
+		if isHTML(b) || isXML(b) {
+			e.Reason = "markup rather than audio: " + snippet(b)
+		}
+		return e
+	case want != "" && e.Detected != want:
+		e.Reason = "expected " + string(want)
+		return e
+	}
+
+	var reason string
+	switch e.Detected {
+	case FormatWAV:
+		e.DetectedRate, reason = checkWAV(b)
+	case FormatOGG:
+		e.DetectedRate, reason = checkOgg(b)
+	case FormatMP3:
+		e.DetectedRate, reason = checkMP3(b)
+	case FormatFLAC:
+		e.DetectedRate, reason = checkFLAC(b)
+	case FormatAIFF:
+		e.DetectedRate, reason = checkAIFF(b)
+	}
+	if reason == "" && rate != "" && e.DetectedRate > 0 && e.DetectedRate != rate.Hz() {
+		reason = "expected " + string(rate) + " Hz"
+	}
+	if reason != "" {
+		e.Reason = reason
+		return e
+	}
+
+	return nil
+}
+
+// readAudio downloads the audio of r, verifying it if the Client has
+// VerifyAudio set
+func (c *Client) readAudio(ctx context.Context, r *SpeakExtendedResponse, input *SpeakExtendedInput) ([]byte, error) {
+	body, err := r.Download(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.VerifyAudio {
+		if err := VerifyAudio(b, input.AudioFormat, input.SampleRate); err != nil {
+			err.(*AudioError).URL = r.FileURL
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// sniffAudio returns the format of b from its leading bytes, empty if it is
+// not recognised
+func sniffAudio(b []byte) AudioFormat {
+	switch {
+	case len(b) >= 12 && string(b[0:4]) == "RIFF" && string(b[8:12]) == "WAVE":
+		return FormatWAV
+	case len(b) >= 12 && string(b[0:4]) == "FORM" && (string(b[8:12]) == "AIFF" || string(b[8:12]) == "AIFC"):
+		return FormatAIFF
+	case bytes.HasPrefix(b, []byte("OggS")):
+		return FormatOGG
+	case bytes.HasPrefix(b, []byte("fLaC")):
+		return FormatFLAC
+	case bytes.HasPrefix(b, []byte("ID3")), len(b) >= 2 && b[0] == 0xFF && b[1]&0xE0 == 0xE0:
+		return FormatMP3
+	}
+
+	return ""
+}
+
+// checkWAV returns the sample rate of a WAV file and what is wrong with it,
+// if anything
+func checkWAV(b []byte) (rate int, reason string) {
+	w, err := audio.DecodeWAV(b)
+	if err != nil {
+		return 0, "invalid WAV header"
+	}
+	rate = w.Format.SampleRate
+
+	// Streamed files may have a placeholder size
+	if size := binary.LittleEndian.Uint32(b[4:8]); size != 0 && size != 0xFFFFFFFF && int64(size)+8 > int64(len(b)) {
+		return rate, fmt.Sprintf("truncated, header gives %d bytes", int64(size)+8)
+	}
+	if len(w.Data) == 0 {
+		return rate, "no audio data"
+	}
+	if align := w.Format.BlockAlign(); align > 0 && len(w.Data)%align != 0 {
+		return rate, "truncated mid sample"
+	}
+
+	return rate, ""
+}
+
+// checkOgg returns the sample rate of an Ogg Vorbis file, 0 for other codecs,
+// and what is wrong with it, if anything
+func checkOgg(b []byte) (rate int, reason string) {
+	if i := bytes.Index(b, []byte("\x01vorbis")); i >= 0 && i+16 <= len(b) {
+		rate = int(binary.LittleEndian.Uint32(b[i+12 : i+16]))
+	}
+
+	// The last page must be whole and end the stream
+	last := bytes.LastIndex(b, []byte("OggS"))
+	if last+27 > len(b) {
+		return rate, "truncated in the last page header"
+	}
+	segments := int(b[last+26])
+	if last+27+segments > len(b) {
+		return rate, "truncated in the last page header"
+	}
+	end := last + 27 + segments
+	for _, n := range b[last+27 : last+27+segments] {
+		end += int(n)
+	}
+	if end > len(b) {
+		return rate, "truncated in the last page"
+	}
+	if b[last+5]&0x04 == 0 {
+		return rate, "truncated, no end of stream page"
+	}
+
+	return rate, ""
+}
+
+// mp3Rates are the sample rates of MPEG audio by version bits and index
+var mp3Rates = map[byte][3]int{
+	3: {44100, 48000, 32000}, // MPEG 1
+	2: {22050, 24000, 16000}, // MPEG 2
+	0: {11025, 12000, 8000},  // MPEG 2.5
+}
+
+// checkMP3 returns the sample rate of the first frame of an MP3 file and
+// what is wrong with it, if anything
+func checkMP3(b []byte) (rate int, reason string) {
+	if bytes.HasPrefix(b, []byte("ID3")) && len(b) < 10 {
+		return 0, "truncated in the ID3 tag"
+	}
+	p := skipID3(b)
+	if p+4 > len(b) {
+		return 0, "no audio frames"
+	}
+	if b[p] != 0xFF || b[p+1]&0xE0 != 0xE0 {
+		return 0, "invalid frame header"
+	}
+
+	rates, ok := mp3Rates[(b[p+1]>>3)&3]
+	index := (b[p+2] >> 2) & 3
+	if !ok || index == 3 {
+		return 0, "invalid frame header"
+	}
+
+	return rates[index], ""
+}
+
+// skipID3 returns the offset of the first frame of an MP3 file, after any
+// ID3v2 tag
+func skipID3(b []byte) int {
+	if !bytes.HasPrefix(b, []byte("ID3")) || len(b) < 10 {
+		return 0
+	}
+
+	p := 10 + (int(b[6])<<21 | int(b[7])<<14 | int(b[8])<<7 | int(b[9]))
+	if b[5]&0x10 != 0 {
+		p += 10
+	}
+	return p
+}
+
+// checkFLAC returns the sample rate of a FLAC file from its STREAMINFO block
+// and what is wrong with it, if anything
+func checkFLAC(b []byte) (rate int, reason string) {
+	if len(b) < 42 || b[4]&0x7F != 0 {
+		return 0, "missing STREAMINFO"
+	}
+
+	return int(b[18])<<12 | int(b[19])<<4 | int(b[20])>>4, ""
+}
+
+// checkAIFF returns the sample rate of an AIFF file and what is wrong with
+// it, if anything
+func checkAIFF(b []byte) (rate int, reason string) {
+	haveData := false
+	for p := 12; p+8 <= len(b); {
+		id := string(b[p : p+4])
+		size := int(binary.BigEndian.Uint32(b[p+4 : p+8]))
+		p += 8
+		if p+size > len(b) {
+			if id == "SSND" {
+				return rate, "truncated in the sound data"
+			}
+			return rate, "truncated in the " + strings.TrimSpace(id) + " chunk"
+		}
+
+		switch id {
+		case "COMM":
+			if size < 18 {
+				return 0, "invalid COMM chunk"
+			}
+			rate = extendedToInt(b[p+8 : p+18])
+		case "SSND":
+			haveData = size > 8
+		}
+		p += size + size%2
+	}
+
+	switch {
+	case rate == 0:
+		return 0, "missing COMM chunk"
+	case !haveData:
+		return rate, "no audio data"
+	}
+
+	return rate, ""
+}
+
+// extendedToInt converts an 80 bit IEEE 754 extended precision number, as
+// used for AIFF sample rates, to an integer
+func extendedToInt(x []byte) int {
+	exp := int(binary.BigEndian.Uint16(x[0:2])&0x7FFF) - 16383 - 63
+	mantissa := binary.BigEndian.Uint64(x[2:10])
+	switch {
+	case exp < -63 || exp > 0:
+		return 0
+	case exp < 0:
+		return int(mantissa >> uint(-exp))
+	}
+
+	return int(mantissa)
+}
@@ -0,0 +1,186 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+// Package viseme converts CereVoice phone timings into a viseme timeline for
+// lip sync. Visemes use the 15 shape Oculus set, whose IDs are also used by
+// most avatar and game engine lip sync plugins.
+//
+//	res, err := cerevoice.SpeakExtended(&cerevoicego.SpeakExtendedInput{
+//		Voice:    "Jess",
+//		Text:     "Hello world",
+//		Metadata: true,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//
+//	cues, err := viseme.FromResponse(ctx, cerevoice, res)
+package viseme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+// Viseme is an Oculus viseme ID
+type Viseme int
+
+// Oculus visemes
+const (
+	Sil Viseme = iota // Silence
+	PP                // p, b, m
+	FF                // f, v
+	TH                // th
+	DD                // t, d
+	KK                // k, g
+	CH                // ch, j, sh
+	SS                // s, z
+	NN                // n, l
+	RR                // r
+	AA                // a
+	E                 // e
+	IH                // i
+	OH                // o
+	OU                // u
+)
+
+var names = [...]string{"sil", "PP", "FF", "TH", "DD", "kk", "CH", "SS", "nn", "RR", "aa", "E", "ih", "oh", "ou"}
+
+// String returns the Oculus name of the viseme, e.g. "PP"
+func (v Viseme) String() string {
+	if v < 0 || int(v) >= len(names) {
+		return "sil"
+	}
+
+	return names[v]
+}
+
+// Mapping maps phones, without stress digits, to visemes
+type Mapping map[string]Viseme
+
+// English maps the phones of CereVoice English voices
+var English = Mapping{
+	"sil": Sil, "pau": Sil, "#": Sil, "_": Sil,
+
+	"p": PP, "b": PP, "m": PP,
+	"f": FF, "v": FF,
+	"th": TH, "dh": TH,
+	"t": DD, "d": DD,
+	"k": KK, "g": KK, "ng": KK, "h": KK,
+	"ch": CH, "jh": CH, "sh": CH, "zh": CH,
+	"s": SS, "z": SS,
+	"n": NN, "l": NN,
+	"r": RR,
+	"y": IH,
+	"w": OU,
+
+	"a": AA, "aa": AA, "ae": AA, "ah": AA, "ai": AA, "au": AA, "uh": AA,
+	"e": E, "ei": E, "e@": E, "@": E, "@@": E,
+	"i": IH, "ii": IH, "i@": IH,
+	"o": OH, "oo": OH, "oi": OH,
+	"u": OU, "uu": OU, "ou": OU, "u@": OU,
+}
+
+// Viseme returns the viseme for phone, ignoring case and any stress digit.
+// Unknown phones map to Sil.
+func (m Mapping) Viseme(phone string) Viseme {
+	phone = strings.ToLower(strings.TrimRight(phone, "0123456789"))
+	if v, ok := m[phone]; ok {
+		return v
+	}
+
+	return Sil
+}
+
+// Cue is a viseme held for a period of the audio
+type Cue struct {
+	Viseme Viseme
+	Start  time.Duration // Offset from the start of the audio
+	End    time.Duration // Offset from the start of the audio
+}
+
+// MarshalJSON encodes the cue with the viseme ID and name, and times in
+// seconds
+func (c Cue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID    int     `json:"id"`
+		Name  string  `json:"name"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	}{int(c.Viseme), c.Viseme.String(), c.Start.Seconds(), c.End.Seconds()})
+}
+
+// FromMetadata converts the phone events of m into a continuous timeline of
+// cues, using mapping or English if nil. Consecutive phones with the same
+// viseme are merged and gaps between phones are filled with Sil.
+func FromMetadata(m *cerevoicego.Metadata, mapping Mapping) []Cue {
+	if mapping == nil {
+		mapping = English
+	}
+
+	var cues []Cue
+	add := func(v Viseme, start, end time.Duration) {
+		if end <= start {
+			return
+		}
+		if n := len(cues); n > 0 && cues[n-1].Viseme == v && cues[n-1].End >= start {
+			if end > cues[n-1].End {
+				cues[n-1].End = end
+			}
+			return
+		}
+		cues = append(cues, Cue{Viseme: v, Start: start, End: end})
+	}
+
+	var last time.Duration
+	for _, e := range m.Phones() {
+		if e.Start > last {
+			add(Sil, last, e.Start)
+		}
+		add(mapping.Viseme(e.Token), e.Start, e.End)
+		if e.End > last {
+			last = e.End
+		}
+	}
+
+	return cues
+}
+
+// FromResponse downloads the metadata of a SpeakExtended result, which must
+// have been requested with Metadata set, and converts it with the English
+// mapping
+func FromResponse(ctx context.Context, c *cerevoicego.Client, r *cerevoicego.SpeakExtendedResponse) ([]Cue, error) {
+	if r.Metadata == "" {
+		return nil, errors.New("viseme: no metadata, set Metadata in SpeakExtendedInput")
+	}
+
+	m, err := c.GetMetadataWithContext(ctx, r.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromMetadata(m, nil), nil
+}
+
+// At returns the viseme at offset t, or Sil outside the cues
+func At(cues []Cue, t time.Duration) Viseme {
+	lo, hi := 0, len(cues)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case t < cues[mid].Start:
+			hi = mid
+		case t >= cues[mid].End:
+			lo = mid + 1
+		default:
+			return cues[mid].Viseme
+		}
+	}
+
+	return Sil
+}
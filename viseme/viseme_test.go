@@ -0,0 +1,137 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package viseme
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+)
+
+const ms = time.Millisecond
+
+// phones returns metadata with phone events of the given tokens and times
+// in milliseconds, interleaved with a word event
+func phones(events ...interface{}) *cerevoicego.Metadata {
+	m := &cerevoicego.Metadata{Events: []cerevoicego.MetadataEvent{{Type: cerevoicego.EventWord, Token: "word"}}}
+	for i := 0; i < len(events); i += 3 {
+		m.Events = append(m.Events, cerevoicego.MetadataEvent{
+			Type:  cerevoicego.EventPhone,
+			Token: events[i].(string),
+			Start: time.Duration(events[i+1].(int)) * ms,
+			End:   time.Duration(events[i+2].(int)) * ms,
+		})
+	}
+	return m
+}
+
+func TestMappingViseme(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  Viseme
+	}{
+		{"p", PP},
+		{"aa1", AA},
+		{"@0", E},
+		{"TH", TH},
+		{"ou2", OU},
+		{"sil", Sil},
+		{"xx", Sil},
+		{"", Sil},
+	}
+
+	for _, tt := range tests {
+		if got := English.Viseme(tt.phone); got != tt.want {
+			t.Errorf("Viseme(%q) = %v, want %v", tt.phone, got, tt.want)
+		}
+	}
+}
+
+func TestFromMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    *cerevoicego.Metadata
+		mapping Mapping
+		want    []Cue
+	}{
+		{
+			"phones",
+			phones("h", 0, 50, "e1", 50, 120, "l", 120, 180),
+			nil,
+			[]Cue{{KK, 0, 50 * ms}, {E, 50 * ms, 120 * ms}, {NN, 120 * ms, 180 * ms}},
+		},
+		{
+			"merged",
+			phones("p", 0, 50, "b", 50, 90, "m", 90, 130),
+			nil,
+			[]Cue{{PP, 0, 130 * ms}},
+		},
+		{
+			"gaps filled",
+			phones("s", 100, 150, "t", 200, 250),
+			nil,
+			[]Cue{{Sil, 0, 100 * ms}, {SS, 100 * ms, 150 * ms}, {Sil, 150 * ms, 200 * ms}, {DD, 200 * ms, 250 * ms}},
+		},
+		{
+			"overlapping",
+			phones("a", 0, 100, "aa", 80, 90, "o", 100, 150),
+			nil,
+			[]Cue{{AA, 0, 100 * ms}, {OH, 100 * ms, 150 * ms}},
+		},
+		{
+			"empty phone dropped",
+			phones("f", 0, 40, "k", 40, 40, "v", 40, 80),
+			nil,
+			[]Cue{{FF, 0, 80 * ms}},
+		},
+		{
+			"custom mapping",
+			phones("x", 0, 40, "y", 40, 80),
+			Mapping{"x": CH, "y": RR},
+			[]Cue{{CH, 0, 40 * ms}, {RR, 40 * ms, 80 * ms}},
+		},
+		{"no phones", &cerevoicego.Metadata{}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		if got := FromMetadata(tt.meta, tt.mapping); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: cues = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	cues := []Cue{{PP, 10 * ms, 50 * ms}, {AA, 50 * ms, 100 * ms}, {SS, 120 * ms, 150 * ms}}
+	tests := []struct {
+		t    time.Duration
+		want Viseme
+	}{
+		{0, Sil},
+		{10 * ms, PP},
+		{49 * ms, PP},
+		{50 * ms, AA},
+		{110 * ms, Sil},
+		{149 * ms, SS},
+		{150 * ms, Sil},
+	}
+
+	for _, tt := range tests {
+		if got := At(cues, tt.t); got != tt.want {
+			t.Errorf("At(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestCueJSON(t *testing.T) {
+	b, err := json.Marshal(Cue{KK, 250 * ms, 1500 * ms})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":5,"name":"kk","start":0.25,"end":1.5}`; string(b) != want {
+		t.Errorf("JSON = %s, want %s", b, want)
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bganderson/cerevoicego/internal/atomicfile"
+)
+
+// DefaultVoiceCacheTTL is how long a VoiceCache keeps the catalogue when no
+// TTL is set
+const DefaultVoiceCacheTTL = time.Hour
+
+// VoiceCache keeps the result of listVoices in memory, and optionally on
+// disk, so voices can be checked per request without calling the API each
+// time. The catalogue is fetched when first needed and again once TTL has
+// passed, or periodically in the background after Start. Concurrent refreshes
+// share one listVoices request, and if it fails the stale catalogue is used
+// until a refresh succeeds.
+type VoiceCache struct {
+	// API is used to list the voices
+	API CereVoiceAPI
+	// TTL is how long the catalogue is used before it is fetched again,
+	// DefaultVoiceCacheTTL when 0
+	TTL time.Duration
+	// Path, if set, is a file the catalogue is saved to and loaded from, so
+	// it survives restarts
+	Path string
+	// OnChange, if set, is called after a refresh changes the catalogue
+	OnChange func(old, new VoiceCatalog)
+	// OnDiff, if set, is called with the differences after a refresh changes
+	// a catalogue already loaded, such as when a voice is retired
+	OnDiff func(diff *VoiceDiff)
+
+	mu      sync.Mutex
+	voices  VoiceCatalog
+	fetched time.Time
+	loaded  bool
+	stop    chan struct{}
+	pending *voiceRefresh // Refresh in progress, if any
+}
+
+// voiceRefresh is a listVoices request shared by concurrent refreshes
+type voiceRefresh struct {
+	done   chan struct{}
+	voices VoiceCatalog
+	err    error
+}
+
+// voiceCacheFile is the on-disk format of a VoiceCache
+type voiceCacheFile struct {
+	XMLName xml.Name  `xml:"voiceCache"`
+	Fetched time.Time `xml:"fetched,attr"`
+	Voices  []Voice   `xml:"voice"`
+}
+
+// NewVoiceCache returns a VoiceCache listing voices with api
+func NewVoiceCache(api CereVoiceAPI, ttl time.Duration) *VoiceCache {
+	return &VoiceCache{API: api, TTL: ttl}
+}
+
+// Voices returns the voice catalogue, fetching it if it is missing or stale.
+// If fetching a stale catalogue fails, the stale catalogue is returned.
+func (v *VoiceCache) Voices(ctx context.Context) (VoiceCatalog, error) {
+	v.mu.Lock()
+	v.load()
+	voices, fresh := v.voices, v.fresh()
+	v.mu.Unlock()
+
+	if fresh {
+		return voices, nil
+	}
+
+	latest, err := v.refresh(ctx)
+	if err != nil && voices != nil && ctx.Err() == nil {
+		return voices, nil
+	}
+
+	return latest, err
+}
+
+// Lookup returns the voice with the given name from the catalogue
+func (v *VoiceCache) Lookup(ctx context.Context, name string) (Voice, bool, error) {
+	voices, err := v.Voices(ctx)
+	if err != nil {
+		return Voice{}, false, err
+	}
+
+	voice, ok := voices.Lookup(name)
+	return voice, ok, nil
+}
+
+// Refresh fetches the catalogue now
+func (v *VoiceCache) Refresh(ctx context.Context) error {
+	_, err := v.refresh(ctx)
+	return err
+}
+
+// Invalidate forces the catalogue to be fetched when next needed, for
+// example after a custom voice is added to the account
+func (v *VoiceCache) Invalidate() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.loaded = true
+	v.fetched = time.Time{}
+}
+
+// Start refreshes the catalogue in the background every interval, or every
+// TTL if interval is 0, until Stop is called. Errors are ignored and the
+// previous catalogue kept.
+func (v *VoiceCache) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = v.ttl()
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	v.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				v.refresh(ctx)
+				cancel()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops a background refresh started with Start
+func (v *VoiceCache) Stop() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.stop != nil {
+		close(v.stop)
+		v.stop = nil
+	}
+}
+
+// refresh fetches the catalogue, or waits for a fetch already in progress. A
+// caller waiting on a fetch cancelled by the caller which made it makes its
+// own.
+func (v *VoiceCache) refresh(ctx context.Context) (VoiceCatalog, error) {
+	for {
+		v.mu.Lock()
+		r := v.pending
+		if r == nil {
+			r = &voiceRefresh{done: make(chan struct{})}
+			v.pending = r
+			v.mu.Unlock()
+
+			r.voices, r.err = v.fetch(ctx)
+
+			v.mu.Lock()
+			v.pending = nil
+			v.mu.Unlock()
+			close(r.done)
+
+			return r.voices, r.err
+		}
+		v.mu.Unlock()
+
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if r.err != nil && ctx.Err() == nil &&
+			(errors.Is(r.err, context.Canceled) || errors.Is(r.err, context.DeadlineExceeded)) {
+			continue
+		}
+
+		return r.voices, r.err
+	}
+}
+
+// fetch lists the voices and notifies OnChange and OnDiff if they changed
+func (v *VoiceCache) fetch(ctx context.Context) (VoiceCatalog, error) {
+	res, err := v.API.ListVoicesWithContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	voices := res.Catalog()
+
+	v.mu.Lock()
+	old := v.voices
+	v.voices, v.fetched, v.loaded = voices, time.Now(), true
+	v.save()
+	onChange, onDiff := v.OnChange, v.OnDiff
+	v.mu.Unlock()
+
+	if !reflect.DeepEqual(old, voices) {
+		if onChange != nil {
+			onChange(old, voices)
+		}
+		if onDiff != nil && old != nil {
+			if diff := DiffVoices(old, voices); !diff.Empty() {
+				onDiff(diff)
+			}
+		}
+	}
+
+	return voices, nil
+}
+
+func (v *VoiceCache) ttl() time.Duration {
+	if v.TTL > 0 {
+		return v.TTL
+	}
+
+	return DefaultVoiceCacheTTL
+}
+
+// fresh reports whether the catalogue is within its TTL, v.mu must be held
+func (v *VoiceCache) fresh() bool {
+	return !v.fetched.IsZero() && time.Since(v.fetched) < v.ttl()
+}
+
+// load reads the catalogue from Path once, v.mu must be held
+func (v *VoiceCache) load() {
+	if v.loaded || v.Path == "" {
+		return
+	}
+	v.loaded = true
+
+	data, err := ioutil.ReadFile(v.Path)
+	if err != nil {
+		return
+	}
+
+	var file voiceCacheFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return
+	}
+	v.voices, v.fetched = file.Voices, file.Fetched
+}
+
+// save writes the catalogue to Path, v.mu must be held
+func (v *VoiceCache) save() {
+	if v.Path == "" {
+		return
+	}
+
+	data, err := xml.MarshalIndent(voiceCacheFile{Fetched: v.fetched, Voices: v.voices}, "", "  ")
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.Path), 0700); err != nil {
+		return
+	}
+	atomicfile.WriteFile(v.Path, data, 0600)
+}
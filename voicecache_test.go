@@ -0,0 +1,73 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bganderson/cerevoicego"
+	"github.com/bganderson/cerevoicego/cerevoicetest"
+)
+
+func TestVoiceCacheSharesRefresh(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	release := make(chan struct{})
+	fake := &cerevoicetest.Fake{ListVoicesFunc: func(*cerevoicego.ListVoicesInput) (*cerevoicego.ListVoicesResponse, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-release
+		return &cerevoicego.ListVoicesResponse{VoiceList: cerevoicetest.Voices}, nil
+	}}
+	cache := cerevoicego.NewVoiceCache(fake, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			voices, err := cache.Voices(context.Background())
+			if err != nil || len(voices) != len(cerevoicetest.Voices) {
+				t.Errorf("Voices = %d voices, %v", len(voices), err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("listVoices called %d times, want 1", calls)
+	}
+}
+
+func TestVoiceCacheServesStale(t *testing.T) {
+	fake := &cerevoicetest.Fake{}
+	cache := cerevoicego.NewVoiceCache(fake, time.Hour)
+	if _, err := cache.Voices(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	unavailable := errors.New("unavailable")
+	fake.ListVoicesFunc = func(*cerevoicego.ListVoicesInput) (*cerevoicego.ListVoicesResponse, error) {
+		return nil, unavailable
+	}
+	cache.Invalidate()
+
+	voices, err := cache.Voices(context.Background())
+	if err != nil {
+		t.Fatalf("Voices = %v, want the stale catalogue", err)
+	}
+	if len(voices) != len(cerevoicetest.Voices) {
+		t.Errorf("Voices = %d voices, want %d", len(voices), len(cerevoicetest.Voices))
+	}
+	if err := cache.Refresh(context.Background()); err != unavailable {
+		t.Errorf("Refresh = %v, want the error", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2018 Bryan Anderson (https://www.bganderson.com)
+// Relesed under a BSD-style license which can be found in the LICENSE file
+
+package cerevoicego
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// VoiceChange is a voice whose details differ between two catalogues
+type VoiceChange struct {
+	Old    Voice    `json:"old"`
+	New    Voice    `json:"new"`
+	Fields []string `json:"fields"` // Names of the fields which differ, e.g. sampleRate
+}
+
+// VoiceDiff lists the differences between two voice catalogues, each sorted
+// by voice name
+type VoiceDiff struct {
+	Added   []Voice       `json:"added"`
+	Removed []Voice       `json:"removed"` // Voices which have been retired
+	Changed []VoiceChange `json:"changed"`
+}
+
+// DiffVoices compares the catalogues old and new, matching voices by name
+// ignoring case
+func DiffVoices(old, new VoiceCatalog) *VoiceDiff {
+	d := &VoiceDiff{}
+
+	before := make(map[string]Voice, len(old))
+	for _, v := range old {
+		before[strings.ToLower(v.VoiceName)] = v
+	}
+	after := make(map[string]Voice, len(new))
+	for _, v := range new {
+		after[strings.ToLower(v.VoiceName)] = v
+	}
+
+	for name, v := range after {
+		o, ok := before[name]
+		if !ok {
+			d.Added = append(d.Added, v)
+			continue
+		}
+		if fields := voiceFieldsChanged(o, v); len(fields) > 0 {
+			d.Changed = append(d.Changed, VoiceChange{Old: o, New: v, Fields: fields})
+		}
+	}
+	for name, v := range before {
+		if _, ok := after[name]; !ok {
+			d.Removed = append(d.Removed, v)
+		}
+	}
+
+	sortVoices(d.Added)
+	sortVoices(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool {
+		return strings.ToLower(d.Changed[i].New.VoiceName) < strings.ToLower(d.Changed[j].New.VoiceName)
+	})
+
+	return d
+}
+
+// Empty reports whether the catalogues are the same
+func (d *VoiceDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String lists the differences one per line, prefixed + for added voices, -
+// for removed voices and ~ for changed voices
+func (d *VoiceDiff) String() string {
+	var b strings.Builder
+	for _, v := range d.Added {
+		fmt.Fprintf(&b, "+ %s (%s)\n", v.VoiceName, v.LanguageTag())
+	}
+	for _, v := range d.Removed {
+		fmt.Fprintf(&b, "- %s (%s)\n", v.VoiceName, v.LanguageTag())
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %s:", c.New.VoiceName)
+		old, new := voiceFields(c.Old), voiceFields(c.New)
+		for _, f := range c.Fields {
+			fmt.Fprintf(&b, " %s %q -> %q", f, old[f], new[f])
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// DiffVoices compares saved with the voices listed now
+func (c *Client) DiffVoices(ctx context.Context, saved VoiceCatalog) (*VoiceDiff, error) {
+	voices, err := c.Voices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffVoices(saved, voices), nil
+}
+
+// LoadVoiceCatalog reads a saved voice catalogue. It accepts a VoiceCache
+// file, a listVoices response, and the JSON of a ListVoicesResponse or a
+// VoiceCatalog, as printed by cerevoice -json voices.
+func LoadVoiceCatalog(path string) (VoiceCatalog, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		var voices VoiceCatalog
+		err = json.Unmarshal(data, &voices)
+		return voices, err
+	case bytes.HasPrefix(data, []byte("{")):
+		var res ListVoicesResponse
+		err = json.Unmarshal(data, &res)
+		return res.Catalog(), err
+	}
+
+	var file voiceCacheFile
+	if err := xml.Unmarshal(data, &file); err == nil && file.XMLName.Local == "voiceCache" {
+		return file.Voices, nil
+	}
+
+	var res ListVoicesResponse
+	if err := xml.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("cerevoicego: %s is not a voice catalogue: %v", path, err)
+	}
+
+	return res.Catalog(), nil
+}
+
+// voiceFieldsChanged returns the JSON names of the fields which differ
+func voiceFieldsChanged(old, new Voice) []string {
+	if old == new {
+		return nil
+	}
+
+	a, b := voiceFields(old), voiceFields(new)
+	var fields []string
+	for _, f := range voiceFieldNames {
+		if a[f] != b[f] {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+// voiceFieldNames are the JSON names of the Voice fields returned by the
+// API, in order, and voiceFieldIndex their indices
+var voiceFieldNames, voiceFieldIndex = func() ([]string, []int) {
+	var names []string
+	var index []int
+	t := reflect.TypeOf(Voice{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := t.Field(i).Tag.Get("json"); name != "-" {
+			names = append(names, name)
+			index = append(index, i)
+		}
+	}
+
+	return names, index
+}()
+
+// voiceFields returns the fields of v by JSON name
+func voiceFields(v Voice) map[string]string {
+	rv := reflect.ValueOf(v)
+	fields := make(map[string]string, len(voiceFieldNames))
+	for i, name := range voiceFieldNames {
+		fields[name] = rv.Field(voiceFieldIndex[i]).String()
+	}
+
+	return fields
+}
+
+func sortVoices(voices []Voice) {
+	sort.Slice(voices, func(i, j int) bool {
+		return strings.ToLower(voices[i].VoiceName) < strings.ToLower(voices[j].VoiceName)
+	})
+}